@@ -0,0 +1,325 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"api-gateway/billingpb"
+	"api-gateway/notifpb"
+	"api-gateway/userpb"
+
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// handleAdminStats fans out to every backend's Stats RPC and composes the
+// results; see aggregate for how a failing backend is reported.
+func (s *apiServer) handleAdminStats() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		sections, failed := s.aggregate(r.Context(), map[string]aggregateCall{
+			"users": func(ctx context.Context) (any, error) {
+				return s.userClient.Stats(ctx, &userpb.StatsRequest{})
+			},
+			"billing": func(ctx context.Context) (any, error) {
+				return s.billingClient.Stats(ctx, &billingpb.StatsRequest{})
+			},
+			"notifications": func(ctx context.Context) (any, error) {
+				return s.notifClient.Stats(ctx, &notifpb.StatsRequest{})
+			},
+		})
+		s.writeAggregate(w, sections, failed)
+	}
+}
+
+// handleAdminEventSchemas lists the events each producing service publishes
+// together with their schema versions.
+func (s *apiServer) handleAdminEventSchemas() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		sections, failed := s.aggregate(r.Context(), map[string]aggregateCall{
+			"user-ms": func(ctx context.Context) (any, error) {
+				return s.userClient.GetEventSchemas(ctx, &userpb.GetEventSchemasRequest{})
+			},
+			"billing-ms": func(ctx context.Context) (any, error) {
+				return s.billingClient.GetEventSchemas(ctx, &billingpb.GetEventSchemasRequest{})
+			},
+		})
+		s.writeAggregate(w, sections, failed)
+	}
+}
+
+// handleAdminPasswordHashStats reports the password hash algorithms and cost
+// parameters in use, without any hash material.
+func (s *apiServer) handleAdminPasswordHashStats() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := s.callContext(r)
+		defer cancel()
+		res, err := s.userClient.GetPasswordHashStats(ctx, &userpb.GetPasswordHashStatsRequest{})
+		if err != nil {
+			s.writeGRPCError(w, r, err, "failed to get password hash stats")
+			return
+		}
+		s.writeJSON(w, http.StatusOK, res)
+	}
+}
+
+// handleAdminSetConsumption pauses (paused=true) or resumes event consumption
+// in the backend named by the {service} path value.
+func (s *apiServer) handleAdminSetConsumption(paused bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var (
+			res any
+			err error
+		)
+		ctx, cancel := s.callContext(r)
+		defer cancel()
+		switch service := r.PathValue("service"); service {
+		case "billing":
+			res, err = s.billingClient.SetConsumptionPaused(ctx, &billingpb.SetConsumptionPausedRequest{Paused: paused})
+		case "notification":
+			res, err = s.notifClient.SetConsumptionPaused(ctx, &notifpb.SetConsumptionPausedRequest{Paused: paused})
+		default:
+			s.writeJSONError(w, http.StatusNotFound, "unknown service "+service+", expected billing or notification")
+			return
+		}
+		if err != nil {
+			s.writeGRPCError(w, r, err, "failed to set consumption state", "service", r.PathValue("service"), "paused", paused)
+			return
+		}
+		s.writeJSON(w, http.StatusOK, res)
+	}
+}
+
+func (s *apiServer) handleAdminNotificationDeliveries() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		notificationID := r.PathValue("notification_id")
+		if !validUUID(notificationID) {
+			s.writeJSONError(w, http.StatusBadRequest, "notification_id must be a valid UUID")
+			return
+		}
+
+		ctx, cancel := s.callContext(r)
+		defer cancel()
+		res, err := s.notifClient.GetNotificationDeliveries(ctx, &notifpb.GetNotificationDeliveriesRequest{NotificationId: notificationID})
+		if err != nil {
+			s.writeGRPCError(w, r, err, "failed to get notification deliveries", "notification_id", notificationID)
+			return
+		}
+		s.writeJSON(w, http.StatusOK, res)
+	}
+}
+
+func (s *apiServer) handleAdminResendNotification() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req notifpb.ResendNotificationRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			s.writeJSONError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+		if !validUUID(req.UserId) || (req.NotificationId != "" && !validUUID(req.NotificationId)) {
+			s.writeJSONError(w, http.StatusBadRequest, "user_id and notification_id must be valid UUIDs")
+			return
+		}
+
+		ctx, cancel := s.callContext(r)
+		defer cancel()
+		res, err := s.notifClient.ResendNotification(ctx, &req)
+		if err != nil {
+			s.writeGRPCError(w, r, err, "failed to resend notification", "user_id", req.UserId, "notification_id", req.NotificationId)
+			return
+		}
+		s.writeProtoJSON(w, http.StatusOK, res)
+	}
+}
+
+// handleAdminTestNotifications sends the user in the body one notification
+// of every type and severity. notification-ms rejects it with 403 unless
+// NOTIF_TEST_NOTIFICATIONS is enabled.
+func (s *apiServer) handleAdminTestNotifications() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req notifpb.SendTestNotificationsRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			s.writeJSONError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+		if !validUUID(req.UserId) {
+			s.writeJSONError(w, http.StatusBadRequest, "user_id must be a valid UUID")
+			return
+		}
+
+		ctx, cancel := s.callContext(r)
+		defer cancel()
+		res, err := s.notifClient.SendTestNotifications(ctx, &req)
+		if err != nil {
+			s.writeGRPCError(w, r, err, "failed to send test notifications", "user_id", req.UserId)
+			return
+		}
+		s.writeProtoJSON(w, http.StatusOK, res)
+	}
+}
+
+func (s *apiServer) handleAdminListTemplates() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := s.callContext(r)
+		defer cancel()
+		res, err := s.notifClient.ListTemplates(ctx, &notifpb.ListTemplatesRequest{})
+		if err != nil {
+			s.writeGRPCError(w, r, err, "failed to list templates")
+			return
+		}
+		s.writeJSON(w, http.StatusOK, res)
+	}
+}
+
+// handleAdminPutTemplate stores the template for the message id and locale in
+// the path. The body is {"body": "<text/template source>"}.
+func (s *apiServer) handleAdminPutTemplate() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Body string `json:"body"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			s.writeJSONError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+
+		req := &notifpb.PutTemplateRequest{MessageId: r.PathValue("message_id"), Locale: r.PathValue("locale"), Body: body.Body}
+		ctx, cancel := s.callContext(r)
+		defer cancel()
+		res, err := s.notifClient.PutTemplate(ctx, req)
+		if err != nil {
+			s.writeGRPCError(w, r, err, "failed to store template", "message_id", req.MessageId, "locale", req.Locale)
+			return
+		}
+		s.writeJSON(w, http.StatusOK, res)
+	}
+}
+
+func (s *apiServer) handleAdminDeleteTemplate() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		req := &notifpb.DeleteTemplateRequest{MessageId: r.PathValue("message_id"), Locale: r.PathValue("locale")}
+		ctx, cancel := s.callContext(r)
+		defer cancel()
+		_, err := s.notifClient.DeleteTemplate(ctx, req)
+		if err != nil {
+			s.writeGRPCError(w, r, err, "failed to delete template", "message_id", req.MessageId, "locale", req.Locale)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// handleAdminStartBackfill starts a job that sends a catalog message to every
+// user. The body is a StartBackfillRequest in protobuf JSON, e.g.
+// {"message_id": "policy.updated", "type": "NOTIFICATION_TYPE_BILLING"}.
+// It answers 202 with the job; poll GET /admin/backfills/{job_id} for progress.
+func (s *apiServer) handleAdminStartBackfill() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			s.writeJSONError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+		var req notifpb.StartBackfillRequest
+		if err := protojson.Unmarshal(body, &req); err != nil {
+			s.writeJSONError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+
+		ctx, cancel := s.callContext(r)
+		defer cancel()
+		res, err := s.notifClient.StartBackfill(ctx, &req)
+		if err != nil {
+			s.writeGRPCError(w, r, err, "failed to start backfill", "message_id", req.MessageId)
+			return
+		}
+		s.writeProtoJSON(w, http.StatusAccepted, res.Job)
+	}
+}
+
+// handleAdminGetBackfill reports a backfill job's status and progress.
+func (s *apiServer) handleAdminGetBackfill() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		jobID := r.PathValue("job_id")
+		if !validUUID(jobID) {
+			s.writeJSONError(w, http.StatusBadRequest, "Job ID must be a valid UUID")
+			return
+		}
+
+		ctx, cancel := s.callContext(r)
+		defer cancel()
+		res, err := s.notifClient.GetBackfill(ctx, &notifpb.GetBackfillRequest{Id: jobID})
+		if err != nil {
+			s.writeGRPCError(w, r, err, "failed to get backfill", "job_id", jobID)
+			return
+		}
+		s.writeProtoJSON(w, http.StatusOK, res.Job)
+	}
+}
+
+// handleAdminResumeBackfill restarts a failed backfill job where it stopped.
+func (s *apiServer) handleAdminResumeBackfill() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		jobID := r.PathValue("job_id")
+		if !validUUID(jobID) {
+			s.writeJSONError(w, http.StatusBadRequest, "Job ID must be a valid UUID")
+			return
+		}
+
+		ctx, cancel := s.callContext(r)
+		defer cancel()
+		res, err := s.notifClient.ResumeBackfill(ctx, &notifpb.ResumeBackfillRequest{Id: jobID})
+		if err != nil {
+			s.writeGRPCError(w, r, err, "failed to resume backfill", "job_id", jobID)
+			return
+		}
+		s.writeProtoJSON(w, http.StatusAccepted, res.Job)
+	}
+}
+
+// handleAdminListStreams lists the open notification streams, e.g.
+// GET /admin/streams?limit=50&offset=100.
+func (s *apiServer) handleAdminListStreams() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		req, errs := parseListStreamsQuery(r)
+		if len(errs) > 0 {
+			s.writeValidationError(w, errs)
+			return
+		}
+
+		ctx, cancel := s.callContext(r)
+		defer cancel()
+		res, err := s.notifClient.ListStreams(ctx, req)
+		if err != nil {
+			s.writeGRPCError(w, r, err, "failed to list notification streams")
+			return
+		}
+		s.writeProtoJSON(w, http.StatusOK, res)
+	}
+}
+
+// handleAdminTerminateStream closes the notification streams of a WebSocket
+// session. An optional user_id query parameter limits it to that user.
+func (s *apiServer) handleAdminTerminateStream() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		sessionID := r.PathValue("session_id")
+		userID := r.URL.Query().Get("user_id")
+		var errs fieldErrors
+		errs.check(validUUID(sessionID), "session_id", "must be a valid UUID")
+		errs.check(userID == "" || validUUID(userID), "user_id", "must be a valid UUID")
+		if len(errs) > 0 {
+			s.writeValidationError(w, errs)
+			return
+		}
+
+		ctx, cancel := s.callContext(r)
+		defer cancel()
+		res, err := s.notifClient.TerminateStream(ctx, &notifpb.TerminateStreamRequest{SessionId: sessionID, UserId: userID})
+		if err != nil {
+			s.writeGRPCError(w, r, err, "failed to terminate notification stream", "session_id", sessionID)
+			return
+		}
+		s.requestLogger(r.Context()).Info("terminated notification streams", "session_id", sessionID, "terminated", res.Terminated)
+		s.writeProtoJSON(w, http.StatusOK, res)
+	}
+}
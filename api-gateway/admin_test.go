@@ -0,0 +1,354 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"api-gateway/billingpb"
+	"api-gateway/notifpb"
+	"api-gateway/userpb"
+)
+
+// internalErr is a backend failure whose message must not reach clients.
+var internalErr = status.Error(codes.Internal, `pq: relation "notification_deliveries" does not exist`)
+
+func TestAdminNotificationDeliveries(t *testing.T) {
+	var err error
+	notif := &fakeNotifClient{getNotificationDeliveries: func(in *notifpb.GetNotificationDeliveriesRequest) (*notifpb.GetNotificationDeliveriesResponse, error) {
+		if err != nil {
+			return nil, err
+		}
+		return &notifpb.GetNotificationDeliveriesResponse{SourceEventId: "evt-1", Attempts: []*notifpb.DeliveryAttempt{{}}}, nil
+	}}
+	s := newTestServer(t, testConfig(), nil, nil, notif)
+	target := "/admin/notifications/" + notificationID + "/deliveries"
+
+	w := serveAdmin(s, http.MethodGet, target, "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("status %d, body %s", w.Code, w.Body)
+	}
+	if res := decodeBody(t, w); res["source_event_id"] != "evt-1" {
+		t.Errorf("response = %v", res)
+	}
+
+	if w := serveAdmin(s, http.MethodGet, "/admin/notifications/not-a-uuid/deliveries", ""); w.Code != http.StatusBadRequest {
+		t.Errorf("invalid id: status %d, want 400", w.Code)
+	}
+
+	err = status.Error(codes.NotFound, "notification not found")
+	if w := serveAdmin(s, http.MethodGet, target, ""); w.Code != http.StatusNotFound {
+		t.Errorf("unknown notification: status %d, want 404", w.Code)
+	}
+
+	err = internalErr
+	w = serveAdmin(s, http.MethodGet, target, "")
+	if w.Code != http.StatusInternalServerError || containsAny(w.Body.String(), "pq:", "relation") {
+		t.Errorf("backend failure: status %d, body %s; want 500 without the backend error", w.Code, w.Body)
+	}
+}
+
+func TestAdminResendNotification(t *testing.T) {
+	var got *notifpb.ResendNotificationRequest
+	var err error
+	notif := &fakeNotifClient{resendNotification: func(in *notifpb.ResendNotificationRequest) (*notifpb.ResendNotificationResponse, error) {
+		got = in
+		if err != nil {
+			return nil, err
+		}
+		return &notifpb.ResendNotificationResponse{Notification: &notifpb.Notification{Id: in.NotificationId, UserId: in.UserId, Severity: notifpb.Severity_SEVERITY_WARNING}}, nil
+	}}
+	s := newTestServer(t, testConfig(), nil, nil, notif)
+	body := `{"user_id":"` + aliceID + `","notification_id":"` + notificationID + `"}`
+
+	w := serveAdmin(s, http.MethodPost, "/admin/notifications/resend", body)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status %d, body %s", w.Code, w.Body)
+	}
+	if got.GetUserId() != aliceID || got.GetNotificationId() != notificationID {
+		t.Errorf("ResendNotification request = %+v", got)
+	}
+	if !strings.Contains(w.Body.String(), `"severity":"SEVERITY_WARNING"`) {
+		t.Errorf("body %s, want the severity by name", w.Body)
+	}
+
+	if w := serveAdmin(s, http.MethodPost, "/admin/notifications/resend", `{"user_id":"alice"}`); w.Code != http.StatusBadRequest {
+		t.Errorf("invalid user id: status %d, want 400", w.Code)
+	}
+
+	err = status.Error(codes.NotFound, "no notification to resend")
+	if w := serveAdmin(s, http.MethodPost, "/admin/notifications/resend", body); w.Code != http.StatusNotFound {
+		t.Errorf("nothing to resend: status %d, want 404", w.Code)
+	}
+
+	err = internalErr
+	w = serveAdmin(s, http.MethodPost, "/admin/notifications/resend", body)
+	if w.Code != http.StatusInternalServerError || containsAny(w.Body.String(), "pq:", "relation") {
+		t.Errorf("backend failure: status %d, body %s; want 500 without the backend error", w.Code, w.Body)
+	}
+}
+
+func TestAdminPasswordHashStats(t *testing.T) {
+	var err error
+	user := &fakeUserClient{getPasswordHashStats: func(*userpb.GetPasswordHashStatsRequest) (*userpb.GetPasswordHashStatsResponse, error) {
+		if err != nil {
+			return nil, err
+		}
+		return &userpb.GetPasswordHashStatsResponse{CurrentAlgorithm: "argon2id", PendingUpgrade: 4}, nil
+	}}
+	s := newTestServer(t, testConfig(), user, nil, nil)
+
+	w := serveAdmin(s, http.MethodGet, "/admin/password-hashes", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("status %d, body %s", w.Code, w.Body)
+	}
+	if res := decodeBody(t, w); res["current_algorithm"] != "argon2id" || res["pending_upgrade"] != 4.0 {
+		t.Errorf("response = %v", res)
+	}
+
+	err = status.Error(codes.Unavailable, "dial tcp 10.0.0.5:50051: connection refused")
+	w = serveAdmin(s, http.MethodGet, "/admin/password-hashes", "")
+	if w.Code != http.StatusServiceUnavailable || containsAny(w.Body.String(), "10.0.0.5", "connection refused") {
+		t.Errorf("backend down: status %d, body %s; want 503 without the backend error", w.Code, w.Body)
+	}
+}
+
+func TestAdminStats(t *testing.T) {
+	var billingErr, notifErr error
+	user := &fakeUserClient{stats: func(*userpb.StatsRequest) (*userpb.StatsResponse, error) {
+		return &userpb.StatsResponse{TotalUsers: 12}, nil
+	}}
+	billing := &fakeBillingClient{stats: func(*billingpb.StatsRequest) (*billingpb.StatsResponse, error) {
+		if billingErr != nil {
+			return nil, billingErr
+		}
+		return &billingpb.StatsResponse{Accounts: 11, TotalAmount: 250.5}, nil
+	}}
+	notif := &fakeNotifClient{stats: func(*notifpb.StatsRequest) (*notifpb.StatsResponse, error) {
+		if notifErr != nil {
+			return nil, notifErr
+		}
+		return &notifpb.StatsResponse{ActiveSubscribers: 3, NotificationsToday: 40}, nil
+	}}
+	s := newTestServer(t, testConfig(), user, billing, notif)
+
+	w := serveAdmin(s, http.MethodGet, "/admin/stats", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("status %d, body %s", w.Code, w.Body)
+	}
+	res := decodeBody(t, w)
+	users, _ := res["users"].(map[string]any)
+	bills, _ := res["billing"].(map[string]any)
+	notifs, _ := res["notifications"].(map[string]any)
+	if users["total_users"] != 12.0 || bills["accounts"] != 11.0 || bills["total_amount"] != 250.5 ||
+		notifs["active_subscribers"] != 3.0 || notifs["notifications_today"] != 40.0 || res["degraded"] != nil {
+		t.Errorf("response = %v", res)
+	}
+
+	// One backend down: the rest is still reported, marked degraded.
+	billingErr = status.Error(codes.Unavailable, "billing down")
+	w = serveAdmin(s, http.MethodGet, "/admin/stats", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("partial failure: status %d, body %s", w.Code, w.Body)
+	}
+	res = decodeBody(t, w)
+	bills, _ = res["billing"].(map[string]any)
+	users, _ = res["users"].(map[string]any)
+	if res["degraded"] != true || bills["error"] == nil || users["total_users"] != 12.0 {
+		t.Errorf("partial failure response = %v", res)
+	}
+
+	s.cfg.aggregateRequireAll = true
+	if w = serveAdmin(s, http.MethodGet, "/admin/stats", ""); w.Code != http.StatusBadGateway {
+		t.Errorf("partial failure with AGGREGATE_REQUIRE_ALL: status %d, want 502", w.Code)
+	}
+}
+
+func TestAdminStatsAllBackendsDown(t *testing.T) {
+	down := status.Error(codes.Unavailable, "down")
+	s := newTestServer(t, testConfig(),
+		&fakeUserClient{stats: func(*userpb.StatsRequest) (*userpb.StatsResponse, error) { return nil, down }},
+		&fakeBillingClient{stats: func(*billingpb.StatsRequest) (*billingpb.StatsResponse, error) { return nil, down }},
+		&fakeNotifClient{stats: func(*notifpb.StatsRequest) (*notifpb.StatsResponse, error) { return nil, down }})
+	if w := serveAdmin(s, http.MethodGet, "/admin/stats", ""); w.Code != http.StatusBadGateway {
+		t.Errorf("status %d, body %s; want 502", w.Code, w.Body)
+	}
+	if w := serve(s, http.MethodGet, "/admin/stats", "", tokenFor(aliceID)); w.Code == http.StatusOK {
+		t.Error("stats served without the admin key")
+	}
+}
+
+func TestAdminEventSchemas(t *testing.T) {
+	user := &fakeUserClient{getEventSchemas: func(*userpb.GetEventSchemasRequest) (*userpb.GetEventSchemasResponse, error) {
+		return &userpb.GetEventSchemasResponse{Events: []*userpb.EventSchema{{Subject: "user.created", Version: 2}}}, nil
+	}}
+	billing := &fakeBillingClient{getEventSchemas: func(*billingpb.GetEventSchemasRequest) (*billingpb.GetEventSchemasResponse, error) {
+		return &billingpb.GetEventSchemasResponse{Events: []*billingpb.EventSchema{{Subject: "bill.update", Version: 2}}}, nil
+	}}
+	s := newTestServer(t, testConfig(), user, billing, nil)
+
+	w := serveAdmin(s, http.MethodGet, "/admin/events", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("status %d, body %s", w.Code, w.Body)
+	}
+	res := decodeBody(t, w)
+	for service, subject := range map[string]string{"user-ms": "user.created", "billing-ms": "bill.update"} {
+		section, _ := res[service].(map[string]any)
+		events, _ := section["events"].([]any)
+		if len(events) != 1 {
+			t.Errorf("%s: events = %v", service, section["events"])
+			continue
+		}
+		if e, _ := events[0].(map[string]any); e["subject"] != subject || e["version"] != 2.0 {
+			t.Errorf("%s: event = %v, want %s at version 2", service, e, subject)
+		}
+	}
+}
+
+func TestAdminTestNotifications(t *testing.T) {
+	var got *notifpb.SendTestNotificationsRequest
+	enabled := true
+	notif := &fakeNotifClient{sendTestNotifications: func(in *notifpb.SendTestNotificationsRequest) (*notifpb.SendTestNotificationsResponse, error) {
+		got = in
+		if !enabled {
+			return nil, status.Error(codes.PermissionDenied, "test notifications are disabled")
+		}
+		return &notifpb.SendTestNotificationsResponse{Notifications: []*notifpb.Notification{
+			{UserId: in.UserId, Type: notifpb.NotificationType_NOTIFICATION_TYPE_WELCOME},
+			{UserId: in.UserId, Type: notifpb.NotificationType_NOTIFICATION_TYPE_BILLING},
+		}}, nil
+	}}
+	s := newTestServer(t, testConfig(), nil, nil, notif)
+	body := `{"user_id":"` + aliceID + `"}`
+
+	if w := serve(s, http.MethodPost, "/admin/notify/test-all", body, tokenFor(aliceID)); w.Code != http.StatusUnauthorized {
+		t.Errorf("without the admin key: status %d, want 401", w.Code)
+	}
+	w := serveAdmin(s, http.MethodPost, "/admin/notify/test-all", body)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status %d, body %s", w.Code, w.Body)
+	}
+	if got.GetUserId() != aliceID {
+		t.Errorf("SendTestNotifications request = %+v", got)
+	}
+	if notifs, _ := decodeBody(t, w)["notifications"].([]any); len(notifs) != 2 {
+		t.Errorf("notifications %v, want both sent", notifs)
+	}
+
+	enabled = false
+	if w := serveAdmin(s, http.MethodPost, "/admin/notify/test-all", body); w.Code != http.StatusForbidden {
+		t.Errorf("disabled in notification-ms: status %d, want 403", w.Code)
+	}
+}
+
+func TestAdminBackfills(t *testing.T) {
+	var started *notifpb.StartBackfillRequest
+	resumeErr := status.Error(codes.FailedPrecondition, "backfill job is running, only failed jobs can be resumed")
+	notif := &fakeNotifClient{
+		startBackfill: func(in *notifpb.StartBackfillRequest) (*notifpb.StartBackfillResponse, error) {
+			started = in
+			return &notifpb.StartBackfillResponse{Job: &notifpb.BackfillJob{Id: notificationID, MessageId: in.MessageId, Status: "running"}}, nil
+		},
+		getBackfill: func(in *notifpb.GetBackfillRequest) (*notifpb.GetBackfillResponse, error) {
+			if in.Id != notificationID {
+				return nil, status.Error(codes.NotFound, "backfill job not found")
+			}
+			return &notifpb.GetBackfillResponse{Job: &notifpb.BackfillJob{Id: in.Id, Status: "completed", Processed: 3}}, nil
+		},
+		resumeBackfill: func(*notifpb.ResumeBackfillRequest) (*notifpb.ResumeBackfillResponse, error) {
+			return nil, resumeErr
+		},
+	}
+	s := newTestServer(t, testConfig(), nil, nil, notif)
+	body := `{"message_id":"policy.updated","type":"NOTIFICATION_TYPE_BILLING"}`
+
+	if w := serve(s, http.MethodPost, "/admin/backfills", body, tokenFor(aliceID)); w.Code != http.StatusUnauthorized {
+		t.Errorf("without the admin key: status %d, want 401", w.Code)
+	}
+	w := serveAdmin(s, http.MethodPost, "/admin/backfills", body)
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("start: status %d, body %s", w.Code, w.Body)
+	}
+	if started.GetMessageId() != "policy.updated" || started.GetType() != notifpb.NotificationType_NOTIFICATION_TYPE_BILLING {
+		t.Errorf("StartBackfill request = %+v", started)
+	}
+	if w := serveAdmin(s, http.MethodPost, "/admin/backfills", `{"message_id":`); w.Code != http.StatusBadRequest {
+		t.Errorf("malformed body: status %d, want 400", w.Code)
+	}
+
+	w = serveAdmin(s, http.MethodGet, "/admin/backfills/"+notificationID, "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("get: status %d, body %s", w.Code, w.Body)
+	}
+	if res := decodeBody(t, w); res["status"] != "completed" || res["processed"] != "3" {
+		t.Errorf("get response = %v", res)
+	}
+	if w := serveAdmin(s, http.MethodGet, "/admin/backfills/not-a-uuid", ""); w.Code != http.StatusBadRequest {
+		t.Errorf("invalid job id: status %d, want 400", w.Code)
+	}
+	if w := serveAdmin(s, http.MethodGet, "/admin/backfills/"+aliceID, ""); w.Code != http.StatusNotFound {
+		t.Errorf("unknown job: status %d, want 404", w.Code)
+	}
+
+	if w := serveAdmin(s, http.MethodPost, "/admin/backfills/"+notificationID+"/resume", ""); w.Code != http.StatusBadRequest {
+		t.Errorf("resume of a running job: status %d, want 400", w.Code)
+	}
+}
+
+func TestAdminStreams(t *testing.T) {
+	var listed *notifpb.ListStreamsRequest
+	var terminated *notifpb.TerminateStreamRequest
+	notif := &fakeNotifClient{
+		listStreams: func(in *notifpb.ListStreamsRequest) (*notifpb.ListStreamsResponse, error) {
+			listed = in
+			return &notifpb.ListStreamsResponse{Total: 3, Streams: []*notifpb.StreamInfo{{UserId: aliceID, SessionId: notificationID, Buffered: 2}}}, nil
+		},
+		terminateStream: func(in *notifpb.TerminateStreamRequest) (*notifpb.TerminateStreamResponse, error) {
+			terminated = in
+			if in.SessionId != notificationID {
+				return nil, status.Error(codes.NotFound, "no open stream for session")
+			}
+			return &notifpb.TerminateStreamResponse{Terminated: 1}, nil
+		},
+	}
+	s := newTestServer(t, testConfig(), nil, nil, notif)
+
+	w := serveAdmin(s, http.MethodGet, "/admin/streams?limit=1&offset=2", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("list: status %d, body %s", w.Code, w.Body)
+	}
+	if listed.GetLimit() != 1 || listed.GetOffset() != 2 {
+		t.Errorf("ListStreams request = %+v", listed)
+	}
+	res := decodeBody(t, w)
+	streams, _ := res["streams"].([]any)
+	if res["total"] != "3" || len(streams) != 1 {
+		t.Errorf("list response = %v", res)
+	}
+	if w := serveAdmin(s, http.MethodGet, "/admin/streams?limit=-1", ""); w.Code != http.StatusBadRequest {
+		t.Errorf("negative limit: status %d, want 400", w.Code)
+	}
+	if w := serve(s, http.MethodGet, "/admin/streams", "", tokenFor(aliceID)); w.Code != http.StatusUnauthorized {
+		t.Errorf("without the admin key: status %d, want 401", w.Code)
+	}
+
+	w = serveAdmin(s, http.MethodDelete, "/admin/streams/"+notificationID+"?user_id="+aliceID, "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("terminate: status %d, body %s", w.Code, w.Body)
+	}
+	if terminated.GetSessionId() != notificationID || terminated.GetUserId() != aliceID {
+		t.Errorf("TerminateStream request = %+v", terminated)
+	}
+	if res := decodeBody(t, w); res["terminated"] != 1.0 {
+		t.Errorf("terminate response = %v", res)
+	}
+	if w := serveAdmin(s, http.MethodDelete, "/admin/streams/"+bobID, ""); w.Code != http.StatusNotFound {
+		t.Errorf("unknown session: status %d, want 404", w.Code)
+	}
+	if w := serveAdmin(s, http.MethodDelete, "/admin/streams/not-a-uuid", ""); w.Code != http.StatusBadRequest {
+		t.Errorf("invalid session id: status %d, want 400", w.Code)
+	}
+}
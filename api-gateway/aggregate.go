@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"sync"
+)
+
+// aggregateCall fetches one section of an aggregated response.
+type aggregateCall func(ctx context.Context) (any, error)
+
+// aggregate runs every call concurrently under the shared
+// cfg.aggregateTimeout deadline and collects the sections that succeeded.
+// Failed sections are returned as {"error": "..."} and reported in failed.
+func (s *apiServer) aggregate(ctx context.Context, calls map[string]aggregateCall) (sections map[string]any, failed int) {
+	ctx, cancel := context.WithTimeout(ctx, s.cfg.aggregateTimeout)
+	defer cancel()
+
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+	sections = make(map[string]any, len(calls))
+	for name, call := range calls {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			res, err := call(ctx)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				s.requestLogger(ctx).Error("aggregation section failed", "section", name, "error", err)
+				sections[name] = map[string]string{"error": err.Error()}
+				failed++
+				return
+			}
+			sections[name] = res
+		}()
+	}
+	wg.Wait()
+	return sections, failed
+}
+
+// writeAggregate responds with the aggregated sections. A partial result is
+// still a 200 with "degraded": true, unless cfg.aggregateRequireAll is set;
+// when nothing succeeded the response is a 502.
+func (s *apiServer) writeAggregate(w http.ResponseWriter, sections map[string]any, failed int) {
+	status := http.StatusOK
+	switch {
+	case failed == len(sections):
+		status = http.StatusBadGateway
+	case failed > 0 && s.cfg.aggregateRequireAll:
+		status = http.StatusBadGateway
+	}
+	if failed > 0 {
+		sections["degraded"] = true
+	}
+	s.writeJSON(w, status, sections)
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAggregateDegradesOnSlowAndFailingSections(t *testing.T) {
+	cfg := testConfig()
+	cfg.aggregateTimeout = 50 * time.Millisecond
+	s := newTestServer(t, cfg, nil, nil, nil)
+	calls := map[string]aggregateCall{
+		"ok": func(context.Context) (any, error) {
+			return map[string]int{"count": 3}, nil
+		},
+		"slow": func(ctx context.Context) (any, error) {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		},
+		"failing": func(context.Context) (any, error) {
+			return nil, errors.New("connection refused")
+		},
+	}
+
+	start := time.Now()
+	sections, failed := s.aggregate(context.Background(), calls)
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("aggregate took %s, want it bounded by the shared deadline", elapsed)
+	}
+	if failed != 2 {
+		t.Errorf("failed = %d, want 2", failed)
+	}
+	w := httptest.NewRecorder()
+	s.writeAggregate(w, sections, failed)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status %d, want 200 for a partial result", w.Code)
+	}
+	res := decodeBody(t, w)
+	if res["degraded"] != true {
+		t.Errorf("degraded = %v, want true", res["degraded"])
+	}
+	if ok, _ := res["ok"].(map[string]any); ok["count"] != 3.0 {
+		t.Errorf("ok section = %v", res["ok"])
+	}
+	for name, want := range map[string]string{"slow": "deadline exceeded", "failing": "connection refused"} {
+		section, _ := res[name].(map[string]any)
+		if msg, _ := section["error"].(string); !strings.Contains(msg, want) {
+			t.Errorf("%s section = %v, want an error mentioning %q", name, res[name], want)
+		}
+	}
+
+	s.cfg.aggregateRequireAll = true
+	w = httptest.NewRecorder()
+	s.writeAggregate(w, sections, failed)
+	if w.Code != http.StatusBadGateway {
+		t.Errorf("status %d with every section required, want 502", w.Code)
+	}
+}
+
+func TestAggregateCompleteIsNotDegraded(t *testing.T) {
+	s := newTestServer(t, testConfig(), nil, nil, nil)
+	sections, failed := s.aggregate(context.Background(), map[string]aggregateCall{
+		"a": func(context.Context) (any, error) { return "x", nil },
+		"b": func(context.Context) (any, error) { return "y", nil },
+	})
+	w := httptest.NewRecorder()
+	s.writeAggregate(w, sections, failed)
+	if res := decodeBody(t, w); w.Code != http.StatusOK || res["degraded"] != nil || res["a"] != "x" || res["b"] != "y" {
+		t.Errorf("status %d, body %v", w.Code, res)
+	}
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"api-gateway/userpb"
+)
+
+// authMiddleware requires an "Authorization: Bearer <token>" header carrying a
+// token that user-ms accepts, and stores the authenticated user id and the
+// token's verified claims in the request context for authUserID and
+// authClaims.
+func (s *apiServer) authMiddleware(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		header := r.Header.Get("Authorization")
+		if header == "" {
+			s.writeJSONError(w, http.StatusUnauthorized, "missing Authorization header")
+			return
+		}
+		token, ok := bearerToken(header)
+		if !ok {
+			s.writeJSONError(w, http.StatusUnauthorized, "Authorization header must be of the form \"Bearer <token>\"")
+			return
+		}
+
+		callCtx, cancel := s.callContext(r)
+		res, err := s.userClient.ValidateToken(callCtx, &userpb.ValidateTokenRequest{Token: token})
+		cancel()
+		if err != nil {
+			s.writeGRPCError(w, r, err, "failed to validate token")
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), userIDKey, res.UserId)
+		ctx = context.WithValue(ctx, authClaimsKey, res)
+		ctx = withLogger(ctx, s.requestLogger(ctx).With("auth_user_id", res.UserId))
+		next(w, r.WithContext(ctx))
+	}
+}
+
+// bearerToken returns the token of an "Authorization: Bearer <token>" header.
+func bearerToken(header string) (string, bool) {
+	scheme, token, ok := strings.Cut(header, " ")
+	token = strings.TrimSpace(token)
+	if !ok || !strings.EqualFold(scheme, "Bearer") || token == "" {
+		return "", false
+	}
+	return token, true
+}
+
+// authUserID returns the user id authMiddleware stored in r's context.
+func authUserID(r *http.Request) (string, bool) {
+	userID, ok := r.Context().Value(userIDKey).(string)
+	return userID, ok
+}
+
+// authClaims returns the claims of the token authMiddleware verified for r.
+func authClaims(r *http.Request) (*userpb.ValidateTokenResponse, bool) {
+	claims, ok := r.Context().Value(authClaimsKey).(*userpb.ValidateTokenResponse)
+	return claims, ok
+}
+
+// handleIntrospect returns the claims of the caller's own token, as verified
+// by user-ms in authMiddleware, so clients need not decode it themselves.
+func (s *apiServer) handleIntrospect() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		claims, ok := authClaims(r)
+		if !ok {
+			s.writeJSONError(w, http.StatusUnauthorized, "not authenticated")
+			return
+		}
+		w.Header().Set("Cache-Control", "no-store")
+		s.writeJSON(w, http.StatusOK, map[string]any{
+			"sub":   claims.UserId,
+			"email": claims.Email,
+			"iat":   claims.IssuedAt,
+			"exp":   claims.ExpiresAt,
+		})
+	}
+}
+
+// handleRefresh exchanges a refresh token for a new access token and refresh
+// token. The old refresh token stops working.
+func (s *apiServer) handleRefresh() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req userpb.RefreshRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			s.writeJSONError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+		var errs fieldErrors
+		if errs.check(req.RefreshToken != "", "refresh_token", "is required"); len(errs) > 0 {
+			s.writeValidationError(w, errs)
+			return
+		}
+
+		ctx, cancel := s.callContext(r)
+		defer cancel()
+		res, err := s.userClient.Refresh(ctx, &req)
+		if err != nil {
+			s.writeGRPCError(w, r, err, "failed to refresh token")
+			return
+		}
+		w.Header().Set("Cache-Control", "no-store")
+		s.writeJSON(w, http.StatusOK, res)
+	}
+}
+
+// handleLogout revokes the caller's token, so later requests carrying it fail
+// with 401 even before it expires. A refresh_token in the optional JSON body is
+// revoked as well.
+func (s *apiServer) handleLogout() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			RefreshToken string `json:"refresh_token"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil && !errors.Is(err, io.EOF) {
+			s.writeJSONError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+		// authMiddleware has already checked the header.
+		token, _ := bearerToken(r.Header.Get("Authorization"))
+
+		ctx, cancel := s.callContext(r)
+		defer cancel()
+		if _, err := s.userClient.Logout(ctx, &userpb.LogoutRequest{Token: token, RefreshToken: body.RefreshToken}); err != nil {
+			s.writeGRPCError(w, r, err, "failed to log out")
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// authorizeUser reports whether the authenticated caller may act on userID's
+// data, writing a 403 response when not.
+func (s *apiServer) authorizeUser(w http.ResponseWriter, r *http.Request, userID string) bool {
+	if authID, ok := authUserID(r); !ok || authID != userID {
+		s.requestLogger(r.Context()).Warn("rejected access to another user's data", "user_id", userID)
+		s.writeJSONError(w, http.StatusForbidden, "not allowed to access another user's data")
+		return false
+	}
+	return true
+}
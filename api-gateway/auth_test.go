@@ -0,0 +1,222 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"api-gateway/billingpb"
+	"api-gateway/userpb"
+)
+
+func TestBillingRoutesRequireToken(t *testing.T) {
+	calls := 0
+	billing := &fakeBillingClient{getBilling: func(in *billingpb.GetBillingRequest) (*billingpb.GetBillingResponse, error) {
+		calls++
+		return &billingpb.GetBillingResponse{}, nil
+	}}
+	s := newTestServer(t, testConfig(), nil, billing, nil)
+	path := "/user/billing/" + aliceID
+
+	for _, tt := range []struct {
+		name   string
+		header string
+		want   string
+	}{
+		{"missing header", "", "missing Authorization header"},
+		{"other scheme", "Basic " + tokenFor(aliceID), "Bearer <token>"},
+		{"no scheme", tokenFor(aliceID), "Bearer <token>"},
+		{"empty token", "Bearer ", "Bearer <token>"},
+		// The fake user-ms rejects it like an expired or forged token.
+		{"rejected token", "Bearer expired", ""},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, path, nil)
+			if tt.header != "" {
+				r.Header.Set("Authorization", tt.header)
+			}
+			w := httptest.NewRecorder()
+			s.ServeHTTP(w, r)
+			if w.Code != http.StatusUnauthorized {
+				t.Fatalf("status %d, want 401", w.Code)
+			}
+			msg, _ := decodeBody(t, w)["error"].(string)
+			if msg == "" || !strings.Contains(msg, tt.want) {
+				t.Errorf("error %q, want it to mention %q", msg, tt.want)
+			}
+		})
+	}
+	if w := serve(s, http.MethodPost, "/user/billing/update", `{"user_id":"`+aliceID+`","amount":1}`, ""); w.Code != http.StatusUnauthorized {
+		t.Errorf("update without a token: status %d, want 401", w.Code)
+	}
+	if calls != 0 {
+		t.Fatalf("billing called %d times for unauthenticated requests", calls)
+	}
+
+	if w := serve(s, http.MethodGet, path, "", tokenFor(aliceID)); w.Code != http.StatusOK {
+		t.Errorf("valid token: status %d, body %s", w.Code, w.Body)
+	}
+}
+
+func TestRegisterIsPublic(t *testing.T) {
+	user := &fakeUserClient{register: func(*userpb.RegisterRequest) (*userpb.RegisterResponse, error) {
+		return &userpb.RegisterResponse{UserId: aliceID}, nil
+	}}
+	s := newTestServer(t, testConfig(), user, nil, nil)
+	if w := serve(s, http.MethodPost, "/register", `{"email":"alice@example.com","password":"secret123"}`, ""); w.Code != http.StatusOK {
+		t.Errorf("register without a token: status %d, body %s", w.Code, w.Body)
+	}
+}
+
+func TestBillingOfAnotherUserForbidden(t *testing.T) {
+	calls := 0
+	billing := &fakeBillingClient{
+		getBilling: func(*billingpb.GetBillingRequest) (*billingpb.GetBillingResponse, error) {
+			calls++
+			return &billingpb.GetBillingResponse{}, nil
+		},
+		updateBilling: func(*billingpb.UpdateBillingRequest) (*billingpb.UpdateBillingResponse, error) {
+			calls++
+			return &billingpb.UpdateBillingResponse{Success: true}, nil
+		},
+	}
+	s := newTestServer(t, testConfig(), nil, billing, nil)
+
+	if w := serve(s, http.MethodGet, "/user/billing/"+bobID, "", tokenFor(aliceID)); w.Code != http.StatusForbidden {
+		t.Errorf("alice reading bob's billing: status %d, want 403", w.Code)
+	}
+	if w := serve(s, http.MethodPost, "/user/billing/update", `{"user_id":"`+bobID+`","amount":10}`, tokenFor(aliceID)); w.Code != http.StatusForbidden {
+		t.Errorf("alice updating bob's billing: status %d, want 403", w.Code)
+	}
+	if calls != 0 {
+		t.Errorf("billing called %d times for another user's data", calls)
+	}
+	if w := serve(s, http.MethodGet, "/user/billing/"+bobID, "", tokenFor(bobID)); w.Code != http.StatusOK {
+		t.Errorf("bob reading their own billing: status %d", w.Code)
+	}
+}
+
+func TestIntrospectReturnsOwnClaims(t *testing.T) {
+	s := newTestServer(t, testConfig(), nil, nil, nil)
+
+	for _, user := range []string{aliceID, bobID} {
+		w := serve(s, http.MethodGet, "/auth/introspect", "", tokenFor(user))
+		if w.Code != http.StatusOK {
+			t.Fatalf("status %d, body %s", w.Code, w.Body)
+		}
+		if got := w.Header().Get("Cache-Control"); got != "no-store" {
+			t.Errorf("Cache-Control %q, want no-store", got)
+		}
+		res := decodeBody(t, w)
+		if res["sub"] != user || res["email"] != user+"@example.com" ||
+			res["iat"] != float64(tokenIssuedAt) || res["exp"] != float64(tokenIssuedAt+900) {
+			t.Errorf("claims for %s = %v", user, res)
+		}
+	}
+}
+
+func TestIntrospectRejectsMissingOrInvalidToken(t *testing.T) {
+	s := newTestServer(t, testConfig(), nil, nil, nil)
+	for name, token := range map[string]string{"missing": "", "invalid": "forged"} {
+		w := serve(s, http.MethodGet, "/auth/introspect", "", token)
+		if w.Code != http.StatusUnauthorized || strings.Contains(w.Body.String(), "@example.com") {
+			t.Errorf("%s token: status %d, body %s; want 401 without claims", name, w.Code, w.Body)
+		}
+	}
+}
+
+// rotatingRefreshTokens is a user-ms that accepts each refresh token once,
+// handing out the next one in its place, and forgets those revoked by logout.
+func rotatingRefreshTokens() *fakeUserClient {
+	var mu sync.Mutex
+	valid := map[string]bool{"refresh-1": true}
+	issued := 1
+	return &fakeUserClient{
+		refresh: func(in *userpb.RefreshRequest) (*userpb.LoginResponse, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			if !valid[in.RefreshToken] {
+				return nil, status.Error(codes.Unauthenticated, "invalid refresh token")
+			}
+			delete(valid, in.RefreshToken)
+			issued++
+			next := fmt.Sprintf("refresh-%d", issued)
+			valid[next] = true
+			return &userpb.LoginResponse{Token: tokenFor(aliceID), RefreshToken: next, User: &userpb.User{Id: aliceID}}, nil
+		},
+		logout: func(in *userpb.LogoutRequest) (*userpb.LogoutResponse, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			delete(valid, in.RefreshToken)
+			return &userpb.LogoutResponse{}, nil
+		},
+	}
+}
+
+func TestRefreshRotatesToken(t *testing.T) {
+	s := newTestServer(t, testConfig(), rotatingRefreshTokens(), nil, nil)
+
+	w := serve(s, http.MethodPost, "/refresh", `{"refresh_token":"refresh-1"}`, "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("status %d, body %s", w.Code, w.Body)
+	}
+	if got := w.Header().Get("Cache-Control"); got != "no-store" {
+		t.Errorf("Cache-Control %q, want no-store", got)
+	}
+	res := decodeBody(t, w)
+	if res["token"] != tokenFor(aliceID) || res["refresh_token"] != "refresh-2" {
+		t.Fatalf("response %v, want a new access token and refresh-2", res)
+	}
+
+	// The presented token was used up by the rotation.
+	if w := serve(s, http.MethodPost, "/refresh", `{"refresh_token":"refresh-1"}`, ""); w.Code != http.StatusUnauthorized {
+		t.Errorf("reused refresh token: status %d, want 401", w.Code)
+	}
+}
+
+func TestRefreshAfterLogout(t *testing.T) {
+	s := newTestServer(t, testConfig(), rotatingRefreshTokens(), nil, nil)
+
+	if w := serve(s, http.MethodPost, "/logout", `{"refresh_token":"refresh-1"}`, tokenFor(aliceID)); w.Code != http.StatusNoContent {
+		t.Fatalf("logout: status %d, body %s", w.Code, w.Body)
+	}
+	if w := serve(s, http.MethodPost, "/refresh", `{"refresh_token":"refresh-1"}`, ""); w.Code != http.StatusUnauthorized {
+		t.Errorf("revoked refresh token: status %d, want 401", w.Code)
+	}
+}
+
+func TestRefreshErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		err      error
+		wantCode int
+	}{
+		{"invalid body", `{`, nil, http.StatusBadRequest},
+		{"missing token", `{}`, nil, http.StatusBadRequest},
+		{"expired token", `{"refresh_token":"r"}`, status.Error(codes.Unauthenticated, "invalid refresh token: expired"), http.StatusUnauthorized},
+		{"user-ms failure", `{"refresh_token":"r"}`, status.Error(codes.Internal, "internal server error"), http.StatusInternalServerError},
+		{"user-ms down", `{"refresh_token":"r"}`, status.Error(codes.Unavailable, "connection refused"), http.StatusServiceUnavailable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user := &fakeUserClient{refresh: func(*userpb.RefreshRequest) (*userpb.LoginResponse, error) {
+				if tt.err == nil {
+					t.Error("user-ms called for a request the gateway should reject")
+				}
+				return nil, tt.err
+			}}
+			s := newTestServer(t, testConfig(), user, nil, nil)
+			w := serve(s, http.MethodPost, "/refresh", tt.body, "")
+			if w.Code != tt.wantCode {
+				t.Errorf("status %d, want %d: %s", w.Code, tt.wantCode, w.Body)
+			}
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"api-gateway/billingpb"
+)
+
+func TestUpdateBillingExpectedVersion(t *testing.T) {
+	current := int64(4)
+	billing := &fakeBillingClient{updateBilling: func(in *billingpb.UpdateBillingRequest) (*billingpb.UpdateBillingResponse, error) {
+		if in.ExpectedVersion != nil && *in.ExpectedVersion != current {
+			return nil, status.Errorf(codes.Aborted, "expected version %d, current version %d", *in.ExpectedVersion, current)
+		}
+		current++
+		return &billingpb.UpdateBillingResponse{Success: true, Version: current}, nil
+	}}
+	s := newTestServer(t, testConfig(), nil, billing, nil)
+
+	w := serve(s, http.MethodPost, "/user/billing/update", `{"user_id":"`+aliceID+`","amount":10,"expected_version":3}`, tokenFor(aliceID))
+	if w.Code != http.StatusConflict {
+		t.Errorf("stale version: status %d, body %s; want 409", w.Code, w.Body)
+	}
+
+	w = serve(s, http.MethodPost, "/user/billing/update", `{"user_id":"`+aliceID+`","amount":10,"expected_version":4}`, tokenFor(aliceID))
+	if w.Code != http.StatusOK {
+		t.Fatalf("current version: status %d, body %s", w.Code, w.Body)
+	}
+	if res := decodeBody(t, w); res["version"] != 5.0 {
+		t.Errorf("response = %v, want version 5", res)
+	}
+}
+
+func TestGetBillingMissingAccountIs404(t *testing.T) {
+	billing := &fakeBillingClient{getBilling: func(in *billingpb.GetBillingRequest) (*billingpb.GetBillingResponse, error) {
+		if in.UserId == aliceID {
+			return &billingpb.GetBillingResponse{Amount: 12.5, Version: 2}, nil
+		}
+		return nil, status.Error(codes.NotFound, "billing account not found")
+	}}
+	s := newTestServer(t, testConfig(), nil, billing, nil)
+
+	w := serve(s, http.MethodGet, "/user/billing/"+aliceID, "", tokenFor(aliceID))
+	if w.Code != http.StatusOK || decodeBody(t, w)["amount"] != 12.5 {
+		t.Errorf("existing account: status %d, body %s", w.Code, w.Body)
+	}
+	w = serve(s, http.MethodGet, "/user/billing/"+bobID, "", tokenFor(bobID))
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("missing account: status %d, want 404", w.Code)
+	}
+	if msg := decodeBody(t, w)["error"]; msg != "billing account not found" {
+		t.Errorf("error %v", msg)
+	}
+}
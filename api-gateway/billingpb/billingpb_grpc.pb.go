@@ -22,6 +22,11 @@ const (
 	BillingService_CreateBillingAccount_FullMethodName = "/billingpb.BillingService/CreateBillingAccount"
 	BillingService_GetBilling_FullMethodName           = "/billingpb.BillingService/GetBilling"
 	BillingService_UpdateBilling_FullMethodName        = "/billingpb.BillingService/UpdateBilling"
+	BillingService_RecalculateBilling_FullMethodName   = "/billingpb.BillingService/RecalculateBilling"
+	BillingService_Stats_FullMethodName                = "/billingpb.BillingService/Stats"
+	BillingService_SetConsumptionPaused_FullMethodName = "/billingpb.BillingService/SetConsumptionPaused"
+	BillingService_GetEventSchemas_FullMethodName      = "/billingpb.BillingService/GetEventSchemas"
+	BillingService_ExportTransactions_FullMethodName   = "/billingpb.BillingService/ExportTransactions"
 )
 
 // BillingServiceClient is the client API for BillingService service.
@@ -31,6 +36,16 @@ type BillingServiceClient interface {
 	CreateBillingAccount(ctx context.Context, in *CreateBillingAccountRequest, opts ...grpc.CallOption) (*CreateBillingAccountResponse, error)
 	GetBilling(ctx context.Context, in *GetBillingRequest, opts ...grpc.CallOption) (*GetBillingResponse, error)
 	UpdateBilling(ctx context.Context, in *UpdateBillingRequest, opts ...grpc.CallOption) (*UpdateBillingResponse, error)
+	RecalculateBilling(ctx context.Context, in *RecalculateBillingRequest, opts ...grpc.CallOption) (*RecalculateBillingResponse, error)
+	Stats(ctx context.Context, in *StatsRequest, opts ...grpc.CallOption) (*StatsResponse, error)
+	// Pauses or resumes processing of consumed NATS events. Paused events are
+	// queued, not dropped.
+	SetConsumptionPaused(ctx context.Context, in *SetConsumptionPausedRequest, opts ...grpc.CallOption) (*SetConsumptionPausedResponse, error)
+	// Describes the events this service publishes.
+	GetEventSchemas(ctx context.Context, in *GetEventSchemasRequest, opts ...grpc.CallOption) (*GetEventSchemasResponse, error)
+	// Streams a user's ledger, oldest first, one page at a time so exports of
+	// any size never have to be held in memory.
+	ExportTransactions(ctx context.Context, in *ExportTransactionsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[TransactionPage], error)
 }
 
 type billingServiceClient struct {
@@ -71,6 +86,65 @@ func (c *billingServiceClient) UpdateBilling(ctx context.Context, in *UpdateBill
 	return out, nil
 }
 
+func (c *billingServiceClient) RecalculateBilling(ctx context.Context, in *RecalculateBillingRequest, opts ...grpc.CallOption) (*RecalculateBillingResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RecalculateBillingResponse)
+	err := c.cc.Invoke(ctx, BillingService_RecalculateBilling_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *billingServiceClient) Stats(ctx context.Context, in *StatsRequest, opts ...grpc.CallOption) (*StatsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(StatsResponse)
+	err := c.cc.Invoke(ctx, BillingService_Stats_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *billingServiceClient) SetConsumptionPaused(ctx context.Context, in *SetConsumptionPausedRequest, opts ...grpc.CallOption) (*SetConsumptionPausedResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SetConsumptionPausedResponse)
+	err := c.cc.Invoke(ctx, BillingService_SetConsumptionPaused_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *billingServiceClient) GetEventSchemas(ctx context.Context, in *GetEventSchemasRequest, opts ...grpc.CallOption) (*GetEventSchemasResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetEventSchemasResponse)
+	err := c.cc.Invoke(ctx, BillingService_GetEventSchemas_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *billingServiceClient) ExportTransactions(ctx context.Context, in *ExportTransactionsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[TransactionPage], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &BillingService_ServiceDesc.Streams[0], BillingService_ExportTransactions_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[ExportTransactionsRequest, TransactionPage]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type BillingService_ExportTransactionsClient = grpc.ServerStreamingClient[TransactionPage]
+
 // BillingServiceServer is the server API for BillingService service.
 // All implementations must embed UnimplementedBillingServiceServer
 // for forward compatibility.
@@ -78,6 +152,16 @@ type BillingServiceServer interface {
 	CreateBillingAccount(context.Context, *CreateBillingAccountRequest) (*CreateBillingAccountResponse, error)
 	GetBilling(context.Context, *GetBillingRequest) (*GetBillingResponse, error)
 	UpdateBilling(context.Context, *UpdateBillingRequest) (*UpdateBillingResponse, error)
+	RecalculateBilling(context.Context, *RecalculateBillingRequest) (*RecalculateBillingResponse, error)
+	Stats(context.Context, *StatsRequest) (*StatsResponse, error)
+	// Pauses or resumes processing of consumed NATS events. Paused events are
+	// queued, not dropped.
+	SetConsumptionPaused(context.Context, *SetConsumptionPausedRequest) (*SetConsumptionPausedResponse, error)
+	// Describes the events this service publishes.
+	GetEventSchemas(context.Context, *GetEventSchemasRequest) (*GetEventSchemasResponse, error)
+	// Streams a user's ledger, oldest first, one page at a time so exports of
+	// any size never have to be held in memory.
+	ExportTransactions(*ExportTransactionsRequest, grpc.ServerStreamingServer[TransactionPage]) error
 	mustEmbedUnimplementedBillingServiceServer()
 }
 
@@ -97,6 +181,21 @@ func (UnimplementedBillingServiceServer) GetBilling(context.Context, *GetBilling
 func (UnimplementedBillingServiceServer) UpdateBilling(context.Context, *UpdateBillingRequest) (*UpdateBillingResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method UpdateBilling not implemented")
 }
+func (UnimplementedBillingServiceServer) RecalculateBilling(context.Context, *RecalculateBillingRequest) (*RecalculateBillingResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RecalculateBilling not implemented")
+}
+func (UnimplementedBillingServiceServer) Stats(context.Context, *StatsRequest) (*StatsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Stats not implemented")
+}
+func (UnimplementedBillingServiceServer) SetConsumptionPaused(context.Context, *SetConsumptionPausedRequest) (*SetConsumptionPausedResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetConsumptionPaused not implemented")
+}
+func (UnimplementedBillingServiceServer) GetEventSchemas(context.Context, *GetEventSchemasRequest) (*GetEventSchemasResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetEventSchemas not implemented")
+}
+func (UnimplementedBillingServiceServer) ExportTransactions(*ExportTransactionsRequest, grpc.ServerStreamingServer[TransactionPage]) error {
+	return status.Errorf(codes.Unimplemented, "method ExportTransactions not implemented")
+}
 func (UnimplementedBillingServiceServer) mustEmbedUnimplementedBillingServiceServer() {}
 func (UnimplementedBillingServiceServer) testEmbeddedByValue()                        {}
 
@@ -172,6 +271,89 @@ func _BillingService_UpdateBilling_Handler(srv interface{}, ctx context.Context,
 	return interceptor(ctx, in, info, handler)
 }
 
+func _BillingService_RecalculateBilling_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RecalculateBillingRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BillingServiceServer).RecalculateBilling(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BillingService_RecalculateBilling_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BillingServiceServer).RecalculateBilling(ctx, req.(*RecalculateBillingRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BillingService_Stats_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(StatsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BillingServiceServer).Stats(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BillingService_Stats_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BillingServiceServer).Stats(ctx, req.(*StatsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BillingService_SetConsumptionPaused_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetConsumptionPausedRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BillingServiceServer).SetConsumptionPaused(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BillingService_SetConsumptionPaused_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BillingServiceServer).SetConsumptionPaused(ctx, req.(*SetConsumptionPausedRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BillingService_GetEventSchemas_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetEventSchemasRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BillingServiceServer).GetEventSchemas(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BillingService_GetEventSchemas_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BillingServiceServer).GetEventSchemas(ctx, req.(*GetEventSchemasRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BillingService_ExportTransactions_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ExportTransactionsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(BillingServiceServer).ExportTransactions(m, &grpc.GenericServerStream[ExportTransactionsRequest, TransactionPage]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type BillingService_ExportTransactionsServer = grpc.ServerStreamingServer[TransactionPage]
+
 // BillingService_ServiceDesc is the grpc.ServiceDesc for BillingService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -191,7 +373,29 @@ var BillingService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "UpdateBilling",
 			Handler:    _BillingService_UpdateBilling_Handler,
 		},
+		{
+			MethodName: "RecalculateBilling",
+			Handler:    _BillingService_RecalculateBilling_Handler,
+		},
+		{
+			MethodName: "Stats",
+			Handler:    _BillingService_Stats_Handler,
+		},
+		{
+			MethodName: "SetConsumptionPaused",
+			Handler:    _BillingService_SetConsumptionPaused_Handler,
+		},
+		{
+			MethodName: "GetEventSchemas",
+			Handler:    _BillingService_GetEventSchemas_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "ExportTransactions",
+			Handler:       _BillingService_ExportTransactions_Handler,
+			ServerStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "billingpb/billingpb.proto",
 }
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/sony/gobreaker"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// healthMethodPrefix identifies the gRPC health checks made by /readyz. They
+// bypass the breaker so readiness always reflects the backend itself.
+const healthMethodPrefix = "/grpc.health.v1.Health/"
+
+var breakerState = promauto.NewGaugeVec(prometheus.GaugeOpts{
+	Name: "gateway_circuit_breaker_state",
+	Help: "State of the circuit breaker in front of each backend: 0 closed, 1 half-open, 2 open.",
+}, []string{"service"})
+
+// breakerConfig configures the circuit breaker in front of one backend.
+type breakerConfig struct {
+	// failures is the number of consecutive failed calls that opens the
+	// breaker; zero disables it.
+	failures int
+	// cooldown is how long the breaker stays open before a trial call is let
+	// through.
+	cooldown time.Duration
+}
+
+// loadBreakerConfig reads the breaker settings for service, e.g. "billing",
+// from BILLING_BREAKER_FAILURES and BILLING_BREAKER_COOLDOWN, falling back to
+// BREAKER_FAILURES (default 5) and BREAKER_COOLDOWN (default 30s).
+func loadBreakerConfig(service string) breakerConfig {
+	prefix := strings.ToUpper(service) + "_"
+	failures := getEnvInt("BREAKER_FAILURES", 5)
+	cooldown := getEnvDuration("BREAKER_COOLDOWN", 30*time.Second)
+	return breakerConfig{
+		failures: getEnvInt(prefix+"BREAKER_FAILURES", failures),
+		cooldown: getEnvDuration(prefix+"BREAKER_COOLDOWN", cooldown),
+	}
+}
+
+// breakerFailure reports whether err means the backend itself is unhealthy.
+// Client errors such as NOT_FOUND and calls cancelled by the caller do not
+// count against it.
+func breakerFailure(err error) bool {
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded, codes.Internal, codes.Unknown:
+		return true
+	}
+	return false
+}
+
+// breakerDialOptions returns the interceptors that guard the connection to
+// service with a circuit breaker. After cfg.failures consecutive failures the
+// breaker opens and calls fail immediately with UNAVAILABLE, which handlers
+// answer with 503, until cfg.cooldown has passed and a trial call succeeds.
+func breakerDialOptions(logger *slog.Logger, service string, cfg breakerConfig) []grpc.DialOption {
+	if cfg.failures <= 0 {
+		return nil
+	}
+	cb := gobreaker.NewCircuitBreaker(gobreaker.Settings{
+		Name:    service,
+		Timeout: cfg.cooldown,
+		ReadyToTrip: func(counts gobreaker.Counts) bool {
+			return counts.ConsecutiveFailures >= uint32(cfg.failures)
+		},
+		IsSuccessful: func(err error) bool { return !breakerFailure(err) },
+		OnStateChange: func(name string, from, to gobreaker.State) {
+			logger.Warn("circuit breaker state changed", "service", name, "from", from.String(), "to", to.String())
+			breakerState.WithLabelValues(name).Set(float64(to))
+		},
+	})
+	breakerState.WithLabelValues(service).Set(float64(gobreaker.StateClosed))
+
+	// call runs fn through the breaker, turning a rejected call into
+	// UNAVAILABLE.
+	call := func(fn func() error) error {
+		_, err := cb.Execute(func() (any, error) { return nil, fn() })
+		if err == gobreaker.ErrOpenState || err == gobreaker.ErrTooManyRequests {
+			return status.Errorf(codes.Unavailable, "%s service circuit breaker is open", service)
+		}
+		return err
+	}
+
+	unary := func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		if strings.HasPrefix(method, healthMethodPrefix) {
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}
+		return call(func() error { return invoker(ctx, method, req, reply, cc, opts...) })
+	}
+	// Only opening a stream goes through the breaker; errors on an
+	// established stream are the handler's to deal with.
+	stream := func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		var cs grpc.ClientStream
+		err := call(func() error {
+			var err error
+			cs, err = streamer(ctx, desc, cc, method, opts...)
+			return err
+		})
+		return cs, err
+	}
+	return []grpc.DialOption{
+		grpc.WithChainUnaryInterceptor(unary),
+		grpc.WithChainStreamInterceptor(stream),
+	}
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"api-gateway/billingpb"
+)
+
+// flakyBilling is a billing-ms that fails GetBilling while down is set and
+// counts the calls that reach it.
+type flakyBilling struct {
+	billingpb.UnimplementedBillingServiceServer
+	down  atomic.Bool
+	calls atomic.Int32
+}
+
+func (b *flakyBilling) GetBilling(context.Context, *billingpb.GetBillingRequest) (*billingpb.GetBillingResponse, error) {
+	b.calls.Add(1)
+	if b.down.Load() {
+		return nil, status.Error(codes.Unavailable, "billing down")
+	}
+	return &billingpb.GetBillingResponse{Amount: 5}, nil
+}
+
+// breakerBackend serves b over an in-memory connection guarded by a circuit
+// breaker named service.
+func breakerBackend(t *testing.T, b *flakyBilling, service string, cfg breakerConfig) billingpb.BillingServiceClient {
+	t.Helper()
+	lis := bufconn.Listen(1 << 16)
+	srv := grpc.NewServer()
+	billingpb.RegisterBillingServiceServer(srv, b)
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	opts := append([]grpc.DialOption{
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return lis.Dial() }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	}, breakerDialOptions(logger, service, cfg)...)
+	conn, err := grpc.NewClient("passthrough:///bufnet", opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return billingpb.NewBillingServiceClient(conn)
+}
+
+// breakerGauge returns the /metrics line for service's breaker state.
+func breakerGauge(t *testing.T, s http.Handler, service string) string {
+	t.Helper()
+	w := serve(s, http.MethodGet, "/metrics", "", "")
+	for _, line := range strings.Split(w.Body.String(), "\n") {
+		if strings.HasPrefix(line, `gateway_circuit_breaker_state{service="`+service+`"}`) {
+			return line
+		}
+	}
+	t.Fatalf("no breaker state for %s in /metrics", service)
+	return ""
+}
+
+func TestBreakerOpensAndRecovers(t *testing.T) {
+	const service = "billing-trip"
+	backend := &flakyBilling{}
+	backend.down.Store(true)
+	billing := breakerBackend(t, backend, service, breakerConfig{failures: 3, cooldown: 100 * time.Millisecond})
+	s := newAPIServer(&fakeUserClient{}, billing, &fakeNotifClient{}, nil, testConfig(), slog.New(slog.NewTextHandler(io.Discard, nil)))
+	path := "/user/billing/" + aliceID
+
+	for i := range 3 {
+		if w := serve(s, http.MethodGet, path, "", tokenFor(aliceID)); w.Code != http.StatusServiceUnavailable {
+			t.Fatalf("failure %d: status %d, want 503", i+1, w.Code)
+		}
+	}
+	if got := backend.calls.Load(); got != 3 {
+		t.Fatalf("backend saw %d calls before the breaker opened, want 3", got)
+	}
+	if line := breakerGauge(t, s, service); !strings.HasSuffix(line, " 2") {
+		t.Errorf("after tripping: %s, want open (2)", line)
+	}
+
+	// While open, requests fail without reaching the backend, even if it
+	// has already recovered.
+	backend.down.Store(false)
+	for range 5 {
+		if w := serve(s, http.MethodGet, path, "", tokenFor(aliceID)); w.Code != http.StatusServiceUnavailable {
+			t.Fatalf("open breaker: status %d, want 503", w.Code)
+		}
+	}
+	if got := backend.calls.Load(); got != 3 {
+		t.Errorf("backend saw %d calls, want none while the breaker is open", got-3)
+	}
+
+	// After the cooldown a trial call goes through and closes the breaker.
+	time.Sleep(150 * time.Millisecond)
+	if w := serve(s, http.MethodGet, path, "", tokenFor(aliceID)); w.Code != http.StatusOK {
+		t.Fatalf("after cooldown: status %d, body %s", w.Code, w.Body)
+	}
+	if line := breakerGauge(t, s, service); !strings.HasSuffix(line, " 0") {
+		t.Errorf("after recovery: %s, want closed (0)", line)
+	}
+}
+
+func TestBreakerIgnoresClientErrors(t *testing.T) {
+	backend := &flakyBilling{}
+	billing := breakerBackend(t, backend, "billing-client-errors", breakerConfig{failures: 2, cooldown: time.Minute})
+
+	// Each of these is the caller's fault, not the backend's.
+	for range 5 {
+		if _, err := billing.GetBilling(context.Background(), &billingpb.GetBillingRequest{UserId: aliceID}, grpc.WaitForReady(true)); err != nil {
+			t.Fatal(err)
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		billing.GetBilling(ctx, &billingpb.GetBillingRequest{UserId: aliceID})
+	}
+	if _, err := billing.GetBilling(context.Background(), &billingpb.GetBillingRequest{UserId: aliceID}); err != nil {
+		t.Errorf("breaker opened on cancelled calls: %v", err)
+	}
+}
+
+func TestBreakerDisabled(t *testing.T) {
+	if opts := breakerDialOptions(slog.New(slog.NewTextHandler(io.Discard, nil)), "billing", breakerConfig{failures: 0}); len(opts) != 0 {
+		t.Errorf("BREAKER_FAILURES=0 installed %d interceptors", len(opts))
+	}
+}
+
+func TestLoadBreakerConfig(t *testing.T) {
+	t.Setenv("BREAKER_FAILURES", "7")
+	t.Setenv("BREAKER_COOLDOWN", "10s")
+	t.Setenv("BILLING_BREAKER_FAILURES", "2")
+	if got := loadBreakerConfig("billing"); got != (breakerConfig{failures: 2, cooldown: 10 * time.Second}) {
+		t.Errorf("billing config = %+v", got)
+	}
+	if got := loadBreakerConfig("user"); got != (breakerConfig{failures: 7, cooldown: 10 * time.Second}) {
+		t.Errorf("user config = %+v", got)
+	}
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// config holds the gateway settings read from the environment at startup.
+type config struct {
+	// adminAPIKey guards the /admin routes; they are disabled when it is empty.
+	adminAPIKey string
+	// wsMaxLifetime caps how long a WebSocket may stay open; zero means unlimited.
+	wsMaxLifetime time.Duration
+	// allowedOrigins are the cross-site origins allowed to open a WebSocket;
+	// allowAllOrigins lifts the check entirely and is meant for local
+	// development only.
+	allowedOrigins  []string
+	allowAllOrigins bool
+	// grpcTimeout bounds each outbound gRPC call made by a handler.
+	grpcTimeout time.Duration
+	// aggregateTimeout is the shared deadline for the backend calls behind an
+	// aggregated endpoint such as /admin/stats.
+	aggregateTimeout time.Duration
+	// aggregateRequireAll fails an aggregated response with 502 when any
+	// section fails instead of returning a degraded 200.
+	aggregateRequireAll bool
+	// maxURLLength rejects longer request URIs with 414; zero disables the check.
+	maxURLLength int
+	// trailingSlash is the policy for unmatched paths with a trailing slash:
+	// "strict", "redirect" or "strip".
+	trailingSlash string
+	// maintenanceMode is the initial maintenance state; admins can toggle it at runtime.
+	maintenanceMode       bool
+	maintenanceMessage    string
+	maintenanceRetryAfter time.Duration
+	// readyzTimeout bounds each backend health check made by /readyz.
+	readyzTimeout time.Duration
+	// passwordMinLength is the shortest password accepted at registration. It
+	// reads the same PASSWORD_MIN_LENGTH as user-ms, which has the final say.
+	passwordMinLength int
+	// rateLimitAuthPerMinute limits login and registration attempts per
+	// client IP; zero disables the limit.
+	rateLimitAuthPerMinute int
+	// rateLimitPerSecond and rateLimitBurst limit every other route per
+	// client IP; a zero rate disables the limit.
+	rateLimitPerSecond int
+	rateLimitBurst     int
+	// rateLimitIdleTTL is how long an unused client's bucket is kept.
+	rateLimitIdleTTL time.Duration
+	// supportedLocales are the locales Accept-Language is resolved to, with
+	// defaultLocale used when none matches.
+	supportedLocales []string
+	defaultLocale    string
+}
+
+func loadConfig() config {
+	return config{
+		adminAPIKey:            os.Getenv("ADMIN_API_KEY"),
+		wsMaxLifetime:          getEnvDuration("WS_MAX_LIFETIME", 0),
+		allowedOrigins:         loadAllowedOrigins(),
+		allowAllOrigins:        getEnvBool("WS_ALLOW_ALL_ORIGINS", false),
+		grpcTimeout:            getEnvDuration("GRPC_TIMEOUT", 5*time.Second),
+		aggregateTimeout:       getEnvDuration("AGGREGATE_TIMEOUT", 3*time.Second),
+		aggregateRequireAll:    getEnvBool("AGGREGATE_REQUIRE_ALL", false),
+		maxURLLength:           getEnvInt("MAX_URL_LENGTH", 2048),
+		trailingSlash:          loadTrailingSlashPolicy(),
+		maintenanceMode:        getEnvBool("MAINTENANCE_MODE", false),
+		maintenanceMessage:     os.Getenv("MAINTENANCE_MESSAGE"),
+		maintenanceRetryAfter:  getEnvDuration("MAINTENANCE_RETRY_AFTER", 5*time.Minute),
+		readyzTimeout:          getEnvDuration("READYZ_TIMEOUT", time.Second),
+		passwordMinLength:      getEnvInt("PASSWORD_MIN_LENGTH", 8),
+		rateLimitAuthPerMinute: getEnvInt("RATE_LIMIT_AUTH_PER_MINUTE", 5),
+		rateLimitPerSecond:     getEnvInt("RATE_LIMIT_PER_SECOND", 10),
+		rateLimitBurst:         getEnvInt("RATE_LIMIT_BURST", 20),
+		rateLimitIdleTTL:       getEnvDuration("RATE_LIMIT_IDLE_TTL", 10*time.Minute),
+		supportedLocales:       loadSupportedLocales(),
+		defaultLocale:          strings.ToLower(getEnv("DEFAULT_LOCALE", "en")),
+	}
+}
+
+// getEnv returns the value of key, or def when it is unset.
+func getEnv(key, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return def
+}
+
+// getEnvDuration returns key parsed as a time.Duration, or def when it is
+// unset or invalid.
+func getEnvDuration(key string, def time.Duration) time.Duration {
+	v := os.Getenv(key)
+	if v == "" {
+		return def
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		slog.Warn("invalid duration setting, using default", "key", key, "value", v, "default", def)
+		return def
+	}
+	return d
+}
+
+// getEnvInt returns key parsed as an int, or def when it is unset or invalid.
+func getEnvInt(key string, def int) int {
+	v := os.Getenv(key)
+	if v == "" {
+		return def
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		slog.Warn("invalid integer setting, using default", "key", key, "value", v, "default", def)
+		return def
+	}
+	return n
+}
+
+// getEnvBool returns key parsed as a bool, or def when it is unset or invalid.
+func getEnvBool(key string, def bool) bool {
+	v := os.Getenv(key)
+	if v == "" {
+		return def
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		slog.Warn("invalid boolean setting, using default", "key", key, "value", v, "default", def)
+		return def
+	}
+	return b
+}
+
+// loadTrailingSlashPolicy reads TRAILING_SLASH, defaulting to strict.
+func loadTrailingSlashPolicy() string {
+	switch v := getEnv("TRAILING_SLASH", trailingSlashStrict); v {
+	case trailingSlashStrict, trailingSlashRedirect, trailingSlashStrip:
+		return v
+	default:
+		slog.Warn("invalid trailing slash policy, using default", "key", "TRAILING_SLASH", "value", v, "default", trailingSlashStrict)
+		return trailingSlashStrict
+	}
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"api-gateway/billingpb"
+)
+
+func TestAdminSetConsumption(t *testing.T) {
+	var got []bool
+	billing := &fakeBillingClient{setConsumptionPaused: func(in *billingpb.SetConsumptionPausedRequest) (*billingpb.SetConsumptionPausedResponse, error) {
+		got = append(got, in.Paused)
+		return &billingpb.SetConsumptionPausedResponse{Paused: in.Paused, PendingEvents: 3}, nil
+	}}
+	s := newTestServer(t, testConfig(), nil, billing, nil)
+
+	w := serveAdmin(s, http.MethodPost, "/admin/consumers/billing/pause", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("pause: status %d, body %s", w.Code, w.Body)
+	}
+	if res := decodeBody(t, w); res["paused"] != true || res["pending_events"] != 3.0 {
+		t.Errorf("pause response = %v", res)
+	}
+	if w := serveAdmin(s, http.MethodPost, "/admin/consumers/billing/resume", ""); w.Code != http.StatusOK {
+		t.Fatalf("resume: status %d, body %s", w.Code, w.Body)
+	}
+	if len(got) != 2 || !got[0] || got[1] {
+		t.Errorf("SetConsumptionPaused calls = %v, want [true false]", got)
+	}
+
+	if w := serveAdmin(s, http.MethodPost, "/admin/consumers/user/pause", ""); w.Code != http.StatusNotFound {
+		t.Errorf("unknown service: status %d, want 404", w.Code)
+	}
+}
+
+func TestAdminSetConsumptionMapsBackendErrors(t *testing.T) {
+	billing := &fakeBillingClient{setConsumptionPaused: func(*billingpb.SetConsumptionPausedRequest) (*billingpb.SetConsumptionPausedResponse, error) {
+		return nil, status.Error(codes.Unavailable, "dial tcp 10.0.0.7:50052: connection refused")
+	}}
+	s := newTestServer(t, testConfig(), nil, billing, nil)
+
+	w := serveAdmin(s, http.MethodPost, "/admin/consumers/billing/pause", "")
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("status %d, want 503", w.Code)
+	}
+	if body := w.Body.String(); containsAny(body, "10.0.0.7", "connection refused") {
+		t.Errorf("backend error leaked to the client: %s", body)
+	}
+}
+
+// healthBackend serves hs over an in-memory connection and returns a client
+// connection to it.
+func healthBackend(t *testing.T, hs *health.Server) *grpc.ClientConn {
+	t.Helper()
+	lis := bufconn.Listen(1 << 16)
+	srv := grpc.NewServer()
+	healthpb.RegisterHealthServer(srv, hs)
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return lis.Dial() }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func TestReadyzReportsPausedConsumption(t *testing.T) {
+	billing := health.NewServer()
+	billing.SetServingStatus(eventsHealthService, healthpb.HealthCheckResponse_NOT_SERVING)
+	notif := health.NewServer()
+	notif.SetServingStatus(eventsHealthService, healthpb.HealthCheckResponse_SERVING)
+	user := health.NewServer() // no event consumption
+
+	s := newTestServer(t, testConfig(), nil, nil, nil)
+	s.backends = map[string]backendConn{
+		"billing":      healthBackend(t, billing),
+		"notification": healthBackend(t, notif),
+		"user":         healthBackend(t, user),
+	}
+
+	w := serve(s, http.MethodGet, "/readyz", "", "")
+	// Paused consumption does not make the gateway unready.
+	if w.Code != http.StatusOK {
+		t.Fatalf("status %d, body %s", w.Code, w.Body)
+	}
+	paused, _ := decodeBody(t, w)["consumption_paused"].([]any)
+	if len(paused) != 1 || paused[0] != "billing" {
+		t.Errorf("consumption_paused = %v, want [billing]", paused)
+	}
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestPprofOnlyOnDebugListener(t *testing.T) {
+	t.Setenv("DEBUG_PPROF", "true")
+	t.Setenv("DEBUG_ADDR", "127.0.0.1:0")
+	addr, err := startDebugServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if addr == "" {
+		t.Fatal("debug server not started")
+	}
+
+	res, err := http.Get("http://" + addr + "/debug/pprof/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		t.Errorf("internal listener: status %d, want 200", res.StatusCode)
+	}
+
+	public := httptest.NewServer(newTestServer(t, testConfig(), nil, nil, nil))
+	defer public.Close()
+	for _, path := range []string{"/debug/pprof/", "/debug/pprof/cmdline", "/debug/pprof/heap"} {
+		res, err := http.Get(public.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+		if res.StatusCode != http.StatusNotFound {
+			t.Errorf("public listener %s: status %d, want 404", path, res.StatusCode)
+		}
+	}
+}
+
+func TestPprofDisabledByDefault(t *testing.T) {
+	t.Setenv("DEBUG_PPROF", "")
+	addr, err := startDebugServer()
+	if err != nil || addr != "" {
+		t.Errorf("startDebugServer() = %q, %v; want it disabled", addr, err)
+	}
+}
+
+func TestDebugMuxServesOneRequestAtATime(t *testing.T) {
+	mux := newDebugMux()
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		// A one-second CPU profile keeps the slot busy.
+		mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/debug/pprof/profile?seconds=1", nil))
+	}()
+	defer wg.Wait()
+
+	for deadline := time.Now().Add(900 * time.Millisecond); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/debug/pprof/cmdline", nil))
+		if w.Code == http.StatusTooManyRequests {
+			return
+		}
+	}
+	t.Error("concurrent debug request was never refused")
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"encoding/csv"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"api-gateway/billingpb"
+)
+
+// handleExportTransactions streams the user's ledger as CSV. Each page from
+// billing-ms is written and flushed as it arrives, so the gateway never holds
+// more than one page in memory.
+func (s *apiServer) handleExportTransactions() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := r.PathValue("user_id")
+		if !validUUID(userID) {
+			s.writeJSONError(w, http.StatusBadRequest, "User ID must be a valid UUID")
+			return
+		}
+		if !s.authorizeUser(w, r, userID) {
+			return
+		}
+
+		stream, err := s.billingClient.ExportTransactions(r.Context(), &billingpb.ExportTransactionsRequest{UserId: userID})
+		if err != nil {
+			s.writeGRPCError(w, r, err, "failed to export transactions", "user_id", userID)
+			return
+		}
+		// Errors are only reported as JSON until the first page arrives;
+		// after that the status line has been sent and the body is cut short.
+		page, err := stream.Recv()
+		if err != nil && !errors.Is(err, io.EOF) {
+			s.writeGRPCError(w, r, err, "failed to export transactions", "user_id", userID)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/csv")
+		w.Header().Set("Content-Disposition", `attachment; filename="transactions.csv"`)
+		out := csv.NewWriter(w)
+		out.Write([]string{"id", "delta", "created_at"})
+		rc := http.NewResponseController(w)
+		rows := 0
+		for page != nil {
+			for _, t := range page.Transactions {
+				out.Write([]string{strconv.FormatInt(t.Id, 10), strconv.FormatFloat(t.Delta, 'f', 2, 64), t.CreatedAt})
+			}
+			rows += len(page.Transactions)
+			out.Flush()
+			if err := out.Error(); err != nil {
+				s.requestLogger(r.Context()).Warn("client went away during export", "user_id", userID, "error", err)
+				return
+			}
+			rc.Flush()
+
+			page, err = stream.Recv()
+			if err != nil && !errors.Is(err, io.EOF) {
+				s.requestLogger(r.Context()).Error("transaction export interrupted", "user_id", userID, "rows", rows, "error", err)
+				return
+			}
+		}
+		out.Flush()
+	}
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+
+	"api-gateway/billingpb"
+)
+
+// pageStream is an export stream that returns the pages sent on pages and
+// io.EOF once it is closed.
+type pageStream struct {
+	grpc.ClientStream
+	ctx   context.Context
+	pages chan *billingpb.TransactionPage
+}
+
+func (f *pageStream) Recv() (*billingpb.TransactionPage, error) {
+	select {
+	case page, ok := <-f.pages:
+		if !ok {
+			return nil, io.EOF
+		}
+		return page, nil
+	case <-f.ctx.Done():
+		return nil, status.FromContextError(f.ctx.Err()).Err()
+	}
+}
+
+// transactionPage returns a page of ledger entries with ids from..to.
+func transactionPage(from, to int64) *billingpb.TransactionPage {
+	page := &billingpb.TransactionPage{}
+	for id := from; id <= to; id++ {
+		page.Transactions = append(page.Transactions, &billingpb.Transaction{Id: id, Delta: 1.5, CreatedAt: "2025-01-01T00:00:00Z"})
+	}
+	return page
+}
+
+func TestExportTransactionsStreamsCSV(t *testing.T) {
+	pages := make(chan *billingpb.TransactionPage)
+	billing := &fakeBillingClient{exportTransactions: func(ctx context.Context, _ *billingpb.ExportTransactionsRequest) (grpc.ServerStreamingClient[billingpb.TransactionPage], error) {
+		return &pageStream{ctx: ctx, pages: pages}, nil
+	}}
+	srv := httptest.NewServer(newTestServer(t, testConfig(), nil, billing, nil))
+	defer srv.Close()
+
+	req, _ := http.NewRequest(http.MethodGet, srv.URL+"/user/billing/"+aliceID+"/transactions.csv", nil)
+	req.Header.Set("Authorization", "Bearer "+tokenFor(aliceID))
+	resDone := make(chan *http.Response, 1)
+	go func() {
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Error(err)
+			close(resDone)
+			return
+		}
+		resDone <- res
+	}()
+
+	pages <- transactionPage(1, 500)
+	res := <-resDone
+	if res == nil {
+		t.FailNow()
+	}
+	defer res.Body.Close()
+	if ct := res.Header.Get("Content-Type"); ct != "text/csv" {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	body := bufio.NewReader(res.Body)
+	readLines := func(n int) []string {
+		t.Helper()
+		lines := make([]string, n)
+		for i := range lines {
+			line, err := body.ReadString('\n')
+			if err != nil {
+				t.Fatalf("line %d: %v", i, err)
+			}
+			lines[i] = strings.TrimSpace(line)
+		}
+		return lines
+	}
+
+	// The first page reaches the client while billing-ms is still
+	// producing the next one.
+	first := readLines(501)
+	if first[0] != "id,delta,created_at" || first[1] != "1,1.50,2025-01-01T00:00:00Z" || !strings.HasPrefix(first[500], "500,") {
+		t.Errorf("first page lines %q ... %q", first[:2], first[500])
+	}
+	pages <- transactionPage(501, 700)
+	second := readLines(200)
+	if !strings.HasPrefix(second[199], "700,") {
+		t.Errorf("last line %q, want id 700", second[199])
+	}
+	close(pages)
+	if rest, _ := io.ReadAll(body); len(rest) != 0 {
+		t.Errorf("trailing output %q", rest)
+	}
+}
+
+func TestExportTransactionsBackendError(t *testing.T) {
+	billing := &fakeBillingClient{exportTransactions: func(ctx context.Context, _ *billingpb.ExportTransactionsRequest) (grpc.ServerStreamingClient[billingpb.TransactionPage], error) {
+		return &pageStream{ctx: ctx, pages: make(chan *billingpb.TransactionPage)}, nil
+	}}
+	s := newTestServer(t, testConfig(), nil, billing, nil)
+	// A stream that never produces a first page fails like any other call
+	// once the request ends.
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	r := httptest.NewRequest(http.MethodGet, "/user/billing/"+aliceID+"/transactions.csv", nil).WithContext(ctx)
+	r.Header.Set("Authorization", "Bearer "+tokenFor(aliceID))
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, r)
+	if w.Code != http.StatusGatewayTimeout {
+		t.Errorf("status %d, want 504 before any CSV is written", w.Code)
+	}
+	if strings.Contains(w.Body.String(), "id,delta") {
+		t.Errorf("CSV header written for a failed export: %s", w.Body)
+	}
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"net/http"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// internalErrorMessage replaces the backend message of server-side failures,
+// which may contain internal details such as SQL errors.
+const internalErrorMessage = "An internal error occurred"
+
+// grpcErrorToHTTP maps an error returned by a backend call to the HTTP status
+// and message the gateway responds with.
+func grpcErrorToHTTP(err error) (int, string) {
+	st, ok := status.FromError(err)
+	if !ok {
+		return http.StatusInternalServerError, internalErrorMessage
+	}
+	switch st.Code() {
+	case codes.InvalidArgument, codes.OutOfRange, codes.FailedPrecondition:
+		return http.StatusBadRequest, st.Message()
+	case codes.Unauthenticated:
+		return http.StatusUnauthorized, st.Message()
+	case codes.PermissionDenied:
+		return http.StatusForbidden, st.Message()
+	case codes.NotFound:
+		return http.StatusNotFound, st.Message()
+	case codes.AlreadyExists, codes.Aborted:
+		// Aborted is a lost optimistic-concurrency race, e.g. a stale
+		// expected_version: the client should re-read and retry.
+		return http.StatusConflict, st.Message()
+	case codes.ResourceExhausted:
+		return http.StatusTooManyRequests, st.Message()
+	case codes.Unimplemented:
+		return http.StatusNotImplemented, st.Message()
+	case codes.Unavailable:
+		return http.StatusServiceUnavailable, "service temporarily unavailable"
+	case codes.DeadlineExceeded:
+		return http.StatusGatewayTimeout, "upstream service timed out"
+	default:
+		return http.StatusInternalServerError, internalErrorMessage
+	}
+}
+
+// writeGRPCError writes err as a JSON error response. Server-side failures
+// are logged with msg and args; client errors are only returned.
+func (s *apiServer) writeGRPCError(w http.ResponseWriter, r *http.Request, err error, msg string, args ...any) {
+	code, message := grpcErrorToHTTP(err)
+	if code >= http.StatusInternalServerError {
+		s.requestLogger(r.Context()).Error(msg, append(args, "error", err)...)
+	}
+	s.writeJSONError(w, code, message)
+}
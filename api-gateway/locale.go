@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// localeMetadataKey is the gRPC metadata key the resolved locale is forwarded
+// in.
+const localeMetadataKey = "x-locale"
+
+// withLocale returns a copy of ctx carrying locale.
+func withLocale(ctx context.Context, locale string) context.Context {
+	return context.WithValue(ctx, localeKey, locale)
+}
+
+// localeFrom returns the locale stored by localeMiddleware, or "".
+func localeFrom(ctx context.Context) string {
+	locale, _ := ctx.Value(localeKey).(string)
+	return locale
+}
+
+// resolveLocale picks the supported locale that best matches an
+// Accept-Language header such as "pt-BR,fr;q=0.8,en;q=0.5". Languages are
+// tried in order of preference, first as given and then by their base
+// language, so "fr-CA" matches "fr". def is returned when nothing matches.
+func resolveLocale(acceptLanguage string, supported []string, def string) string {
+	type candidate struct {
+		tag string
+		q   float64
+	}
+	var candidates []candidate
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || tag == "*" {
+			continue
+		}
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			var err error
+			if q, err = strconv.ParseFloat(v, 64); err != nil {
+				continue
+			}
+		}
+		if q > 0 {
+			candidates = append(candidates, candidate{tag, q})
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].q > candidates[j].q })
+
+	for _, c := range candidates {
+		base, _, _ := strings.Cut(c.tag, "-")
+		for _, tag := range []string{c.tag, base} {
+			for _, locale := range supported {
+				if tag == locale {
+					return locale
+				}
+			}
+		}
+	}
+	return def
+}
+
+// loadSupportedLocales reads SUPPORTED_LOCALES, a comma-separated list of the
+// locales the services have translations for (default "en,de,es,fr,hi").
+func loadSupportedLocales() []string {
+	var locales []string
+	for _, locale := range strings.Split(getEnv("SUPPORTED_LOCALES", "en,de,es,fr,hi"), ",") {
+		if locale = strings.ToLower(strings.TrimSpace(locale)); locale != "" {
+			locales = append(locales, locale)
+		}
+	}
+	return locales
+}
+
+// localeMiddleware resolves the request's Accept-Language to a supported
+// locale, falling back to cfg.defaultLocale, and stores it in the request
+// context. Backend calls forward it, so user-ms stores it for new users and
+// billing-ms tags its events with it.
+func (s *apiServer) localeMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		locale := resolveLocale(r.Header.Get("Accept-Language"), s.cfg.supportedLocales, s.cfg.defaultLocale)
+		w.Header().Set("Content-Language", locale)
+		next.ServeHTTP(w, r.WithContext(withLocale(r.Context(), locale)))
+	})
+}
+
+// outgoingLocale adds the locale in ctx, if any, to the outgoing metadata.
+func outgoingLocale(ctx context.Context) context.Context {
+	if locale := localeFrom(ctx); locale != "" {
+		return metadata.AppendToOutgoingContext(ctx, localeMetadataKey, locale)
+	}
+	return ctx
+}
+
+// localeUnaryInterceptor forwards the locale on unary backend calls.
+func localeUnaryInterceptor(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	return invoker(outgoingLocale(ctx), method, req, reply, cc, opts...)
+}
+
+// localeStreamInterceptor forwards the locale on streaming backend calls.
+func localeStreamInterceptor(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	return streamer(outgoingLocale(ctx), desc, cc, method, opts...)
+}
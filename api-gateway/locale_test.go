@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/test/bufconn"
+
+	"api-gateway/billingpb"
+)
+
+func TestResolveLocale(t *testing.T) {
+	supported := []string{"en", "de", "es", "fr", "hi"}
+	for header, want := range map[string]string{
+		"":                             "en",
+		"de":                           "de",
+		"fr-CA":                        "fr",
+		"pt-BR,fr;q=0.8,en;q=0.5":      "fr",
+		"en;q=0.3, es;q=0.9":           "es",
+		"DE-at":                        "de",
+		"pt, *":                        "en",
+		"de;q=0, hi":                   "hi",
+		"es;q=bad, fr;q=0.1":           "fr",
+		"zh-Hant-TW;q=0.9, ja;q=0.8  ": "en",
+	} {
+		if got := resolveLocale(header, supported, "en"); got != want {
+			t.Errorf("resolveLocale(%q) = %q, want %q", header, got, want)
+		}
+	}
+}
+
+// localeBilling is a billing-ms that records the metadata of the last
+// GetBilling call.
+type localeBilling struct {
+	billingpb.UnimplementedBillingServiceServer
+	md chan metadata.MD
+}
+
+func (b *localeBilling) GetBilling(ctx context.Context, _ *billingpb.GetBillingRequest) (*billingpb.GetBillingResponse, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	b.md <- md
+	return &billingpb.GetBillingResponse{Amount: 5}, nil
+}
+
+func TestLocaleForwardedToBackends(t *testing.T) {
+	backend := &localeBilling{md: make(chan metadata.MD, 1)}
+	lis := bufconn.Listen(1 << 16)
+	srv := grpc.NewServer()
+	billingpb.RegisterBillingServiceServer(srv, backend)
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return lis.Dial() }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithChainUnaryInterceptor(localeUnaryInterceptor))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	s := newAPIServer(&fakeUserClient{}, billingpb.NewBillingServiceClient(conn), &fakeNotifClient{}, nil, testConfig(), slog.New(slog.NewTextHandler(io.Discard, nil)))
+	h := s.localeMiddleware(s)
+
+	for header, want := range map[string]string{"fr-CA,de;q=0.5": "fr", "pt-BR": "en"} {
+		r := httptest.NewRequest(http.MethodGet, "/user/billing/"+aliceID, nil)
+		r.Header.Set("Authorization", "Bearer "+tokenFor(aliceID))
+		r.Header.Set("Accept-Language", header)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: status %d, body %s", header, w.Code, w.Body)
+		}
+		if got := w.Header().Get("Content-Language"); got != want {
+			t.Errorf("%s: Content-Language %q, want %q", header, got, want)
+		}
+		if got := (<-backend.md).Get(localeMetadataKey); len(got) != 1 || got[0] != want {
+			t.Errorf("%s: billing-ms got %s %v, want %q", header, localeMetadataKey, got, want)
+		}
+	}
+}
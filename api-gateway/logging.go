@@ -0,0 +1,31 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+)
+
+// ctxKey is the type of the gateway's request context keys.
+type ctxKey int
+
+const (
+	loggerKey ctxKey = iota
+	userIDKey
+	requestIDKey
+	authClaimsKey
+	localeKey
+)
+
+// withLogger returns a copy of ctx carrying logger.
+func withLogger(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey, logger)
+}
+
+// requestLogger returns the request-scoped logger stored by loggingMiddleware,
+// falling back to the server logger outside a request.
+func (s *apiServer) requestLogger(ctx context.Context) *slog.Logger {
+	if logger, ok := ctx.Value(loggerKey).(*slog.Logger); ok {
+		return logger
+	}
+	return s.logger
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"api-gateway/billingpb"
+)
+
+// logLines decodes every JSON line in buf.
+func logLines(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	t.Helper()
+	var lines []map[string]any
+	sc := bufio.NewScanner(buf)
+	for sc.Scan() {
+		var line map[string]any
+		if err := json.Unmarshal(sc.Bytes(), &line); err != nil {
+			t.Fatalf("log line %q: %v", sc.Text(), err)
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+func TestHandlerLogsCarryRequestID(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+	billing := &fakeBillingClient{getBilling: func(*billingpb.GetBillingRequest) (*billingpb.GetBillingResponse, error) {
+		return nil, status.Error(codes.Internal, "billing table missing")
+	}}
+	s := newAPIServer(&fakeUserClient{}, billing, &fakeNotifClient{}, nil, testConfig(), logger)
+	h := loggingMiddleware(logger, s.clock)(s)
+
+	for _, tc := range []struct {
+		target, msg string
+	}{
+		{"/user/billing/" + bobID, "rejected access to another user's data"},
+		{"/user/billing/" + aliceID, "failed to get billing info"},
+	} {
+		buf.Reset()
+		r := httptest.NewRequest(http.MethodGet, tc.target, nil)
+		r.Header.Set("Authorization", "Bearer "+tokenFor(aliceID))
+		r.Header.Set(requestIDHeader, "req-123")
+		h.ServeHTTP(httptest.NewRecorder(), r)
+
+		var handlerLine, accessLine map[string]any
+		for _, line := range logLines(t, &buf) {
+			switch line["msg"] {
+			case tc.msg:
+				handlerLine = line
+			case "handled request":
+				accessLine = line
+			}
+		}
+		if handlerLine == nil || accessLine == nil {
+			t.Fatalf("%s: missing %q or the request line in %s", tc.target, tc.msg, buf.String())
+		}
+		if handlerLine["request_id"] != "req-123" || accessLine["request_id"] != "req-123" {
+			t.Errorf("%s: request ids %v and %v, want req-123 on both", tc.target, handlerLine["request_id"], accessLine["request_id"])
+		}
+		if handlerLine["auth_user_id"] != aliceID {
+			t.Errorf("%s: handler line auth_user_id = %v, want %s", tc.target, handlerLine["auth_user_id"], aliceID)
+		}
+	}
+}
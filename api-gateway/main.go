@@ -7,13 +7,20 @@ import (
 	"log/slog"
 	"net/http"
 	"os"
-	"strings"
+	"slices"
+	"sync/atomic"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/gorilla/websocket"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
 
 	"api-gateway/billingpb"
 	"api-gateway/notifpb"
@@ -21,11 +28,10 @@ import (
 )
 
 // --- WebSocket Upgrader ---
+// handleWebSocket checks the origin itself, with originAllowed, before
+// upgrading; the upgrader's own check would answer a rejection in plain text.
 var upgrader = websocket.Upgrader{
-	// Allow all origins
-	CheckOrigin: func(r *http.Request) bool {
-		return true
-	},
+	CheckOrigin: func(r *http.Request) bool { return true },
 }
 
 // apiServer holds the dependencies for our HTTP handlers.
@@ -33,25 +39,42 @@ type apiServer struct {
 	userClient    userpb.UserServiceClient
 	billingClient billingpb.BillingServiceClient
 	notifClient   notifpb.NotificationServiceClient
-	router        *http.ServeMux
-	logger        *slog.Logger
+	// backends are the connections behind the clients, by service name, for /readyz.
+	backends map[string]backendConn
+	router   *http.ServeMux
+	cfg      config
+	// maintenance is the current maintenance mode, see checkMaintenance.
+	maintenance atomic.Pointer[maintenanceState]
+	// rateLimits are the per-client-IP limits, see rateLimitMiddleware.
+	rateLimits *rateLimits
+	clock      Clock
+	logger     *slog.Logger
 }
 
 // newAPIServer creates a new instance of our server.
-func newAPIServer(userClient userpb.UserServiceClient, billingClient billingpb.BillingServiceClient, notifClient notifpb.NotificationServiceClient, logger *slog.Logger) *apiServer {
+func newAPIServer(userClient userpb.UserServiceClient, billingClient billingpb.BillingServiceClient, notifClient notifpb.NotificationServiceClient, backends map[string]backendConn, cfg config, logger *slog.Logger) *apiServer {
 	s := &apiServer{
 		userClient:    userClient,
 		billingClient: billingClient,
 		notifClient:   notifClient,
+		backends:      backends,
 		router:        http.NewServeMux(),
+		cfg:           cfg,
+		clock:         realClock{},
 		logger:        logger,
 	}
+	s.setMaintenance(cfg.maintenanceMode, cfg.maintenanceMessage)
+	s.rateLimits = newRateLimits(cfg, s.clock)
 	s.routes()
 	return s
 }
 
 // ServeHTTP makes our apiServer implement the http.Handler interface.
 func (s *apiServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if _, pattern := s.router.Handler(r); pattern == "" {
+		s.handleUnmatched(w, r)
+		return
+	}
 	s.router.ServeHTTP(w, r)
 }
 
@@ -59,53 +82,187 @@ func (s *apiServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 func (s *apiServer) routes() {
 	s.router.HandleFunc("POST /register", s.handleRegister())
 	s.router.HandleFunc("POST /login", s.handleLogin())
-	s.router.HandleFunc("GET /user/billing/{user_id}", s.handleGetBillingInfo())
-	s.router.HandleFunc("POST /user/billing/update", s.handleUpdateBilling())
+	s.router.HandleFunc("POST /refresh", s.handleRefresh())
+	s.router.HandleFunc("GET /auth/introspect", s.authMiddleware(s.handleIntrospect()))
+	s.router.HandleFunc("POST /logout", s.authMiddleware(s.handleLogout()))
+	s.router.HandleFunc("POST /user/username", s.authMiddleware(s.handleSetUsername()))
+	s.router.HandleFunc("GET /user/{user_id}", s.authMiddleware(s.handleGetUser()))
+	s.router.HandleFunc("GET /user/billing/{user_id}", s.authMiddleware(s.handleGetBillingInfo()))
+	s.router.HandleFunc("GET /user/billing/{user_id}/transactions.csv", s.authMiddleware(s.handleExportTransactions()))
+	s.router.HandleFunc("POST /user/billing/update", s.authMiddleware(s.handleUpdateBilling()))
+	s.router.HandleFunc("POST /user/billing/recalculate", s.authMiddleware(s.handleRecalculateBilling()))
+	s.router.HandleFunc("POST /user/notifications/read", s.authMiddleware(s.handleMarkNotificationRead()))
+	s.router.HandleFunc("GET /user/notifications/{user_id}", s.authMiddleware(s.handleGetNotificationHistory()))
 	s.router.HandleFunc("GET /ws", s.handleWebSocket())
+	s.router.HandleFunc("GET /healthz", s.handleHealthz())
+	s.router.HandleFunc("GET /readyz", s.handleReadyz())
+	s.router.Handle("GET /metrics", promhttp.Handler())
+
+	// --- Admin Routes ---
+	s.router.HandleFunc("POST /admin/billing/recalculate/{user_id}", s.requireAdmin(s.handleAdminRecalculateBilling()))
+	s.router.HandleFunc("GET /admin/stats", s.requireAdmin(s.handleAdminStats()))
+	s.router.HandleFunc("GET /admin/maintenance", s.requireAdmin(s.handleAdminGetMaintenance()))
+	s.router.HandleFunc("POST /admin/maintenance", s.requireAdmin(s.handleAdminSetMaintenance()))
+	s.router.HandleFunc("GET /admin/events", s.requireAdmin(s.handleAdminEventSchemas()))
+	s.router.HandleFunc("GET /admin/password-hashes", s.requireAdmin(s.handleAdminPasswordHashStats()))
+	s.router.HandleFunc("POST /admin/consumers/{service}/pause", s.requireAdmin(s.handleAdminSetConsumption(true)))
+	s.router.HandleFunc("POST /admin/consumers/{service}/resume", s.requireAdmin(s.handleAdminSetConsumption(false)))
+	s.router.HandleFunc("GET /admin/notifications/{notification_id}/deliveries", s.requireAdmin(s.handleAdminNotificationDeliveries()))
+	s.router.HandleFunc("POST /admin/notifications/resend", s.requireAdmin(s.handleAdminResendNotification()))
+	s.router.HandleFunc("POST /admin/notify/test-all", s.requireAdmin(s.handleAdminTestNotifications()))
+	s.router.HandleFunc("GET /admin/templates", s.requireAdmin(s.handleAdminListTemplates()))
+	s.router.HandleFunc("PUT /admin/templates/{message_id}/{locale}", s.requireAdmin(s.handleAdminPutTemplate()))
+	s.router.HandleFunc("DELETE /admin/templates/{message_id}/{locale}", s.requireAdmin(s.handleAdminDeleteTemplate()))
+	s.router.HandleFunc("POST /admin/backfills", s.requireAdmin(s.handleAdminStartBackfill()))
+	s.router.HandleFunc("GET /admin/backfills/{job_id}", s.requireAdmin(s.handleAdminGetBackfill()))
+	s.router.HandleFunc("POST /admin/backfills/{job_id}/resume", s.requireAdmin(s.handleAdminResumeBackfill()))
+	s.router.HandleFunc("GET /admin/streams", s.requireAdmin(s.handleAdminListStreams()))
+	s.router.HandleFunc("DELETE /admin/streams/{session_id}", s.requireAdmin(s.handleAdminTerminateStream()))
 }
 
 func main() {
 	// --- Structured Logger Setup ---
-	// Initialize a new JSON-based logger that writes to standard output.
-	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+	// Initialize a new JSON-based logger that writes to standard output. Its
+	// level can be changed at runtime by a SIGHUP reload.
+	logLevel := new(slog.LevelVar)
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: logLevel}))
+
+	// CONFIG_FILE settings override the environment; see reload.go.
+	if err := loadConfigFile(); err != nil {
+		logger.Error("failed to load config file", "error", err)
+		os.Exit(1)
+	}
+	logLevel.Set(loadLogLevel())
+
+	shutdownTracing, err := setupTracing(context.Background(), "api-gateway")
+	if err != nil {
+		logger.Error("failed to set up tracing", "error", err)
+		os.Exit(1)
+	}
+	defer shutdownTracing(context.Background())
 
 	// --- gRPC Client Connections ---
-	userConn, err := grpc.NewClient("user-ms:50051", grpc.WithTransportCredentials(insecure.NewCredentials()))
+	creds, err := grpcClientCredentials()
+	if err != nil {
+		logger.Error("failed to configure gRPC TLS", "error", err)
+		os.Exit(1)
+	}
+
+	// Every backend call carries the HTTP request's id, locale and trace
+	// context in its metadata, and is recorded in the gRPC client metrics.
+	clientOpts := []grpc.DialOption{
+		grpc.WithTransportCredentials(creds),
+		grpc.WithChainUnaryInterceptor(requestIDUnaryInterceptor, localeUnaryInterceptor, metricsUnaryInterceptor),
+		grpc.WithChainStreamInterceptor(requestIDStreamInterceptor, localeStreamInterceptor, metricsStreamInterceptor),
+		grpc.WithStatsHandler(otelgrpc.NewClientHandler()),
+	}
+
+	// Idempotent reads are retried on transient failures. Retries run inside
+	// the circuit breaker, which sees only the final result of a call.
+	retryOpts := []grpc.DialOption{grpc.WithChainUnaryInterceptor(retryUnaryInterceptor(logger, loadRetryConfig(), realClock{}))}
+
+	// Each backend also gets its own circuit breaker, so one that is down
+	// fails fast without slowing calls to the others.
+	//
+	// grpc.NewClient does not dial, so an unavailable backend never stops the
+	// gateway from starting; it is reported by /readyz instead. An error here
+	// means the target itself is invalid, which is a configuration error.
+	userConn, err := grpc.NewClient(getEnv("USER_SERVICE_ADDR", "user-ms:50051"), slices.Concat(clientOpts, breakerDialOptions(logger, "user", loadBreakerConfig("user")), retryOpts)...)
 	if err != nil {
-		logger.Error("did not connect to user service", "error", err)
+		logger.Error("invalid user service address", "error", err)
 		os.Exit(1)
 	}
 	defer userConn.Close()
 	userClient := userpb.NewUserServiceClient(userConn)
 
-	billingConn, err := grpc.NewClient("billing-ms:50052", grpc.WithTransportCredentials(insecure.NewCredentials()))
+	billingConn, err := grpc.NewClient(getEnv("BILLING_SERVICE_ADDR", "billing-ms:50052"), slices.Concat(clientOpts, breakerDialOptions(logger, "billing", loadBreakerConfig("billing")), retryOpts)...)
 	if err != nil {
-		logger.Error("did not connect to billing service", "error", err)
+		logger.Error("invalid billing service address", "error", err)
 		os.Exit(1)
 	}
 	defer billingConn.Close()
 	billingClient := billingpb.NewBillingServiceClient(billingConn)
 
-	notifConn, err := grpc.NewClient("notification-ms:50053", grpc.WithTransportCredentials(insecure.NewCredentials()))
+	notifConn, err := grpc.NewClient(getEnv("NOTIFICATION_SERVICE_ADDR", "notification-ms:50053"), slices.Concat(clientOpts, breakerDialOptions(logger, "notification", loadBreakerConfig("notification")), retryOpts)...)
 	if err != nil {
-		logger.Error("did not connect to notification service", "error", err)
+		logger.Error("invalid notification service address", "error", err)
 		os.Exit(1)
 	}
 	defer notifConn.Close()
 	notifClient := notifpb.NewNotificationServiceClient(notifConn)
 
+	// Start dialing in the background so readiness reflects the backends early.
+	backends := map[string]backendConn{"user": userConn, "billing": billingConn, "notification": notifConn}
+	for _, conn := range backends {
+		conn.Connect()
+	}
+
 	// --- HTTP Server Setup ---
-	server := newAPIServer(userClient, billingClient, notifClient, logger)
-	// Wrap the main handler with logging and then CORS middleware.
-	handler := loggingMiddleware(logger)(corsMiddleware(server))
+	server := newAPIServer(userClient, billingClient, notifClient, backends, loadConfig(), logger)
+	go server.watchReload(logLevel)
+	if server.cfg.allowAllOrigins {
+		logger.Warn("WS_ALLOW_ALL_ORIGINS is set: WebSockets accept any origin, do not use in production")
+	}
+	// Wrap the main handler with metrics, logging, security headers and then CORS middleware.
+	handler := server.metricsMiddleware(loggingMiddleware(logger, server.clock)(securityHeadersMiddleware(loadSecurityHeaders())(server.limitURLLength(corsMiddleware(server.rateLimitMiddleware(server.checkMaintenance(server.localeMiddleware(server))))))))
+	// The outermost span covers the whole request, so backend calls nest under it.
+	handler = otelhttp.NewHandler(handler, "api-gateway")
 
 	port := os.Getenv("PORT")
 	if port == "" {
 		port = "8080"
 	}
 
-	logger.Info("API Gateway starting", "port", port)
-	if err := http.ListenAndServe(":"+port, handler); err != nil {
+	tlsConfig, err := newTLSConfig()
+	if err != nil {
+		logger.Error("failed to configure TLS", "error", err)
+		os.Exit(1)
+	}
+	httpServer := &http.Server{
+		Addr:      ":" + port,
+		Handler:   handler,
+		TLSConfig: tlsConfig,
+	}
+
+	certFile, keyFile := os.Getenv("TLS_CERT_FILE"), os.Getenv("TLS_KEY_FILE")
+	useTLS := certFile != "" && keyFile != ""
+	cfg := server.cfg
+
+	// Profiling endpoints on an internal listener, never on the public mux.
+	debugAddr, err := startDebugServer()
+	if err != nil {
+		logger.Error("failed to start debug server", "error", err)
+		os.Exit(1)
+	}
+
+	logStartup(logger, httpServer.Addr, map[string]string{
+		"user":         userConn.Target(),
+		"billing":      billingConn.Target(),
+		"notification": notifConn.Target(),
+	}, map[string]any{
+		"tls":             useTLS,
+		"grpc_tls":        os.Getenv("GRPC_TLS_CA_FILE") != "",
+		"admin_api":       cfg.adminAPIKey != "",
+		"ws_max_lifetime": cfg.wsMaxLifetime.String(),
+		"ws_origins":      cfg.allowedOrigins,
+		"max_url_length":  cfg.maxURLLength,
+		"rate_limits": map[string]int{
+			"auth_per_minute": cfg.rateLimitAuthPerMinute,
+			"per_second":      cfg.rateLimitPerSecond,
+			"burst":           cfg.rateLimitBurst,
+		},
+		"maintenance": cfg.maintenanceMode,
+		"debug_addr":  debugAddr,
+		"config_file": os.Getenv("CONFIG_FILE"),
+	})
+
+	// Serve HTTPS when a certificate is configured, plain HTTP otherwise.
+	if useTLS {
+		err = httpServer.ListenAndServeTLS(certFile, keyFile)
+	} else {
+		err = httpServer.ListenAndServe()
+	}
+	if err != nil {
 		logger.Error("failed to start server", "error", err)
 		os.Exit(1)
 	}
@@ -113,58 +270,141 @@ func main() {
 
 // --- WebSocket Handler ---
 
+// webSocketProtocolBearer is the Sec-WebSocket-Protocol a browser client
+// offers, followed by its token, to authenticate without putting the token in
+// the URL: new WebSocket(url, ["bearer", token]).
+const webSocketProtocolBearer = "bearer"
+
+// webSocketToken returns the token of a WebSocket upgrade request, from the
+// Sec-WebSocket-Protocol header or else the token query parameter. When the
+// header carried it, the returned response header selects the bearer
+// protocol, which the browser requires to accept the upgrade.
+func webSocketToken(r *http.Request) (string, http.Header) {
+	protocols := websocket.Subprotocols(r)
+	if len(protocols) == 2 && protocols[0] == webSocketProtocolBearer {
+		return protocols[1], http.Header{"Sec-Websocket-Protocol": {webSocketProtocolBearer}}
+	}
+	return r.URL.Query().Get("token"), nil
+}
+
 func (s *apiServer) handleWebSocket() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		userID := r.URL.Query().Get("userId")
-		if userID == "" {
-			s.logger.Warn("WebSocket connection attempt with no userId")
-			s.writeJSONError(w, http.StatusBadRequest, "userId query parameter is required")
+		logger := s.requestLogger(r.Context())
+		// Browsers send cookies and other ambient credentials with cross-site
+		// upgrades, so only trusted origins may open a socket. This is checked
+		// first so a page on another origin cannot probe tokens through us.
+		if !s.originAllowed(r) {
+			logger.Warn("rejected websocket from disallowed origin", "origin", r.Header.Get("Origin"))
+			s.writeJSONError(w, http.StatusForbidden, "origin not allowed")
+			return
+		}
+		token, responseHeader := webSocketToken(r)
+		if token == "" {
+			logger.Warn("WebSocket connection attempt with no token")
+			s.writeJSONError(w, http.StatusUnauthorized, "a token is required, as the token query parameter or a \"bearer, <token>\" Sec-WebSocket-Protocol")
+			return
+		}
+		// The stream is always the token holder's own; userId is only
+		// accepted for older clients and must match.
+		callCtx, cancel := s.callContext(r)
+		claims, err := s.userClient.ValidateToken(callCtx, &userpb.ValidateTokenRequest{Token: token})
+		cancel()
+		if err != nil {
+			s.writeGRPCError(w, r, err, "failed to validate websocket token")
+			return
+		}
+		userID := claims.UserId
+		if queryUserID := r.URL.Query().Get("userId"); queryUserID != "" && queryUserID != userID {
+			logger.Warn("WebSocket userId does not match its token", "user_id", queryUserID, "auth_user_id", userID)
+			s.writeJSONError(w, http.StatusForbidden, "not allowed to access another user's data")
 			return
 		}
 
 		// Upgrade the HTTP connection to a WebSocket
-		conn, err := upgrader.Upgrade(w, r, nil)
+		conn, err := upgrader.Upgrade(w, r, responseHeader)
 		if err != nil {
-			s.logger.Error("failed to upgrade websocket", "error", err)
+			logger.Error("failed to upgrade websocket", "error", err)
 			return
 		}
 		defer conn.Close()
-		s.logger.Info("WebSocket connected", "user_id", userID)
+
+		// Each session gets its own id so it can be traced across reconnects;
+		// notification-ms logs its deliveries with the same id. A client may
+		// resume a session by sending its id back as sessionId, which lets
+		// notification-ms spot duplicate streams from the same session.
+		sessionID := r.URL.Query().Get("sessionId")
+		if !validUUID(sessionID) {
+			sessionID = uuid.NewString()
+		}
+		logger = logger.With("session_id", sessionID)
+		logger.Info("WebSocket connected", "user_id", userID)
 
 		// Create a context for the gRPC stream
-		ctx, cancel := context.WithCancel(r.Context())
+		ctx, cancel := context.WithCancel(withLogger(r.Context(), logger))
 		defer cancel()
+		ctx = metadata.AppendToOutgoingContext(ctx, sessionIDMetadataKey, sessionID)
 
 		// Call the gRPC stream on the Notification service
-		stream, err := s.notifClient.SubscribeToNotifications(ctx, &notifpb.SubscribeRequest{UserId: userID})
+		stream, err := s.subscribeWithRetry(ctx, userID)
 		if err != nil {
-			s.logger.Error("failed to subscribe to notifications", "error", err)
+			logger.Error("failed to subscribe to notifications", "error", err, "user_id", userID)
+			// Tell the client why instead of leaving it with a silently dead socket.
+			conn.WriteJSON(map[string]string{"error": "notification service unavailable"})
+			msg := websocket.FormatCloseMessage(websocket.CloseTryAgainLater, "notification service unavailable")
+			conn.WriteControl(websocket.CloseMessage, msg, time.Now().Add(time.Second))
 			return
 		}
 
+		// Close the connection once it reaches its maximum lifetime so the client
+		// reconnects (to a new replica, with a fresh token).
+		if s.cfg.wsMaxLifetime > 0 {
+			go func() {
+				select {
+				case <-s.clock.After(s.cfg.wsMaxLifetime):
+				case <-ctx.Done():
+					return
+				}
+				logger.Info("WebSocket reached max lifetime", "user_id", userID, "lifetime", s.cfg.wsMaxLifetime)
+				msg := websocket.FormatCloseMessage(websocket.CloseServiceRestart, "please reconnect")
+				conn.WriteControl(websocket.CloseMessage, msg, time.Now().Add(time.Second))
+				cancel()
+				// Give the client a moment to acknowledge the close before the read loop gives up.
+				conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+			}()
+		}
+
 		// Goroutine to read from gRPC stream and write to WebSocket
 		go func() {
 			for {
-				notification, err := stream.Recv()
+				batch, err := stream.Recv()
 				if err != nil {
 					// Handle stream ending or error
 					if err == io.EOF {
-						s.logger.Info("gRPC stream closed by server", "user_id", userID)
+						logger.Info("gRPC stream closed by server", "user_id", userID)
 					} else if ctx.Err() != nil {
 						// Check if the context was cancelled (client disconnected)
-						s.logger.Info("gRPC stream cancelled by client", "user_id", userID)
+						logger.Info("gRPC stream cancelled by client", "user_id", userID)
 					} else {
-						s.logger.Error("gRPC stream error", "error", err, "user_id", userID)
+						logger.Error("gRPC stream error", "error", err, "user_id", userID)
 					}
 					conn.WriteMessage(websocket.CloseMessage, []byte("Stream closed"))
 					return
 				}
 
-				// Write the notification to the WebSocket as JSON
-				s.logger.Info("Sending notification to WebSocket", "user_id", userID)
-				if err := conn.WriteJSON(notification); err != nil {
-					s.logger.Error("failed to write message to websocket", "error", err)
-					return
+				// Write each notification to the WebSocket as JSON. protojson
+				// renders enums by name and uses the camelCase field names the
+				// frontend expects.
+				for _, notification := range batch.Notifications {
+					logger.Info("Sending notification to WebSocket", "user_id", userID)
+					data, err := protojson.Marshal(notification)
+					if err != nil {
+						logger.Error("failed to encode notification", "error", err)
+						continue
+					}
+					if err := conn.WriteMessage(websocket.TextMessage, data); err != nil {
+						logger.Error("failed to write message to websocket", "error", err)
+						return
+					}
 				}
 			}
 		}()
@@ -173,9 +413,9 @@ func (s *apiServer) handleWebSocket() http.HandlerFunc {
 		for {
 			if _, _, err := conn.ReadMessage(); err != nil {
 				if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
-					s.logger.Error("websocket read error", "error", err)
+					logger.Error("websocket read error", "error", err)
 				} else {
-					s.logger.Info("WebSocket disconnected", "user_id", userID)
+					logger.Info("WebSocket disconnected", "user_id", userID)
 				}
 				cancel() // Cancel the gRPC stream context
 				break
@@ -184,6 +424,37 @@ func (s *apiServer) handleWebSocket() http.HandlerFunc {
 	}
 }
 
+// subscribeAttempts and subscribeBackoff control how hard handleWebSocket
+// tries to reach notification-ms before giving up.
+const (
+	subscribeAttempts = 3
+	subscribeBackoff  = 250 * time.Millisecond
+)
+
+// subscribeWithRetry opens the notification stream, retrying with
+// exponential backoff while notification-ms is unreachable.
+func (s *apiServer) subscribeWithRetry(ctx context.Context, userID string) (notifpb.NotificationService_SubscribeToNotificationsClient, error) {
+	backoff := subscribeBackoff
+	for attempt := 1; ; attempt++ {
+		stream, err := s.notifClient.SubscribeToNotifications(ctx, &notifpb.SubscribeRequest{UserId: userID})
+		if err == nil || attempt == subscribeAttempts {
+			return stream, err
+		}
+		s.requestLogger(ctx).Warn("notification subscribe failed, retrying", "user_id", userID, "attempt", attempt, "error", err)
+
+		select {
+		case <-s.clock.After(backoff):
+			backoff *= 2
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// sessionIDMetadataKey carries the WebSocket session id on the notification
+// subscribe call.
+const sessionIDMetadataKey = "x-session-id"
+
 // --- Route Handlers ---
 
 func (s *apiServer) handleRegister() http.HandlerFunc {
@@ -193,11 +464,16 @@ func (s *apiServer) handleRegister() http.HandlerFunc {
 			s.writeJSONError(w, http.StatusBadRequest, "Invalid request body")
 			return
 		}
+		if errs := validateRegister(&req, s.cfg.passwordMinLength); len(errs) > 0 {
+			s.writeValidationError(w, errs)
+			return
+		}
 
-		res, err := s.userClient.Register(r.Context(), &req)
+		ctx, cancel := s.callContext(r)
+		defer cancel()
+		res, err := s.userClient.Register(ctx, &req)
 		if err != nil {
-			s.logger.Error("failed to register user", "error", err)
-			s.writeJSONError(w, http.StatusInternalServerError, err.Error())
+			s.writeGRPCError(w, r, err, "failed to register user")
 			return
 		}
 		s.writeJSON(w, http.StatusOK, res)
@@ -212,20 +488,65 @@ func (s *apiServer) handleLogin() http.HandlerFunc {
 			return
 		}
 
-		res, err := s.userClient.Login(r.Context(), &req)
+		ctx, cancel := s.callContext(r)
+		defer cancel()
+		res, err := s.userClient.Login(ctx, &req)
 		if err != nil {
-			if strings.Contains(err.Error(), "invalid credentials") {
-				s.writeJSONError(w, http.StatusUnauthorized, err.Error())
-			} else {
-				s.logger.Error("failed during login", "error", err)
-				s.writeJSONError(w, http.StatusInternalServerError, "An internal error occurred")
-			}
+			s.writeGRPCError(w, r, err, "failed during login")
 			return
 		}
 		s.writeJSON(w, http.StatusOK, res)
 	}
 }
 
+func (s *apiServer) handleSetUsername() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req userpb.SetUsernameRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			s.writeJSONError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+		if errs := validateSetUsername(&req); len(errs) > 0 {
+			s.writeValidationError(w, errs)
+			return
+		}
+		if !s.authorizeUser(w, r, req.UserId) {
+			return
+		}
+
+		ctx, cancel := s.callContext(r)
+		defer cancel()
+		res, err := s.userClient.SetUsername(ctx, &req)
+		if err != nil {
+			s.writeGRPCError(w, r, err, "failed to set username", "user_id", req.UserId)
+			return
+		}
+		s.writeJSON(w, http.StatusOK, res)
+	}
+}
+
+func (s *apiServer) handleGetUser() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := r.PathValue("user_id")
+		if !validUUID(userID) {
+			s.writeJSONError(w, http.StatusBadRequest, "User ID must be a valid UUID")
+			return
+		}
+		if !s.authorizeUser(w, r, userID) {
+			return
+		}
+
+		ctx, cancel := s.callContext(r)
+		defer cancel()
+		res, err := s.userClient.GetUser(ctx, &userpb.GetUserRequest{UserId: userID})
+		if err != nil {
+			s.writeGRPCError(w, r, err, "failed to get user", "user_id", userID)
+			return
+		}
+		s.writeJSON(w, http.StatusOK, res.User)
+	}
+}
+
 func (s *apiServer) handleGetBillingInfo() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		userID := r.PathValue("user_id")
@@ -233,12 +554,20 @@ func (s *apiServer) handleGetBillingInfo() http.HandlerFunc {
 			s.writeJSONError(w, http.StatusBadRequest, "User ID is required")
 			return
 		}
+		if !validUUID(userID) {
+			s.writeJSONError(w, http.StatusBadRequest, "User ID must be a valid UUID")
+			return
+		}
+		if !s.authorizeUser(w, r, userID) {
+			return
+		}
 
 		req := &billingpb.GetBillingRequest{UserId: userID}
-		res, err := s.billingClient.GetBilling(r.Context(), req)
+		ctx, cancel := s.callContext(r)
+		defer cancel()
+		res, err := s.billingClient.GetBilling(ctx, req)
 		if err != nil {
-			s.logger.Error("failed to get billing info", "user_id", userID, "error", err)
-			s.writeJSONError(w, http.StatusInternalServerError, err.Error())
+			s.writeGRPCError(w, r, err, "failed to get billing info", "user_id", userID)
 			return
 		}
 		s.writeJSON(w, http.StatusOK, res)
@@ -252,17 +581,119 @@ func (s *apiServer) handleUpdateBilling() http.HandlerFunc {
 			s.writeJSONError(w, http.StatusBadRequest, "Invalid request body")
 			return
 		}
+		if errs := validateUpdateBilling(&req); len(errs) > 0 {
+			s.writeValidationError(w, errs)
+			return
+		}
+		if !s.authorizeUser(w, r, req.UserId) {
+			return
+		}
 
-		res, err := s.billingClient.UpdateBilling(r.Context(), &req)
+		ctx, cancel := s.callContext(r)
+		defer cancel()
+		res, err := s.billingClient.UpdateBilling(ctx, &req)
 		if err != nil {
-			s.logger.Error("failed to update billing", "user_id", req.UserId, "error", err)
-			s.writeJSONError(w, http.StatusInternalServerError, err.Error())
+			s.writeGRPCError(w, r, err, "failed to update billing", "user_id", req.UserId)
 			return
 		}
 		s.writeJSON(w, http.StatusOK, res)
 	}
 }
 
+func (s *apiServer) handleMarkNotificationRead() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req notifpb.MarkNotificationReadRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			s.writeJSONError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+		if !validUUID(req.UserId) || !validUUID(req.NotificationId) {
+			s.writeJSONError(w, http.StatusBadRequest, "user_id and notification_id must be valid UUIDs")
+			return
+		}
+		if !s.authorizeUser(w, r, req.UserId) {
+			return
+		}
+
+		ctx, cancel := s.callContext(r)
+		defer cancel()
+		res, err := s.notifClient.MarkNotificationRead(ctx, &req)
+		if err != nil {
+			s.writeGRPCError(w, r, err, "failed to mark notification read", "user_id", req.UserId, "notification_id", req.NotificationId)
+			return
+		}
+		s.writeJSON(w, http.StatusOK, res)
+	}
+}
+
+// handleGetNotificationHistory returns a page of the user's notifications;
+// see parseHistoryQuery for the query parameters.
+func (s *apiServer) handleGetNotificationHistory() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		req, errs := parseHistoryQuery(r)
+		if len(errs) > 0 {
+			s.writeValidationError(w, errs)
+			return
+		}
+		if !s.authorizeUser(w, r, req.UserId) {
+			return
+		}
+
+		ctx, cancel := s.callContext(r)
+		defer cancel()
+		res, err := s.notifClient.GetNotificationHistory(ctx, req)
+		if err != nil {
+			s.writeGRPCError(w, r, err, "failed to get notification history", "user_id", req.UserId)
+			return
+		}
+		s.writeProtoJSON(w, http.StatusOK, res)
+	}
+}
+
+func (s *apiServer) handleRecalculateBilling() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req billingpb.RecalculateBillingRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			s.writeJSONError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+		if !validUUID(req.UserId) {
+			s.writeJSONError(w, http.StatusBadRequest, "User ID must be a valid UUID")
+			return
+		}
+		if !s.authorizeUser(w, r, req.UserId) {
+			return
+		}
+		s.recalculateBilling(w, r, req.UserId)
+	}
+}
+
+func (s *apiServer) handleAdminRecalculateBilling() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		s.recalculateBilling(w, r, r.PathValue("user_id"))
+	}
+}
+
+// recalculateBilling asks billing-ms to rebuild userID's balance from the ledger.
+func (s *apiServer) recalculateBilling(w http.ResponseWriter, r *http.Request, userID string) {
+	if !validUUID(userID) {
+		s.writeJSONError(w, http.StatusBadRequest, "User ID must be a valid UUID")
+		return
+	}
+
+	ctx, cancel := s.callContext(r)
+	defer cancel()
+	res, err := s.billingClient.RecalculateBilling(ctx, &billingpb.RecalculateBillingRequest{UserId: userID})
+	if err != nil {
+		s.writeGRPCError(w, r, err, "failed to recalculate billing", "user_id", userID)
+		return
+	}
+	if res.Corrected {
+		s.requestLogger(r.Context()).Warn("billing balance corrected", "user_id", userID, "previous_amount", res.PreviousAmount, "amount", res.Amount)
+	}
+	s.writeJSON(w, http.StatusOK, res)
+}
+
 // --- Helper Functions & Middleware ---
 
 func (s *apiServer) writeJSON(w http.ResponseWriter, status int, v any) {
@@ -273,6 +704,32 @@ func (s *apiServer) writeJSON(w http.ResponseWriter, status int, v any) {
 	}
 }
 
+// writeProtoJSON writes a protobuf message with protojson, so enums are
+// rendered by name rather than number.
+func (s *apiServer) writeProtoJSON(w http.ResponseWriter, status int, m proto.Message) {
+	// Emit zero values so clients see e.g. "total": "0" and empty lists.
+	data, err := protojson.MarshalOptions{EmitUnpopulated: true}.Marshal(m)
+	if err != nil {
+		s.logger.Error("error encoding JSON", "error", err)
+		s.writeJSONError(w, http.StatusInternalServerError, "An internal error occurred")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(data)
+}
+
+// validUUID reports whether id is a canonical UUID, the format used for user
+// and notification ids. uuid.Parse alone also accepts braced and urn: forms, so the length
+// is pinned to the 36-character canonical form.
+func validUUID(id string) bool {
+	if len(id) != 36 {
+		return false
+	}
+	_, err := uuid.Parse(id)
+	return err == nil
+}
+
 func (s *apiServer) writeJSONError(w http.ResponseWriter, status int, message string) {
 	s.writeJSON(w, status, map[string]string{"error": message})
 }
@@ -281,7 +738,8 @@ func corsMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Access-Control-Allow-Origin", "*")
 		w.Header().Set("Access-Control-Allow-Methods", "POST, GET, OPTIONS, PUT, DELETE")
-		w.Header().Set("Access-Control-Allow-Headers", "Accept, Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization")
+		w.Header().Set("Access-Control-Allow-Headers", "Accept, Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, X-Admin-Key, X-Request-ID")
+		w.Header().Set("Access-Control-Expose-Headers", "X-Request-ID")
 
 		if r.Method == "OPTIONS" {
 			w.WriteHeader(http.StatusOK)
@@ -291,15 +749,24 @@ func corsMiddleware(next http.Handler) http.Handler {
 	})
 }
 
-// loggingMiddleware logs details about each incoming HTTP request.
-func loggingMiddleware(logger *slog.Logger) func(http.Handler) http.Handler {
+// loggingMiddleware logs details about each incoming HTTP request. It also
+// takes the request id from the X-Request-ID header, or assigns a new one, and
+// stores it and a logger carrying it in the request context, so every line a
+// handler logs for the request can be correlated. The id is forwarded to the
+// backends, which log it and pass it on in the events they publish.
+func loggingMiddleware(logger *slog.Logger, clock Clock) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			start := time.Now()
+			requestID := incomingRequestID(r.Header.Get(requestIDHeader))
+			reqLogger := logger.With("request_id", requestID)
+			w.Header().Set(requestIDHeader, requestID)
+			r = r.WithContext(withLogger(withRequestID(r.Context(), requestID), reqLogger))
+
+			start := clock.Now()
 			next.ServeHTTP(w, r)
-			duration := time.Since(start)
+			duration := clock.Now().Sub(start)
 
-			logger.Info("handled request",
+			reqLogger.Info("handled request",
 				"method", r.Method,
 				"path", r.URL.Path,
 				"duration", duration,
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// defaultMaintenanceMessage is returned while maintenance mode is on and no
+// custom message was set.
+const defaultMaintenanceMessage = "The service is undergoing maintenance. Please try again shortly."
+
+// maintenanceState is swapped atomically so toggling takes effect on the next
+// request without locking the request path.
+type maintenanceState struct {
+	Enabled bool   `json:"enabled"`
+	Message string `json:"message"`
+}
+
+// maintenanceExempt reports whether a path stays reachable in maintenance
+// mode: health checks, metrics and the admin API used to switch it off.
+func maintenanceExempt(path string) bool {
+	switch {
+	case path == "/healthz", path == "/readyz", path == "/metrics":
+		return true
+	case strings.HasPrefix(path, "/admin/"):
+		return true
+	}
+	return false
+}
+
+// checkMaintenance answers non-exempt requests with 503 and a Retry-After
+// header while maintenance mode is on.
+func (s *apiServer) checkMaintenance(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		state := s.maintenance.Load()
+		if state.Enabled && !maintenanceExempt(r.URL.Path) {
+			if s.cfg.maintenanceRetryAfter > 0 {
+				w.Header().Set("Retry-After", strconv.Itoa(int(s.cfg.maintenanceRetryAfter.Seconds())))
+			}
+			s.writeJSONError(w, http.StatusServiceUnavailable, state.Message)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// setMaintenance switches maintenance mode; an empty message uses the default.
+func (s *apiServer) setMaintenance(enabled bool, message string) {
+	if message == "" {
+		message = defaultMaintenanceMessage
+	}
+	s.maintenance.Store(&maintenanceState{Enabled: enabled, Message: message})
+}
+
+func (s *apiServer) handleAdminGetMaintenance() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		s.writeJSON(w, http.StatusOK, s.maintenance.Load())
+	}
+}
+
+// handleAdminSetMaintenance turns maintenance mode on or off, e.g.
+// {"enabled": true, "message": "Back at 14:00 UTC"}.
+func (s *apiServer) handleAdminSetMaintenance() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req maintenanceState
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			s.writeJSONError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+		s.setMaintenance(req.Enabled, req.Message)
+		s.requestLogger(r.Context()).Info("maintenance mode changed", "enabled", req.Enabled)
+		s.writeJSON(w, http.StatusOK, s.maintenance.Load())
+	}
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"api-gateway/billingpb"
+)
+
+func TestMaintenanceMode(t *testing.T) {
+	cfg := testConfig()
+	cfg.maintenanceMode = true
+	cfg.maintenanceMessage = "Back at 14:00 UTC"
+	cfg.maintenanceRetryAfter = 5 * time.Minute
+	billing := &fakeBillingClient{getBilling: func(*billingpb.GetBillingRequest) (*billingpb.GetBillingResponse, error) {
+		return &billingpb.GetBillingResponse{}, nil
+	}}
+	s := newTestServer(t, cfg, nil, billing, nil)
+	h := s.checkMaintenance(s)
+
+	w := serve(h, http.MethodGet, "/user/billing/"+aliceID, "", tokenFor(aliceID))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("status %d, want 503", w.Code)
+	}
+	if got := w.Header().Get("Retry-After"); got != "300" {
+		t.Errorf("Retry-After = %q, want 300", got)
+	}
+	if got := decodeBody(t, w)["error"]; got != "Back at 14:00 UTC" {
+		t.Errorf("error = %v, want the configured message", got)
+	}
+
+	for _, path := range []string{"/healthz", "/metrics"} {
+		if w := serve(h, http.MethodGet, path, "", ""); w.Code != http.StatusOK {
+			t.Errorf("%s in maintenance: status %d", path, w.Code)
+		}
+	}
+	if w := serveAdmin(h, http.MethodGet, "/admin/maintenance", ""); w.Code != http.StatusOK || decodeBody(t, w)["enabled"] != true {
+		t.Errorf("admin in maintenance: status %d, body %s", w.Code, w.Body)
+	}
+
+	// Switching it off applies to the next request.
+	if w := serveAdmin(h, http.MethodPost, "/admin/maintenance", `{"enabled":false}`); w.Code != http.StatusOK {
+		t.Fatalf("disable: status %d, body %s", w.Code, w.Body)
+	}
+	if w := serve(h, http.MethodGet, "/user/billing/"+aliceID, "", tokenFor(aliceID)); w.Code != http.StatusOK {
+		t.Errorf("after maintenance: status %d", w.Code)
+	}
+
+	// Switching it on without a message uses the default one.
+	serveAdmin(h, http.MethodPost, "/admin/maintenance", `{"enabled":true}`)
+	w = serve(h, http.MethodGet, "/user/billing/"+aliceID, "", tokenFor(aliceID))
+	if w.Code != http.StatusServiceUnavailable || decodeBody(t, w)["error"] != defaultMaintenanceMessage {
+		t.Errorf("re-enabled: status %d, body %s", w.Code, w.Body)
+	}
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/felixge/httpsnoop"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+// unmatchedRoute labels requests that match no route, so arbitrary paths
+// cannot create new series.
+const unmatchedRoute = "unmatched"
+
+var (
+	httpRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "gateway_http_requests_total",
+		Help: "HTTP requests handled, by route pattern and status code.",
+	}, []string{"route", "code"})
+	httpDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "gateway_http_request_duration_seconds",
+		Help:    "Time to handle an HTTP request, by route pattern and status code.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"route", "code"})
+	httpInFlight = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "gateway_http_requests_in_flight",
+		Help: "HTTP requests currently being handled, by route pattern.",
+	}, []string{"route"})
+
+	grpcClientCalls = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "gateway_grpc_client_calls_total",
+		Help: "gRPC calls made to the backends, by full method and status code.",
+	}, []string{"method", "code"})
+	grpcClientDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "gateway_grpc_client_call_duration_seconds",
+		Help:    "Latency of unary gRPC calls to the backends, by full method.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method"})
+)
+
+// metricsMiddleware records the count, duration and in-flight number of
+// requests. Requests are labeled by the pattern of the route they match, e.g.
+// "GET /user/{user_id}", rather than by path.
+func (s *apiServer) metricsMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route := unmatchedRoute
+		if _, pattern := s.router.Handler(r); pattern != "" {
+			route = pattern
+		}
+
+		inFlight := httpInFlight.WithLabelValues(route)
+		inFlight.Inc()
+		defer inFlight.Dec()
+
+		// httpsnoop keeps the Flusher and Hijacker interfaces that CSV exports
+		// and WebSocket upgrades rely on.
+		m := httpsnoop.CaptureMetrics(next, w, r)
+		code := strconv.Itoa(m.Code)
+		httpRequests.WithLabelValues(route, code).Inc()
+		httpDuration.WithLabelValues(route, code).Observe(m.Duration.Seconds())
+	})
+}
+
+// metricsUnaryInterceptor records the latency and status of every unary call
+// to a backend.
+func metricsUnaryInterceptor(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	start := time.Now()
+	err := invoker(ctx, method, req, reply, cc, opts...)
+	grpcClientDuration.WithLabelValues(method).Observe(time.Since(start).Seconds())
+	grpcClientCalls.WithLabelValues(method, status.Code(err).String()).Inc()
+	return err
+}
+
+// metricsStreamInterceptor counts stream opens by status. Streams such as
+// notification subscriptions live as long as the client stays connected, so
+// their duration is not recorded.
+func metricsStreamInterceptor(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	stream, err := streamer(ctx, desc, cc, method, opts...)
+	grpcClientCalls.WithLabelValues(method, status.Code(err).String()).Inc()
+	return stream, err
+}
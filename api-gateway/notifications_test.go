@@ -0,0 +1,46 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+
+	"api-gateway/notifpb"
+)
+
+const notificationID = "33333333-3333-4333-8333-333333333333"
+
+func TestMarkNotificationReadRequiresOwnAccount(t *testing.T) {
+	var got *notifpb.MarkNotificationReadRequest
+	notif := &fakeNotifClient{markNotificationRead: func(in *notifpb.MarkNotificationReadRequest) (*notifpb.MarkNotificationReadResponse, error) {
+		got = in
+		return &notifpb.MarkNotificationReadResponse{Success: true}, nil
+	}}
+	s := newTestServer(t, testConfig(), nil, nil, notif)
+	body := `{"user_id":"` + aliceID + `","notification_id":"` + notificationID + `"}`
+
+	if w := serve(s, http.MethodPost, "/user/notifications/read", body, ""); w.Code != http.StatusUnauthorized {
+		t.Errorf("anonymous: status %d, want 401", w.Code)
+	}
+	if w := serve(s, http.MethodPost, "/user/notifications/read", body, tokenFor(bobID)); w.Code != http.StatusForbidden {
+		t.Errorf("other user: status %d, want 403", w.Code)
+	}
+	if got != nil {
+		t.Fatalf("MarkNotificationRead called for a rejected request: %+v", got)
+	}
+
+	w := serve(s, http.MethodPost, "/user/notifications/read", body, tokenFor(aliceID))
+	if w.Code != http.StatusOK {
+		t.Fatalf("own notification: status %d, body %s", w.Code, w.Body)
+	}
+	if got.GetUserId() != aliceID || got.GetNotificationId() != notificationID {
+		t.Errorf("MarkNotificationRead request = %+v", got)
+	}
+}
+
+func TestMarkNotificationReadValidatesIDs(t *testing.T) {
+	s := newTestServer(t, testConfig(), nil, nil, nil)
+	w := serve(s, http.MethodPost, "/user/notifications/read", `{"user_id":"`+aliceID+`","notification_id":"n1"}`, tokenFor(aliceID))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("status %d, want 400", w.Code)
+	}
+}
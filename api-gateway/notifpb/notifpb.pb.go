@@ -21,9 +21,165 @@ const (
 	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
 )
 
+// What a notification is about.
+type NotificationType int32
+
+const (
+	NotificationType_NOTIFICATION_TYPE_UNSPECIFIED NotificationType = 0
+	NotificationType_NOTIFICATION_TYPE_WELCOME     NotificationType = 1
+	NotificationType_NOTIFICATION_TYPE_BILLING     NotificationType = 2
+)
+
+// Enum value maps for NotificationType.
+var (
+	NotificationType_name = map[int32]string{
+		0: "NOTIFICATION_TYPE_UNSPECIFIED",
+		1: "NOTIFICATION_TYPE_WELCOME",
+		2: "NOTIFICATION_TYPE_BILLING",
+	}
+	NotificationType_value = map[string]int32{
+		"NOTIFICATION_TYPE_UNSPECIFIED": 0,
+		"NOTIFICATION_TYPE_WELCOME":     1,
+		"NOTIFICATION_TYPE_BILLING":     2,
+	}
+)
+
+func (x NotificationType) Enum() *NotificationType {
+	p := new(NotificationType)
+	*p = x
+	return p
+}
+
+func (x NotificationType) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (NotificationType) Descriptor() protoreflect.EnumDescriptor {
+	return file_notifpb_notifpb_proto_enumTypes[0].Descriptor()
+}
+
+func (NotificationType) Type() protoreflect.EnumType {
+	return &file_notifpb_notifpb_proto_enumTypes[0]
+}
+
+func (x NotificationType) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use NotificationType.Descriptor instead.
+func (NotificationType) EnumDescriptor() ([]byte, []int) {
+	return file_notifpb_notifpb_proto_rawDescGZIP(), []int{0}
+}
+
+// How prominently a client should show a notification.
+type Severity int32
+
+const (
+	Severity_SEVERITY_UNSPECIFIED Severity = 0
+	Severity_SEVERITY_INFO        Severity = 1
+	Severity_SEVERITY_WARNING     Severity = 2
+	Severity_SEVERITY_CRITICAL    Severity = 3
+)
+
+// Enum value maps for Severity.
+var (
+	Severity_name = map[int32]string{
+		0: "SEVERITY_UNSPECIFIED",
+		1: "SEVERITY_INFO",
+		2: "SEVERITY_WARNING",
+		3: "SEVERITY_CRITICAL",
+	}
+	Severity_value = map[string]int32{
+		"SEVERITY_UNSPECIFIED": 0,
+		"SEVERITY_INFO":        1,
+		"SEVERITY_WARNING":     2,
+		"SEVERITY_CRITICAL":    3,
+	}
+)
+
+func (x Severity) Enum() *Severity {
+	p := new(Severity)
+	*p = x
+	return p
+}
+
+func (x Severity) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Severity) Descriptor() protoreflect.EnumDescriptor {
+	return file_notifpb_notifpb_proto_enumTypes[1].Descriptor()
+}
+
+func (Severity) Type() protoreflect.EnumType {
+	return &file_notifpb_notifpb_proto_enumTypes[1]
+}
+
+func (x Severity) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Severity.Descriptor instead.
+func (Severity) EnumDescriptor() ([]byte, []int) {
+	return file_notifpb_notifpb_proto_rawDescGZIP(), []int{1}
+}
+
+// The service whose event produced a notification.
+type Source int32
+
+const (
+	Source_SOURCE_UNSPECIFIED Source = 0
+	Source_SOURCE_USER        Source = 1
+	Source_SOURCE_BILLING     Source = 2
+)
+
+// Enum value maps for Source.
+var (
+	Source_name = map[int32]string{
+		0: "SOURCE_UNSPECIFIED",
+		1: "SOURCE_USER",
+		2: "SOURCE_BILLING",
+	}
+	Source_value = map[string]int32{
+		"SOURCE_UNSPECIFIED": 0,
+		"SOURCE_USER":        1,
+		"SOURCE_BILLING":     2,
+	}
+)
+
+func (x Source) Enum() *Source {
+	p := new(Source)
+	*p = x
+	return p
+}
+
+func (x Source) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Source) Descriptor() protoreflect.EnumDescriptor {
+	return file_notifpb_notifpb_proto_enumTypes[2].Descriptor()
+}
+
+func (Source) Type() protoreflect.EnumType {
+	return &file_notifpb_notifpb_proto_enumTypes[2]
+}
+
+func (x Source) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Source.Descriptor instead.
+func (Source) EnumDescriptor() ([]byte, []int) {
+	return file_notifpb_notifpb_proto_rawDescGZIP(), []int{2}
+}
+
 type SubscribeRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	UserId string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	// Maximum number of stored notifications per batch when catching up on
+	// connect; 0 uses the server default.
+	BatchSize     int32 `protobuf:"varint,2,opt,name=batch_size,json=batchSize,proto3" json:"batch_size,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -65,19 +221,80 @@ func (x *SubscribeRequest) GetUserId() string {
 	return ""
 }
 
-type Notification struct {
+func (x *SubscribeRequest) GetBatchSize() int32 {
+	if x != nil {
+		return x.BatchSize
+	}
+	return 0
+}
+
+type NotificationBatch struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
-	UserId        string                 `protobuf:"bytes,2,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
-	Message       string                 `protobuf:"bytes,3,opt,name=message,proto3" json:"message,omitempty"`
-	Timestamp     string                 `protobuf:"bytes,4,opt,name=timestamp,proto3" json:"timestamp,omitempty"` // ISO 8601 timestamp
+	Notifications []*Notification        `protobuf:"bytes,1,rep,name=notifications,proto3" json:"notifications,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *NotificationBatch) Reset() {
+	*x = NotificationBatch{}
+	mi := &file_notifpb_notifpb_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *NotificationBatch) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*NotificationBatch) ProtoMessage() {}
+
+func (x *NotificationBatch) ProtoReflect() protoreflect.Message {
+	mi := &file_notifpb_notifpb_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use NotificationBatch.ProtoReflect.Descriptor instead.
+func (*NotificationBatch) Descriptor() ([]byte, []int) {
+	return file_notifpb_notifpb_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *NotificationBatch) GetNotifications() []*Notification {
+	if x != nil {
+		return x.Notifications
+	}
+	return nil
+}
+
+type Notification struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	Id        string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	UserId    string                 `protobuf:"bytes,2,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	Message   string                 `protobuf:"bytes,3,opt,name=message,proto3" json:"message,omitempty"`
+	Timestamp string                 `protobuf:"bytes,4,opt,name=timestamp,proto3" json:"timestamp,omitempty"` // ISO 8601 timestamp
+	// Empty for regular notifications. Control messages set it, e.g.
+	// "notification.read" with id set to the notification that was read.
+	Event string `protobuf:"bytes,5,opt,name=event,proto3" json:"event,omitempty"`
+	// event_id of the NATS event the notification was created from, if any.
+	SourceEventId string           `protobuf:"bytes,6,opt,name=source_event_id,json=sourceEventId,proto3" json:"source_event_id,omitempty"`
+	Type          NotificationType `protobuf:"varint,7,opt,name=type,proto3,enum=notifpb.NotificationType" json:"type,omitempty"`
+	Severity      Severity         `protobuf:"varint,8,opt,name=severity,proto3,enum=notifpb.Severity" json:"severity,omitempty"`
+	Source        Source           `protobuf:"varint,9,opt,name=source,proto3,enum=notifpb.Source" json:"source,omitempty"`
+	// Only set by GetNotificationHistory.
+	Read          bool `protobuf:"varint,10,opt,name=read,proto3" json:"read,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *Notification) Reset() {
 	*x = Notification{}
-	mi := &file_notifpb_notifpb_proto_msgTypes[1]
+	mi := &file_notifpb_notifpb_proto_msgTypes[2]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -89,7 +306,7 @@ func (x *Notification) String() string {
 func (*Notification) ProtoMessage() {}
 
 func (x *Notification) ProtoReflect() protoreflect.Message {
-	mi := &file_notifpb_notifpb_proto_msgTypes[1]
+	mi := &file_notifpb_notifpb_proto_msgTypes[2]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -102,7 +319,7 @@ func (x *Notification) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Notification.ProtoReflect.Descriptor instead.
 func (*Notification) Descriptor() ([]byte, []int) {
-	return file_notifpb_notifpb_proto_rawDescGZIP(), []int{1}
+	return file_notifpb_notifpb_proto_rawDescGZIP(), []int{2}
 }
 
 func (x *Notification) GetId() string {
@@ -133,20 +350,2053 @@ func (x *Notification) GetTimestamp() string {
 	return ""
 }
 
-var File_notifpb_notifpb_proto protoreflect.FileDescriptor
+func (x *Notification) GetEvent() string {
+	if x != nil {
+		return x.Event
+	}
+	return ""
+}
 
-const file_notifpb_notifpb_proto_rawDesc = "" +
-	"\n" +
-	"\x15notifpb/notifpb.proto\x12\anotifpb\"+\n" +
-	"\x10SubscribeRequest\x12\x17\n" +
-	"\auser_id\x18\x01 \x01(\tR\x06userId\"o\n" +
-	"\fNotification\x12\x0e\n" +
-	"\x02id\x18\x01 \x01(\tR\x02id\x12\x17\n" +
-	"\auser_id\x18\x02 \x01(\tR\x06userId\x12\x18\n" +
-	"\amessage\x18\x03 \x01(\tR\amessage\x12\x1c\n" +
-	"\ttimestamp\x18\x04 \x01(\tR\ttimestamp2e\n" +
-	"\x13NotificationService\x12N\n" +
-	"\x18SubscribeToNotifications\x12\x19.notifpb.SubscribeRequest\x1a\x15.notifpb.Notification0\x01B\vZ\t./notifpbb\x06proto3"
+func (x *Notification) GetSourceEventId() string {
+	if x != nil {
+		return x.SourceEventId
+	}
+	return ""
+}
+
+func (x *Notification) GetType() NotificationType {
+	if x != nil {
+		return x.Type
+	}
+	return NotificationType_NOTIFICATION_TYPE_UNSPECIFIED
+}
+
+func (x *Notification) GetSeverity() Severity {
+	if x != nil {
+		return x.Severity
+	}
+	return Severity_SEVERITY_UNSPECIFIED
+}
+
+func (x *Notification) GetSource() Source {
+	if x != nil {
+		return x.Source
+	}
+	return Source_SOURCE_UNSPECIFIED
+}
+
+func (x *Notification) GetRead() bool {
+	if x != nil {
+		return x.Read
+	}
+	return false
+}
+
+type MarkNotificationReadRequest struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	UserId         string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	NotificationId string                 `protobuf:"bytes,2,opt,name=notification_id,json=notificationId,proto3" json:"notification_id,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *MarkNotificationReadRequest) Reset() {
+	*x = MarkNotificationReadRequest{}
+	mi := &file_notifpb_notifpb_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MarkNotificationReadRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MarkNotificationReadRequest) ProtoMessage() {}
+
+func (x *MarkNotificationReadRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_notifpb_notifpb_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MarkNotificationReadRequest.ProtoReflect.Descriptor instead.
+func (*MarkNotificationReadRequest) Descriptor() ([]byte, []int) {
+	return file_notifpb_notifpb_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *MarkNotificationReadRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *MarkNotificationReadRequest) GetNotificationId() string {
+	if x != nil {
+		return x.NotificationId
+	}
+	return ""
+}
+
+type MarkNotificationReadResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MarkNotificationReadResponse) Reset() {
+	*x = MarkNotificationReadResponse{}
+	mi := &file_notifpb_notifpb_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MarkNotificationReadResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MarkNotificationReadResponse) ProtoMessage() {}
+
+func (x *MarkNotificationReadResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_notifpb_notifpb_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MarkNotificationReadResponse.ProtoReflect.Descriptor instead.
+func (*MarkNotificationReadResponse) Descriptor() ([]byte, []int) {
+	return file_notifpb_notifpb_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *MarkNotificationReadResponse) GetSuccess() bool {
+	if x != nil {
+		return x.Success
+	}
+	return false
+}
+
+type SetConsumptionPausedRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Paused        bool                   `protobuf:"varint,1,opt,name=paused,proto3" json:"paused,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SetConsumptionPausedRequest) Reset() {
+	*x = SetConsumptionPausedRequest{}
+	mi := &file_notifpb_notifpb_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetConsumptionPausedRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetConsumptionPausedRequest) ProtoMessage() {}
+
+func (x *SetConsumptionPausedRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_notifpb_notifpb_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetConsumptionPausedRequest.ProtoReflect.Descriptor instead.
+func (*SetConsumptionPausedRequest) Descriptor() ([]byte, []int) {
+	return file_notifpb_notifpb_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *SetConsumptionPausedRequest) GetPaused() bool {
+	if x != nil {
+		return x.Paused
+	}
+	return false
+}
+
+type SetConsumptionPausedResponse struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Paused bool                   `protobuf:"varint,1,opt,name=paused,proto3" json:"paused,omitempty"`
+	// Events received but not yet processed.
+	PendingEvents int64 `protobuf:"varint,2,opt,name=pending_events,json=pendingEvents,proto3" json:"pending_events,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SetConsumptionPausedResponse) Reset() {
+	*x = SetConsumptionPausedResponse{}
+	mi := &file_notifpb_notifpb_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetConsumptionPausedResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetConsumptionPausedResponse) ProtoMessage() {}
+
+func (x *SetConsumptionPausedResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_notifpb_notifpb_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetConsumptionPausedResponse.ProtoReflect.Descriptor instead.
+func (*SetConsumptionPausedResponse) Descriptor() ([]byte, []int) {
+	return file_notifpb_notifpb_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *SetConsumptionPausedResponse) GetPaused() bool {
+	if x != nil {
+		return x.Paused
+	}
+	return false
+}
+
+func (x *SetConsumptionPausedResponse) GetPendingEvents() int64 {
+	if x != nil {
+		return x.PendingEvents
+	}
+	return 0
+}
+
+type StatsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StatsRequest) Reset() {
+	*x = StatsRequest{}
+	mi := &file_notifpb_notifpb_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StatsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StatsRequest) ProtoMessage() {}
+
+func (x *StatsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_notifpb_notifpb_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StatsRequest.ProtoReflect.Descriptor instead.
+func (*StatsRequest) Descriptor() ([]byte, []int) {
+	return file_notifpb_notifpb_proto_rawDescGZIP(), []int{7}
+}
+
+type StatsResponse struct {
+	state              protoimpl.MessageState `protogen:"open.v1"`
+	ActiveSubscribers  int64                  `protobuf:"varint,1,opt,name=active_subscribers,json=activeSubscribers,proto3" json:"active_subscribers,omitempty"`
+	NotificationsToday int64                  `protobuf:"varint,2,opt,name=notifications_today,json=notificationsToday,proto3" json:"notifications_today,omitempty"`
+	// Subscribers closed by the idle reaper since the service started.
+	ReapedSubscribers int64 `protobuf:"varint,3,opt,name=reaped_subscribers,json=reapedSubscribers,proto3" json:"reaped_subscribers,omitempty"`
+	ConsumptionPaused bool  `protobuf:"varint,4,opt,name=consumption_paused,json=consumptionPaused,proto3" json:"consumption_paused,omitempty"`
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
+}
+
+func (x *StatsResponse) Reset() {
+	*x = StatsResponse{}
+	mi := &file_notifpb_notifpb_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StatsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StatsResponse) ProtoMessage() {}
+
+func (x *StatsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_notifpb_notifpb_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StatsResponse.ProtoReflect.Descriptor instead.
+func (*StatsResponse) Descriptor() ([]byte, []int) {
+	return file_notifpb_notifpb_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *StatsResponse) GetActiveSubscribers() int64 {
+	if x != nil {
+		return x.ActiveSubscribers
+	}
+	return 0
+}
+
+func (x *StatsResponse) GetNotificationsToday() int64 {
+	if x != nil {
+		return x.NotificationsToday
+	}
+	return 0
+}
+
+func (x *StatsResponse) GetReapedSubscribers() int64 {
+	if x != nil {
+		return x.ReapedSubscribers
+	}
+	return 0
+}
+
+func (x *StatsResponse) GetConsumptionPaused() bool {
+	if x != nil {
+		return x.ConsumptionPaused
+	}
+	return false
+}
+
+type DeliveryAttempt struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Channel       string                 `protobuf:"bytes,1,opt,name=channel,proto3" json:"channel,omitempty"`     // e.g. "websocket"
+	Timestamp     string                 `protobuf:"bytes,2,opt,name=timestamp,proto3" json:"timestamp,omitempty"` // RFC 3339 timestamp
+	Result        string                 `protobuf:"bytes,3,opt,name=result,proto3" json:"result,omitempty"`       // "delivered", "failed", "dropped" or "no_subscriber"
+	Error         string                 `protobuf:"bytes,4,opt,name=error,proto3" json:"error,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeliveryAttempt) Reset() {
+	*x = DeliveryAttempt{}
+	mi := &file_notifpb_notifpb_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeliveryAttempt) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeliveryAttempt) ProtoMessage() {}
+
+func (x *DeliveryAttempt) ProtoReflect() protoreflect.Message {
+	mi := &file_notifpb_notifpb_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeliveryAttempt.ProtoReflect.Descriptor instead.
+func (*DeliveryAttempt) Descriptor() ([]byte, []int) {
+	return file_notifpb_notifpb_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *DeliveryAttempt) GetChannel() string {
+	if x != nil {
+		return x.Channel
+	}
+	return ""
+}
+
+func (x *DeliveryAttempt) GetTimestamp() string {
+	if x != nil {
+		return x.Timestamp
+	}
+	return ""
+}
+
+func (x *DeliveryAttempt) GetResult() string {
+	if x != nil {
+		return x.Result
+	}
+	return ""
+}
+
+func (x *DeliveryAttempt) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+type GetNotificationDeliveriesRequest struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	NotificationId string                 `protobuf:"bytes,1,opt,name=notification_id,json=notificationId,proto3" json:"notification_id,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *GetNotificationDeliveriesRequest) Reset() {
+	*x = GetNotificationDeliveriesRequest{}
+	mi := &file_notifpb_notifpb_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetNotificationDeliveriesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetNotificationDeliveriesRequest) ProtoMessage() {}
+
+func (x *GetNotificationDeliveriesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_notifpb_notifpb_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetNotificationDeliveriesRequest.ProtoReflect.Descriptor instead.
+func (*GetNotificationDeliveriesRequest) Descriptor() ([]byte, []int) {
+	return file_notifpb_notifpb_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *GetNotificationDeliveriesRequest) GetNotificationId() string {
+	if x != nil {
+		return x.NotificationId
+	}
+	return ""
+}
+
+type GetNotificationDeliveriesResponse struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	Attempts []*DeliveryAttempt     `protobuf:"bytes,1,rep,name=attempts,proto3" json:"attempts,omitempty"`
+	// event_id of the NATS event the notification was created from, if any.
+	SourceEventId string `protobuf:"bytes,2,opt,name=source_event_id,json=sourceEventId,proto3" json:"source_event_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetNotificationDeliveriesResponse) Reset() {
+	*x = GetNotificationDeliveriesResponse{}
+	mi := &file_notifpb_notifpb_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetNotificationDeliveriesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetNotificationDeliveriesResponse) ProtoMessage() {}
+
+func (x *GetNotificationDeliveriesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_notifpb_notifpb_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetNotificationDeliveriesResponse.ProtoReflect.Descriptor instead.
+func (*GetNotificationDeliveriesResponse) Descriptor() ([]byte, []int) {
+	return file_notifpb_notifpb_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *GetNotificationDeliveriesResponse) GetAttempts() []*DeliveryAttempt {
+	if x != nil {
+		return x.Attempts
+	}
+	return nil
+}
+
+func (x *GetNotificationDeliveriesResponse) GetSourceEventId() string {
+	if x != nil {
+		return x.SourceEventId
+	}
+	return ""
+}
+
+type ResendNotificationRequest struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	UserId string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	// The notification to resend; the user's most recent one when empty.
+	NotificationId string `protobuf:"bytes,2,opt,name=notification_id,json=notificationId,proto3" json:"notification_id,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *ResendNotificationRequest) Reset() {
+	*x = ResendNotificationRequest{}
+	mi := &file_notifpb_notifpb_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ResendNotificationRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ResendNotificationRequest) ProtoMessage() {}
+
+func (x *ResendNotificationRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_notifpb_notifpb_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ResendNotificationRequest.ProtoReflect.Descriptor instead.
+func (*ResendNotificationRequest) Descriptor() ([]byte, []int) {
+	return file_notifpb_notifpb_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *ResendNotificationRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *ResendNotificationRequest) GetNotificationId() string {
+	if x != nil {
+		return x.NotificationId
+	}
+	return ""
+}
+
+type ResendNotificationResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Notification  *Notification          `protobuf:"bytes,1,opt,name=notification,proto3" json:"notification,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ResendNotificationResponse) Reset() {
+	*x = ResendNotificationResponse{}
+	mi := &file_notifpb_notifpb_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ResendNotificationResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ResendNotificationResponse) ProtoMessage() {}
+
+func (x *ResendNotificationResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_notifpb_notifpb_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ResendNotificationResponse.ProtoReflect.Descriptor instead.
+func (*ResendNotificationResponse) Descriptor() ([]byte, []int) {
+	return file_notifpb_notifpb_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *ResendNotificationResponse) GetNotification() *Notification {
+	if x != nil {
+		return x.Notification
+	}
+	return nil
+}
+
+type Template struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	MessageId string                 `protobuf:"bytes,1,opt,name=message_id,json=messageId,proto3" json:"message_id,omitempty"` // e.g. "bill.updated"
+	Locale    string                 `protobuf:"bytes,2,opt,name=locale,proto3" json:"locale,omitempty"`                        // e.g. "en" or "pt-br"
+	// text/template source rendered with the event's params, e.g.
+	// "Your bill is now {{.amount}}".
+	Body          string `protobuf:"bytes,3,opt,name=body,proto3" json:"body,omitempty"`
+	UpdatedAt     string `protobuf:"bytes,4,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"` // RFC 3339 timestamp
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Template) Reset() {
+	*x = Template{}
+	mi := &file_notifpb_notifpb_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Template) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Template) ProtoMessage() {}
+
+func (x *Template) ProtoReflect() protoreflect.Message {
+	mi := &file_notifpb_notifpb_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Template.ProtoReflect.Descriptor instead.
+func (*Template) Descriptor() ([]byte, []int) {
+	return file_notifpb_notifpb_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *Template) GetMessageId() string {
+	if x != nil {
+		return x.MessageId
+	}
+	return ""
+}
+
+func (x *Template) GetLocale() string {
+	if x != nil {
+		return x.Locale
+	}
+	return ""
+}
+
+func (x *Template) GetBody() string {
+	if x != nil {
+		return x.Body
+	}
+	return ""
+}
+
+func (x *Template) GetUpdatedAt() string {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return ""
+}
+
+type ListTemplatesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListTemplatesRequest) Reset() {
+	*x = ListTemplatesRequest{}
+	mi := &file_notifpb_notifpb_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListTemplatesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListTemplatesRequest) ProtoMessage() {}
+
+func (x *ListTemplatesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_notifpb_notifpb_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListTemplatesRequest.ProtoReflect.Descriptor instead.
+func (*ListTemplatesRequest) Descriptor() ([]byte, []int) {
+	return file_notifpb_notifpb_proto_rawDescGZIP(), []int{15}
+}
+
+type ListTemplatesResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Templates     []*Template            `protobuf:"bytes,1,rep,name=templates,proto3" json:"templates,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListTemplatesResponse) Reset() {
+	*x = ListTemplatesResponse{}
+	mi := &file_notifpb_notifpb_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListTemplatesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListTemplatesResponse) ProtoMessage() {}
+
+func (x *ListTemplatesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_notifpb_notifpb_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListTemplatesResponse.ProtoReflect.Descriptor instead.
+func (*ListTemplatesResponse) Descriptor() ([]byte, []int) {
+	return file_notifpb_notifpb_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *ListTemplatesResponse) GetTemplates() []*Template {
+	if x != nil {
+		return x.Templates
+	}
+	return nil
+}
+
+type PutTemplateRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	MessageId     string                 `protobuf:"bytes,1,opt,name=message_id,json=messageId,proto3" json:"message_id,omitempty"`
+	Locale        string                 `protobuf:"bytes,2,opt,name=locale,proto3" json:"locale,omitempty"`
+	Body          string                 `protobuf:"bytes,3,opt,name=body,proto3" json:"body,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PutTemplateRequest) Reset() {
+	*x = PutTemplateRequest{}
+	mi := &file_notifpb_notifpb_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PutTemplateRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PutTemplateRequest) ProtoMessage() {}
+
+func (x *PutTemplateRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_notifpb_notifpb_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PutTemplateRequest.ProtoReflect.Descriptor instead.
+func (*PutTemplateRequest) Descriptor() ([]byte, []int) {
+	return file_notifpb_notifpb_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *PutTemplateRequest) GetMessageId() string {
+	if x != nil {
+		return x.MessageId
+	}
+	return ""
+}
+
+func (x *PutTemplateRequest) GetLocale() string {
+	if x != nil {
+		return x.Locale
+	}
+	return ""
+}
+
+func (x *PutTemplateRequest) GetBody() string {
+	if x != nil {
+		return x.Body
+	}
+	return ""
+}
+
+type PutTemplateResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Template      *Template              `protobuf:"bytes,1,opt,name=template,proto3" json:"template,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PutTemplateResponse) Reset() {
+	*x = PutTemplateResponse{}
+	mi := &file_notifpb_notifpb_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PutTemplateResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PutTemplateResponse) ProtoMessage() {}
+
+func (x *PutTemplateResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_notifpb_notifpb_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PutTemplateResponse.ProtoReflect.Descriptor instead.
+func (*PutTemplateResponse) Descriptor() ([]byte, []int) {
+	return file_notifpb_notifpb_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *PutTemplateResponse) GetTemplate() *Template {
+	if x != nil {
+		return x.Template
+	}
+	return nil
+}
+
+type DeleteTemplateRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	MessageId     string                 `protobuf:"bytes,1,opt,name=message_id,json=messageId,proto3" json:"message_id,omitempty"`
+	Locale        string                 `protobuf:"bytes,2,opt,name=locale,proto3" json:"locale,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteTemplateRequest) Reset() {
+	*x = DeleteTemplateRequest{}
+	mi := &file_notifpb_notifpb_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteTemplateRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteTemplateRequest) ProtoMessage() {}
+
+func (x *DeleteTemplateRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_notifpb_notifpb_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteTemplateRequest.ProtoReflect.Descriptor instead.
+func (*DeleteTemplateRequest) Descriptor() ([]byte, []int) {
+	return file_notifpb_notifpb_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *DeleteTemplateRequest) GetMessageId() string {
+	if x != nil {
+		return x.MessageId
+	}
+	return ""
+}
+
+func (x *DeleteTemplateRequest) GetLocale() string {
+	if x != nil {
+		return x.Locale
+	}
+	return ""
+}
+
+type DeleteTemplateResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteTemplateResponse) Reset() {
+	*x = DeleteTemplateResponse{}
+	mi := &file_notifpb_notifpb_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteTemplateResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteTemplateResponse) ProtoMessage() {}
+
+func (x *DeleteTemplateResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_notifpb_notifpb_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteTemplateResponse.ProtoReflect.Descriptor instead.
+func (*DeleteTemplateResponse) Descriptor() ([]byte, []int) {
+	return file_notifpb_notifpb_proto_rawDescGZIP(), []int{20}
+}
+
+type SendTestNotificationsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SendTestNotificationsRequest) Reset() {
+	*x = SendTestNotificationsRequest{}
+	mi := &file_notifpb_notifpb_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SendTestNotificationsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SendTestNotificationsRequest) ProtoMessage() {}
+
+func (x *SendTestNotificationsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_notifpb_notifpb_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SendTestNotificationsRequest.ProtoReflect.Descriptor instead.
+func (*SendTestNotificationsRequest) Descriptor() ([]byte, []int) {
+	return file_notifpb_notifpb_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *SendTestNotificationsRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+type SendTestNotificationsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Notifications []*Notification        `protobuf:"bytes,1,rep,name=notifications,proto3" json:"notifications,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SendTestNotificationsResponse) Reset() {
+	*x = SendTestNotificationsResponse{}
+	mi := &file_notifpb_notifpb_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SendTestNotificationsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SendTestNotificationsResponse) ProtoMessage() {}
+
+func (x *SendTestNotificationsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_notifpb_notifpb_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SendTestNotificationsResponse.ProtoReflect.Descriptor instead.
+func (*SendTestNotificationsResponse) Descriptor() ([]byte, []int) {
+	return file_notifpb_notifpb_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *SendTestNotificationsResponse) GetNotifications() []*Notification {
+	if x != nil {
+		return x.Notifications
+	}
+	return nil
+}
+
+type HistoryRequest struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	UserId string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	// Page size; 0 uses the default of 20, larger values are capped at 100.
+	Limit         int32 `protobuf:"varint,2,opt,name=limit,proto3" json:"limit,omitempty"`
+	Offset        int32 `protobuf:"varint,3,opt,name=offset,proto3" json:"offset,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HistoryRequest) Reset() {
+	*x = HistoryRequest{}
+	mi := &file_notifpb_notifpb_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HistoryRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HistoryRequest) ProtoMessage() {}
+
+func (x *HistoryRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_notifpb_notifpb_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HistoryRequest.ProtoReflect.Descriptor instead.
+func (*HistoryRequest) Descriptor() ([]byte, []int) {
+	return file_notifpb_notifpb_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *HistoryRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *HistoryRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+func (x *HistoryRequest) GetOffset() int32 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+type HistoryResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Notifications []*Notification        `protobuf:"bytes,1,rep,name=notifications,proto3" json:"notifications,omitempty"`
+	// Number of stored notifications for the user across all pages.
+	Total         int64 `protobuf:"varint,2,opt,name=total,proto3" json:"total,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HistoryResponse) Reset() {
+	*x = HistoryResponse{}
+	mi := &file_notifpb_notifpb_proto_msgTypes[24]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HistoryResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HistoryResponse) ProtoMessage() {}
+
+func (x *HistoryResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_notifpb_notifpb_proto_msgTypes[24]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HistoryResponse.ProtoReflect.Descriptor instead.
+func (*HistoryResponse) Descriptor() ([]byte, []int) {
+	return file_notifpb_notifpb_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *HistoryResponse) GetNotifications() []*Notification {
+	if x != nil {
+		return x.Notifications
+	}
+	return nil
+}
+
+func (x *HistoryResponse) GetTotal() int64 {
+	if x != nil {
+		return x.Total
+	}
+	return 0
+}
+
+// A job that sends the same catalog message to every user.
+type BackfillJob struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	Id        string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	MessageId string                 `protobuf:"bytes,2,opt,name=message_id,json=messageId,proto3" json:"message_id,omitempty"`
+	Params    map[string]string      `protobuf:"bytes,3,rep,name=params,proto3" json:"params,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	Type      NotificationType       `protobuf:"varint,4,opt,name=type,proto3,enum=notifpb.NotificationType" json:"type,omitempty"`
+	Severity  Severity               `protobuf:"varint,5,opt,name=severity,proto3,enum=notifpb.Severity" json:"severity,omitempty"`
+	// "running", "completed" or "failed".
+	Status string `protobuf:"bytes,6,opt,name=status,proto3" json:"status,omitempty"`
+	// Users reached so far, including any skipped because they already had
+	// the notification.
+	Processed int64 `protobuf:"varint,7,opt,name=processed,proto3" json:"processed,omitempty"`
+	// Id of the last user reached; the job continues after it.
+	Cursor string `protobuf:"bytes,8,opt,name=cursor,proto3" json:"cursor,omitempty"`
+	// Why the job failed, when status is "failed".
+	Error         string `protobuf:"bytes,9,opt,name=error,proto3" json:"error,omitempty"`
+	CreatedAt     string `protobuf:"bytes,10,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	UpdatedAt     string `protobuf:"bytes,11,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BackfillJob) Reset() {
+	*x = BackfillJob{}
+	mi := &file_notifpb_notifpb_proto_msgTypes[25]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BackfillJob) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BackfillJob) ProtoMessage() {}
+
+func (x *BackfillJob) ProtoReflect() protoreflect.Message {
+	mi := &file_notifpb_notifpb_proto_msgTypes[25]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BackfillJob.ProtoReflect.Descriptor instead.
+func (*BackfillJob) Descriptor() ([]byte, []int) {
+	return file_notifpb_notifpb_proto_rawDescGZIP(), []int{25}
+}
+
+func (x *BackfillJob) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *BackfillJob) GetMessageId() string {
+	if x != nil {
+		return x.MessageId
+	}
+	return ""
+}
+
+func (x *BackfillJob) GetParams() map[string]string {
+	if x != nil {
+		return x.Params
+	}
+	return nil
+}
+
+func (x *BackfillJob) GetType() NotificationType {
+	if x != nil {
+		return x.Type
+	}
+	return NotificationType_NOTIFICATION_TYPE_UNSPECIFIED
+}
+
+func (x *BackfillJob) GetSeverity() Severity {
+	if x != nil {
+		return x.Severity
+	}
+	return Severity_SEVERITY_UNSPECIFIED
+}
+
+func (x *BackfillJob) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *BackfillJob) GetProcessed() int64 {
+	if x != nil {
+		return x.Processed
+	}
+	return 0
+}
+
+func (x *BackfillJob) GetCursor() string {
+	if x != nil {
+		return x.Cursor
+	}
+	return ""
+}
+
+func (x *BackfillJob) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+func (x *BackfillJob) GetCreatedAt() string {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return ""
+}
+
+func (x *BackfillJob) GetUpdatedAt() string {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return ""
+}
+
+type StartBackfillRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Catalog message rendered for each user in their locale.
+	MessageId     string            `protobuf:"bytes,1,opt,name=message_id,json=messageId,proto3" json:"message_id,omitempty"`
+	Params        map[string]string `protobuf:"bytes,2,rep,name=params,proto3" json:"params,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	Type          NotificationType  `protobuf:"varint,3,opt,name=type,proto3,enum=notifpb.NotificationType" json:"type,omitempty"`
+	Severity      Severity          `protobuf:"varint,4,opt,name=severity,proto3,enum=notifpb.Severity" json:"severity,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StartBackfillRequest) Reset() {
+	*x = StartBackfillRequest{}
+	mi := &file_notifpb_notifpb_proto_msgTypes[26]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StartBackfillRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StartBackfillRequest) ProtoMessage() {}
+
+func (x *StartBackfillRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_notifpb_notifpb_proto_msgTypes[26]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StartBackfillRequest.ProtoReflect.Descriptor instead.
+func (*StartBackfillRequest) Descriptor() ([]byte, []int) {
+	return file_notifpb_notifpb_proto_rawDescGZIP(), []int{26}
+}
+
+func (x *StartBackfillRequest) GetMessageId() string {
+	if x != nil {
+		return x.MessageId
+	}
+	return ""
+}
+
+func (x *StartBackfillRequest) GetParams() map[string]string {
+	if x != nil {
+		return x.Params
+	}
+	return nil
+}
+
+func (x *StartBackfillRequest) GetType() NotificationType {
+	if x != nil {
+		return x.Type
+	}
+	return NotificationType_NOTIFICATION_TYPE_UNSPECIFIED
+}
+
+func (x *StartBackfillRequest) GetSeverity() Severity {
+	if x != nil {
+		return x.Severity
+	}
+	return Severity_SEVERITY_UNSPECIFIED
+}
+
+type StartBackfillResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Job           *BackfillJob           `protobuf:"bytes,1,opt,name=job,proto3" json:"job,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StartBackfillResponse) Reset() {
+	*x = StartBackfillResponse{}
+	mi := &file_notifpb_notifpb_proto_msgTypes[27]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StartBackfillResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StartBackfillResponse) ProtoMessage() {}
+
+func (x *StartBackfillResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_notifpb_notifpb_proto_msgTypes[27]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StartBackfillResponse.ProtoReflect.Descriptor instead.
+func (*StartBackfillResponse) Descriptor() ([]byte, []int) {
+	return file_notifpb_notifpb_proto_rawDescGZIP(), []int{27}
+}
+
+func (x *StartBackfillResponse) GetJob() *BackfillJob {
+	if x != nil {
+		return x.Job
+	}
+	return nil
+}
+
+type GetBackfillRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetBackfillRequest) Reset() {
+	*x = GetBackfillRequest{}
+	mi := &file_notifpb_notifpb_proto_msgTypes[28]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetBackfillRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetBackfillRequest) ProtoMessage() {}
+
+func (x *GetBackfillRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_notifpb_notifpb_proto_msgTypes[28]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetBackfillRequest.ProtoReflect.Descriptor instead.
+func (*GetBackfillRequest) Descriptor() ([]byte, []int) {
+	return file_notifpb_notifpb_proto_rawDescGZIP(), []int{28}
+}
+
+func (x *GetBackfillRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type GetBackfillResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Job           *BackfillJob           `protobuf:"bytes,1,opt,name=job,proto3" json:"job,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetBackfillResponse) Reset() {
+	*x = GetBackfillResponse{}
+	mi := &file_notifpb_notifpb_proto_msgTypes[29]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetBackfillResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetBackfillResponse) ProtoMessage() {}
+
+func (x *GetBackfillResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_notifpb_notifpb_proto_msgTypes[29]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetBackfillResponse.ProtoReflect.Descriptor instead.
+func (*GetBackfillResponse) Descriptor() ([]byte, []int) {
+	return file_notifpb_notifpb_proto_rawDescGZIP(), []int{29}
+}
+
+func (x *GetBackfillResponse) GetJob() *BackfillJob {
+	if x != nil {
+		return x.Job
+	}
+	return nil
+}
+
+type ResumeBackfillRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ResumeBackfillRequest) Reset() {
+	*x = ResumeBackfillRequest{}
+	mi := &file_notifpb_notifpb_proto_msgTypes[30]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ResumeBackfillRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ResumeBackfillRequest) ProtoMessage() {}
+
+func (x *ResumeBackfillRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_notifpb_notifpb_proto_msgTypes[30]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ResumeBackfillRequest.ProtoReflect.Descriptor instead.
+func (*ResumeBackfillRequest) Descriptor() ([]byte, []int) {
+	return file_notifpb_notifpb_proto_rawDescGZIP(), []int{30}
+}
+
+func (x *ResumeBackfillRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type ResumeBackfillResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Job           *BackfillJob           `protobuf:"bytes,1,opt,name=job,proto3" json:"job,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ResumeBackfillResponse) Reset() {
+	*x = ResumeBackfillResponse{}
+	mi := &file_notifpb_notifpb_proto_msgTypes[31]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ResumeBackfillResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ResumeBackfillResponse) ProtoMessage() {}
+
+func (x *ResumeBackfillResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_notifpb_notifpb_proto_msgTypes[31]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ResumeBackfillResponse.ProtoReflect.Descriptor instead.
+func (*ResumeBackfillResponse) Descriptor() ([]byte, []int) {
+	return file_notifpb_notifpb_proto_rawDescGZIP(), []int{31}
+}
+
+func (x *ResumeBackfillResponse) GetJob() *BackfillJob {
+	if x != nil {
+		return x.Job
+	}
+	return nil
+}
+
+// An open SubscribeToNotifications stream.
+type StreamInfo struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	UserId string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	// "-" when the gateway sent no session id.
+	SessionId   string `protobuf:"bytes,2,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
+	ConnectedAt string `protobuf:"bytes,3,opt,name=connected_at,json=connectedAt,proto3" json:"connected_at,omitempty"` // RFC 3339 timestamp
+	// Last successful send, or connected_at when nothing was sent yet.
+	LastActiveAt string `protobuf:"bytes,4,opt,name=last_active_at,json=lastActiveAt,proto3" json:"last_active_at,omitempty"`
+	// Batches waiting in the stream's buffer, out of buffer_size.
+	Buffered   int32 `protobuf:"varint,5,opt,name=buffered,proto3" json:"buffered,omitempty"`
+	BufferSize int32 `protobuf:"varint,6,opt,name=buffer_size,json=bufferSize,proto3" json:"buffer_size,omitempty"`
+	// Notifications dropped because the buffer stayed full.
+	Dropped       int64 `protobuf:"varint,7,opt,name=dropped,proto3" json:"dropped,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StreamInfo) Reset() {
+	*x = StreamInfo{}
+	mi := &file_notifpb_notifpb_proto_msgTypes[32]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StreamInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamInfo) ProtoMessage() {}
+
+func (x *StreamInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_notifpb_notifpb_proto_msgTypes[32]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamInfo.ProtoReflect.Descriptor instead.
+func (*StreamInfo) Descriptor() ([]byte, []int) {
+	return file_notifpb_notifpb_proto_rawDescGZIP(), []int{32}
+}
+
+func (x *StreamInfo) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *StreamInfo) GetSessionId() string {
+	if x != nil {
+		return x.SessionId
+	}
+	return ""
+}
+
+func (x *StreamInfo) GetConnectedAt() string {
+	if x != nil {
+		return x.ConnectedAt
+	}
+	return ""
+}
+
+func (x *StreamInfo) GetLastActiveAt() string {
+	if x != nil {
+		return x.LastActiveAt
+	}
+	return ""
+}
+
+func (x *StreamInfo) GetBuffered() int32 {
+	if x != nil {
+		return x.Buffered
+	}
+	return 0
+}
+
+func (x *StreamInfo) GetBufferSize() int32 {
+	if x != nil {
+		return x.BufferSize
+	}
+	return 0
+}
+
+func (x *StreamInfo) GetDropped() int64 {
+	if x != nil {
+		return x.Dropped
+	}
+	return 0
+}
+
+type ListStreamsRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Page size; 0 uses the default of 50, larger values are capped at 500.
+	Limit         int32 `protobuf:"varint,1,opt,name=limit,proto3" json:"limit,omitempty"`
+	Offset        int32 `protobuf:"varint,2,opt,name=offset,proto3" json:"offset,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListStreamsRequest) Reset() {
+	*x = ListStreamsRequest{}
+	mi := &file_notifpb_notifpb_proto_msgTypes[33]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListStreamsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListStreamsRequest) ProtoMessage() {}
+
+func (x *ListStreamsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_notifpb_notifpb_proto_msgTypes[33]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListStreamsRequest.ProtoReflect.Descriptor instead.
+func (*ListStreamsRequest) Descriptor() ([]byte, []int) {
+	return file_notifpb_notifpb_proto_rawDescGZIP(), []int{33}
+}
+
+func (x *ListStreamsRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+func (x *ListStreamsRequest) GetOffset() int32 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+type ListStreamsResponse struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	Streams []*StreamInfo          `protobuf:"bytes,1,rep,name=streams,proto3" json:"streams,omitempty"`
+	// Number of open streams across all pages.
+	Total         int64 `protobuf:"varint,2,opt,name=total,proto3" json:"total,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListStreamsResponse) Reset() {
+	*x = ListStreamsResponse{}
+	mi := &file_notifpb_notifpb_proto_msgTypes[34]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListStreamsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListStreamsResponse) ProtoMessage() {}
+
+func (x *ListStreamsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_notifpb_notifpb_proto_msgTypes[34]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListStreamsResponse.ProtoReflect.Descriptor instead.
+func (*ListStreamsResponse) Descriptor() ([]byte, []int) {
+	return file_notifpb_notifpb_proto_rawDescGZIP(), []int{34}
+}
+
+func (x *ListStreamsResponse) GetStreams() []*StreamInfo {
+	if x != nil {
+		return x.Streams
+	}
+	return nil
+}
+
+func (x *ListStreamsResponse) GetTotal() int64 {
+	if x != nil {
+		return x.Total
+	}
+	return 0
+}
+
+type TerminateStreamRequest struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	SessionId string                 `protobuf:"bytes,1,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
+	// Optional; limits the match to one user's streams.
+	UserId        string `protobuf:"bytes,2,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TerminateStreamRequest) Reset() {
+	*x = TerminateStreamRequest{}
+	mi := &file_notifpb_notifpb_proto_msgTypes[35]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TerminateStreamRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TerminateStreamRequest) ProtoMessage() {}
+
+func (x *TerminateStreamRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_notifpb_notifpb_proto_msgTypes[35]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TerminateStreamRequest.ProtoReflect.Descriptor instead.
+func (*TerminateStreamRequest) Descriptor() ([]byte, []int) {
+	return file_notifpb_notifpb_proto_rawDescGZIP(), []int{35}
+}
+
+func (x *TerminateStreamRequest) GetSessionId() string {
+	if x != nil {
+		return x.SessionId
+	}
+	return ""
+}
+
+func (x *TerminateStreamRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+type TerminateStreamResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Number of streams closed.
+	Terminated    int32 `protobuf:"varint,1,opt,name=terminated,proto3" json:"terminated,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TerminateStreamResponse) Reset() {
+	*x = TerminateStreamResponse{}
+	mi := &file_notifpb_notifpb_proto_msgTypes[36]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TerminateStreamResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TerminateStreamResponse) ProtoMessage() {}
+
+func (x *TerminateStreamResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_notifpb_notifpb_proto_msgTypes[36]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TerminateStreamResponse.ProtoReflect.Descriptor instead.
+func (*TerminateStreamResponse) Descriptor() ([]byte, []int) {
+	return file_notifpb_notifpb_proto_rawDescGZIP(), []int{36}
+}
+
+func (x *TerminateStreamResponse) GetTerminated() int32 {
+	if x != nil {
+		return x.Terminated
+	}
+	return 0
+}
+
+var File_notifpb_notifpb_proto protoreflect.FileDescriptor
+
+const file_notifpb_notifpb_proto_rawDesc = "" +
+	"\n" +
+	"\x15notifpb/notifpb.proto\x12\anotifpb\"J\n" +
+	"\x10SubscribeRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\x12\x1d\n" +
+	"\n" +
+	"batch_size\x18\x02 \x01(\x05R\tbatchSize\"P\n" +
+	"\x11NotificationBatch\x12;\n" +
+	"\rnotifications\x18\x01 \x03(\v2\x15.notifpb.NotificationR\rnotifications\"\xc8\x02\n" +
+	"\fNotification\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x17\n" +
+	"\auser_id\x18\x02 \x01(\tR\x06userId\x12\x18\n" +
+	"\amessage\x18\x03 \x01(\tR\amessage\x12\x1c\n" +
+	"\ttimestamp\x18\x04 \x01(\tR\ttimestamp\x12\x14\n" +
+	"\x05event\x18\x05 \x01(\tR\x05event\x12&\n" +
+	"\x0fsource_event_id\x18\x06 \x01(\tR\rsourceEventId\x12-\n" +
+	"\x04type\x18\a \x01(\x0e2\x19.notifpb.NotificationTypeR\x04type\x12-\n" +
+	"\bseverity\x18\b \x01(\x0e2\x11.notifpb.SeverityR\bseverity\x12'\n" +
+	"\x06source\x18\t \x01(\x0e2\x0f.notifpb.SourceR\x06source\x12\x12\n" +
+	"\x04read\x18\n" +
+	" \x01(\bR\x04read\"_\n" +
+	"\x1bMarkNotificationReadRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\x12'\n" +
+	"\x0fnotification_id\x18\x02 \x01(\tR\x0enotificationId\"8\n" +
+	"\x1cMarkNotificationReadResponse\x12\x18\n" +
+	"\asuccess\x18\x01 \x01(\bR\asuccess\"5\n" +
+	"\x1bSetConsumptionPausedRequest\x12\x16\n" +
+	"\x06paused\x18\x01 \x01(\bR\x06paused\"]\n" +
+	"\x1cSetConsumptionPausedResponse\x12\x16\n" +
+	"\x06paused\x18\x01 \x01(\bR\x06paused\x12%\n" +
+	"\x0epending_events\x18\x02 \x01(\x03R\rpendingEvents\"\x0e\n" +
+	"\fStatsRequest\"\xcd\x01\n" +
+	"\rStatsResponse\x12-\n" +
+	"\x12active_subscribers\x18\x01 \x01(\x03R\x11activeSubscribers\x12/\n" +
+	"\x13notifications_today\x18\x02 \x01(\x03R\x12notificationsToday\x12-\n" +
+	"\x12reaped_subscribers\x18\x03 \x01(\x03R\x11reapedSubscribers\x12-\n" +
+	"\x12consumption_paused\x18\x04 \x01(\bR\x11consumptionPaused\"w\n" +
+	"\x0fDeliveryAttempt\x12\x18\n" +
+	"\achannel\x18\x01 \x01(\tR\achannel\x12\x1c\n" +
+	"\ttimestamp\x18\x02 \x01(\tR\ttimestamp\x12\x16\n" +
+	"\x06result\x18\x03 \x01(\tR\x06result\x12\x14\n" +
+	"\x05error\x18\x04 \x01(\tR\x05error\"K\n" +
+	" GetNotificationDeliveriesRequest\x12'\n" +
+	"\x0fnotification_id\x18\x01 \x01(\tR\x0enotificationId\"\x81\x01\n" +
+	"!GetNotificationDeliveriesResponse\x124\n" +
+	"\battempts\x18\x01 \x03(\v2\x18.notifpb.DeliveryAttemptR\battempts\x12&\n" +
+	"\x0fsource_event_id\x18\x02 \x01(\tR\rsourceEventId\"]\n" +
+	"\x19ResendNotificationRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\x12'\n" +
+	"\x0fnotification_id\x18\x02 \x01(\tR\x0enotificationId\"W\n" +
+	"\x1aResendNotificationResponse\x129\n" +
+	"\fnotification\x18\x01 \x01(\v2\x15.notifpb.NotificationR\fnotification\"t\n" +
+	"\bTemplate\x12\x1d\n" +
+	"\n" +
+	"message_id\x18\x01 \x01(\tR\tmessageId\x12\x16\n" +
+	"\x06locale\x18\x02 \x01(\tR\x06locale\x12\x12\n" +
+	"\x04body\x18\x03 \x01(\tR\x04body\x12\x1d\n" +
+	"\n" +
+	"updated_at\x18\x04 \x01(\tR\tupdatedAt\"\x16\n" +
+	"\x14ListTemplatesRequest\"H\n" +
+	"\x15ListTemplatesResponse\x12/\n" +
+	"\ttemplates\x18\x01 \x03(\v2\x11.notifpb.TemplateR\ttemplates\"_\n" +
+	"\x12PutTemplateRequest\x12\x1d\n" +
+	"\n" +
+	"message_id\x18\x01 \x01(\tR\tmessageId\x12\x16\n" +
+	"\x06locale\x18\x02 \x01(\tR\x06locale\x12\x12\n" +
+	"\x04body\x18\x03 \x01(\tR\x04body\"D\n" +
+	"\x13PutTemplateResponse\x12-\n" +
+	"\btemplate\x18\x01 \x01(\v2\x11.notifpb.TemplateR\btemplate\"N\n" +
+	"\x15DeleteTemplateRequest\x12\x1d\n" +
+	"\n" +
+	"message_id\x18\x01 \x01(\tR\tmessageId\x12\x16\n" +
+	"\x06locale\x18\x02 \x01(\tR\x06locale\"\x18\n" +
+	"\x16DeleteTemplateResponse\"7\n" +
+	"\x1cSendTestNotificationsRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\"\\\n" +
+	"\x1dSendTestNotificationsResponse\x12;\n" +
+	"\rnotifications\x18\x01 \x03(\v2\x15.notifpb.NotificationR\rnotifications\"W\n" +
+	"\x0eHistoryRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\x12\x14\n" +
+	"\x05limit\x18\x02 \x01(\x05R\x05limit\x12\x16\n" +
+	"\x06offset\x18\x03 \x01(\x05R\x06offset\"d\n" +
+	"\x0fHistoryResponse\x12;\n" +
+	"\rnotifications\x18\x01 \x03(\v2\x15.notifpb.NotificationR\rnotifications\x12\x14\n" +
+	"\x05total\x18\x02 \x01(\x03R\x05total\"\xb1\x03\n" +
+	"\vBackfillJob\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x1d\n" +
+	"\n" +
+	"message_id\x18\x02 \x01(\tR\tmessageId\x128\n" +
+	"\x06params\x18\x03 \x03(\v2 .notifpb.BackfillJob.ParamsEntryR\x06params\x12-\n" +
+	"\x04type\x18\x04 \x01(\x0e2\x19.notifpb.NotificationTypeR\x04type\x12-\n" +
+	"\bseverity\x18\x05 \x01(\x0e2\x11.notifpb.SeverityR\bseverity\x12\x16\n" +
+	"\x06status\x18\x06 \x01(\tR\x06status\x12\x1c\n" +
+	"\tprocessed\x18\a \x01(\x03R\tprocessed\x12\x16\n" +
+	"\x06cursor\x18\b \x01(\tR\x06cursor\x12\x14\n" +
+	"\x05error\x18\t \x01(\tR\x05error\x12\x1d\n" +
+	"\n" +
+	"created_at\x18\n" +
+	" \x01(\tR\tcreatedAt\x12\x1d\n" +
+	"\n" +
+	"updated_at\x18\v \x01(\tR\tupdatedAt\x1a9\n" +
+	"\vParamsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\x91\x02\n" +
+	"\x14StartBackfillRequest\x12\x1d\n" +
+	"\n" +
+	"message_id\x18\x01 \x01(\tR\tmessageId\x12A\n" +
+	"\x06params\x18\x02 \x03(\v2).notifpb.StartBackfillRequest.ParamsEntryR\x06params\x12-\n" +
+	"\x04type\x18\x03 \x01(\x0e2\x19.notifpb.NotificationTypeR\x04type\x12-\n" +
+	"\bseverity\x18\x04 \x01(\x0e2\x11.notifpb.SeverityR\bseverity\x1a9\n" +
+	"\vParamsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"?\n" +
+	"\x15StartBackfillResponse\x12&\n" +
+	"\x03job\x18\x01 \x01(\v2\x14.notifpb.BackfillJobR\x03job\"$\n" +
+	"\x12GetBackfillRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"=\n" +
+	"\x13GetBackfillResponse\x12&\n" +
+	"\x03job\x18\x01 \x01(\v2\x14.notifpb.BackfillJobR\x03job\"'\n" +
+	"\x15ResumeBackfillRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"@\n" +
+	"\x16ResumeBackfillResponse\x12&\n" +
+	"\x03job\x18\x01 \x01(\v2\x14.notifpb.BackfillJobR\x03job\"\xe4\x01\n" +
+	"\n" +
+	"StreamInfo\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\x12\x1d\n" +
+	"\n" +
+	"session_id\x18\x02 \x01(\tR\tsessionId\x12!\n" +
+	"\fconnected_at\x18\x03 \x01(\tR\vconnectedAt\x12$\n" +
+	"\x0elast_active_at\x18\x04 \x01(\tR\flastActiveAt\x12\x1a\n" +
+	"\bbuffered\x18\x05 \x01(\x05R\bbuffered\x12\x1f\n" +
+	"\vbuffer_size\x18\x06 \x01(\x05R\n" +
+	"bufferSize\x12\x18\n" +
+	"\adropped\x18\a \x01(\x03R\adropped\"B\n" +
+	"\x12ListStreamsRequest\x12\x14\n" +
+	"\x05limit\x18\x01 \x01(\x05R\x05limit\x12\x16\n" +
+	"\x06offset\x18\x02 \x01(\x05R\x06offset\"Z\n" +
+	"\x13ListStreamsResponse\x12-\n" +
+	"\astreams\x18\x01 \x03(\v2\x13.notifpb.StreamInfoR\astreams\x12\x14\n" +
+	"\x05total\x18\x02 \x01(\x03R\x05total\"P\n" +
+	"\x16TerminateStreamRequest\x12\x1d\n" +
+	"\n" +
+	"session_id\x18\x01 \x01(\tR\tsessionId\x12\x17\n" +
+	"\auser_id\x18\x02 \x01(\tR\x06userId\"9\n" +
+	"\x17TerminateStreamResponse\x12\x1e\n" +
+	"\n" +
+	"terminated\x18\x01 \x01(\x05R\n" +
+	"terminated*s\n" +
+	"\x10NotificationType\x12!\n" +
+	"\x1dNOTIFICATION_TYPE_UNSPECIFIED\x10\x00\x12\x1d\n" +
+	"\x19NOTIFICATION_TYPE_WELCOME\x10\x01\x12\x1d\n" +
+	"\x19NOTIFICATION_TYPE_BILLING\x10\x02*d\n" +
+	"\bSeverity\x12\x18\n" +
+	"\x14SEVERITY_UNSPECIFIED\x10\x00\x12\x11\n" +
+	"\rSEVERITY_INFO\x10\x01\x12\x14\n" +
+	"\x10SEVERITY_WARNING\x10\x02\x12\x15\n" +
+	"\x11SEVERITY_CRITICAL\x10\x03*E\n" +
+	"\x06Source\x12\x16\n" +
+	"\x12SOURCE_UNSPECIFIED\x10\x00\x12\x0f\n" +
+	"\vSOURCE_USER\x10\x01\x12\x12\n" +
+	"\x0eSOURCE_BILLING\x10\x022\xee\n" +
+	"\n" +
+	"\x13NotificationService\x12S\n" +
+	"\x18SubscribeToNotifications\x12\x19.notifpb.SubscribeRequest\x1a\x1a.notifpb.NotificationBatch0\x01\x12c\n" +
+	"\x14MarkNotificationRead\x12$.notifpb.MarkNotificationReadRequest\x1a%.notifpb.MarkNotificationReadResponse\x126\n" +
+	"\x05Stats\x12\x15.notifpb.StatsRequest\x1a\x16.notifpb.StatsResponse\x12c\n" +
+	"\x14SetConsumptionPaused\x12$.notifpb.SetConsumptionPausedRequest\x1a%.notifpb.SetConsumptionPausedResponse\x12r\n" +
+	"\x19GetNotificationDeliveries\x12).notifpb.GetNotificationDeliveriesRequest\x1a*.notifpb.GetNotificationDeliveriesResponse\x12]\n" +
+	"\x12ResendNotification\x12\".notifpb.ResendNotificationRequest\x1a#.notifpb.ResendNotificationResponse\x12f\n" +
+	"\x15SendTestNotifications\x12%.notifpb.SendTestNotificationsRequest\x1a&.notifpb.SendTestNotificationsResponse\x12K\n" +
+	"\x16GetNotificationHistory\x12\x17.notifpb.HistoryRequest\x1a\x18.notifpb.HistoryResponse\x12N\n" +
+	"\rListTemplates\x12\x1d.notifpb.ListTemplatesRequest\x1a\x1e.notifpb.ListTemplatesResponse\x12H\n" +
+	"\vPutTemplate\x12\x1b.notifpb.PutTemplateRequest\x1a\x1c.notifpb.PutTemplateResponse\x12Q\n" +
+	"\x0eDeleteTemplate\x12\x1e.notifpb.DeleteTemplateRequest\x1a\x1f.notifpb.DeleteTemplateResponse\x12N\n" +
+	"\rStartBackfill\x12\x1d.notifpb.StartBackfillRequest\x1a\x1e.notifpb.StartBackfillResponse\x12H\n" +
+	"\vGetBackfill\x12\x1b.notifpb.GetBackfillRequest\x1a\x1c.notifpb.GetBackfillResponse\x12Q\n" +
+	"\x0eResumeBackfill\x12\x1e.notifpb.ResumeBackfillRequest\x1a\x1f.notifpb.ResumeBackfillResponse\x12H\n" +
+	"\vListStreams\x12\x1b.notifpb.ListStreamsRequest\x1a\x1c.notifpb.ListStreamsResponse\x12T\n" +
+	"\x0fTerminateStream\x12\x1f.notifpb.TerminateStreamRequest\x1a .notifpb.TerminateStreamResponseB\vZ\t./notifpbb\x06proto3"
 
 var (
 	file_notifpb_notifpb_proto_rawDescOnce sync.Once
@@ -160,19 +2410,110 @@ func file_notifpb_notifpb_proto_rawDescGZIP() []byte {
 	return file_notifpb_notifpb_proto_rawDescData
 }
 
-var file_notifpb_notifpb_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
+var file_notifpb_notifpb_proto_enumTypes = make([]protoimpl.EnumInfo, 3)
+var file_notifpb_notifpb_proto_msgTypes = make([]protoimpl.MessageInfo, 39)
 var file_notifpb_notifpb_proto_goTypes = []any{
-	(*SubscribeRequest)(nil), // 0: notifpb.SubscribeRequest
-	(*Notification)(nil),     // 1: notifpb.Notification
+	(NotificationType)(0),                     // 0: notifpb.NotificationType
+	(Severity)(0),                             // 1: notifpb.Severity
+	(Source)(0),                               // 2: notifpb.Source
+	(*SubscribeRequest)(nil),                  // 3: notifpb.SubscribeRequest
+	(*NotificationBatch)(nil),                 // 4: notifpb.NotificationBatch
+	(*Notification)(nil),                      // 5: notifpb.Notification
+	(*MarkNotificationReadRequest)(nil),       // 6: notifpb.MarkNotificationReadRequest
+	(*MarkNotificationReadResponse)(nil),      // 7: notifpb.MarkNotificationReadResponse
+	(*SetConsumptionPausedRequest)(nil),       // 8: notifpb.SetConsumptionPausedRequest
+	(*SetConsumptionPausedResponse)(nil),      // 9: notifpb.SetConsumptionPausedResponse
+	(*StatsRequest)(nil),                      // 10: notifpb.StatsRequest
+	(*StatsResponse)(nil),                     // 11: notifpb.StatsResponse
+	(*DeliveryAttempt)(nil),                   // 12: notifpb.DeliveryAttempt
+	(*GetNotificationDeliveriesRequest)(nil),  // 13: notifpb.GetNotificationDeliveriesRequest
+	(*GetNotificationDeliveriesResponse)(nil), // 14: notifpb.GetNotificationDeliveriesResponse
+	(*ResendNotificationRequest)(nil),         // 15: notifpb.ResendNotificationRequest
+	(*ResendNotificationResponse)(nil),        // 16: notifpb.ResendNotificationResponse
+	(*Template)(nil),                          // 17: notifpb.Template
+	(*ListTemplatesRequest)(nil),              // 18: notifpb.ListTemplatesRequest
+	(*ListTemplatesResponse)(nil),             // 19: notifpb.ListTemplatesResponse
+	(*PutTemplateRequest)(nil),                // 20: notifpb.PutTemplateRequest
+	(*PutTemplateResponse)(nil),               // 21: notifpb.PutTemplateResponse
+	(*DeleteTemplateRequest)(nil),             // 22: notifpb.DeleteTemplateRequest
+	(*DeleteTemplateResponse)(nil),            // 23: notifpb.DeleteTemplateResponse
+	(*SendTestNotificationsRequest)(nil),      // 24: notifpb.SendTestNotificationsRequest
+	(*SendTestNotificationsResponse)(nil),     // 25: notifpb.SendTestNotificationsResponse
+	(*HistoryRequest)(nil),                    // 26: notifpb.HistoryRequest
+	(*HistoryResponse)(nil),                   // 27: notifpb.HistoryResponse
+	(*BackfillJob)(nil),                       // 28: notifpb.BackfillJob
+	(*StartBackfillRequest)(nil),              // 29: notifpb.StartBackfillRequest
+	(*StartBackfillResponse)(nil),             // 30: notifpb.StartBackfillResponse
+	(*GetBackfillRequest)(nil),                // 31: notifpb.GetBackfillRequest
+	(*GetBackfillResponse)(nil),               // 32: notifpb.GetBackfillResponse
+	(*ResumeBackfillRequest)(nil),             // 33: notifpb.ResumeBackfillRequest
+	(*ResumeBackfillResponse)(nil),            // 34: notifpb.ResumeBackfillResponse
+	(*StreamInfo)(nil),                        // 35: notifpb.StreamInfo
+	(*ListStreamsRequest)(nil),                // 36: notifpb.ListStreamsRequest
+	(*ListStreamsResponse)(nil),               // 37: notifpb.ListStreamsResponse
+	(*TerminateStreamRequest)(nil),            // 38: notifpb.TerminateStreamRequest
+	(*TerminateStreamResponse)(nil),           // 39: notifpb.TerminateStreamResponse
+	nil,                                       // 40: notifpb.BackfillJob.ParamsEntry
+	nil,                                       // 41: notifpb.StartBackfillRequest.ParamsEntry
 }
 var file_notifpb_notifpb_proto_depIdxs = []int32{
-	0, // 0: notifpb.NotificationService.SubscribeToNotifications:input_type -> notifpb.SubscribeRequest
-	1, // 1: notifpb.NotificationService.SubscribeToNotifications:output_type -> notifpb.Notification
-	1, // [1:2] is the sub-list for method output_type
-	0, // [0:1] is the sub-list for method input_type
-	0, // [0:0] is the sub-list for extension type_name
-	0, // [0:0] is the sub-list for extension extendee
-	0, // [0:0] is the sub-list for field type_name
+	5,  // 0: notifpb.NotificationBatch.notifications:type_name -> notifpb.Notification
+	0,  // 1: notifpb.Notification.type:type_name -> notifpb.NotificationType
+	1,  // 2: notifpb.Notification.severity:type_name -> notifpb.Severity
+	2,  // 3: notifpb.Notification.source:type_name -> notifpb.Source
+	12, // 4: notifpb.GetNotificationDeliveriesResponse.attempts:type_name -> notifpb.DeliveryAttempt
+	5,  // 5: notifpb.ResendNotificationResponse.notification:type_name -> notifpb.Notification
+	17, // 6: notifpb.ListTemplatesResponse.templates:type_name -> notifpb.Template
+	17, // 7: notifpb.PutTemplateResponse.template:type_name -> notifpb.Template
+	5,  // 8: notifpb.SendTestNotificationsResponse.notifications:type_name -> notifpb.Notification
+	5,  // 9: notifpb.HistoryResponse.notifications:type_name -> notifpb.Notification
+	40, // 10: notifpb.BackfillJob.params:type_name -> notifpb.BackfillJob.ParamsEntry
+	0,  // 11: notifpb.BackfillJob.type:type_name -> notifpb.NotificationType
+	1,  // 12: notifpb.BackfillJob.severity:type_name -> notifpb.Severity
+	41, // 13: notifpb.StartBackfillRequest.params:type_name -> notifpb.StartBackfillRequest.ParamsEntry
+	0,  // 14: notifpb.StartBackfillRequest.type:type_name -> notifpb.NotificationType
+	1,  // 15: notifpb.StartBackfillRequest.severity:type_name -> notifpb.Severity
+	28, // 16: notifpb.StartBackfillResponse.job:type_name -> notifpb.BackfillJob
+	28, // 17: notifpb.GetBackfillResponse.job:type_name -> notifpb.BackfillJob
+	28, // 18: notifpb.ResumeBackfillResponse.job:type_name -> notifpb.BackfillJob
+	35, // 19: notifpb.ListStreamsResponse.streams:type_name -> notifpb.StreamInfo
+	3,  // 20: notifpb.NotificationService.SubscribeToNotifications:input_type -> notifpb.SubscribeRequest
+	6,  // 21: notifpb.NotificationService.MarkNotificationRead:input_type -> notifpb.MarkNotificationReadRequest
+	10, // 22: notifpb.NotificationService.Stats:input_type -> notifpb.StatsRequest
+	8,  // 23: notifpb.NotificationService.SetConsumptionPaused:input_type -> notifpb.SetConsumptionPausedRequest
+	13, // 24: notifpb.NotificationService.GetNotificationDeliveries:input_type -> notifpb.GetNotificationDeliveriesRequest
+	15, // 25: notifpb.NotificationService.ResendNotification:input_type -> notifpb.ResendNotificationRequest
+	24, // 26: notifpb.NotificationService.SendTestNotifications:input_type -> notifpb.SendTestNotificationsRequest
+	26, // 27: notifpb.NotificationService.GetNotificationHistory:input_type -> notifpb.HistoryRequest
+	18, // 28: notifpb.NotificationService.ListTemplates:input_type -> notifpb.ListTemplatesRequest
+	20, // 29: notifpb.NotificationService.PutTemplate:input_type -> notifpb.PutTemplateRequest
+	22, // 30: notifpb.NotificationService.DeleteTemplate:input_type -> notifpb.DeleteTemplateRequest
+	29, // 31: notifpb.NotificationService.StartBackfill:input_type -> notifpb.StartBackfillRequest
+	31, // 32: notifpb.NotificationService.GetBackfill:input_type -> notifpb.GetBackfillRequest
+	33, // 33: notifpb.NotificationService.ResumeBackfill:input_type -> notifpb.ResumeBackfillRequest
+	36, // 34: notifpb.NotificationService.ListStreams:input_type -> notifpb.ListStreamsRequest
+	38, // 35: notifpb.NotificationService.TerminateStream:input_type -> notifpb.TerminateStreamRequest
+	4,  // 36: notifpb.NotificationService.SubscribeToNotifications:output_type -> notifpb.NotificationBatch
+	7,  // 37: notifpb.NotificationService.MarkNotificationRead:output_type -> notifpb.MarkNotificationReadResponse
+	11, // 38: notifpb.NotificationService.Stats:output_type -> notifpb.StatsResponse
+	9,  // 39: notifpb.NotificationService.SetConsumptionPaused:output_type -> notifpb.SetConsumptionPausedResponse
+	14, // 40: notifpb.NotificationService.GetNotificationDeliveries:output_type -> notifpb.GetNotificationDeliveriesResponse
+	16, // 41: notifpb.NotificationService.ResendNotification:output_type -> notifpb.ResendNotificationResponse
+	25, // 42: notifpb.NotificationService.SendTestNotifications:output_type -> notifpb.SendTestNotificationsResponse
+	27, // 43: notifpb.NotificationService.GetNotificationHistory:output_type -> notifpb.HistoryResponse
+	19, // 44: notifpb.NotificationService.ListTemplates:output_type -> notifpb.ListTemplatesResponse
+	21, // 45: notifpb.NotificationService.PutTemplate:output_type -> notifpb.PutTemplateResponse
+	23, // 46: notifpb.NotificationService.DeleteTemplate:output_type -> notifpb.DeleteTemplateResponse
+	30, // 47: notifpb.NotificationService.StartBackfill:output_type -> notifpb.StartBackfillResponse
+	32, // 48: notifpb.NotificationService.GetBackfill:output_type -> notifpb.GetBackfillResponse
+	34, // 49: notifpb.NotificationService.ResumeBackfill:output_type -> notifpb.ResumeBackfillResponse
+	37, // 50: notifpb.NotificationService.ListStreams:output_type -> notifpb.ListStreamsResponse
+	39, // 51: notifpb.NotificationService.TerminateStream:output_type -> notifpb.TerminateStreamResponse
+	36, // [36:52] is the sub-list for method output_type
+	20, // [20:36] is the sub-list for method input_type
+	20, // [20:20] is the sub-list for extension type_name
+	20, // [20:20] is the sub-list for extension extendee
+	0,  // [0:20] is the sub-list for field type_name
 }
 
 func init() { file_notifpb_notifpb_proto_init() }
@@ -185,13 +2526,14 @@ func file_notifpb_notifpb_proto_init() {
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_notifpb_notifpb_proto_rawDesc), len(file_notifpb_notifpb_proto_rawDesc)),
-			NumEnums:      0,
-			NumMessages:   2,
+			NumEnums:      3,
+			NumMessages:   39,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
 		GoTypes:           file_notifpb_notifpb_proto_goTypes,
 		DependencyIndexes: file_notifpb_notifpb_proto_depIdxs,
+		EnumInfos:         file_notifpb_notifpb_proto_enumTypes,
 		MessageInfos:      file_notifpb_notifpb_proto_msgTypes,
 	}.Build()
 	File_notifpb_notifpb_proto = out.File
@@ -19,7 +19,22 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	NotificationService_SubscribeToNotifications_FullMethodName = "/notifpb.NotificationService/SubscribeToNotifications"
+	NotificationService_SubscribeToNotifications_FullMethodName  = "/notifpb.NotificationService/SubscribeToNotifications"
+	NotificationService_MarkNotificationRead_FullMethodName      = "/notifpb.NotificationService/MarkNotificationRead"
+	NotificationService_Stats_FullMethodName                     = "/notifpb.NotificationService/Stats"
+	NotificationService_SetConsumptionPaused_FullMethodName      = "/notifpb.NotificationService/SetConsumptionPaused"
+	NotificationService_GetNotificationDeliveries_FullMethodName = "/notifpb.NotificationService/GetNotificationDeliveries"
+	NotificationService_ResendNotification_FullMethodName        = "/notifpb.NotificationService/ResendNotification"
+	NotificationService_SendTestNotifications_FullMethodName     = "/notifpb.NotificationService/SendTestNotifications"
+	NotificationService_GetNotificationHistory_FullMethodName    = "/notifpb.NotificationService/GetNotificationHistory"
+	NotificationService_ListTemplates_FullMethodName             = "/notifpb.NotificationService/ListTemplates"
+	NotificationService_PutTemplate_FullMethodName               = "/notifpb.NotificationService/PutTemplate"
+	NotificationService_DeleteTemplate_FullMethodName            = "/notifpb.NotificationService/DeleteTemplate"
+	NotificationService_StartBackfill_FullMethodName             = "/notifpb.NotificationService/StartBackfill"
+	NotificationService_GetBackfill_FullMethodName               = "/notifpb.NotificationService/GetBackfill"
+	NotificationService_ResumeBackfill_FullMethodName            = "/notifpb.NotificationService/ResumeBackfill"
+	NotificationService_ListStreams_FullMethodName               = "/notifpb.NotificationService/ListStreams"
+	NotificationService_TerminateStream_FullMethodName           = "/notifpb.NotificationService/TerminateStream"
 )
 
 // NotificationServiceClient is the client API for NotificationService service.
@@ -30,7 +45,49 @@ const (
 type NotificationServiceClient interface {
 	// A server-streaming RPC for a client to subscribe to notifications.
 	// The client sends its user_id, and the server streams notifications back.
-	SubscribeToNotifications(ctx context.Context, in *SubscribeRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Notification], error)
+	// Live notifications arrive one per batch; notifications stored while the
+	// user was offline are flushed in batches of up to batch_size.
+	SubscribeToNotifications(ctx context.Context, in *SubscribeRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[NotificationBatch], error)
+	// Marks a notification as read and tells the user's other streams about it.
+	MarkNotificationRead(ctx context.Context, in *MarkNotificationReadRequest, opts ...grpc.CallOption) (*MarkNotificationReadResponse, error)
+	// Returns aggregate counters for the admin dashboard.
+	Stats(ctx context.Context, in *StatsRequest, opts ...grpc.CallOption) (*StatsResponse, error)
+	// Pauses or resumes processing of consumed NATS events. Paused events are
+	// queued, not dropped.
+	SetConsumptionPaused(ctx context.Context, in *SetConsumptionPausedRequest, opts ...grpc.CallOption) (*SetConsumptionPausedResponse, error)
+	// Returns every recorded delivery attempt for a notification, oldest first.
+	GetNotificationDeliveries(ctx context.Context, in *GetNotificationDeliveriesRequest, opts ...grpc.CallOption) (*GetNotificationDeliveriesResponse, error)
+	// Re-broadcasts a persisted notification to the user's active streams
+	// without storing a new copy.
+	ResendNotification(ctx context.Context, in *ResendNotificationRequest, opts ...grpc.CallOption) (*ResendNotificationResponse, error)
+	// Sends the user one sample notification of every type and severity so
+	// clients can check how each is rendered. Disabled unless the service
+	// enables test notifications.
+	SendTestNotifications(ctx context.Context, in *SendTestNotificationsRequest, opts ...grpc.CallOption) (*SendTestNotificationsResponse, error)
+	// Returns a user's stored notifications, newest first, one page at a time.
+	GetNotificationHistory(ctx context.Context, in *HistoryRequest, opts ...grpc.CallOption) (*HistoryResponse, error)
+	// Lists the message templates stored in the database. Messages without a
+	// stored template use the defaults built into the service.
+	ListTemplates(ctx context.Context, in *ListTemplatesRequest, opts ...grpc.CallOption) (*ListTemplatesResponse, error)
+	// Creates or replaces the template for a message id and locale. Rendering
+	// uses it from the next notification on.
+	PutTemplate(ctx context.Context, in *PutTemplateRequest, opts ...grpc.CallOption) (*PutTemplateResponse, error)
+	// Deletes a stored template, reverting the message to the built-in default.
+	DeleteTemplate(ctx context.Context, in *DeleteTemplateRequest, opts ...grpc.CallOption) (*DeleteTemplateResponse, error)
+	// Starts a job that sends one notification to every user, page by page and
+	// rate limited. The job runs in the background; poll it with GetBackfill.
+	StartBackfill(ctx context.Context, in *StartBackfillRequest, opts ...grpc.CallOption) (*StartBackfillResponse, error)
+	// Returns a backfill job and its progress, or NOT_FOUND.
+	GetBackfill(ctx context.Context, in *GetBackfillRequest, opts ...grpc.CallOption) (*GetBackfillResponse, error)
+	// Restarts a failed backfill job from where it stopped. Users it already
+	// reached are not notified again.
+	ResumeBackfill(ctx context.Context, in *ResumeBackfillRequest, opts ...grpc.CallOption) (*ResumeBackfillResponse, error)
+	// Lists the streams open on this instance, ordered by user and session,
+	// one page at a time.
+	ListStreams(ctx context.Context, in *ListStreamsRequest, opts ...grpc.CallOption) (*ListStreamsResponse, error)
+	// Closes the streams of a session. The client sees the stream end with
+	// ABORTED and may reconnect.
+	TerminateStream(ctx context.Context, in *TerminateStreamRequest, opts ...grpc.CallOption) (*TerminateStreamResponse, error)
 }
 
 type notificationServiceClient struct {
@@ -41,13 +98,13 @@ func NewNotificationServiceClient(cc grpc.ClientConnInterface) NotificationServi
 	return &notificationServiceClient{cc}
 }
 
-func (c *notificationServiceClient) SubscribeToNotifications(ctx context.Context, in *SubscribeRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Notification], error) {
+func (c *notificationServiceClient) SubscribeToNotifications(ctx context.Context, in *SubscribeRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[NotificationBatch], error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	stream, err := c.cc.NewStream(ctx, &NotificationService_ServiceDesc.Streams[0], NotificationService_SubscribeToNotifications_FullMethodName, cOpts...)
 	if err != nil {
 		return nil, err
 	}
-	x := &grpc.GenericClientStream[SubscribeRequest, Notification]{ClientStream: stream}
+	x := &grpc.GenericClientStream[SubscribeRequest, NotificationBatch]{ClientStream: stream}
 	if err := x.ClientStream.SendMsg(in); err != nil {
 		return nil, err
 	}
@@ -58,7 +115,157 @@ func (c *notificationServiceClient) SubscribeToNotifications(ctx context.Context
 }
 
 // This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
-type NotificationService_SubscribeToNotificationsClient = grpc.ServerStreamingClient[Notification]
+type NotificationService_SubscribeToNotificationsClient = grpc.ServerStreamingClient[NotificationBatch]
+
+func (c *notificationServiceClient) MarkNotificationRead(ctx context.Context, in *MarkNotificationReadRequest, opts ...grpc.CallOption) (*MarkNotificationReadResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(MarkNotificationReadResponse)
+	err := c.cc.Invoke(ctx, NotificationService_MarkNotificationRead_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *notificationServiceClient) Stats(ctx context.Context, in *StatsRequest, opts ...grpc.CallOption) (*StatsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(StatsResponse)
+	err := c.cc.Invoke(ctx, NotificationService_Stats_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *notificationServiceClient) SetConsumptionPaused(ctx context.Context, in *SetConsumptionPausedRequest, opts ...grpc.CallOption) (*SetConsumptionPausedResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SetConsumptionPausedResponse)
+	err := c.cc.Invoke(ctx, NotificationService_SetConsumptionPaused_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *notificationServiceClient) GetNotificationDeliveries(ctx context.Context, in *GetNotificationDeliveriesRequest, opts ...grpc.CallOption) (*GetNotificationDeliveriesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetNotificationDeliveriesResponse)
+	err := c.cc.Invoke(ctx, NotificationService_GetNotificationDeliveries_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *notificationServiceClient) ResendNotification(ctx context.Context, in *ResendNotificationRequest, opts ...grpc.CallOption) (*ResendNotificationResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ResendNotificationResponse)
+	err := c.cc.Invoke(ctx, NotificationService_ResendNotification_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *notificationServiceClient) SendTestNotifications(ctx context.Context, in *SendTestNotificationsRequest, opts ...grpc.CallOption) (*SendTestNotificationsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SendTestNotificationsResponse)
+	err := c.cc.Invoke(ctx, NotificationService_SendTestNotifications_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *notificationServiceClient) GetNotificationHistory(ctx context.Context, in *HistoryRequest, opts ...grpc.CallOption) (*HistoryResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(HistoryResponse)
+	err := c.cc.Invoke(ctx, NotificationService_GetNotificationHistory_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *notificationServiceClient) ListTemplates(ctx context.Context, in *ListTemplatesRequest, opts ...grpc.CallOption) (*ListTemplatesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListTemplatesResponse)
+	err := c.cc.Invoke(ctx, NotificationService_ListTemplates_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *notificationServiceClient) PutTemplate(ctx context.Context, in *PutTemplateRequest, opts ...grpc.CallOption) (*PutTemplateResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(PutTemplateResponse)
+	err := c.cc.Invoke(ctx, NotificationService_PutTemplate_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *notificationServiceClient) DeleteTemplate(ctx context.Context, in *DeleteTemplateRequest, opts ...grpc.CallOption) (*DeleteTemplateResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DeleteTemplateResponse)
+	err := c.cc.Invoke(ctx, NotificationService_DeleteTemplate_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *notificationServiceClient) StartBackfill(ctx context.Context, in *StartBackfillRequest, opts ...grpc.CallOption) (*StartBackfillResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(StartBackfillResponse)
+	err := c.cc.Invoke(ctx, NotificationService_StartBackfill_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *notificationServiceClient) GetBackfill(ctx context.Context, in *GetBackfillRequest, opts ...grpc.CallOption) (*GetBackfillResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetBackfillResponse)
+	err := c.cc.Invoke(ctx, NotificationService_GetBackfill_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *notificationServiceClient) ResumeBackfill(ctx context.Context, in *ResumeBackfillRequest, opts ...grpc.CallOption) (*ResumeBackfillResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ResumeBackfillResponse)
+	err := c.cc.Invoke(ctx, NotificationService_ResumeBackfill_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *notificationServiceClient) ListStreams(ctx context.Context, in *ListStreamsRequest, opts ...grpc.CallOption) (*ListStreamsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListStreamsResponse)
+	err := c.cc.Invoke(ctx, NotificationService_ListStreams_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *notificationServiceClient) TerminateStream(ctx context.Context, in *TerminateStreamRequest, opts ...grpc.CallOption) (*TerminateStreamResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(TerminateStreamResponse)
+	err := c.cc.Invoke(ctx, NotificationService_TerminateStream_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
 
 // NotificationServiceServer is the server API for NotificationService service.
 // All implementations must embed UnimplementedNotificationServiceServer
@@ -68,7 +275,49 @@ type NotificationService_SubscribeToNotificationsClient = grpc.ServerStreamingCl
 type NotificationServiceServer interface {
 	// A server-streaming RPC for a client to subscribe to notifications.
 	// The client sends its user_id, and the server streams notifications back.
-	SubscribeToNotifications(*SubscribeRequest, grpc.ServerStreamingServer[Notification]) error
+	// Live notifications arrive one per batch; notifications stored while the
+	// user was offline are flushed in batches of up to batch_size.
+	SubscribeToNotifications(*SubscribeRequest, grpc.ServerStreamingServer[NotificationBatch]) error
+	// Marks a notification as read and tells the user's other streams about it.
+	MarkNotificationRead(context.Context, *MarkNotificationReadRequest) (*MarkNotificationReadResponse, error)
+	// Returns aggregate counters for the admin dashboard.
+	Stats(context.Context, *StatsRequest) (*StatsResponse, error)
+	// Pauses or resumes processing of consumed NATS events. Paused events are
+	// queued, not dropped.
+	SetConsumptionPaused(context.Context, *SetConsumptionPausedRequest) (*SetConsumptionPausedResponse, error)
+	// Returns every recorded delivery attempt for a notification, oldest first.
+	GetNotificationDeliveries(context.Context, *GetNotificationDeliveriesRequest) (*GetNotificationDeliveriesResponse, error)
+	// Re-broadcasts a persisted notification to the user's active streams
+	// without storing a new copy.
+	ResendNotification(context.Context, *ResendNotificationRequest) (*ResendNotificationResponse, error)
+	// Sends the user one sample notification of every type and severity so
+	// clients can check how each is rendered. Disabled unless the service
+	// enables test notifications.
+	SendTestNotifications(context.Context, *SendTestNotificationsRequest) (*SendTestNotificationsResponse, error)
+	// Returns a user's stored notifications, newest first, one page at a time.
+	GetNotificationHistory(context.Context, *HistoryRequest) (*HistoryResponse, error)
+	// Lists the message templates stored in the database. Messages without a
+	// stored template use the defaults built into the service.
+	ListTemplates(context.Context, *ListTemplatesRequest) (*ListTemplatesResponse, error)
+	// Creates or replaces the template for a message id and locale. Rendering
+	// uses it from the next notification on.
+	PutTemplate(context.Context, *PutTemplateRequest) (*PutTemplateResponse, error)
+	// Deletes a stored template, reverting the message to the built-in default.
+	DeleteTemplate(context.Context, *DeleteTemplateRequest) (*DeleteTemplateResponse, error)
+	// Starts a job that sends one notification to every user, page by page and
+	// rate limited. The job runs in the background; poll it with GetBackfill.
+	StartBackfill(context.Context, *StartBackfillRequest) (*StartBackfillResponse, error)
+	// Returns a backfill job and its progress, or NOT_FOUND.
+	GetBackfill(context.Context, *GetBackfillRequest) (*GetBackfillResponse, error)
+	// Restarts a failed backfill job from where it stopped. Users it already
+	// reached are not notified again.
+	ResumeBackfill(context.Context, *ResumeBackfillRequest) (*ResumeBackfillResponse, error)
+	// Lists the streams open on this instance, ordered by user and session,
+	// one page at a time.
+	ListStreams(context.Context, *ListStreamsRequest) (*ListStreamsResponse, error)
+	// Closes the streams of a session. The client sees the stream end with
+	// ABORTED and may reconnect.
+	TerminateStream(context.Context, *TerminateStreamRequest) (*TerminateStreamResponse, error)
 	mustEmbedUnimplementedNotificationServiceServer()
 }
 
@@ -79,9 +328,54 @@ type NotificationServiceServer interface {
 // pointer dereference when methods are called.
 type UnimplementedNotificationServiceServer struct{}
 
-func (UnimplementedNotificationServiceServer) SubscribeToNotifications(*SubscribeRequest, grpc.ServerStreamingServer[Notification]) error {
+func (UnimplementedNotificationServiceServer) SubscribeToNotifications(*SubscribeRequest, grpc.ServerStreamingServer[NotificationBatch]) error {
 	return status.Errorf(codes.Unimplemented, "method SubscribeToNotifications not implemented")
 }
+func (UnimplementedNotificationServiceServer) MarkNotificationRead(context.Context, *MarkNotificationReadRequest) (*MarkNotificationReadResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method MarkNotificationRead not implemented")
+}
+func (UnimplementedNotificationServiceServer) Stats(context.Context, *StatsRequest) (*StatsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Stats not implemented")
+}
+func (UnimplementedNotificationServiceServer) SetConsumptionPaused(context.Context, *SetConsumptionPausedRequest) (*SetConsumptionPausedResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetConsumptionPaused not implemented")
+}
+func (UnimplementedNotificationServiceServer) GetNotificationDeliveries(context.Context, *GetNotificationDeliveriesRequest) (*GetNotificationDeliveriesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetNotificationDeliveries not implemented")
+}
+func (UnimplementedNotificationServiceServer) ResendNotification(context.Context, *ResendNotificationRequest) (*ResendNotificationResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ResendNotification not implemented")
+}
+func (UnimplementedNotificationServiceServer) SendTestNotifications(context.Context, *SendTestNotificationsRequest) (*SendTestNotificationsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SendTestNotifications not implemented")
+}
+func (UnimplementedNotificationServiceServer) GetNotificationHistory(context.Context, *HistoryRequest) (*HistoryResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetNotificationHistory not implemented")
+}
+func (UnimplementedNotificationServiceServer) ListTemplates(context.Context, *ListTemplatesRequest) (*ListTemplatesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListTemplates not implemented")
+}
+func (UnimplementedNotificationServiceServer) PutTemplate(context.Context, *PutTemplateRequest) (*PutTemplateResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PutTemplate not implemented")
+}
+func (UnimplementedNotificationServiceServer) DeleteTemplate(context.Context, *DeleteTemplateRequest) (*DeleteTemplateResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteTemplate not implemented")
+}
+func (UnimplementedNotificationServiceServer) StartBackfill(context.Context, *StartBackfillRequest) (*StartBackfillResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method StartBackfill not implemented")
+}
+func (UnimplementedNotificationServiceServer) GetBackfill(context.Context, *GetBackfillRequest) (*GetBackfillResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetBackfill not implemented")
+}
+func (UnimplementedNotificationServiceServer) ResumeBackfill(context.Context, *ResumeBackfillRequest) (*ResumeBackfillResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ResumeBackfill not implemented")
+}
+func (UnimplementedNotificationServiceServer) ListStreams(context.Context, *ListStreamsRequest) (*ListStreamsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListStreams not implemented")
+}
+func (UnimplementedNotificationServiceServer) TerminateStream(context.Context, *TerminateStreamRequest) (*TerminateStreamResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method TerminateStream not implemented")
+}
 func (UnimplementedNotificationServiceServer) mustEmbedUnimplementedNotificationServiceServer() {}
 func (UnimplementedNotificationServiceServer) testEmbeddedByValue()                             {}
 
@@ -108,11 +402,281 @@ func _NotificationService_SubscribeToNotifications_Handler(srv interface{}, stre
 	if err := stream.RecvMsg(m); err != nil {
 		return err
 	}
-	return srv.(NotificationServiceServer).SubscribeToNotifications(m, &grpc.GenericServerStream[SubscribeRequest, Notification]{ServerStream: stream})
+	return srv.(NotificationServiceServer).SubscribeToNotifications(m, &grpc.GenericServerStream[SubscribeRequest, NotificationBatch]{ServerStream: stream})
 }
 
 // This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
-type NotificationService_SubscribeToNotificationsServer = grpc.ServerStreamingServer[Notification]
+type NotificationService_SubscribeToNotificationsServer = grpc.ServerStreamingServer[NotificationBatch]
+
+func _NotificationService_MarkNotificationRead_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MarkNotificationReadRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NotificationServiceServer).MarkNotificationRead(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: NotificationService_MarkNotificationRead_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NotificationServiceServer).MarkNotificationRead(ctx, req.(*MarkNotificationReadRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _NotificationService_Stats_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(StatsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NotificationServiceServer).Stats(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: NotificationService_Stats_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NotificationServiceServer).Stats(ctx, req.(*StatsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _NotificationService_SetConsumptionPaused_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetConsumptionPausedRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NotificationServiceServer).SetConsumptionPaused(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: NotificationService_SetConsumptionPaused_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NotificationServiceServer).SetConsumptionPaused(ctx, req.(*SetConsumptionPausedRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _NotificationService_GetNotificationDeliveries_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetNotificationDeliveriesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NotificationServiceServer).GetNotificationDeliveries(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: NotificationService_GetNotificationDeliveries_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NotificationServiceServer).GetNotificationDeliveries(ctx, req.(*GetNotificationDeliveriesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _NotificationService_ResendNotification_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ResendNotificationRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NotificationServiceServer).ResendNotification(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: NotificationService_ResendNotification_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NotificationServiceServer).ResendNotification(ctx, req.(*ResendNotificationRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _NotificationService_SendTestNotifications_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SendTestNotificationsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NotificationServiceServer).SendTestNotifications(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: NotificationService_SendTestNotifications_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NotificationServiceServer).SendTestNotifications(ctx, req.(*SendTestNotificationsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _NotificationService_GetNotificationHistory_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(HistoryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NotificationServiceServer).GetNotificationHistory(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: NotificationService_GetNotificationHistory_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NotificationServiceServer).GetNotificationHistory(ctx, req.(*HistoryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _NotificationService_ListTemplates_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListTemplatesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NotificationServiceServer).ListTemplates(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: NotificationService_ListTemplates_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NotificationServiceServer).ListTemplates(ctx, req.(*ListTemplatesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _NotificationService_PutTemplate_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PutTemplateRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NotificationServiceServer).PutTemplate(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: NotificationService_PutTemplate_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NotificationServiceServer).PutTemplate(ctx, req.(*PutTemplateRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _NotificationService_DeleteTemplate_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteTemplateRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NotificationServiceServer).DeleteTemplate(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: NotificationService_DeleteTemplate_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NotificationServiceServer).DeleteTemplate(ctx, req.(*DeleteTemplateRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _NotificationService_StartBackfill_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(StartBackfillRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NotificationServiceServer).StartBackfill(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: NotificationService_StartBackfill_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NotificationServiceServer).StartBackfill(ctx, req.(*StartBackfillRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _NotificationService_GetBackfill_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetBackfillRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NotificationServiceServer).GetBackfill(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: NotificationService_GetBackfill_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NotificationServiceServer).GetBackfill(ctx, req.(*GetBackfillRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _NotificationService_ResumeBackfill_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ResumeBackfillRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NotificationServiceServer).ResumeBackfill(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: NotificationService_ResumeBackfill_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NotificationServiceServer).ResumeBackfill(ctx, req.(*ResumeBackfillRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _NotificationService_ListStreams_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListStreamsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NotificationServiceServer).ListStreams(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: NotificationService_ListStreams_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NotificationServiceServer).ListStreams(ctx, req.(*ListStreamsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _NotificationService_TerminateStream_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TerminateStreamRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NotificationServiceServer).TerminateStream(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: NotificationService_TerminateStream_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NotificationServiceServer).TerminateStream(ctx, req.(*TerminateStreamRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
 
 // NotificationService_ServiceDesc is the grpc.ServiceDesc for NotificationService service.
 // It's only intended for direct use with grpc.RegisterService,
@@ -120,7 +684,68 @@ type NotificationService_SubscribeToNotificationsServer = grpc.ServerStreamingSe
 var NotificationService_ServiceDesc = grpc.ServiceDesc{
 	ServiceName: "notifpb.NotificationService",
 	HandlerType: (*NotificationServiceServer)(nil),
-	Methods:     []grpc.MethodDesc{},
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "MarkNotificationRead",
+			Handler:    _NotificationService_MarkNotificationRead_Handler,
+		},
+		{
+			MethodName: "Stats",
+			Handler:    _NotificationService_Stats_Handler,
+		},
+		{
+			MethodName: "SetConsumptionPaused",
+			Handler:    _NotificationService_SetConsumptionPaused_Handler,
+		},
+		{
+			MethodName: "GetNotificationDeliveries",
+			Handler:    _NotificationService_GetNotificationDeliveries_Handler,
+		},
+		{
+			MethodName: "ResendNotification",
+			Handler:    _NotificationService_ResendNotification_Handler,
+		},
+		{
+			MethodName: "SendTestNotifications",
+			Handler:    _NotificationService_SendTestNotifications_Handler,
+		},
+		{
+			MethodName: "GetNotificationHistory",
+			Handler:    _NotificationService_GetNotificationHistory_Handler,
+		},
+		{
+			MethodName: "ListTemplates",
+			Handler:    _NotificationService_ListTemplates_Handler,
+		},
+		{
+			MethodName: "PutTemplate",
+			Handler:    _NotificationService_PutTemplate_Handler,
+		},
+		{
+			MethodName: "DeleteTemplate",
+			Handler:    _NotificationService_DeleteTemplate_Handler,
+		},
+		{
+			MethodName: "StartBackfill",
+			Handler:    _NotificationService_StartBackfill_Handler,
+		},
+		{
+			MethodName: "GetBackfill",
+			Handler:    _NotificationService_GetBackfill_Handler,
+		},
+		{
+			MethodName: "ResumeBackfill",
+			Handler:    _NotificationService_ResumeBackfill_Handler,
+		},
+		{
+			MethodName: "ListStreams",
+			Handler:    _NotificationService_ListStreams_Handler,
+		},
+		{
+			MethodName: "TerminateStream",
+			Handler:    _NotificationService_TerminateStream_Handler,
+		},
+	},
 	Streams: []grpc.StreamDesc{
 		{
 			StreamName:    "SubscribeToNotifications",
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// originAllowed reports whether a WebSocket upgrade may proceed for r's
+// Origin header. Requests without an Origin come from non-browser clients and
+// cannot be cross-site; same-origin requests are always allowed. Any other
+// origin must be listed in cfg.allowedOrigins, unless cfg.allowAllOrigins is
+// set for local development.
+func (s *apiServer) originAllowed(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" || s.cfg.allowAllOrigins {
+		return true
+	}
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	if strings.EqualFold(u.Host, r.Host) {
+		return true
+	}
+	origin = strings.ToLower(u.Scheme + "://" + u.Host)
+	for _, allowed := range s.cfg.allowedOrigins {
+		if origin == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+// loadAllowedOrigins reads ALLOWED_ORIGINS, a comma-separated list of origins
+// such as "https://app.example.com,http://localhost:3000". Origins are
+// compared by scheme and host, ignoring case and any trailing slash.
+func loadAllowedOrigins() []string {
+	var origins []string
+	for _, origin := range strings.Split(getEnv("ALLOWED_ORIGINS", ""), ",") {
+		origin = strings.ToLower(strings.TrimRight(strings.TrimSpace(origin), "/"))
+		if origin != "" {
+			origins = append(origins, origin)
+		}
+	}
+	return origins
+}
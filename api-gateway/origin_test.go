@@ -0,0 +1,87 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+)
+
+func TestOriginAllowed(t *testing.T) {
+	tests := []struct {
+		name     string
+		origin   string
+		allowAll bool
+		want     bool
+	}{
+		{"no origin", "", false, true},
+		{"same origin", "http://gateway.example:8080", false, true},
+		{"listed origin", "https://app.example.com", false, true},
+		{"listed origin in another case with a trailing slash", "HTTPS://App.Example.com/", false, true},
+		{"listed host over another scheme", "http://app.example.com", false, false},
+		{"unlisted origin", "https://evil.example", false, false},
+		{"unparseable origin", "://", false, false},
+		{"any origin in development", "https://evil.example", true, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testConfig()
+			cfg.allowedOrigins = []string{"https://app.example.com"}
+			cfg.allowAllOrigins = tt.allowAll
+			s := newTestServer(t, cfg, nil, nil, nil)
+
+			r := httptest.NewRequest(http.MethodGet, "http://gateway.example:8080/ws", nil)
+			if tt.origin != "" {
+				r.Header.Set("Origin", tt.origin)
+			}
+			if got := s.originAllowed(r); got != tt.want {
+				t.Errorf("originAllowed(%q) = %v, want %v", tt.origin, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWebSocketFromUnlistedOrigin(t *testing.T) {
+	cfg := testConfig()
+	cfg.allowedOrigins = []string{"https://app.example.com"}
+	s := newTestServer(t, cfg, nil, nil, nil)
+
+	r := httptest.NewRequest(http.MethodGet, "/ws?token="+tokenFor(aliceID), nil)
+	r.Header.Set("Origin", "https://evil.example")
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, r)
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("status %d, want 403: %s", w.Code, w.Body)
+	}
+	if got := decodeBody(t, w)["error"]; got != "origin not allowed" {
+		t.Errorf("error %q, want \"origin not allowed\"", got)
+	}
+}
+
+func TestLoadAllowedOrigins(t *testing.T) {
+	tests := []struct {
+		env  string
+		want []string
+	}{
+		{"", nil},
+		{"https://app.example.com", []string{"https://app.example.com"}},
+		{" HTTPS://App.Example.com/ , http://localhost:3000,, ", []string{"https://app.example.com", "http://localhost:3000"}},
+	}
+	for _, tt := range tests {
+		t.Setenv("ALLOWED_ORIGINS", tt.env)
+		if got := loadAllowedOrigins(); !slices.Equal(got, tt.want) {
+			t.Errorf("ALLOWED_ORIGINS=%q: got %q, want %q", tt.env, got, tt.want)
+		}
+	}
+}
+
+func TestAllowAllOriginsIsOffByDefault(t *testing.T) {
+	t.Setenv("WS_ALLOW_ALL_ORIGINS", "")
+	if loadConfig().allowAllOrigins {
+		t.Error("every origin allowed without WS_ALLOW_ALL_ORIGINS")
+	}
+	t.Setenv("WS_ALLOW_ALL_ORIGINS", "true")
+	if !loadConfig().allowAllOrigins {
+		t.Error("WS_ALLOW_ALL_ORIGINS=true not applied")
+	}
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// rateLimitSweepInterval is how often idle buckets are evicted.
+const rateLimitSweepInterval = time.Minute
+
+// ipBucket is one client's token bucket and when it was last used.
+type ipBucket struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+// ipRateLimiter keeps a token bucket per client IP. Buckets unused for
+// idleTTL are evicted so the map does not grow with every client ever seen.
+// A zero limit disables it.
+type ipRateLimiter struct {
+	idleTTL time.Duration
+	clock   Clock
+
+	mu      sync.Mutex
+	limit   rate.Limit
+	burst   int
+	buckets map[string]*ipBucket
+}
+
+func newIPRateLimiter(limit rate.Limit, burst int, idleTTL time.Duration, clock Clock) *ipRateLimiter {
+	return &ipRateLimiter{limit: limit, burst: burst, idleTTL: idleTTL, clock: clock, buckets: make(map[string]*ipBucket)}
+}
+
+// setLimit changes the rate and burst of every bucket, existing ones
+// included, so a client keeps the tokens it has left.
+func (l *ipRateLimiter) setLimit(limit rate.Limit, burst int) {
+	now := l.clock.Now()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.limit, l.burst = limit, burst
+	if limit == 0 {
+		clear(l.buckets)
+		return
+	}
+	for _, b := range l.buckets {
+		b.limiter.SetLimitAt(now, limit)
+		b.limiter.SetBurstAt(now, burst)
+	}
+}
+
+// reserve takes a token from ip's bucket. It returns zero when the request
+// may proceed, or how long the client has to wait for the next token.
+func (l *ipRateLimiter) reserve(ip string) time.Duration {
+	now := l.clock.Now()
+	l.mu.Lock()
+	if l.limit == 0 {
+		l.mu.Unlock()
+		return 0
+	}
+	b, ok := l.buckets[ip]
+	if !ok {
+		b = &ipBucket{limiter: rate.NewLimiter(l.limit, l.burst)}
+		l.buckets[ip] = b
+	}
+	b.lastSeen = now
+	l.mu.Unlock()
+
+	res := b.limiter.ReserveN(now, 1)
+	if !res.OK() {
+		return time.Duration(math.MaxInt64)
+	}
+	delay := res.DelayFrom(now)
+	if delay > 0 {
+		// A rejected request must not use up a future token.
+		res.CancelAt(now)
+	}
+	return delay
+}
+
+// evictIdle drops buckets that have not been used for idleTTL. An idle bucket
+// has refilled completely, so dropping it does not change any client's limit.
+func (l *ipRateLimiter) evictIdle() {
+	cutoff := l.clock.Now().Add(-l.idleTTL)
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for ip, b := range l.buckets {
+		if b.lastSeen.Before(cutoff) {
+			delete(l.buckets, ip)
+		}
+	}
+}
+
+// rateLimits holds the limiter of each tier.
+type rateLimits struct {
+	auth    *ipRateLimiter
+	general *ipRateLimiter
+}
+
+// newRateLimits builds the limiters from cfg and starts evicting their idle
+// buckets.
+func newRateLimits(cfg config, clock Clock) *rateLimits {
+	rl := &rateLimits{
+		auth:    newIPRateLimiter(0, 0, cfg.rateLimitIdleTTL, clock),
+		general: newIPRateLimiter(0, 0, cfg.rateLimitIdleTTL, clock),
+	}
+	rl.apply(cfg)
+	go func() {
+		for range time.Tick(rateLimitSweepInterval) {
+			rl.auth.evictIdle()
+			rl.general.evictIdle()
+		}
+	}()
+	return rl
+}
+
+// apply sets the limits from cfg. Login and registration share the strict
+// auth tier of cfg.rateLimitAuthPerMinute requests per minute, with bursts
+// of the same size; every other route gets cfg.rateLimitPerSecond with
+// bursts of cfg.rateLimitBurst. A zero rate disables its tier.
+func (rl *rateLimits) apply(cfg config) {
+	if n := cfg.rateLimitAuthPerMinute; n > 0 {
+		rl.auth.setLimit(rate.Limit(float64(n)/60), n)
+	} else {
+		rl.auth.setLimit(0, 0)
+	}
+	if cfg.rateLimitPerSecond > 0 {
+		rl.general.setLimit(rate.Limit(cfg.rateLimitPerSecond), max(cfg.rateLimitBurst, 1))
+	} else {
+		rl.general.setLimit(0, 0)
+	}
+}
+
+// limiterFor returns the limiter that applies to r, or nil for routes that
+// are never limited.
+func (rl *rateLimits) limiterFor(r *http.Request) *ipRateLimiter {
+	switch {
+	case r.Method == http.MethodPost && (r.URL.Path == "/login" || r.URL.Path == "/register"):
+		return rl.auth
+	case r.URL.Path == "/healthz", r.URL.Path == "/readyz", r.URL.Path == "/metrics":
+		// Probes and scrapes come from a few addresses at a steady rate.
+		return nil
+	}
+	return rl.general
+}
+
+// clientIP returns the host part of r.RemoteAddr.
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// rateLimitMiddleware answers requests over their client IP's limit with 429
+// and a Retry-After header saying when the next request will be allowed.
+func (s *apiServer) rateLimitMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		limiter := s.rateLimits.limiterFor(r)
+		if limiter == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if wait := limiter.reserve(clientIP(r)); wait > 0 {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			s.requestLogger(r.Context()).Warn("rate limit exceeded", "client_ip", clientIP(r), "retry_after", wait)
+			s.writeJSONError(w, http.StatusTooManyRequests, "too many requests, please retry later")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"api-gateway/userpb"
+)
+
+// stoppedClock is a Clock whose time only moves when the test advances it.
+type stoppedClock struct {
+	realClock
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *stoppedClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *stoppedClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	c.now = c.now.Add(d)
+	c.mu.Unlock()
+}
+
+// rateLimited returns s behind rateLimitMiddleware with the limits of cfg,
+// measured against clock.
+func rateLimited(s *apiServer, cfg config, clock Clock) http.Handler {
+	s.rateLimits = &rateLimits{
+		auth:    newIPRateLimiter(0, 0, cfg.rateLimitIdleTTL, clock),
+		general: newIPRateLimiter(0, 0, cfg.rateLimitIdleTTL, clock),
+	}
+	s.rateLimits.apply(cfg)
+	return s.rateLimitMiddleware(s)
+}
+
+// serveFrom is serve for a request from the client at ip.
+func serveFrom(h http.Handler, ip, method, target, body string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, target, strings.NewReader(body))
+	r.RemoteAddr = ip + ":51234"
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w
+}
+
+func TestLoginRateLimitedPerIP(t *testing.T) {
+	user := &fakeUserClient{login: func(*userpb.LoginRequest) (*userpb.LoginResponse, error) {
+		return &userpb.LoginResponse{Token: "t"}, nil
+	}}
+	cfg := testConfig()
+	cfg.rateLimitAuthPerMinute = 5
+	clock := &stoppedClock{now: time.Now()}
+	h := rateLimited(newTestServer(t, cfg, user, nil, nil), cfg, clock)
+	body := `{"email":"alice@example.com","password":"correct horse"}`
+
+	for i := range 5 {
+		if w := serveFrom(h, "203.0.113.7", http.MethodPost, "/login", body); w.Code == http.StatusTooManyRequests {
+			t.Fatalf("attempt %d rejected", i+1)
+		}
+	}
+	w := serveFrom(h, "203.0.113.7", http.MethodPost, "/login", body)
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("6th attempt: status %d, want 429", w.Code)
+	}
+	// One token comes back every 12 seconds.
+	if got := w.Header().Get("Retry-After"); got != "12" {
+		t.Errorf("Retry-After %q, want 12", got)
+	}
+
+	// Other clients and other routes have their own buckets.
+	if w := serveFrom(h, "198.51.100.2", http.MethodPost, "/login", body); w.Code == http.StatusTooManyRequests {
+		t.Error("another IP was rejected")
+	}
+	if w := serveFrom(h, "203.0.113.7", http.MethodGet, "/healthz", ""); w.Code == http.StatusTooManyRequests {
+		t.Error("/healthz was rate limited")
+	}
+
+	// Rejected attempts do not push the next token further out.
+	serveFrom(h, "203.0.113.7", http.MethodPost, "/login", body)
+	clock.Advance(12 * time.Second)
+	if w := serveFrom(h, "203.0.113.7", http.MethodPost, "/login", body); w.Code == http.StatusTooManyRequests {
+		t.Errorf("after Retry-After: status %d, body %s", w.Code, w.Body)
+	}
+}
+
+func TestGeneralRateLimit(t *testing.T) {
+	cfg := testConfig()
+	cfg.rateLimitPerSecond, cfg.rateLimitBurst = 1, 3
+	clock := &stoppedClock{now: time.Now()}
+	h := rateLimited(newTestServer(t, cfg, nil, nil, nil), cfg, clock)
+
+	for i := range 3 {
+		if w := serveFrom(h, "203.0.113.7", http.MethodGet, "/no-such-route", ""); w.Code == http.StatusTooManyRequests {
+			t.Fatalf("request %d within the burst rejected", i+1)
+		}
+	}
+	w := serveFrom(h, "203.0.113.7", http.MethodGet, "/no-such-route", "")
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("over the burst: status %d, want 429", w.Code)
+	}
+	if n, _ := strconv.Atoi(w.Header().Get("Retry-After")); n != 1 {
+		t.Errorf("Retry-After %q, want 1", w.Header().Get("Retry-After"))
+	}
+}
+
+func TestRateLimiterEvictsIdleBuckets(t *testing.T) {
+	clock := &stoppedClock{now: time.Now()}
+	l := newIPRateLimiter(1, 1, time.Minute, clock)
+	l.reserve("203.0.113.7")
+	clock.Advance(30 * time.Second)
+	l.reserve("198.51.100.2")
+
+	clock.Advance(45 * time.Second)
+	l.evictIdle()
+	if _, ok := l.buckets["203.0.113.7"]; ok {
+		t.Error("bucket idle for 75s was kept")
+	}
+	if _, ok := l.buckets["198.51.100.2"]; !ok {
+		t.Error("bucket used 45s ago was evicted")
+	}
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"sync"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+// backendConn is the part of *grpc.ClientConn readiness needs: the connection
+// state, and calls to the backend's gRPC health service.
+type backendConn interface {
+	grpc.ClientConnInterface
+	GetState() connectivity.State
+	Connect()
+}
+
+// handleHealthz is the liveness probe: it answers 200 as long as the process
+// can serve HTTP, without touching the backends.
+func (s *apiServer) handleHealthz() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		s.writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+	}
+}
+
+// eventsHealthService is the health check name under which billing-ms and
+// notification-ms report event consumption; it is NOT_SERVING while paused.
+const eventsHealthService = "events"
+
+// handleReadyz is the readiness probe. It runs the gRPC health check of every
+// backend in parallel, each bounded by cfg.readyzTimeout, and reports 200 when
+// all of them are SERVING and 503 otherwise. The body has each backend's
+// status, the names of those that are down and of those whose event
+// consumption an operator has paused. A paused backend still serves requests,
+// so it does not make the gateway unready. The gateway itself starts
+// regardless of backend availability; this is where an unavailable backend
+// shows up.
+func (s *apiServer) handleReadyz() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var (
+			mu       sync.Mutex
+			wg       sync.WaitGroup
+			statuses = make(map[string]string, len(s.backends))
+			down     = []string{}
+			paused   = []string{}
+		)
+		for name, conn := range s.backends {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				state := s.checkBackend(r.Context(), conn, "")
+				consumption := s.checkBackend(r.Context(), conn, eventsHealthService)
+				mu.Lock()
+				defer mu.Unlock()
+				statuses[name] = state
+				if state != healthpb.HealthCheckResponse_SERVING.String() {
+					down = append(down, name)
+				}
+				// Backends without event consumption answer NotFound.
+				if consumption == healthpb.HealthCheckResponse_NOT_SERVING.String() {
+					paused = append(paused, name)
+				}
+			}()
+		}
+		wg.Wait()
+		sort.Strings(down)
+		sort.Strings(paused)
+
+		status, code := "ready", http.StatusOK
+		if len(down) > 0 {
+			status, code = "not ready", http.StatusServiceUnavailable
+		}
+		s.writeJSON(w, code, map[string]any{"status": status, "backends": statuses, "down": down, "consumption_paused": paused})
+	}
+}
+
+// checkBackend returns the serving status of service on conn's backend, with
+// "" for the backend as a whole, or the gRPC code of the failed health check,
+// e.g. "DeadlineExceeded" or "Unavailable".
+func (s *apiServer) checkBackend(ctx context.Context, conn backendConn, service string) string {
+	if s.cfg.readyzTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.cfg.readyzTimeout)
+		defer cancel()
+	}
+	res, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{Service: service})
+	if err != nil {
+		return status.Code(err).String()
+	}
+	return res.Status.String()
+}
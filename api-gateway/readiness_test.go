@@ -0,0 +1,67 @@
+package main
+
+import (
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+
+	"api-gateway/billingpb"
+	"api-gateway/notifpb"
+	"api-gateway/userpb"
+)
+
+// deadAddr returns a local address nothing listens on.
+func deadAddr(t *testing.T) string {
+	t.Helper()
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := lis.Addr().String()
+	lis.Close()
+	return addr
+}
+
+func TestGatewayStartsWithBackendDown(t *testing.T) {
+	// Built the way main does: creating the client succeeds without the
+	// backend being up.
+	billingConn, err := grpc.NewClient(deadAddr(t), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("client for an unavailable backend: %v", err)
+	}
+	defer billingConn.Close()
+	billingConn.Connect()
+
+	userConn := healthBackend(t, health.NewServer())
+	notifConn := healthBackend(t, health.NewServer())
+	cfg := testConfig()
+	cfg.readyzTimeout = time.Second
+	s := newAPIServer(userpb.NewUserServiceClient(userConn), billingpb.NewBillingServiceClient(billingConn), notifpb.NewNotificationServiceClient(notifConn),
+		map[string]backendConn{"user": userConn, "billing": billingConn, "notification": notifConn},
+		cfg, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	if w := serve(s, http.MethodGet, "/healthz", "", ""); w.Code != http.StatusOK {
+		t.Fatalf("healthz: status %d", w.Code)
+	}
+	w := serve(s, http.MethodGet, "/readyz", "", "")
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("readyz: status %d, body %s; want 503", w.Code, w.Body)
+	}
+	body := decodeBody(t, w)
+	down, _ := body["down"].([]any)
+	if len(down) != 1 || down[0] != "billing" {
+		t.Errorf("down = %v, want [billing]", down)
+	}
+	backends, _ := body["backends"].(map[string]any)
+	if backends["user"] != healthpb.HealthCheckResponse_SERVING.String() || backends["billing"] == healthpb.HealthCheckResponse_SERVING.String() {
+		t.Errorf("backends = %v", backends)
+	}
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+
+	"api-gateway/billingpb"
+)
+
+func TestRecalculateBilling(t *testing.T) {
+	var got *billingpb.RecalculateBillingRequest
+	billing := &fakeBillingClient{recalculateBilling: func(in *billingpb.RecalculateBillingRequest) (*billingpb.RecalculateBillingResponse, error) {
+		got = in
+		return &billingpb.RecalculateBillingResponse{Amount: 42.5, PreviousAmount: 1000, Corrected: true}, nil
+	}}
+	s := newTestServer(t, testConfig(), nil, billing, nil)
+	body := `{"user_id":"` + aliceID + `"}`
+
+	if w := serve(s, http.MethodPost, "/user/billing/recalculate", body, ""); w.Code != http.StatusUnauthorized {
+		t.Errorf("anonymous: status %d, want 401", w.Code)
+	}
+	if w := serve(s, http.MethodPost, "/user/billing/recalculate", body, tokenFor(bobID)); w.Code != http.StatusForbidden {
+		t.Errorf("other user: status %d, want 403", w.Code)
+	}
+	if got != nil {
+		t.Fatalf("RecalculateBilling called for a rejected request: %+v", got)
+	}
+
+	w := serve(s, http.MethodPost, "/user/billing/recalculate", body, tokenFor(aliceID))
+	if w.Code != http.StatusOK {
+		t.Fatalf("own account: status %d, body %s", w.Code, w.Body)
+	}
+	if got.GetUserId() != aliceID {
+		t.Errorf("RecalculateBilling request = %+v", got)
+	}
+	res := decodeBody(t, w)
+	if res["amount"] != 42.5 || res["previous_amount"] != 1000.0 || res["corrected"] != true {
+		t.Errorf("response = %v", res)
+	}
+}
+
+func TestRecalculateBillingIsRateLimited(t *testing.T) {
+	billing := &fakeBillingClient{recalculateBilling: func(*billingpb.RecalculateBillingRequest) (*billingpb.RecalculateBillingResponse, error) {
+		return &billingpb.RecalculateBillingResponse{}, nil
+	}}
+	cfg := testConfig()
+	cfg.rateLimitPerSecond = 1
+	cfg.rateLimitBurst = 1
+	s := newTestServer(t, cfg, nil, billing, nil)
+	h := s.rateLimitMiddleware(s)
+	body := `{"user_id":"` + aliceID + `"}`
+
+	if w := serve(h, http.MethodPost, "/user/billing/recalculate", body, tokenFor(aliceID)); w.Code != http.StatusOK {
+		t.Fatalf("first request: status %d, body %s", w.Code, w.Body)
+	}
+	w := serve(h, http.MethodPost, "/user/billing/recalculate", body, tokenFor(aliceID))
+	if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") == "" {
+		t.Errorf("second request: status %d, Retry-After %q, want 429 with Retry-After", w.Code, w.Header().Get("Retry-After"))
+	}
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"api-gateway/userpb"
+)
+
+func TestRegisterPasswordLength(t *testing.T) {
+	tests := []struct {
+		name      string
+		minLength int
+		password  string
+		wantCode  int
+	}{
+		{"default rejects 7 characters", 8, "abcdef1", http.StatusBadRequest},
+		{"default accepts 8 characters", 8, "abcdefg1", http.StatusOK},
+		{"raised minimum rejects 8 characters", 12, "abcdefg1", http.StatusBadRequest},
+		{"lowered minimum accepts 6 characters", 6, "abcde1", http.StatusOK},
+		{"length counts characters, not bytes", 8, "ééééééé1", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			called := false
+			user := &fakeUserClient{register: func(*userpb.RegisterRequest) (*userpb.RegisterResponse, error) {
+				called = true
+				return &userpb.RegisterResponse{UserId: aliceID}, nil
+			}}
+			cfg := testConfig()
+			cfg.passwordMinLength = tt.minLength
+			s := newTestServer(t, cfg, user, nil, nil)
+
+			w := serve(s, http.MethodPost, "/register", `{"email":"alice@example.com","password":"`+tt.password+`"}`, "")
+			if w.Code != tt.wantCode {
+				t.Fatalf("status %d, want %d, body %s", w.Code, tt.wantCode, w.Body)
+			}
+			if tt.wantCode == http.StatusBadRequest {
+				if called {
+					t.Error("Register called for a rejected password")
+				}
+				if !strings.Contains(w.Body.String(), "password") {
+					t.Errorf("body %s does not name the password field", w.Body)
+				}
+			}
+		})
+	}
+}
+
+func TestLoadConfigPasswordMinLength(t *testing.T) {
+	if got := loadConfig().passwordMinLength; got != 8 {
+		t.Errorf("default passwordMinLength = %d, want 8", got)
+	}
+	t.Setenv("PASSWORD_MIN_LENGTH", "12")
+	if got := loadConfig().passwordMinLength; got != 12 {
+		t.Errorf("passwordMinLength = %d, want 12", got)
+	}
+}
+
+func TestIdempotentRegisterPassedThrough(t *testing.T) {
+	var got *userpb.RegisterRequest
+	user := &fakeUserClient{register: func(in *userpb.RegisterRequest) (*userpb.RegisterResponse, error) {
+		got = in
+		return &userpb.RegisterResponse{UserId: aliceID, AlreadyExisted: true}, nil
+	}}
+	s := newTestServer(t, testConfig(), user, nil, nil)
+
+	w := serve(s, http.MethodPost, "/register", `{"email":"alice@example.com","password":"secret123","idempotent":true}`, "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("status %d, body %s", w.Code, w.Body)
+	}
+	if !got.GetIdempotent() {
+		t.Error("idempotent flag not sent to user-ms")
+	}
+	if body := decodeBody(t, w); body["user_id"] != aliceID || body["already_existed"] != true {
+		t.Errorf("body %v, want the existing user flagged", body)
+	}
+}
+
+func TestRegisterDuplicateEmailConflict(t *testing.T) {
+	registered := map[string]bool{}
+	user := &fakeUserClient{register: func(in *userpb.RegisterRequest) (*userpb.RegisterResponse, error) {
+		if registered[in.Email] {
+			return nil, status.Error(codes.AlreadyExists, "email already registered")
+		}
+		registered[in.Email] = true
+		return &userpb.RegisterResponse{UserId: aliceID}, nil
+	}}
+	s := newTestServer(t, testConfig(), user, nil, nil)
+	body := `{"email":"alice@example.com","password":"secret123"}`
+
+	if w := serve(s, http.MethodPost, "/register", body, ""); w.Code != http.StatusOK {
+		t.Fatalf("first registration: status %d, body %s", w.Code, w.Body)
+	}
+	w := serve(s, http.MethodPost, "/register", body, "")
+	if w.Code != http.StatusConflict {
+		t.Fatalf("second registration: status %d, want 409", w.Code)
+	}
+	if msg := decodeBody(t, w)["error"]; msg != "email already registered" {
+		t.Errorf("error %v", msg)
+	}
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+)
+
+// reloadableKeys are the settings applied to the running gateway on SIGHUP.
+var reloadableKeys = []string{
+	"LOG_LEVEL", "MAINTENANCE_MODE", "MAINTENANCE_MESSAGE",
+	"RATE_LIMIT_AUTH_PER_MINUTE", "RATE_LIMIT_PER_SECOND", "RATE_LIMIT_BURST",
+}
+
+// restartOnlyKeys are read once at startup. A SIGHUP that changes one of them
+// logs that it was ignored.
+var restartOnlyKeys = []string{
+	"PORT", "TLS_CERT_FILE", "TLS_KEY_FILE", "GRPC_TLS_CA_FILE",
+	"USER_SERVICE_ADDR", "BILLING_SERVICE_ADDR", "NOTIFICATION_SERVICE_ADDR",
+	"ADMIN_API_KEY", "GRPC_TIMEOUT", "AGGREGATE_TIMEOUT", "READYZ_TIMEOUT",
+	"MAX_URL_LENGTH", "TRAILING_SLASH", "WS_MAX_LIFETIME", "SECURITY_HEADERS",
+	"ALLOWED_ORIGINS", "WS_ALLOW_ALL_ORIGINS", "SUPPORTED_LOCALES", "DEFAULT_LOCALE",
+	"RATE_LIMIT_IDLE_TTL",
+}
+
+// loadLogLevel reads LOG_LEVEL: "debug", "info" (default), "warn" or "error".
+func loadLogLevel() slog.Level {
+	v := getEnv("LOG_LEVEL", "info")
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(v)); err != nil {
+		slog.Warn("invalid log level, using default", "key", "LOG_LEVEL", "value", v, "default", slog.LevelInfo.String())
+		return slog.LevelInfo
+	}
+	return level
+}
+
+// envValue is an environment variable's value before CONFIG_FILE overrode it.
+type envValue struct {
+	value string
+	set   bool
+}
+
+// fileOverrides holds the previous value of every variable the last loaded
+// CONFIG_FILE set, so a key removed from the file goes back to the process
+// environment, or to its default when the environment does not set it.
+var fileOverrides = map[string]envValue{}
+
+// loadConfigFile sets the environment variables listed in the file named by
+// CONFIG_FILE, if any. Each line is KEY=VALUE; blank lines and lines starting
+// with # are skipped. Values in the file override the process environment.
+// A file that cannot be parsed changes nothing.
+func loadConfigFile() error {
+	path := os.Getenv("CONFIG_FILE")
+	if path == "" {
+		return nil
+	}
+	values, err := parseConfigFile(path)
+	if err != nil {
+		return err
+	}
+
+	for key, prev := range fileOverrides {
+		if _, ok := values[key]; ok {
+			continue
+		}
+		if prev.set {
+			os.Setenv(key, prev.value)
+		} else {
+			os.Unsetenv(key)
+		}
+		delete(fileOverrides, key)
+	}
+	for key, value := range values {
+		if _, ok := fileOverrides[key]; !ok {
+			prev, set := os.LookupEnv(key)
+			fileOverrides[key] = envValue{value: prev, set: set}
+		}
+		if err := os.Setenv(key, value); err != nil {
+			return fmt.Errorf("%s: %s: %w", path, key, err)
+		}
+	}
+	return nil
+}
+
+// parseConfigFile reads the KEY=VALUE lines of the file at path.
+func parseConfigFile(path string) (map[string]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	values := make(map[string]string)
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("%s:%d: expected KEY=VALUE", path, n)
+		}
+		values[key] = strings.TrimSpace(value)
+	}
+	return values, scanner.Err()
+}
+
+// watchReload reloads the configuration every time the process receives
+// SIGHUP.
+func (s *apiServer) watchReload(level *slog.LevelVar) {
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, syscall.SIGHUP)
+	s.reloadOn(sig, level)
+}
+
+// reloadOn reloads the configuration for every signal received on sig until
+// it is closed.
+func (s *apiServer) reloadOn(sig <-chan os.Signal, level *slog.LevelVar) {
+	for range sig {
+		s.reload(level)
+	}
+}
+
+// reload re-reads CONFIG_FILE and applies the reloadable settings that
+// changed. Only settings whose value changed are applied, so a reload does
+// not undo e.g. maintenance mode switched through the admin API.
+func (s *apiServer) reload(level *slog.LevelVar) {
+	keys := append(append([]string{}, reloadableKeys...), restartOnlyKeys...)
+	before := make(map[string]string, len(keys))
+	for _, key := range keys {
+		before[key] = os.Getenv(key)
+	}
+	if err := loadConfigFile(); err != nil {
+		s.logger.Error("failed to reload configuration, keeping the current settings", "error", err)
+		return
+	}
+	changed := func(key string) bool { return os.Getenv(key) != before[key] }
+
+	applied := []string{}
+	if changed("LOG_LEVEL") {
+		level.Set(loadLogLevel())
+		applied = append(applied, "LOG_LEVEL")
+	}
+	if changed("MAINTENANCE_MODE") || changed("MAINTENANCE_MESSAGE") {
+		s.setMaintenance(getEnvBool("MAINTENANCE_MODE", false), os.Getenv("MAINTENANCE_MESSAGE"))
+		applied = append(applied, "MAINTENANCE_MODE")
+	}
+	if changed("RATE_LIMIT_AUTH_PER_MINUTE") || changed("RATE_LIMIT_PER_SECOND") || changed("RATE_LIMIT_BURST") {
+		cfg := loadConfig()
+		s.rateLimits.apply(cfg)
+		s.logger.Info("rate limits changed",
+			"auth_per_minute", cfg.rateLimitAuthPerMinute,
+			"per_second", cfg.rateLimitPerSecond,
+			"burst", cfg.rateLimitBurst,
+		)
+		applied = append(applied, "RATE_LIMITS")
+	}
+	for _, key := range restartOnlyKeys {
+		if changed(key) {
+			s.logger.Warn("setting changed but requires a restart, ignored", "key", key)
+		}
+	}
+	s.logger.Info("configuration reloaded",
+		"applied", applied,
+		"log_level", level.Level().String(),
+		"maintenance", s.maintenance.Load().Enabled,
+	)
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// useConfigFile points CONFIG_FILE at a file in a temporary directory and
+// returns a function that replaces its contents. keys are the variables the
+// file will set; they are restored once the test ends.
+func useConfigFile(t *testing.T, keys ...string) func(contents string) {
+	t.Helper()
+	for _, key := range keys {
+		prev, set := os.LookupEnv(key)
+		t.Cleanup(func() {
+			if set {
+				os.Setenv(key, prev)
+			} else {
+				os.Unsetenv(key)
+			}
+		})
+	}
+	t.Cleanup(func() { fileOverrides = map[string]envValue{} })
+
+	path := filepath.Join(t.TempDir(), "gateway.env")
+	t.Setenv("CONFIG_FILE", path)
+	return func(contents string) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(contents), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestReloadLogLevelOnSIGHUP(t *testing.T) {
+	write := useConfigFile(t, "LOG_LEVEL")
+	write("LOG_LEVEL=info\n")
+	if err := loadConfigFile(); err != nil {
+		t.Fatal(err)
+	}
+	level := new(slog.LevelVar)
+	level.Set(loadLogLevel())
+
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, syscall.SIGHUP)
+	s := newTestServer(t, testConfig(), nil, nil, nil)
+	done := make(chan struct{})
+	go func() {
+		s.reloadOn(sig, level)
+		close(done)
+	}()
+	defer func() {
+		signal.Stop(sig)
+		close(sig)
+		<-done
+	}()
+
+	write("LOG_LEVEL=debug\n")
+	deadline := time.Now().Add(5 * time.Second)
+	for level.Level() != slog.LevelDebug {
+		if time.Now().After(deadline) {
+			t.Fatalf("log level still %v after SIGHUP", level.Level())
+		}
+		if err := syscall.Kill(os.Getpid(), syscall.SIGHUP); err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+func TestReloadRateLimits(t *testing.T) {
+	write := useConfigFile(t, "RATE_LIMIT_PER_SECOND", "RATE_LIMIT_BURST", "RATE_LIMIT_AUTH_PER_MINUTE")
+	os.Unsetenv("RATE_LIMIT_PER_SECOND")
+	os.Unsetenv("RATE_LIMIT_BURST")
+	os.Setenv("RATE_LIMIT_AUTH_PER_MINUTE", "0")
+
+	s := newTestServer(t, testConfig(), nil, nil, nil)
+	ok := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) { w.WriteHeader(http.StatusOK) })
+	h := s.rateLimitMiddleware(ok)
+	level := new(slog.LevelVar)
+
+	requests := func(ip string, n int) (rejected int) {
+		for range n {
+			r := httptest.NewRequest(http.MethodGet, "/user/billing", nil)
+			r.RemoteAddr = ip + ":1234"
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+			if w.Code == http.StatusTooManyRequests {
+				rejected++
+			}
+		}
+		return rejected
+	}
+	if n := requests("192.0.2.1", 5); n != 0 {
+		t.Fatalf("%d requests limited before the reload", n)
+	}
+
+	write("RATE_LIMIT_PER_SECOND=1\nRATE_LIMIT_BURST=1\n")
+	s.reload(level)
+	if n := requests("192.0.2.1", 3); n != 2 {
+		t.Errorf("after lowering the limit: %d of 3 requests limited, want 2", n)
+	}
+
+	// Removing the keys restores the environment, where they are unset, so
+	// the defaults of 10 per second with bursts of 20 apply again. A client
+	// that used up its bucket keeps it and refills at the new rate.
+	write("# no rate limits\n")
+	s.reload(level)
+	if v, set := os.LookupEnv("RATE_LIMIT_PER_SECOND"); set {
+		t.Errorf("RATE_LIMIT_PER_SECOND = %q after removal from the file, want unset", v)
+	}
+	if n := requests("192.0.2.2", 15); n != 0 {
+		t.Errorf("after removing the limit: %d of 15 requests from a new client limited", n)
+	}
+	time.Sleep(150 * time.Millisecond)
+	if n := requests("192.0.2.1", 1); n != 0 {
+		t.Error("limited client not refilled at the restored rate")
+	}
+}
+
+func TestLoadConfigFileRestoresRemovedKeys(t *testing.T) {
+	write := useConfigFile(t, "GATEWAY_TEST_FROM_ENV", "GATEWAY_TEST_FILE_ONLY")
+	os.Setenv("GATEWAY_TEST_FROM_ENV", "env")
+	os.Unsetenv("GATEWAY_TEST_FILE_ONLY")
+
+	write("GATEWAY_TEST_FROM_ENV=file\nGATEWAY_TEST_FILE_ONLY=file\n")
+	if err := loadConfigFile(); err != nil {
+		t.Fatal(err)
+	}
+	if got := os.Getenv("GATEWAY_TEST_FROM_ENV"); got != "file" {
+		t.Errorf("file value not applied: %q", got)
+	}
+
+	write("GATEWAY_TEST_FILE_ONLY=changed\n")
+	if err := loadConfigFile(); err != nil {
+		t.Fatal(err)
+	}
+	if got := os.Getenv("GATEWAY_TEST_FROM_ENV"); got != "env" {
+		t.Errorf("removed key = %q, want the environment value %q", got, "env")
+	}
+	if got := os.Getenv("GATEWAY_TEST_FILE_ONLY"); got != "changed" {
+		t.Errorf("GATEWAY_TEST_FILE_ONLY = %q, want %q", got, "changed")
+	}
+
+	write("not a setting\n")
+	if err := loadConfigFile(); err == nil {
+		t.Fatal("malformed file loaded without error")
+	}
+	if got := os.Getenv("GATEWAY_TEST_FILE_ONLY"); got != "changed" {
+		t.Errorf("malformed file changed GATEWAY_TEST_FILE_ONLY to %q", got)
+	}
+
+	write("")
+	if err := loadConfigFile(); err != nil {
+		t.Fatal(err)
+	}
+	if v, set := os.LookupEnv("GATEWAY_TEST_FILE_ONLY"); set {
+		t.Errorf("GATEWAY_TEST_FILE_ONLY = %q after removal, want unset", v)
+	}
+}
+
+func TestSetLimitKeepsExistingBuckets(t *testing.T) {
+	l := newIPRateLimiter(rate.Every(time.Minute), 1, time.Minute, realClock{})
+	if wait := l.reserve("10.0.0.1"); wait != 0 {
+		t.Fatalf("first request waits %v", wait)
+	}
+	if wait := l.reserve("10.0.0.1"); wait < 50*time.Second {
+		t.Fatalf("second request waits %v, want about a minute", wait)
+	}
+
+	l.setLimit(rate.Limit(10), 1)
+	if wait := l.reserve("10.0.0.1"); wait > 100*time.Millisecond {
+		t.Errorf("after raising the rate the client waits %v", wait)
+	}
+
+	l.setLimit(0, 0)
+	for range 5 {
+		if wait := l.reserve("10.0.0.1"); wait != 0 {
+			t.Fatalf("disabled limiter made a request wait %v", wait)
+		}
+	}
+}
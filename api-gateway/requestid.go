@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// requestIDHeader is the HTTP header carrying the request id, both from the
+// client and in the response.
+const requestIDHeader = "X-Request-ID"
+
+// requestIDMetadataKey is the gRPC metadata key the request id is forwarded in.
+const requestIDMetadataKey = "x-request-id"
+
+// maxRequestIDLength bounds a client-supplied request id.
+const maxRequestIDLength = 128
+
+// incomingRequestID returns the client's request id when it is usable and a
+// new one otherwise. Ids end up in every service's logs, so only short
+// strings of printable ASCII without spaces are accepted.
+func incomingRequestID(id string) string {
+	if id == "" || len(id) > maxRequestIDLength {
+		return uuid.NewString()
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] <= ' ' || id[i] > '~' {
+			return uuid.NewString()
+		}
+	}
+	return id
+}
+
+// withRequestID returns a copy of ctx carrying the request id.
+func withRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey, id)
+}
+
+// requestIDFrom returns the request id stored by loggingMiddleware, or "".
+func requestIDFrom(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey).(string)
+	return id
+}
+
+// outgoingRequestID adds the request id in ctx, if any, to the outgoing gRPC
+// metadata.
+func outgoingRequestID(ctx context.Context) context.Context {
+	if id := requestIDFrom(ctx); id != "" {
+		return metadata.AppendToOutgoingContext(ctx, requestIDMetadataKey, id)
+	}
+	return ctx
+}
+
+// requestIDUnaryInterceptor forwards the request id on unary backend calls.
+func requestIDUnaryInterceptor(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	return invoker(outgoingRequestID(ctx), method, req, reply, cc, opts...)
+}
+
+// requestIDStreamInterceptor forwards the request id on streaming backend calls.
+func requestIDStreamInterceptor(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	return streamer(outgoingRequestID(ctx), desc, cc, method, opts...)
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"math/rand/v2"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"api-gateway/billingpb"
+	"api-gateway/userpb"
+)
+
+// idempotentMethods are the read-only calls that are safe to send again.
+// Writes such as Register or UpdateBilling are never retried: a call that
+// timed out may still have been applied.
+var idempotentMethods = map[string]bool{
+	userpb.UserService_GetUser_FullMethodName:          true,
+	billingpb.BillingService_GetBilling_FullMethodName: true,
+}
+
+// retryConfig configures retries of idempotent backend calls.
+type retryConfig struct {
+	// attempts is the total number of tries, including the first; 1 disables
+	// retries.
+	attempts int
+	// baseDelay is the backoff before the first retry; it doubles for each
+	// further retry.
+	baseDelay time.Duration
+}
+
+// loadRetryConfig reads GRPC_RETRY_ATTEMPTS (default 3) and
+// GRPC_RETRY_BASE_DELAY (default 100ms).
+func loadRetryConfig() retryConfig {
+	return retryConfig{
+		attempts:  max(getEnvInt("GRPC_RETRY_ATTEMPTS", 3), 1),
+		baseDelay: max(getEnvDuration("GRPC_RETRY_BASE_DELAY", 100*time.Millisecond), 0),
+	}
+}
+
+// retryable reports whether err is a transient failure worth retrying, e.g.
+// a backend pod restarting.
+func retryable(err error) bool {
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded:
+		return true
+	}
+	return false
+}
+
+// retryUnaryInterceptor retries idempotent calls that fail with UNAVAILABLE or
+// DEADLINE_EXCEEDED, waiting an exponential backoff with jitter between
+// attempts. It gives up early when the caller's context is done, so retries
+// never outlive the handler's own deadline.
+func retryUnaryInterceptor(logger *slog.Logger, cfg retryConfig, clock Clock) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		if cfg.attempts <= 1 || !idempotentMethods[method] {
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}
+		var err error
+		for attempt := 1; ; attempt++ {
+			err = invoker(ctx, method, req, reply, cc, opts...)
+			if err == nil || !retryable(err) || attempt == cfg.attempts || ctx.Err() != nil {
+				return err
+			}
+			logger.Warn("retrying backend call", "request_id", requestIDFrom(ctx), "method", method, "attempt", attempt, "error", err)
+			select {
+			case <-clock.After(retryBackoff(cfg.baseDelay, attempt)):
+			case <-ctx.Done():
+				return err
+			}
+		}
+	}
+}
+
+// retryBackoff returns the wait before retry number attempt: baseDelay
+// doubled for each earlier retry, with the upper half jittered so clients
+// that failed together do not retry in lockstep.
+func retryBackoff(baseDelay time.Duration, attempt int) time.Duration {
+	d := baseDelay << (attempt - 1)
+	if d <= 0 {
+		return 0
+	}
+	return d/2 + rand.N(d/2+1)
+}
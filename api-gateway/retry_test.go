@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"api-gateway/billingpb"
+	"api-gateway/userpb"
+)
+
+// scriptedInvoker fails with the given errors in turn, then succeeds, and
+// counts the attempts made.
+func scriptedInvoker(attempts *int, errs ...error) grpc.UnaryInvoker {
+	return func(context.Context, string, any, any, *grpc.ClientConn, ...grpc.CallOption) error {
+		*attempts++
+		if *attempts <= len(errs) {
+			return errs[*attempts-1]
+		}
+		return nil
+	}
+}
+
+func retryInterceptor(clock Clock) grpc.UnaryClientInterceptor {
+	return retryUnaryInterceptor(slog.New(slog.NewTextHandler(io.Discard, nil)),
+		retryConfig{attempts: 3, baseDelay: 100 * time.Millisecond}, clock)
+}
+
+func TestRetrySucceedsOnSecondAttempt(t *testing.T) {
+	clock := &instantClock{}
+	var attempts int
+	err := retryInterceptor(clock)(context.Background(), billingpb.BillingService_GetBilling_FullMethodName, nil, nil, nil,
+		scriptedInvoker(&attempts, status.Error(codes.Unavailable, "billing restarting")))
+	if err != nil {
+		t.Fatalf("GetBilling: %v", err)
+	}
+	if attempts != 2 {
+		t.Errorf("%d attempts, want 2", attempts)
+	}
+	if len(clock.waits) != 1 || clock.waits[0] < 50*time.Millisecond || clock.waits[0] > 100*time.Millisecond {
+		t.Errorf("backoff %v, want one wait between 50ms and 100ms", clock.waits)
+	}
+}
+
+func TestRetryGivesUpAfterMaxAttempts(t *testing.T) {
+	clock := &instantClock{}
+	var attempts int
+	down := status.Error(codes.DeadlineExceeded, "slow")
+	err := retryInterceptor(clock)(context.Background(), userpb.UserService_GetUser_FullMethodName, nil, nil, nil,
+		scriptedInvoker(&attempts, down, down, down, down))
+	if status.Code(err) != codes.DeadlineExceeded {
+		t.Errorf("error %v, want the last DEADLINE_EXCEEDED", err)
+	}
+	if attempts != 3 {
+		t.Errorf("%d attempts, want 3", attempts)
+	}
+	if len(clock.waits) != 2 || clock.waits[1] < 100*time.Millisecond || clock.waits[1] > 200*time.Millisecond {
+		t.Errorf("backoff %v, want the second wait doubled", clock.waits)
+	}
+}
+
+func TestRetrySkipsWritesAndClientErrors(t *testing.T) {
+	for name, tc := range map[string]struct {
+		method string
+		err    error
+	}{
+		"Register":      {userpb.UserService_Register_FullMethodName, status.Error(codes.Unavailable, "down")},
+		"UpdateBilling": {billingpb.BillingService_UpdateBilling_FullMethodName, status.Error(codes.Unavailable, "down")},
+		"NotFound":      {billingpb.BillingService_GetBilling_FullMethodName, status.Error(codes.NotFound, "no account")},
+	} {
+		var attempts int
+		retryInterceptor(&instantClock{})(context.Background(), tc.method, nil, nil, nil, scriptedInvoker(&attempts, tc.err))
+		if attempts != 1 {
+			t.Errorf("%s: %d attempts, want 1", name, attempts)
+		}
+	}
+}
+
+func TestRetryStopsWhenContextDone(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	var attempts int
+	invoker := func(context.Context, string, any, any, *grpc.ClientConn, ...grpc.CallOption) error {
+		attempts++
+		cancel()
+		return status.Error(codes.Unavailable, "down")
+	}
+	retryInterceptor(realClock{})(ctx, billingpb.BillingService_GetBilling_FullMethodName, nil, nil, nil, invoker)
+	if attempts != 1 {
+		t.Errorf("%d attempts after the caller gave up, want 1", attempts)
+	}
+}
+
+func TestLoadRetryConfig(t *testing.T) {
+	t.Setenv("GRPC_RETRY_ATTEMPTS", "5")
+	t.Setenv("GRPC_RETRY_BASE_DELAY", "20ms")
+	if got := loadRetryConfig(); got != (retryConfig{attempts: 5, baseDelay: 20 * time.Millisecond}) {
+		t.Errorf("config = %+v", got)
+	}
+	t.Setenv("GRPC_RETRY_ATTEMPTS", "0")
+	if got := loadRetryConfig(); got.attempts != 1 {
+		t.Errorf("GRPC_RETRY_ATTEMPTS=0: %d attempts, want 1", got.attempts)
+	}
+}
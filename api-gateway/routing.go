@@ -0,0 +1,67 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+)
+
+// Trailing-slash policies for requests like "/readyz/" that only match a
+// route without the slash.
+const (
+	// trailingSlashStrict answers 404, like any other unknown path.
+	trailingSlashStrict = "strict"
+	// trailingSlashRedirect answers 308 with the slash removed.
+	trailingSlashRedirect = "redirect"
+	// trailingSlashStrip serves the request as if it had no trailing slash.
+	trailingSlashStrip = "strip"
+)
+
+// handleUnmatched answers requests no route pattern matches: it applies the
+// trailing-slash policy and otherwise returns the mux's 404 or 405 as a JSON
+// error instead of its plain-text body.
+func (s *apiServer) handleUnmatched(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" && strings.HasSuffix(r.URL.Path, "/") && s.cfg.trailingSlash != trailingSlashStrict {
+		trimmed := r.Clone(r.Context())
+		trimmed.URL.Path = strings.TrimRight(r.URL.Path, "/")
+		trimmed.URL.RawPath = ""
+		if _, pattern := s.router.Handler(trimmed); pattern != "" {
+			if s.cfg.trailingSlash == trailingSlashRedirect {
+				http.Redirect(w, r, trimmed.URL.RequestURI(), http.StatusPermanentRedirect)
+				return
+			}
+			s.router.ServeHTTP(w, trimmed)
+			return
+		}
+	}
+
+	// The mux's own handler knows whether the path exists for other methods.
+	h, _ := s.router.Handler(r)
+	rec := &statusRecorder{header: http.Header{}}
+	h.ServeHTTP(rec, r)
+	if rec.status == http.StatusMethodNotAllowed {
+		w.Header().Set("Allow", rec.header.Get("Allow"))
+		s.writeJSONError(w, http.StatusMethodNotAllowed, "method "+r.Method+" not allowed")
+		return
+	}
+	s.writeJSONError(w, http.StatusNotFound, "no route for "+r.URL.Path)
+}
+
+// statusRecorder captures the status and headers a handler writes and
+// discards the body.
+type statusRecorder struct {
+	header http.Header
+	status int
+}
+
+func (rec *statusRecorder) Header() http.Header { return rec.header }
+
+func (rec *statusRecorder) WriteHeader(status int) {
+	if rec.status == 0 {
+		rec.status = status
+	}
+}
+
+func (rec *statusRecorder) Write(b []byte) (int, error) {
+	rec.WriteHeader(http.StatusOK)
+	return len(b), nil
+}
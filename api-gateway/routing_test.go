@@ -0,0 +1,71 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestUnmatchedRoutesGetJSONErrors(t *testing.T) {
+	s := newTestServer(t, testConfig(), nil, nil, nil)
+
+	w := serve(s, http.MethodDelete, "/healthz", "", "")
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("wrong method: status %d, want 405", w.Code)
+	}
+	if allow := w.Header().Get("Allow"); !strings.Contains(allow, http.MethodGet) {
+		t.Errorf("Allow = %q, want GET", allow)
+	}
+	if msg := decodeBody(t, w)["error"]; msg != "method DELETE not allowed" {
+		t.Errorf("405 error %v", msg)
+	}
+
+	w = serve(s, http.MethodGet, "/no-such-route", "", "")
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("unknown path: status %d, want 404", w.Code)
+	}
+	if msg := decodeBody(t, w)["error"]; msg != "no route for /no-such-route" {
+		t.Errorf("404 error %v", msg)
+	}
+}
+
+func TestTrailingSlashPolicy(t *testing.T) {
+	for _, tt := range []struct {
+		policy       string
+		wantCode     int
+		wantLocation string
+	}{
+		{trailingSlashStrict, http.StatusNotFound, ""},
+		{trailingSlashRedirect, http.StatusPermanentRedirect, "/healthz?probe=1"},
+		{trailingSlashStrip, http.StatusOK, ""},
+	} {
+		t.Run(tt.policy, func(t *testing.T) {
+			cfg := testConfig()
+			cfg.trailingSlash = tt.policy
+			s := newTestServer(t, cfg, nil, nil, nil)
+
+			w := serve(s, http.MethodGet, "/healthz/?probe=1", "", "")
+			if w.Code != tt.wantCode {
+				t.Fatalf("status %d, want %d", w.Code, tt.wantCode)
+			}
+			if got := w.Header().Get("Location"); got != tt.wantLocation {
+				t.Errorf("Location = %q, want %q", got, tt.wantLocation)
+			}
+			// Unknown paths stay 404 whatever the policy.
+			if w := serve(s, http.MethodGet, "/no-such-route/", "", ""); w.Code != http.StatusNotFound {
+				t.Errorf("unknown path with a slash: status %d", w.Code)
+			}
+		})
+	}
+}
+
+func TestTrailingSlashPolicyFromEnv(t *testing.T) {
+	t.Setenv("TRAILING_SLASH", "redirect")
+	if got := loadTrailingSlashPolicy(); got != trailingSlashRedirect {
+		t.Errorf("TRAILING_SLASH=redirect: policy %q", got)
+	}
+	t.Setenv("TRAILING_SLASH", "sometimes")
+	if got := loadTrailingSlashPolicy(); got != trailingSlashStrict {
+		t.Errorf("invalid TRAILING_SLASH: policy %q, want strict", got)
+	}
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSecurityHeadersOnResponses(t *testing.T) {
+	s := newTestServer(t, testConfig(), nil, nil, nil)
+	h := securityHeadersMiddleware(loadSecurityHeaders())(s)
+
+	w := serve(h, http.MethodGet, "/healthz", "", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("status %d", w.Code)
+	}
+	for name, want := range map[string]string{
+		"X-Content-Type-Options": "nosniff",
+		"X-Frame-Options":        "DENY",
+		"Referrer-Policy":        "no-referrer",
+	} {
+		if got := w.Header().Get(name); got != want {
+			t.Errorf("%s = %q, want %q", name, got, want)
+		}
+	}
+	if got := w.Header().Get(hstsHeader); got != "" {
+		t.Errorf("HSTS sent over plain HTTP: %q", got)
+	}
+
+	// Error responses carry them too.
+	if w := serve(h, http.MethodGet, "/no-such-route", "", ""); w.Header().Get("X-Content-Type-Options") != "nosniff" {
+		t.Errorf("404 response without X-Content-Type-Options, headers %v", w.Header())
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "https://gateway.example.com/healthz", nil)
+	tw := httptest.NewRecorder()
+	h.ServeHTTP(tw, r)
+	if got := tw.Header().Get(hstsHeader); got != defaultSecurityHeaders[hstsHeader] {
+		t.Errorf("HSTS over TLS = %q, want %q", got, defaultSecurityHeaders[hstsHeader])
+	}
+}
+
+func TestLoadSecurityHeadersOverrides(t *testing.T) {
+	t.Setenv("SECURITY_HEADERS", "x-frame-options=SAMEORIGIN, Referrer-Policy= ,Permissions-Policy=camera=()")
+	headers := loadSecurityHeaders()
+
+	if got := headers["X-Frame-Options"]; got != "SAMEORIGIN" {
+		t.Errorf("X-Frame-Options = %q, want the override", got)
+	}
+	if _, ok := headers["Referrer-Policy"]; ok {
+		t.Error("Referrer-Policy not removed by an empty value")
+	}
+	if got := headers["Permissions-Policy"]; got != "camera=()" {
+		t.Errorf("Permissions-Policy = %q, want camera=()", got)
+	}
+	if got := headers["X-Content-Type-Options"]; got != "nosniff" {
+		t.Errorf("untouched default changed to %q", got)
+	}
+	if defaultSecurityHeaders["X-Frame-Options"] != "DENY" {
+		t.Error("overrides modified the defaults")
+	}
+}
@@ -0,0 +1,293 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"api-gateway/billingpb"
+	"api-gateway/notifpb"
+	"api-gateway/userpb"
+)
+
+// Test user ids. fakeUserClient accepts tokenFor(id) as the token of user id.
+const (
+	aliceID = "11111111-1111-4111-8111-111111111111"
+	bobID   = "22222222-2222-4222-8222-222222222222"
+)
+
+func tokenFor(userID string) string { return "token-" + userID }
+
+// tokenIssuedAt is when every tokenFor token claims to have been issued.
+const tokenIssuedAt = 1_700_000_000
+
+// fakeUserClient answers ValidateToken for tokens made by tokenFor and
+// delegates every other call to the function set for it. Calling a method
+// without one panics through the nil embedded interface.
+type fakeUserClient struct {
+	userpb.UserServiceClient
+	register             func(*userpb.RegisterRequest) (*userpb.RegisterResponse, error)
+	login                func(*userpb.LoginRequest) (*userpb.LoginResponse, error)
+	refresh              func(*userpb.RefreshRequest) (*userpb.LoginResponse, error)
+	logout               func(*userpb.LogoutRequest) (*userpb.LogoutResponse, error)
+	setUsername          func(*userpb.SetUsernameRequest) (*userpb.SetUsernameResponse, error)
+	getPasswordHashStats func(*userpb.GetPasswordHashStatsRequest) (*userpb.GetPasswordHashStatsResponse, error)
+	stats                func(*userpb.StatsRequest) (*userpb.StatsResponse, error)
+	getEventSchemas      func(*userpb.GetEventSchemasRequest) (*userpb.GetEventSchemasResponse, error)
+}
+
+func (f *fakeUserClient) ValidateToken(_ context.Context, in *userpb.ValidateTokenRequest, _ ...grpc.CallOption) (*userpb.ValidateTokenResponse, error) {
+	userID, ok := strings.CutPrefix(in.Token, "token-")
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "invalid token")
+	}
+	return &userpb.ValidateTokenResponse{UserId: userID, Email: userID + "@example.com", IssuedAt: tokenIssuedAt, ExpiresAt: tokenIssuedAt + 900}, nil
+}
+
+func (f *fakeUserClient) Register(_ context.Context, in *userpb.RegisterRequest, _ ...grpc.CallOption) (*userpb.RegisterResponse, error) {
+	return f.register(in)
+}
+
+func (f *fakeUserClient) Login(_ context.Context, in *userpb.LoginRequest, _ ...grpc.CallOption) (*userpb.LoginResponse, error) {
+	return f.login(in)
+}
+
+func (f *fakeUserClient) Refresh(_ context.Context, in *userpb.RefreshRequest, _ ...grpc.CallOption) (*userpb.LoginResponse, error) {
+	return f.refresh(in)
+}
+
+func (f *fakeUserClient) Logout(_ context.Context, in *userpb.LogoutRequest, _ ...grpc.CallOption) (*userpb.LogoutResponse, error) {
+	return f.logout(in)
+}
+
+func (f *fakeUserClient) SetUsername(_ context.Context, in *userpb.SetUsernameRequest, _ ...grpc.CallOption) (*userpb.SetUsernameResponse, error) {
+	return f.setUsername(in)
+}
+
+func (f *fakeUserClient) GetPasswordHashStats(_ context.Context, in *userpb.GetPasswordHashStatsRequest, _ ...grpc.CallOption) (*userpb.GetPasswordHashStatsResponse, error) {
+	return f.getPasswordHashStats(in)
+}
+
+func (f *fakeUserClient) Stats(_ context.Context, in *userpb.StatsRequest, _ ...grpc.CallOption) (*userpb.StatsResponse, error) {
+	return f.stats(in)
+}
+
+func (f *fakeUserClient) GetEventSchemas(_ context.Context, in *userpb.GetEventSchemasRequest, _ ...grpc.CallOption) (*userpb.GetEventSchemasResponse, error) {
+	return f.getEventSchemas(in)
+}
+
+// fakeBillingClient delegates to the function set for each call.
+type fakeBillingClient struct {
+	billingpb.BillingServiceClient
+	getBilling           func(*billingpb.GetBillingRequest) (*billingpb.GetBillingResponse, error)
+	updateBilling        func(*billingpb.UpdateBillingRequest) (*billingpb.UpdateBillingResponse, error)
+	recalculateBilling   func(*billingpb.RecalculateBillingRequest) (*billingpb.RecalculateBillingResponse, error)
+	setConsumptionPaused func(*billingpb.SetConsumptionPausedRequest) (*billingpb.SetConsumptionPausedResponse, error)
+	stats                func(*billingpb.StatsRequest) (*billingpb.StatsResponse, error)
+	getEventSchemas      func(*billingpb.GetEventSchemasRequest) (*billingpb.GetEventSchemasResponse, error)
+	exportTransactions   func(context.Context, *billingpb.ExportTransactionsRequest) (grpc.ServerStreamingClient[billingpb.TransactionPage], error)
+}
+
+func (f *fakeBillingClient) ExportTransactions(ctx context.Context, in *billingpb.ExportTransactionsRequest, _ ...grpc.CallOption) (grpc.ServerStreamingClient[billingpb.TransactionPage], error) {
+	return f.exportTransactions(ctx, in)
+}
+
+func (f *fakeBillingClient) GetBilling(_ context.Context, in *billingpb.GetBillingRequest, _ ...grpc.CallOption) (*billingpb.GetBillingResponse, error) {
+	return f.getBilling(in)
+}
+
+func (f *fakeBillingClient) UpdateBilling(_ context.Context, in *billingpb.UpdateBillingRequest, _ ...grpc.CallOption) (*billingpb.UpdateBillingResponse, error) {
+	return f.updateBilling(in)
+}
+
+func (f *fakeBillingClient) RecalculateBilling(_ context.Context, in *billingpb.RecalculateBillingRequest, _ ...grpc.CallOption) (*billingpb.RecalculateBillingResponse, error) {
+	return f.recalculateBilling(in)
+}
+
+func (f *fakeBillingClient) SetConsumptionPaused(_ context.Context, in *billingpb.SetConsumptionPausedRequest, _ ...grpc.CallOption) (*billingpb.SetConsumptionPausedResponse, error) {
+	return f.setConsumptionPaused(in)
+}
+
+func (f *fakeBillingClient) Stats(_ context.Context, in *billingpb.StatsRequest, _ ...grpc.CallOption) (*billingpb.StatsResponse, error) {
+	return f.stats(in)
+}
+
+func (f *fakeBillingClient) GetEventSchemas(_ context.Context, in *billingpb.GetEventSchemasRequest, _ ...grpc.CallOption) (*billingpb.GetEventSchemasResponse, error) {
+	return f.getEventSchemas(in)
+}
+
+// fakeNotifClient delegates to the function set for each call.
+type fakeNotifClient struct {
+	notifpb.NotificationServiceClient
+	markNotificationRead      func(*notifpb.MarkNotificationReadRequest) (*notifpb.MarkNotificationReadResponse, error)
+	getNotificationDeliveries func(*notifpb.GetNotificationDeliveriesRequest) (*notifpb.GetNotificationDeliveriesResponse, error)
+	resendNotification        func(*notifpb.ResendNotificationRequest) (*notifpb.ResendNotificationResponse, error)
+	listTemplates             func(*notifpb.ListTemplatesRequest) (*notifpb.ListTemplatesResponse, error)
+	putTemplate               func(*notifpb.PutTemplateRequest) (*notifpb.PutTemplateResponse, error)
+	deleteTemplate            func(*notifpb.DeleteTemplateRequest) (*notifpb.DeleteTemplateResponse, error)
+	sendTestNotifications     func(*notifpb.SendTestNotificationsRequest) (*notifpb.SendTestNotificationsResponse, error)
+	startBackfill             func(*notifpb.StartBackfillRequest) (*notifpb.StartBackfillResponse, error)
+	getBackfill               func(*notifpb.GetBackfillRequest) (*notifpb.GetBackfillResponse, error)
+	resumeBackfill            func(*notifpb.ResumeBackfillRequest) (*notifpb.ResumeBackfillResponse, error)
+	listStreams               func(*notifpb.ListStreamsRequest) (*notifpb.ListStreamsResponse, error)
+	terminateStream           func(*notifpb.TerminateStreamRequest) (*notifpb.TerminateStreamResponse, error)
+	subscribe                 func(context.Context, *notifpb.SubscribeRequest) (grpc.ServerStreamingClient[notifpb.NotificationBatch], error)
+	stats                     func(*notifpb.StatsRequest) (*notifpb.StatsResponse, error)
+}
+
+func (f *fakeNotifClient) SendTestNotifications(_ context.Context, in *notifpb.SendTestNotificationsRequest, _ ...grpc.CallOption) (*notifpb.SendTestNotificationsResponse, error) {
+	return f.sendTestNotifications(in)
+}
+
+func (f *fakeNotifClient) StartBackfill(_ context.Context, in *notifpb.StartBackfillRequest, _ ...grpc.CallOption) (*notifpb.StartBackfillResponse, error) {
+	return f.startBackfill(in)
+}
+
+func (f *fakeNotifClient) GetBackfill(_ context.Context, in *notifpb.GetBackfillRequest, _ ...grpc.CallOption) (*notifpb.GetBackfillResponse, error) {
+	return f.getBackfill(in)
+}
+
+func (f *fakeNotifClient) ResumeBackfill(_ context.Context, in *notifpb.ResumeBackfillRequest, _ ...grpc.CallOption) (*notifpb.ResumeBackfillResponse, error) {
+	return f.resumeBackfill(in)
+}
+
+func (f *fakeNotifClient) ListStreams(_ context.Context, in *notifpb.ListStreamsRequest, _ ...grpc.CallOption) (*notifpb.ListStreamsResponse, error) {
+	return f.listStreams(in)
+}
+
+func (f *fakeNotifClient) TerminateStream(_ context.Context, in *notifpb.TerminateStreamRequest, _ ...grpc.CallOption) (*notifpb.TerminateStreamResponse, error) {
+	return f.terminateStream(in)
+}
+
+func (f *fakeNotifClient) SubscribeToNotifications(ctx context.Context, in *notifpb.SubscribeRequest, _ ...grpc.CallOption) (grpc.ServerStreamingClient[notifpb.NotificationBatch], error) {
+	return f.subscribe(ctx, in)
+}
+
+// fakeStream is a notification stream that delivers what is sent on batches
+// until its context is cancelled.
+type fakeStream struct {
+	grpc.ClientStream
+	ctx     context.Context
+	batches chan *notifpb.NotificationBatch
+}
+
+func newFakeStream(ctx context.Context) *fakeStream {
+	return &fakeStream{ctx: ctx, batches: make(chan *notifpb.NotificationBatch, 10)}
+}
+
+func (f *fakeStream) Recv() (*notifpb.NotificationBatch, error) {
+	select {
+	case b := <-f.batches:
+		return b, nil
+	case <-f.ctx.Done():
+		return nil, status.FromContextError(f.ctx.Err()).Err()
+	}
+}
+
+func (f *fakeNotifClient) Stats(_ context.Context, in *notifpb.StatsRequest, _ ...grpc.CallOption) (*notifpb.StatsResponse, error) {
+	return f.stats(in)
+}
+
+func (f *fakeNotifClient) MarkNotificationRead(_ context.Context, in *notifpb.MarkNotificationReadRequest, _ ...grpc.CallOption) (*notifpb.MarkNotificationReadResponse, error) {
+	return f.markNotificationRead(in)
+}
+
+func (f *fakeNotifClient) GetNotificationDeliveries(_ context.Context, in *notifpb.GetNotificationDeliveriesRequest, _ ...grpc.CallOption) (*notifpb.GetNotificationDeliveriesResponse, error) {
+	return f.getNotificationDeliveries(in)
+}
+
+func (f *fakeNotifClient) ResendNotification(_ context.Context, in *notifpb.ResendNotificationRequest, _ ...grpc.CallOption) (*notifpb.ResendNotificationResponse, error) {
+	return f.resendNotification(in)
+}
+
+func (f *fakeNotifClient) ListTemplates(_ context.Context, in *notifpb.ListTemplatesRequest, _ ...grpc.CallOption) (*notifpb.ListTemplatesResponse, error) {
+	return f.listTemplates(in)
+}
+
+func (f *fakeNotifClient) PutTemplate(_ context.Context, in *notifpb.PutTemplateRequest, _ ...grpc.CallOption) (*notifpb.PutTemplateResponse, error) {
+	return f.putTemplate(in)
+}
+
+func (f *fakeNotifClient) DeleteTemplate(_ context.Context, in *notifpb.DeleteTemplateRequest, _ ...grpc.CallOption) (*notifpb.DeleteTemplateResponse, error) {
+	return f.deleteTemplate(in)
+}
+
+// testAdminKey is the admin API key of testConfig.
+const testAdminKey = "test-admin-key"
+
+// testConfig is the configuration with every environment default, the admin
+// API enabled with testAdminKey and the rate limits disabled, so tests are
+// not throttled.
+func testConfig() config {
+	cfg := loadConfig()
+	cfg.adminAPIKey = testAdminKey
+	cfg.rateLimitAuthPerMinute = 0
+	cfg.rateLimitPerSecond = 0
+	return cfg
+}
+
+// newTestServer builds a gateway around the given fakes; nil fakes are
+// replaced with empty ones.
+func newTestServer(t *testing.T, cfg config, user *fakeUserClient, billing *fakeBillingClient, notif *fakeNotifClient) *apiServer {
+	t.Helper()
+	if user == nil {
+		user = &fakeUserClient{}
+	}
+	if billing == nil {
+		billing = &fakeBillingClient{}
+	}
+	if notif == nil {
+		notif = &fakeNotifClient{}
+	}
+	return newAPIServer(user, billing, notif, nil, cfg, slog.New(slog.NewTextHandler(io.Discard, nil)))
+}
+
+// serve sends a request to h and returns the recorded response. A non-empty
+// token is sent as a bearer token.
+func serve(h http.Handler, method, target, body, token string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, target, strings.NewReader(body))
+	if token != "" {
+		r.Header.Set("Authorization", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w
+}
+
+// serveAdmin is serve for the admin API, sending testAdminKey.
+func serveAdmin(h http.Handler, method, target, body string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, target, strings.NewReader(body))
+	r.Header.Set("X-Admin-Key", testAdminKey)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w
+}
+
+// decodeBody decodes a JSON response body into a map.
+func decodeBody(t *testing.T, w *httptest.ResponseRecorder) map[string]any {
+	t.Helper()
+	var body map[string]any
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("response body %q is not JSON: %v", w.Body.String(), err)
+	}
+	return body
+}
+
+// containsAny reports whether s contains any of substrs.
+func containsAny(s string, substrs ...string) bool {
+	for _, sub := range substrs {
+		if strings.Contains(s, sub) {
+			return true
+		}
+	}
+	return false
+}
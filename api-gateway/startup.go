@@ -0,0 +1,32 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+)
+
+// version is the build version, set with -ldflags "-X main.version=...".
+var version = "dev"
+
+// logStartup emits the single "service started" entry summarising what the
+// gateway is wired to. Call it once every backend connection is set up.
+func logStartup(logger *slog.Logger, listenAddr string, backends map[string]string, features map[string]any) {
+	logger.Info("service started",
+		"service", "api-gateway",
+		"version", version,
+		"listen_addr", listenAddr,
+		"log_level", enabledLevel(logger.Handler()).String(),
+		"backends", backends,
+		"features", features,
+	)
+}
+
+// enabledLevel returns the lowest standard level h logs.
+func enabledLevel(h slog.Handler) slog.Level {
+	for _, level := range []slog.Level{slog.LevelDebug, slog.LevelInfo, slog.LevelWarn} {
+		if h.Enabled(context.Background(), level) {
+			return level
+		}
+	}
+	return slog.LevelError
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"testing"
+)
+
+func TestLogStartupSummary(t *testing.T) {
+	var buf bytes.Buffer
+	level := new(slog.LevelVar)
+	level.Set(slog.LevelDebug)
+	logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: level}))
+	logStartup(logger, ":8080", map[string]string{"user": "user-ms:50051"}, map[string]any{"admin_api": true})
+
+	var entry map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("want one JSON line, got %q: %v", buf.String(), err)
+	}
+	want := map[string]any{
+		"msg":         "service started",
+		"service":     "api-gateway",
+		"version":     version,
+		"listen_addr": ":8080",
+		"log_level":   "DEBUG",
+	}
+	for k, v := range want {
+		if entry[k] != v {
+			t.Errorf("%s = %v, want %v", k, entry[k], v)
+		}
+	}
+	if backends, _ := entry["backends"].(map[string]any); backends["user"] != "user-ms:50051" {
+		t.Errorf("backends = %v", entry["backends"])
+	}
+	if features, _ := entry["features"].(map[string]any); features["admin_api"] != true {
+		t.Errorf("features = %v", entry["features"])
+	}
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"api-gateway/notifpb"
+)
+
+func TestAdminTemplates(t *testing.T) {
+	stored := map[string]*notifpb.Template{}
+	notif := &fakeNotifClient{
+		listTemplates: func(*notifpb.ListTemplatesRequest) (*notifpb.ListTemplatesResponse, error) {
+			res := &notifpb.ListTemplatesResponse{}
+			for _, tmpl := range stored {
+				res.Templates = append(res.Templates, tmpl)
+			}
+			return res, nil
+		},
+		putTemplate: func(in *notifpb.PutTemplateRequest) (*notifpb.PutTemplateResponse, error) {
+			if in.Body == "{{" {
+				return nil, status.Error(codes.InvalidArgument, "template: unclosed action")
+			}
+			tmpl := &notifpb.Template{MessageId: in.MessageId, Locale: in.Locale, Body: in.Body}
+			stored[in.MessageId+"/"+in.Locale] = tmpl
+			return &notifpb.PutTemplateResponse{Template: tmpl}, nil
+		},
+		deleteTemplate: func(in *notifpb.DeleteTemplateRequest) (*notifpb.DeleteTemplateResponse, error) {
+			key := in.MessageId + "/" + in.Locale
+			if stored[key] == nil {
+				return nil, status.Error(codes.NotFound, "template not found")
+			}
+			delete(stored, key)
+			return &notifpb.DeleteTemplateResponse{}, nil
+		},
+	}
+	s := newTestServer(t, testConfig(), nil, nil, notif)
+
+	w := serveAdmin(s, http.MethodPut, "/admin/templates/bill.updated/de", `{"body":"Neue Rechnung: {{.amount}}"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("put: status %d, body %s", w.Code, w.Body)
+	}
+	if tmpl, _ := decodeBody(t, w)["template"].(map[string]any); tmpl["locale"] != "de" {
+		t.Errorf("put response template = %v", tmpl)
+	}
+
+	w = serveAdmin(s, http.MethodPut, "/admin/templates/bill.updated/de", `{"body":"{{"}`)
+	if w.Code != http.StatusBadRequest || decodeBody(t, w)["error"] != "template: unclosed action" {
+		t.Errorf("invalid template: status %d, body %s; want 400 with the parse error", w.Code, w.Body)
+	}
+
+	w = serveAdmin(s, http.MethodGet, "/admin/templates", "")
+	if templates, _ := decodeBody(t, w)["templates"].([]any); w.Code != http.StatusOK || len(templates) != 1 {
+		t.Errorf("list: status %d, body %s; want one template", w.Code, w.Body)
+	}
+
+	if w := serveAdmin(s, http.MethodDelete, "/admin/templates/bill.updated/de", ""); w.Code != http.StatusNoContent {
+		t.Errorf("delete: status %d, want 204", w.Code)
+	}
+	if w := serveAdmin(s, http.MethodDelete, "/admin/templates/bill.updated/de", ""); w.Code != http.StatusNotFound {
+		t.Errorf("delete again: status %d, want 404", w.Code)
+	}
+}
+
+func TestAdminTemplatesHideBackendFailures(t *testing.T) {
+	notif := &fakeNotifClient{
+		listTemplates: func(*notifpb.ListTemplatesRequest) (*notifpb.ListTemplatesResponse, error) {
+			return nil, internalErr
+		},
+		putTemplate: func(*notifpb.PutTemplateRequest) (*notifpb.PutTemplateResponse, error) {
+			return nil, internalErr
+		},
+		deleteTemplate: func(*notifpb.DeleteTemplateRequest) (*notifpb.DeleteTemplateResponse, error) {
+			return nil, status.Error(codes.Unavailable, "dial tcp 10.0.0.9:50053: connection refused")
+		},
+	}
+	s := newTestServer(t, testConfig(), nil, nil, notif)
+
+	for _, tt := range []struct {
+		method, target, body string
+		want                 int
+	}{
+		{http.MethodGet, "/admin/templates", "", http.StatusInternalServerError},
+		{http.MethodPut, "/admin/templates/bill.updated/de", `{"body":"x"}`, http.StatusInternalServerError},
+		{http.MethodDelete, "/admin/templates/bill.updated/de", "", http.StatusServiceUnavailable},
+	} {
+		w := serveAdmin(s, tt.method, tt.target, tt.body)
+		if w.Code != tt.want || containsAny(w.Body.String(), "pq:", "10.0.0.9") {
+			t.Errorf("%s %s: status %d, body %s; want %d without the backend error", tt.method, tt.target, w.Code, w.Body, tt.want)
+		}
+	}
+}
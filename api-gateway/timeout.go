@@ -0,0 +1,15 @@
+package main
+
+import (
+	"context"
+	"net/http"
+)
+
+// callContext derives the context for one outbound gRPC call from the
+// request context, bounded by cfg.grpcTimeout.
+func (s *apiServer) callContext(r *http.Request) (context.Context, context.CancelFunc) {
+	if s.cfg.grpcTimeout <= 0 {
+		return context.WithCancel(r.Context())
+	}
+	return context.WithTimeout(r.Context(), s.cfg.grpcTimeout)
+}
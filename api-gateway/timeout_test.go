@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+
+	"api-gateway/billingpb"
+	"api-gateway/userpb"
+)
+
+// hungBackend blocks every call until its context ends and then fails the
+// way a gRPC client does when the deadline passes.
+func hungBackend(ctx context.Context) error {
+	<-ctx.Done()
+	return status.FromContextError(ctx.Err()).Err()
+}
+
+type hungBillingClient struct{ fakeBillingClient }
+
+func (hungBillingClient) GetBilling(ctx context.Context, _ *billingpb.GetBillingRequest, _ ...grpc.CallOption) (*billingpb.GetBillingResponse, error) {
+	return nil, hungBackend(ctx)
+}
+
+type hungUserClient struct{ fakeUserClient }
+
+func (hungUserClient) Register(ctx context.Context, _ *userpb.RegisterRequest, _ ...grpc.CallOption) (*userpb.RegisterResponse, error) {
+	return nil, hungBackend(ctx)
+}
+
+func TestHungBackendTimesOutWith504(t *testing.T) {
+	t.Setenv("GRPC_TIMEOUT", "50ms")
+	cfg := testConfig()
+	if cfg.grpcTimeout != 50*time.Millisecond {
+		t.Fatalf("grpcTimeout = %s, want GRPC_TIMEOUT", cfg.grpcTimeout)
+	}
+	s := newTestServer(t, cfg, nil, nil, nil)
+	s.userClient = &hungUserClient{}
+	s.billingClient = &hungBillingClient{}
+
+	for _, tt := range []struct {
+		name, method, path, body, token string
+	}{
+		{"GetBilling", http.MethodGet, "/user/billing/" + aliceID, "", tokenFor(aliceID)},
+		{"Register", http.MethodPost, "/register", `{"email":"alice@example.com","password":"secret123"}`, ""},
+	} {
+		start := time.Now()
+		w := serve(s, tt.method, tt.path, tt.body, tt.token)
+		if w.Code != http.StatusGatewayTimeout {
+			t.Errorf("%s: status %d, want 504", tt.name, w.Code)
+			continue
+		}
+		if msg := decodeBody(t, w)["error"]; msg != "upstream service timed out" {
+			t.Errorf("%s: error %v", tt.name, msg)
+		}
+		if elapsed := time.Since(start); elapsed > 2*time.Second {
+			t.Errorf("%s: answered after %s, want it bounded by GRPC_TIMEOUT", tt.name, elapsed)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// secureCipherSuites are the TLS 1.2 suites we allow: ECDHE key exchange with
+// AEAD ciphers only. TLS 1.3 suites are not configurable and are all secure.
+var secureCipherSuites = []uint16{
+	tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+	tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+	tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
+	tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
+	tls.TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305_SHA256,
+	tls.TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305_SHA256,
+}
+
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// newTLSConfig returns the TLS policy shared by all services: the minimum
+// protocol version comes from TLS_MIN_VERSION (default 1.2) and only the
+// suites in secureCipherSuites are offered.
+func newTLSConfig() (*tls.Config, error) {
+	minVersion := os.Getenv("TLS_MIN_VERSION")
+	if minVersion == "" {
+		minVersion = "1.2"
+	}
+	version, ok := tlsVersions[minVersion]
+	if !ok {
+		return nil, fmt.Errorf("unsupported TLS_MIN_VERSION %q", minVersion)
+	}
+
+	return &tls.Config{
+		MinVersion:   version,
+		CipherSuites: secureCipherSuites,
+		// Ignored by Go 1.18+, which orders suites itself, but kept so the
+		// intent survives if this config is ever handed to another stack.
+		PreferServerCipherSuites: true,
+	}, nil
+}
+
+// grpcClientCredentials returns TLS credentials for the backend connections
+// when GRPC_TLS_CA_FILE is set, and insecure credentials otherwise.
+func grpcClientCredentials() (credentials.TransportCredentials, error) {
+	caFile := os.Getenv("GRPC_TLS_CA_FILE")
+	if caFile == "" {
+		return insecure.NewCredentials(), nil
+	}
+
+	cfg, err := newTLSConfig()
+	if err != nil {
+		return nil, err
+	}
+	pem, err := os.ReadFile(caFile)
+	if err != nil {
+		return nil, fmt.Errorf("read CA file: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no certificates found in %s", caFile)
+	}
+	cfg.RootCAs = pool
+	return credentials.NewTLS(cfg), nil
+}
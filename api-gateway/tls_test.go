@@ -0,0 +1,116 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"io"
+	"log"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// testCertificate returns a self-signed certificate for localhost.
+func testCertificate(t *testing.T) tls.Certificate {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		DNSNames:     []string{"localhost"},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+// startTLSGateway serves the gateway over HTTPS with the shared TLS policy.
+func startTLSGateway(t *testing.T) *httptest.Server {
+	t.Helper()
+	cfg, err := newTLSConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.Certificates = []tls.Certificate{testCertificate(t)}
+	srv := httptest.NewUnstartedServer(newTestServer(t, testConfig(), nil, nil, nil))
+	srv.TLS = cfg
+	srv.Config.ErrorLog = log.New(io.Discard, "", 0)
+	srv.StartTLS()
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestTLSPolicy(t *testing.T) {
+	srv := startTLSGateway(t)
+	tests := []struct {
+		name   string
+		client *tls.Config
+		ok     bool
+	}{
+		{"TLS 1.0 client", &tls.Config{MinVersion: tls.VersionTLS10, MaxVersion: tls.VersionTLS10}, false},
+		{"TLS 1.1 client", &tls.Config{MinVersion: tls.VersionTLS10, MaxVersion: tls.VersionTLS11}, false},
+		{"TLS 1.2 client with CBC suites only", &tls.Config{MaxVersion: tls.VersionTLS12, CipherSuites: []uint16{tls.TLS_ECDHE_ECDSA_WITH_AES_128_CBC_SHA}}, false},
+		{"TLS 1.2 client", &tls.Config{MinVersion: tls.VersionTLS12, MaxVersion: tls.VersionTLS12}, true},
+		{"TLS 1.3 client", &tls.Config{MinVersion: tls.VersionTLS13}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.client.InsecureSkipVerify = true
+			client := &http.Client{Transport: &http.Transport{TLSClientConfig: tt.client}, Timeout: 5 * time.Second}
+			res, err := client.Get(srv.URL + "/healthz")
+			if !tt.ok {
+				if err == nil {
+					res.Body.Close()
+					t.Fatalf("handshake succeeded with %s", tls.VersionName(res.TLS.Version))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+			res.Body.Close()
+			if res.StatusCode != http.StatusOK {
+				t.Errorf("status %d", res.StatusCode)
+			}
+		})
+	}
+}
+
+func TestNewTLSConfig(t *testing.T) {
+	cfg, err := newTLSConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.MinVersion != tls.VersionTLS12 {
+		t.Errorf("default MinVersion = %s, want TLS 1.2", tls.VersionName(cfg.MinVersion))
+	}
+	for _, id := range cfg.CipherSuites {
+		for _, insecure := range tls.InsecureCipherSuites() {
+			if id == insecure.ID {
+				t.Errorf("insecure suite %s allowed", insecure.Name)
+			}
+		}
+	}
+
+	t.Setenv("TLS_MIN_VERSION", "1.3")
+	if cfg, err := newTLSConfig(); err != nil || cfg.MinVersion != tls.VersionTLS13 {
+		t.Errorf("TLS_MIN_VERSION=1.3: %v, %v", cfg, err)
+	}
+	t.Setenv("TLS_MIN_VERSION", "1.4")
+	if _, err := newTLSConfig(); err == nil {
+		t.Error("unsupported TLS_MIN_VERSION accepted")
+	}
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"api-gateway/billingpb"
+)
+
+func TestOverLongURLRejected(t *testing.T) {
+	cfg := testConfig()
+	cfg.maxURLLength = 100
+	calls := 0
+	billing := &fakeBillingClient{getBilling: func(*billingpb.GetBillingRequest) (*billingpb.GetBillingResponse, error) {
+		calls++
+		return &billingpb.GetBillingResponse{}, nil
+	}}
+	s := newTestServer(t, cfg, nil, billing, nil)
+	h := s.limitURLLength(corsMiddleware(s))
+	path := "/user/billing/" + aliceID
+
+	if w := serve(h, http.MethodGet, path+"?cursor=abc", "", tokenFor(aliceID)); w.Code != http.StatusOK {
+		t.Fatalf("normal request: status %d, body %s", w.Code, w.Body)
+	}
+	w := serve(h, http.MethodGet, path+"?cursor="+strings.Repeat("a", 100), "", tokenFor(aliceID))
+	if w.Code != http.StatusRequestURITooLong {
+		t.Errorf("over-long query: status %d, want 414", w.Code)
+	}
+	if calls != 1 {
+		t.Errorf("billing called %d times, want only for the normal request", calls)
+	}
+
+	// Zero disables the limit.
+	s.cfg.maxURLLength = 0
+	if w := serve(h, http.MethodGet, path+"?cursor="+strings.Repeat("a", 100), "", tokenFor(aliceID)); w.Code != http.StatusOK {
+		t.Errorf("limit disabled: status %d", w.Code)
+	}
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+
+	"api-gateway/userpb"
+)
+
+func TestSetUsernameRequiresOwnAccount(t *testing.T) {
+	var got *userpb.SetUsernameRequest
+	user := &fakeUserClient{setUsername: func(in *userpb.SetUsernameRequest) (*userpb.SetUsernameResponse, error) {
+		got = in
+		return &userpb.SetUsernameResponse{}, nil
+	}}
+	s := newTestServer(t, testConfig(), user, nil, nil)
+	body := `{"user_id":"` + aliceID + `","username":"alice"}`
+
+	if w := serve(s, http.MethodPost, "/user/username", body, ""); w.Code != http.StatusUnauthorized {
+		t.Errorf("anonymous: status %d, want 401", w.Code)
+	}
+	if w := serve(s, http.MethodPost, "/user/username", body, tokenFor(bobID)); w.Code != http.StatusForbidden {
+		t.Errorf("other user: status %d, want 403", w.Code)
+	}
+	if got != nil {
+		t.Fatalf("SetUsername called for a rejected request: %+v", got)
+	}
+
+	if w := serve(s, http.MethodPost, "/user/username", body, tokenFor(aliceID)); w.Code != http.StatusOK {
+		t.Fatalf("own account: status %d, body %s", w.Code, w.Body)
+	}
+	if got.GetUserId() != aliceID || got.GetUsername() != "alice" {
+		t.Errorf("SetUsername request = %+v", got)
+	}
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"google.golang.org/grpc"
+
+	"api-gateway/billingpb"
+	"api-gateway/notifpb"
+)
+
+func TestValidUUID(t *testing.T) {
+	tests := []struct {
+		id   string
+		want bool
+	}{
+		{aliceID, true},
+		{strings.ToUpper(aliceID), true},
+		{"", false},
+		{"alice", false},
+		{"11111111-1111-4111-8111-11111111111", false},
+		{"11111111-1111-4111-8111-1111111111111", false},
+		{"11111111111141118111111111111111", false},
+		{"{" + aliceID + "}", false},
+		{"urn:uuid:" + aliceID, false},
+		{"11111111-1111-4111-8111-11111111111g", false},
+		{strings.Repeat("a", 10000), false},
+	}
+	for _, tt := range tests {
+		if got := validUUID(tt.id); got != tt.want {
+			t.Errorf("validUUID(%.40q) = %v, want %v", tt.id, got, tt.want)
+		}
+	}
+}
+
+func TestBillingHandlersRejectMalformedUserIDs(t *testing.T) {
+	calls := 0
+	billing := &fakeBillingClient{
+		getBilling: func(in *billingpb.GetBillingRequest) (*billingpb.GetBillingResponse, error) {
+			calls++
+			return &billingpb.GetBillingResponse{}, nil
+		},
+		updateBilling: func(in *billingpb.UpdateBillingRequest) (*billingpb.UpdateBillingResponse, error) {
+			calls++
+			return &billingpb.UpdateBillingResponse{}, nil
+		},
+		recalculateBilling: func(in *billingpb.RecalculateBillingRequest) (*billingpb.RecalculateBillingResponse, error) {
+			calls++
+			return &billingpb.RecalculateBillingResponse{}, nil
+		},
+	}
+	s := newTestServer(t, testConfig(), nil, billing, nil)
+	token := tokenFor(aliceID)
+
+	for _, bad := range []string{"alice", "1%27%20OR%201=1", strings.Repeat("a", 2000)} {
+		if w := serve(s, http.MethodGet, "/user/billing/"+bad, "", token); w.Code != http.StatusBadRequest {
+			t.Errorf("GET billing for %.20q: status %d, want 400", bad, w.Code)
+		}
+		body := `{"user_id":"` + bad + `","amount":1}`
+		if w := serve(s, http.MethodPost, "/user/billing/update", body, token); w.Code != http.StatusBadRequest {
+			t.Errorf("update for %.20q: status %d, want 400", bad, w.Code)
+		}
+		body = `{"user_id":"` + bad + `"}`
+		if w := serve(s, http.MethodPost, "/user/billing/recalculate", body, token); w.Code != http.StatusBadRequest {
+			t.Errorf("recalculate for %.20q: status %d, want 400", bad, w.Code)
+		}
+	}
+	if calls != 0 {
+		t.Fatalf("billing-ms called %d times for malformed ids", calls)
+	}
+
+	if w := serve(s, http.MethodGet, "/user/billing/"+aliceID, "", token); w.Code != http.StatusOK {
+		t.Errorf("GET billing for a valid id: status %d, body %s", w.Code, w.Body)
+	}
+	if w := serve(s, http.MethodPost, "/user/billing/update", `{"user_id":"`+aliceID+`","amount":1}`, token); w.Code != http.StatusOK {
+		t.Errorf("update for a valid id: status %d, body %s", w.Code, w.Body)
+	}
+	if calls != 2 {
+		t.Errorf("billing-ms called %d times for valid ids, want 2", calls)
+	}
+}
+
+func TestWebSocketRejectsMalformedUserID(t *testing.T) {
+	subscribed := false
+	notif := &fakeNotifClient{subscribe: func(context.Context, *notifpb.SubscribeRequest) (grpc.ServerStreamingClient[notifpb.NotificationBatch], error) {
+		subscribed = true
+		return nil, nil
+	}}
+	s := newTestServer(t, testConfig(), nil, nil, notif)
+
+	r := httptest.NewRequest(http.MethodGet, "/ws?token="+tokenFor(aliceID)+"&userId=not-a-uuid", nil)
+	r.Header.Set("Connection", "Upgrade")
+	r.Header.Set("Upgrade", "websocket")
+	r.Header.Set("Sec-WebSocket-Version", "13")
+	r.Header.Set("Sec-WebSocket-Key", "dGhlIHNhbXBsZSBub25jZQ==")
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, r)
+
+	if w.Code < 400 || w.Code >= 500 {
+		t.Errorf("status %d, want the upgrade rejected with a 4xx", w.Code)
+	}
+	if subscribed {
+		t.Error("subscribed to notifications for a malformed userId")
+	}
+}
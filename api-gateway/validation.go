@@ -0,0 +1,125 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"net/mail"
+	"regexp"
+	"strconv"
+	"unicode/utf8"
+
+	"api-gateway/billingpb"
+	"api-gateway/notifpb"
+	"api-gateway/userpb"
+)
+
+// localePattern loosely matches a BCP 47 language tag such as "en" or "pt-BR".
+var localePattern = regexp.MustCompile(`^[A-Za-z]{2,3}(-[A-Za-z0-9]{2,8})*$`)
+
+// usernamePattern mirrors user-ms: usernames never contain "@", which keeps
+// them distinguishable from email addresses at login.
+var usernamePattern = regexp.MustCompile(`^[A-Za-z0-9_.-]{3,32}$`)
+
+const usernameMessage = "must be 3-32 letters, digits, '.', '_' or '-'"
+
+// fieldError describes one problem with a request body field.
+type fieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// fieldErrors collects every validation problem in a request body so they can
+// be reported together instead of one at a time.
+type fieldErrors []fieldError
+
+// check records message for field when ok is false.
+func (e *fieldErrors) check(ok bool, field, message string) {
+	if !ok {
+		*e = append(*e, fieldError{Field: field, Message: message})
+	}
+}
+
+// validateRegister checks a registration request; minPasswordLength counts
+// characters, not bytes, as user-ms does.
+func validateRegister(req *userpb.RegisterRequest, minPasswordLength int) fieldErrors {
+	var errs fieldErrors
+	if req.Email == "" {
+		errs.check(false, "email", "is required")
+	} else {
+		_, err := mail.ParseAddress(req.Email)
+		errs.check(err == nil, "email", "is not a valid email address")
+	}
+	if req.Password == "" {
+		errs.check(false, "password", "is required")
+	} else {
+		errs.check(utf8.RuneCountInString(req.Password) >= minPasswordLength, "password", fmt.Sprintf("must be at least %d characters", minPasswordLength))
+	}
+	if req.Locale != "" {
+		errs.check(localePattern.MatchString(req.Locale), "locale", "is not a valid language tag")
+	}
+	if req.Username != "" {
+		errs.check(usernamePattern.MatchString(req.Username), "username", usernameMessage)
+	}
+	return errs
+}
+
+func validateSetUsername(req *userpb.SetUsernameRequest) fieldErrors {
+	var errs fieldErrors
+	errs.check(validUUID(req.UserId), "user_id", "must be a valid UUID")
+	errs.check(usernamePattern.MatchString(req.Username), "username", usernameMessage)
+	return errs
+}
+
+func validateUpdateBilling(req *billingpb.UpdateBillingRequest) fieldErrors {
+	var errs fieldErrors
+	errs.check(validUUID(req.UserId), "user_id", "must be a valid UUID")
+	errs.check(!math.IsNaN(req.Amount) && !math.IsInf(req.Amount, 0), "amount", "must be a finite number")
+	errs.check(req.Amount >= 0, "amount", "must not be negative")
+	return errs
+}
+
+// parseHistoryQuery reads the optional limit and offset query parameters of
+// the notification history route; notification-ms applies the defaults.
+func parseHistoryQuery(r *http.Request) (*notifpb.HistoryRequest, fieldErrors) {
+	var errs fieldErrors
+	req := &notifpb.HistoryRequest{UserId: r.PathValue("user_id")}
+	errs.check(validUUID(req.UserId), "user_id", "must be a valid UUID")
+	parsePaging(r, &errs, &req.Limit, &req.Offset)
+	return req, errs
+}
+
+// parseListStreamsQuery reads the optional limit and offset query parameters
+// of the stream listing; notification-ms applies the defaults.
+func parseListStreamsQuery(r *http.Request) (*notifpb.ListStreamsRequest, fieldErrors) {
+	var errs fieldErrors
+	req := &notifpb.ListStreamsRequest{}
+	parsePaging(r, &errs, &req.Limit, &req.Offset)
+	return req, errs
+}
+
+// parsePaging reads the optional limit and offset query parameters of a
+// paginated route into limit and offset.
+func parsePaging(r *http.Request, errs *fieldErrors, limit, offset *int32) {
+	for _, param := range []struct {
+		name string
+		dst  *int32
+	}{{"limit", limit}, {"offset", offset}} {
+		v := r.URL.Query().Get(param.name)
+		if v == "" {
+			continue
+		}
+		n, err := strconv.ParseInt(v, 10, 32)
+		errs.check(err == nil && n >= 0, param.name, "must be a non-negative integer")
+		*param.dst = int32(n)
+	}
+}
+
+// writeValidationError responds with 400 and every collected problem in the
+// error's details.
+func (s *apiServer) writeValidationError(w http.ResponseWriter, errs fieldErrors) {
+	s.writeJSON(w, http.StatusBadRequest, map[string]any{
+		"error":   "request validation failed",
+		"details": errs,
+	})
+}
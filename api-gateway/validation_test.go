@@ -0,0 +1,72 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"slices"
+	"testing"
+
+	"api-gateway/billingpb"
+	"api-gateway/userpb"
+)
+
+// validationDetails returns the fields named in a validation error response.
+func validationDetails(t *testing.T, body []byte) []string {
+	t.Helper()
+	var res struct {
+		Error   string       `json:"error"`
+		Details []fieldError `json:"details"`
+	}
+	if err := json.Unmarshal(body, &res); err != nil {
+		t.Fatal(err)
+	}
+	if res.Error != "request validation failed" {
+		t.Errorf("error = %q", res.Error)
+	}
+	var fields []string
+	for _, d := range res.Details {
+		if d.Message == "" {
+			t.Errorf("no message for %s", d.Field)
+		}
+		fields = append(fields, d.Field)
+	}
+	return fields
+}
+
+func TestRegisterReportsEveryProblem(t *testing.T) {
+	user := &fakeUserClient{register: func(*userpb.RegisterRequest) (*userpb.RegisterResponse, error) {
+		t.Error("Register called for an invalid body")
+		return nil, nil
+	}}
+	s := newTestServer(t, testConfig(), user, nil, nil)
+
+	w := serve(s, http.MethodPost, "/register", `{"email":"not-an-email","password":"short","locale":"english!","username":"a@b"}`, "")
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("status %d, body %s", w.Code, w.Body)
+	}
+	fields := validationDetails(t, w.Body.Bytes())
+	if !slices.Equal(fields, []string{"email", "password", "locale", "username"}) {
+		t.Errorf("details name %v, want every invalid field", fields)
+	}
+
+	w = serve(s, http.MethodPost, "/register", `{}`, "")
+	if fields := validationDetails(t, w.Body.Bytes()); !slices.Equal(fields, []string{"email", "password"}) {
+		t.Errorf("empty body: details name %v, want email and password", fields)
+	}
+}
+
+func TestUpdateBillingReportsEveryProblem(t *testing.T) {
+	billing := &fakeBillingClient{updateBilling: func(*billingpb.UpdateBillingRequest) (*billingpb.UpdateBillingResponse, error) {
+		t.Error("UpdateBilling called for an invalid body")
+		return nil, nil
+	}}
+	s := newTestServer(t, testConfig(), nil, billing, nil)
+
+	w := serve(s, http.MethodPost, "/user/billing/update", `{"user_id":"nope","amount":-5}`, tokenFor(aliceID))
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("status %d, body %s", w.Code, w.Body)
+	}
+	if fields := validationDetails(t, w.Body.Bytes()); !slices.Equal(fields, []string{"user_id", "amount"}) {
+		t.Errorf("details name %v, want user_id and amount", fields)
+	}
+}
@@ -0,0 +1,356 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"api-gateway/notifpb"
+)
+
+// dialWebSocket opens a notification WebSocket to srv as userID.
+func dialWebSocket(t *testing.T, srv *httptest.Server, userID string) *websocket.Conn {
+	t.Helper()
+	url := "ws" + strings.TrimPrefix(srv.URL, "http") + "/ws?token=" + tokenFor(userID)
+	conn, res, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		t.Fatalf("dial: %v (response %v)", err, res)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func TestWebSocketMaxLifetime(t *testing.T) {
+	streams := make(chan *fakeStream, 1)
+	notif := &fakeNotifClient{subscribe: func(ctx context.Context, _ *notifpb.SubscribeRequest) (grpc.ServerStreamingClient[notifpb.NotificationBatch], error) {
+		stream := newFakeStream(ctx)
+		streams <- stream
+		return stream, nil
+	}}
+	cfg := testConfig()
+	cfg.wsMaxLifetime = 100 * time.Millisecond
+	srv := httptest.NewServer(newTestServer(t, cfg, nil, nil, notif))
+	defer srv.Close()
+
+	start := time.Now()
+	conn := dialWebSocket(t, srv, aliceID)
+	stream := <-streams
+
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	_, _, err := conn.ReadMessage()
+	var closeErr *websocket.CloseError
+	if !errors.As(err, &closeErr) {
+		t.Fatalf("read error %v, want a close frame", err)
+	}
+	if closeErr.Code != websocket.CloseServiceRestart || closeErr.Text != "please reconnect" {
+		t.Errorf("closed with %d %q, want %d \"please reconnect\"", closeErr.Code, closeErr.Text, websocket.CloseServiceRestart)
+	}
+	if elapsed := time.Since(start); elapsed < cfg.wsMaxLifetime {
+		t.Errorf("closed after %v, before the %v lifetime", elapsed, cfg.wsMaxLifetime)
+	}
+
+	select {
+	case <-stream.ctx.Done():
+	case <-time.After(5 * time.Second):
+		t.Error("gRPC stream not cancelled after the lifetime")
+	}
+}
+
+func TestWebSocketWithoutLifetimeStaysOpen(t *testing.T) {
+	streams := make(chan *fakeStream, 1)
+	notif := &fakeNotifClient{subscribe: func(ctx context.Context, _ *notifpb.SubscribeRequest) (grpc.ServerStreamingClient[notifpb.NotificationBatch], error) {
+		stream := newFakeStream(ctx)
+		streams <- stream
+		return stream, nil
+	}}
+	cfg := testConfig()
+	cfg.wsMaxLifetime = 0
+	srv := httptest.NewServer(newTestServer(t, cfg, nil, nil, notif))
+	defer srv.Close()
+
+	conn := dialWebSocket(t, srv, aliceID)
+	stream := <-streams
+	time.Sleep(200 * time.Millisecond)
+	stream.batches <- &notifpb.NotificationBatch{Notifications: []*notifpb.Notification{{Id: "n1", UserId: aliceID, Message: "hello"}}}
+
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	_, data, err := conn.ReadMessage()
+	if err != nil {
+		t.Fatalf("connection closed without a lifetime: %v", err)
+	}
+	if !strings.Contains(string(data), `"hello"`) {
+		t.Errorf("received %s", data)
+	}
+}
+
+// instantClock fires every After immediately and records the durations asked
+// for.
+type instantClock struct {
+	realClock
+	mu    sync.Mutex
+	waits []time.Duration
+}
+
+func (c *instantClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	c.waits = append(c.waits, d)
+	c.mu.Unlock()
+	ch := make(chan time.Time, 1)
+	ch <- time.Now()
+	return ch
+}
+
+func TestWebSocketReportsUnavailableNotificationService(t *testing.T) {
+	var attempts atomic.Int32
+	notif := &fakeNotifClient{subscribe: func(context.Context, *notifpb.SubscribeRequest) (grpc.ServerStreamingClient[notifpb.NotificationBatch], error) {
+		attempts.Add(1)
+		return nil, status.Error(codes.Unavailable, "connection refused")
+	}}
+	s := newTestServer(t, testConfig(), nil, nil, notif)
+	clock := &instantClock{}
+	s.clock = clock
+	srv := httptest.NewServer(s)
+	defer srv.Close()
+
+	conn := dialWebSocket(t, srv, aliceID)
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+
+	var frame map[string]string
+	if err := conn.ReadJSON(&frame); err != nil {
+		t.Fatalf("no error frame: %v", err)
+	}
+	if frame["error"] != "notification service unavailable" {
+		t.Errorf("error frame = %v", frame)
+	}
+	_, _, err := conn.ReadMessage()
+	var closeErr *websocket.CloseError
+	if !errors.As(err, &closeErr) || closeErr.Code != websocket.CloseTryAgainLater {
+		t.Errorf("read error %v, want close %d", err, websocket.CloseTryAgainLater)
+	}
+
+	if n := attempts.Load(); n != subscribeAttempts {
+		t.Errorf("subscribed %d times, want %d", n, subscribeAttempts)
+	}
+	clock.mu.Lock()
+	defer clock.mu.Unlock()
+	want := []time.Duration{subscribeBackoff, 2 * subscribeBackoff}
+	if !slices.Equal(clock.waits, want) {
+		t.Errorf("backoffs %v, want %v", clock.waits, want)
+	}
+}
+
+func TestWebSocketSubscribeRetrySucceeds(t *testing.T) {
+	var attempts atomic.Int32
+	notif := &fakeNotifClient{subscribe: func(ctx context.Context, _ *notifpb.SubscribeRequest) (grpc.ServerStreamingClient[notifpb.NotificationBatch], error) {
+		if attempts.Add(1) == 1 {
+			return nil, status.Error(codes.Unavailable, "connection refused")
+		}
+		stream := newFakeStream(ctx)
+		stream.batches <- &notifpb.NotificationBatch{Notifications: []*notifpb.Notification{{Id: "n1", UserId: aliceID, Message: "hello"}}}
+		return stream, nil
+	}}
+	s := newTestServer(t, testConfig(), nil, nil, notif)
+	s.clock = &instantClock{}
+	srv := httptest.NewServer(s)
+	defer srv.Close()
+
+	conn := dialWebSocket(t, srv, aliceID)
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	_, data, err := conn.ReadMessage()
+	if err != nil {
+		t.Fatalf("no notification after a retried subscribe: %v", err)
+	}
+	if !strings.Contains(string(data), `"hello"`) {
+		t.Errorf("received %s", data)
+	}
+	if n := attempts.Load(); n != 2 {
+		t.Errorf("subscribed %d times, want 2", n)
+	}
+}
+
+func TestWebSocketSerializesEnumsByName(t *testing.T) {
+	notif := &fakeNotifClient{subscribe: func(ctx context.Context, _ *notifpb.SubscribeRequest) (grpc.ServerStreamingClient[notifpb.NotificationBatch], error) {
+		stream := newFakeStream(ctx)
+		stream.batches <- &notifpb.NotificationBatch{Notifications: []*notifpb.Notification{{
+			Id:       "n1",
+			UserId:   aliceID,
+			Type:     notifpb.NotificationType_NOTIFICATION_TYPE_BILLING,
+			Severity: notifpb.Severity_SEVERITY_WARNING,
+		}}}
+		return stream, nil
+	}}
+	srv := httptest.NewServer(newTestServer(t, testConfig(), nil, nil, notif))
+	defer srv.Close()
+
+	conn := dialWebSocket(t, srv, aliceID)
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	var got map[string]any
+	if err := conn.ReadJSON(&got); err != nil {
+		t.Fatalf("no notification: %v", err)
+	}
+	if got["type"] != "NOTIFICATION_TYPE_BILLING" || got["severity"] != "SEVERITY_WARNING" {
+		t.Errorf("received %v, want enums by name", got)
+	}
+}
+
+// lockedBuffer is a bytes.Buffer safe to log to from handler goroutines while
+// a test reads it.
+type lockedBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+// snapshot returns a copy of everything written so far.
+func (b *lockedBuffer) snapshot() *bytes.Buffer {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return bytes.NewBuffer(bytes.Clone(b.buf.Bytes()))
+}
+
+func TestWebSocketSessionIDSentToNotificationService(t *testing.T) {
+	sessions := make(chan string, 2)
+	notif := &fakeNotifClient{subscribe: func(ctx context.Context, _ *notifpb.SubscribeRequest) (grpc.ServerStreamingClient[notifpb.NotificationBatch], error) {
+		md, _ := metadata.FromOutgoingContext(ctx)
+		sessions <- strings.Join(md.Get(sessionIDMetadataKey), ",")
+		return newFakeStream(ctx), nil
+	}}
+	var logs lockedBuffer
+	s := newAPIServer(&fakeUserClient{}, &fakeBillingClient{}, notif, nil, testConfig(), slog.New(slog.NewJSONHandler(&logs, nil)))
+	srv := httptest.NewServer(s)
+	defer srv.Close()
+
+	dialWebSocket(t, srv, aliceID)
+	first := <-sessions
+	dialWebSocket(t, srv, aliceID)
+	second := <-sessions
+	if first == "" || second == "" || first == second {
+		t.Fatalf("session ids %q and %q, want two distinct ids", first, second)
+	}
+
+	connected := map[string]bool{}
+	for _, line := range logLines(t, logs.snapshot()) {
+		if line["msg"] == "WebSocket connected" {
+			id, _ := line["session_id"].(string)
+			connected[id] = true
+		}
+	}
+	if !connected[first] || !connected[second] {
+		t.Errorf("connect logs for sessions %v, want %s and %s", connected, first, second)
+	}
+}
+
+func TestWebSocketResumesClientSessionID(t *testing.T) {
+	sessions := make(chan string, 2)
+	notif := &fakeNotifClient{subscribe: func(ctx context.Context, _ *notifpb.SubscribeRequest) (grpc.ServerStreamingClient[notifpb.NotificationBatch], error) {
+		md, _ := metadata.FromOutgoingContext(ctx)
+		sessions <- strings.Join(md.Get(sessionIDMetadataKey), ",")
+		return newFakeStream(ctx), nil
+	}}
+	srv := httptest.NewServer(newTestServer(t, testConfig(), nil, nil, notif))
+	defer srv.Close()
+	dial := func(sessionID string) {
+		url := "ws" + strings.TrimPrefix(srv.URL, "http") + "/ws?token=" + tokenFor(aliceID) + "&sessionId=" + sessionID
+		conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { conn.Close() })
+	}
+
+	const clientSession = "0b9a3f6e-5c2d-4e8f-9a1b-2c3d4e5f6a7b"
+	dial(clientSession)
+	if got := <-sessions; got != clientSession {
+		t.Errorf("session %q, want the client's %q", got, clientSession)
+	}
+	// Anything but a UUID is replaced by a generated id.
+	dial("not-a-uuid")
+	if got := <-sessions; got == "not-a-uuid" || !validUUID(got) {
+		t.Errorf("session %q, want a generated UUID", got)
+	}
+}
+
+func TestWebSocketRejectsUnauthorizedUpgrades(t *testing.T) {
+	h := newTestServer(t, testConfig(), nil, nil, nil)
+	tests := []struct {
+		name     string
+		target   string
+		header   http.Header
+		wantCode int
+	}{
+		{"no token", "/ws", nil, http.StatusUnauthorized},
+		{"invalid token", "/ws?token=forged", nil, http.StatusUnauthorized},
+		{"invalid bearer protocol token", "/ws", http.Header{"Sec-Websocket-Protocol": {"bearer, forged"}}, http.StatusUnauthorized},
+		{"another user's stream", "/ws?token=" + tokenFor(aliceID) + "&userId=" + bobID, nil, http.StatusForbidden},
+		// The origin is checked before the token, so a page on another origin
+		// learns nothing about whether a token is valid.
+		{"disallowed origin", "/ws?token=forged", http.Header{"Origin": {"https://evil.example"}}, http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			for key, values := range tt.header {
+				r.Header[key] = values
+			}
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+			if w.Code != tt.wantCode {
+				t.Fatalf("status %d, want %d: %s", w.Code, tt.wantCode, w.Body)
+			}
+			if msg, _ := decodeBody(t, w)["error"].(string); msg == "" {
+				t.Errorf("body %s has no error message", w.Body)
+			}
+		})
+	}
+}
+
+func TestWebSocketBearerProtocol(t *testing.T) {
+	users := make(chan string, 1)
+	notif := &fakeNotifClient{subscribe: func(ctx context.Context, in *notifpb.SubscribeRequest) (grpc.ServerStreamingClient[notifpb.NotificationBatch], error) {
+		users <- in.UserId
+		return newFakeStream(ctx), nil
+	}}
+	srv := httptest.NewServer(newTestServer(t, testConfig(), nil, nil, notif))
+	defer srv.Close()
+
+	// A matching userId is still accepted from older clients.
+	url := "ws" + strings.TrimPrefix(srv.URL, "http") + "/ws?userId=" + aliceID
+	dialer := websocket.Dialer{Subprotocols: []string{webSocketProtocolBearer, tokenFor(aliceID)}}
+	conn, res, err := dialer.Dial(url, nil)
+	if err != nil {
+		t.Fatalf("dial: %v (response %v)", err, res)
+	}
+	defer conn.Close()
+
+	if got := conn.Subprotocol(); got != webSocketProtocolBearer {
+		t.Errorf("selected protocol %q, want %q", got, webSocketProtocolBearer)
+	}
+	select {
+	case got := <-users:
+		if got != aliceID {
+			t.Errorf("subscribed to %q, want the token holder %q", got, aliceID)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("notifications never subscribed")
+	}
+}
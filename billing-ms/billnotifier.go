@@ -0,0 +1,191 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"google.golang.org/grpc"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// subjectBillUpdate carries bill changes to notification-ms.
+const subjectBillUpdate = "bill.update"
+
+// notificationHealthTimeout bounds a single health check of notification-ms.
+const notificationHealthTimeout = 2 * time.Second
+
+// billUpdateEvent is the payload of subjectBillUpdate. notification-ms renders
+// MessageID in the user's locale, or in Locale, the locale of the request that
+// changed the bill, when it has none stored for the user.
+type billUpdateEvent struct {
+	EventID   string `json:"event_id"`
+	Id        string
+	MessageID string
+	Params    map[string]string
+	Locale    string `json:"locale,omitempty"`
+}
+
+// newBillUpdateEvent describes a user's bill changing to amount in a request
+// made in locale.
+func newBillUpdateEvent(userID string, amount float64, locale string) billUpdateEvent {
+	event := billUpdateEvent{
+		EventID:   uuid.NewString(),
+		Id:        userID,
+		MessageID: "bill.updated",
+		Params:    map[string]string{"amount": fmt.Sprintf("%.2f", amount)},
+		Locale:    locale,
+	}
+	if amount > 100 {
+		event.MessageID = "bill.updated.high"
+	}
+	return event
+}
+
+// billNotifier publishes bill.update events. With coalescing enabled it
+// watches the health of notification-ms, and while notification-ms is down
+// it keeps only the latest change per user instead of publishing every one.
+// When notification-ms recovers, each affected user gets a single event with
+// their current amount. Held changes live in memory, so they are lost if
+// billing-ms restarts during the outage.
+type billNotifier struct {
+	logger *slog.Logger
+	events *publisher
+	health healthpb.HealthClient // nil when coalescing is disabled
+
+	mu       sync.Mutex
+	degraded bool
+	held     map[string]billUpdateEvent
+	heldFrom int // changes received since degraded, including replaced ones
+}
+
+// newBillNotifier enables coalescing when BILL_UPDATE_COALESCE is true. It
+// then checks notification-ms at NOTIFICATION_SERVICE_ADDR (default
+// notification-ms:50053) every BILL_UPDATE_HEALTH_INTERVAL (default 10s).
+func newBillNotifier(logger *slog.Logger, events *publisher) (*billNotifier, error) {
+	n := &billNotifier{logger: logger, events: events, held: make(map[string]billUpdateEvent)}
+	if enabled, _ := strconv.ParseBool(os.Getenv("BILL_UPDATE_COALESCE")); !enabled {
+		return n, nil
+	}
+
+	interval := 10 * time.Second
+	if v := os.Getenv("BILL_UPDATE_HEALTH_INTERVAL"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("invalid BILL_UPDATE_HEALTH_INTERVAL %q", v)
+		}
+		interval = d
+	}
+	addr := os.Getenv("NOTIFICATION_SERVICE_ADDR")
+	if addr == "" {
+		addr = "notification-ms:50053"
+	}
+	creds, err := grpcClientCredentials()
+	if err != nil {
+		return nil, err
+	}
+	conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(creds), grpc.WithStatsHandler(otelgrpc.NewClientHandler()))
+	if err != nil {
+		return nil, err
+	}
+	n.health = healthpb.NewHealthClient(conn)
+	go n.watch(interval)
+	return n, nil
+}
+
+// Coalescing reports whether the notifier watches notification-ms.
+func (n *billNotifier) Coalescing() bool {
+	return n.health != nil
+}
+
+// Notify publishes a bill.update for the user's new amount, tagged with the
+// locale the gateway forwarded in ctx, or holds it while notification-ms is
+// down.
+func (n *billNotifier) Notify(ctx context.Context, userID string, amount float64) error {
+	event := newBillUpdateEvent(userID, amount, incomingLocale(ctx))
+	n.mu.Lock()
+	if n.degraded {
+		n.held[userID] = event
+		n.heldFrom++
+		n.mu.Unlock()
+		return nil
+	}
+	n.mu.Unlock()
+	return n.publish(ctx, event)
+}
+
+func (n *billNotifier) publish(ctx context.Context, event billUpdateEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	return n.events.Publish(ctx, subjectBillUpdate, data)
+}
+
+// watch checks notification-ms every interval.
+func (n *billNotifier) watch(interval time.Duration) {
+	for {
+		n.checkHealth()
+		time.Sleep(interval)
+	}
+}
+
+// checkHealth asks notification-ms for its health and switches between
+// normal and coalesced publishing when it has changed.
+func (n *billNotifier) checkHealth() {
+	ctx, cancel := context.WithTimeout(context.Background(), notificationHealthTimeout)
+	res, err := n.health.Check(ctx, &healthpb.HealthCheckRequest{})
+	cancel()
+	healthy := err == nil && res.Status == healthpb.HealthCheckResponse_SERVING
+
+	n.mu.Lock()
+	switch {
+	case !healthy && !n.degraded:
+		n.degraded = true
+		n.mu.Unlock()
+		n.logger.Warn("notification-ms is unhealthy, coalescing bill.update events", "error", err)
+	case healthy && n.degraded:
+		n.mu.Unlock()
+		n.flush()
+	default:
+		n.mu.Unlock()
+	}
+}
+
+// flush publishes one event per user held during the outage. Changes that
+// arrive meanwhile are still held, since they are newer than the ones being
+// flushed; normal publishing resumes once nothing is left held, otherwise the
+// next healthy check flushes again. Events that fail to publish are held for
+// that next attempt unless a newer change replaced them.
+func (n *billNotifier) flush() {
+	n.mu.Lock()
+	held, received := n.held, n.heldFrom
+	n.held, n.heldFrom = make(map[string]billUpdateEvent), 0
+	n.mu.Unlock()
+
+	failed := 0
+	for userID, event := range held {
+		if err := n.publish(context.Background(), event); err != nil {
+			n.logger.Error("failed to publish coalesced bill.update", "user_id", userID, "error", err)
+			n.mu.Lock()
+			if _, newer := n.held[userID]; !newer {
+				n.held[userID] = event
+			}
+			n.mu.Unlock()
+			failed++
+		}
+	}
+
+	n.mu.Lock()
+	n.degraded = len(n.held) > 0
+	n.mu.Unlock()
+	n.logger.Info("notification-ms recovered, published coalesced bill.update events",
+		"changes", received, "events", len(held)-failed, "failed", failed)
+}
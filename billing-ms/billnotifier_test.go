@@ -0,0 +1,141 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// coalescingNotifier returns a billNotifier that watches hs as the health of
+// notification-ms, and a subscription to what it publishes.
+func coalescingNotifier(t *testing.T, hs *health.Server) (*billNotifier, *nats.Subscription) {
+	t.Helper()
+	lis := bufconn.Listen(1 << 16)
+	srv := grpc.NewServer()
+	healthpb.RegisterHealthServer(srv, hs)
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return lis.Dial() }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	nc, js := runJetStream(t)
+	events, err := newPublisher(nc, js)
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := &billNotifier{
+		logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+		events: events,
+		health: healthpb.NewHealthClient(conn),
+		held:   make(map[string]billUpdateEvent),
+	}
+	return n, subscribeSync(t, nc, subjectBillUpdate)
+}
+
+// nextBillUpdates reads bill.update events until none arrives for a while,
+// keyed by user.
+func nextBillUpdates(t *testing.T, sub *nats.Subscription) map[string][]billUpdateEvent {
+	t.Helper()
+	got := map[string][]billUpdateEvent{}
+	for {
+		m, err := sub.NextMsg(200 * time.Millisecond)
+		if err != nil {
+			return got
+		}
+		var event billUpdateEvent
+		if err := json.Unmarshal(m.Data, &event); err != nil {
+			t.Fatal(err)
+		}
+		got[event.Id] = append(got[event.Id], event)
+	}
+}
+
+func TestBillNotifierCoalescesDuringOutage(t *testing.T) {
+	hs := health.NewServer()
+	n, updates := coalescingNotifier(t, hs)
+	ctx := context.Background()
+
+	// Healthy: every change is published as it happens.
+	n.checkHealth()
+	n.Notify(ctx, "u1", 1)
+	if got := nextBillUpdates(t, updates); len(got["u1"]) != 1 {
+		t.Fatalf("healthy: published %v, want one event", got)
+	}
+
+	// Outage: changes are held, keeping only the latest per user.
+	hs.SetServingStatus("", healthpb.HealthCheckResponse_NOT_SERVING)
+	n.checkHealth()
+	for _, amount := range []float64{2, 3, 4} {
+		n.Notify(ctx, "u1", amount)
+	}
+	n.Notify(ctx, "u2", 7)
+	if got := nextBillUpdates(t, updates); len(got) != 0 {
+		t.Fatalf("during the outage: published %v, want nothing", got)
+	}
+
+	// Recovery: one event per user with the current amount.
+	hs.SetServingStatus("", healthpb.HealthCheckResponse_SERVING)
+	n.checkHealth()
+	got := nextBillUpdates(t, updates)
+	if len(got["u1"]) != 1 || got["u1"][0].Params["amount"] != "4.00" {
+		t.Errorf("u1 after recovery: %+v, want one event for 4.00", got["u1"])
+	}
+	if len(got["u2"]) != 1 || got["u2"][0].Params["amount"] != "7.00" {
+		t.Errorf("u2 after recovery: %+v, want one event for 7.00", got["u2"])
+	}
+
+	n.Notify(ctx, "u1", 5)
+	if got := nextBillUpdates(t, updates); len(got["u1"]) != 1 {
+		t.Errorf("after recovery: published %v, want normal publishing", got)
+	}
+}
+
+func TestBillNotifierUnreachableCountsAsOutage(t *testing.T) {
+	n, updates := coalescingNotifier(t, health.NewServer())
+
+	lis := bufconn.Listen(1 << 16)
+	lis.Close()
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return lis.Dial() }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	n.health = healthpb.NewHealthClient(conn)
+
+	n.checkHealth()
+	n.Notify(context.Background(), "u1", 1)
+	if got := nextBillUpdates(t, updates); len(got) != 0 {
+		t.Errorf("published %v while notification-ms is unreachable", got)
+	}
+}
+
+func TestBillNotifierCoalescingDisabledByDefault(t *testing.T) {
+	s, _ := newTestServer(t)
+	nc, _ := withPublisher(t, s)
+	updates := subscribeSync(t, nc, subjectBillUpdate)
+	if s.bills.Coalescing() {
+		t.Fatal("coalescing enabled without BILL_UPDATE_COALESCE")
+	}
+	s.bills.Notify(context.Background(), "u1", 1)
+	if got := nextBillUpdates(t, updates); len(got["u1"]) != 1 {
+		t.Errorf("published %v, want the event right away", got)
+	}
+}
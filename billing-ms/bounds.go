@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Default sanity bounds for the amount an update may set. They guard against
+// fat-fingered or malicious updates and are unrelated to account limits.
+const (
+	defaultMinAmount = 0
+	defaultMaxAmount = 1_000_000
+)
+
+// amountBounds is the global range UpdateBilling accepts.
+type amountBounds struct {
+	min, max float64
+}
+
+// loadAmountBounds reads BILLING_MIN_AMOUNT and BILLING_MAX_AMOUNT.
+func loadAmountBounds() (amountBounds, error) {
+	b := amountBounds{min: defaultMinAmount, max: defaultMaxAmount}
+	for _, setting := range []struct {
+		key string
+		dst *float64
+	}{{"BILLING_MIN_AMOUNT", &b.min}, {"BILLING_MAX_AMOUNT", &b.max}} {
+		v := os.Getenv(setting.key)
+		if v == "" {
+			continue
+		}
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return b, fmt.Errorf("invalid %s %q: %w", setting.key, v, err)
+		}
+		*setting.dst = f
+	}
+	if b.min > b.max {
+		return b, fmt.Errorf("BILLING_MIN_AMOUNT %.2f is greater than BILLING_MAX_AMOUNT %.2f", b.min, b.max)
+	}
+	return b, nil
+}
+
+// check returns InvalidArgument naming the violated bound. NaN compares false
+// against both bounds, so it is rejected on its own.
+func (b amountBounds) check(amount float64) error {
+	if math.IsNaN(amount) {
+		return status.Error(codes.InvalidArgument, "amount must be a number")
+	}
+	if amount < b.min {
+		return status.Errorf(codes.InvalidArgument, "amount %.2f is below the minimum of %.2f", amount, b.min)
+	}
+	if amount > b.max {
+		return status.Errorf(codes.InvalidArgument, "amount %.2f is above the maximum of %.2f", amount, b.max)
+	}
+	return nil
+}
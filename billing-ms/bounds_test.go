@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"billing-ms/billingpb"
+)
+
+func TestUpdateBillingAmountBounds(t *testing.T) {
+	t.Setenv("BILLING_MIN_AMOUNT", "1")
+	t.Setenv("BILLING_MAX_AMOUNT", "500")
+	bounds, err := loadAmountBounds()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		name   string
+		amount float64
+		want   string
+	}{
+		{"below the minimum", 0.5, "below the minimum of 1.00"},
+		{"above the maximum", 500.01, "above the maximum of 500.00"},
+		{"not a number", math.NaN(), "must be a number"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			s, _ := newTestServer(t)
+			s.bounds = bounds
+			// No database expectations: a rejected amount never opens a transaction.
+			_, err := s.UpdateBilling(context.Background(), &billingpb.UpdateBillingRequest{UserId: "u1", Amount: tt.amount})
+			wantCode(t, err, codes.InvalidArgument)
+			if msg := status.Convert(err).Message(); !strings.Contains(msg, tt.want) {
+				t.Errorf("message %q does not contain %q", msg, tt.want)
+			}
+		})
+	}
+
+	t.Run("within bounds", func(t *testing.T) {
+		s, mock := newTestServer(t)
+		s.bounds = bounds
+		withPublisher(t, s)
+		mock.ExpectBegin()
+		mock.ExpectQuery(literal(selectForUpdate)).WithArgs("u1").WillReturnRows(sqlmock.NewRows([]string{"amount", "version"}).AddRow(1.0, 1))
+		mock.ExpectExec(literal("UPDATE billing SET amount")).WithArgs(500.0, "u1").WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(literal("INSERT INTO billing_ledger")).WithArgs("u1", 499.0).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+		if _, err := s.UpdateBilling(context.Background(), &billingpb.UpdateBillingRequest{UserId: "u1", Amount: 500}); err != nil {
+			t.Errorf("amount at the maximum rejected: %v", err)
+		}
+	})
+}
+
+func TestLoadAmountBounds(t *testing.T) {
+	b, err := loadAmountBounds()
+	if err != nil || b.min != defaultMinAmount || b.max != defaultMaxAmount {
+		t.Errorf("defaults = %+v, %v", b, err)
+	}
+	t.Setenv("BILLING_MIN_AMOUNT", "10")
+	t.Setenv("BILLING_MAX_AMOUNT", "5")
+	if _, err := loadAmountBounds(); err == nil {
+		t.Error("minimum above the maximum accepted")
+	}
+	t.Setenv("BILLING_MAX_AMOUNT", "lots")
+	if _, err := loadAmountBounds(); err == nil {
+		t.Error("invalid BILLING_MAX_AMOUNT accepted")
+	}
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+
+	"billing-ms/billingpb"
+)
+
+func TestConcurrencyLimiterQueuesThenRejects(t *testing.T) {
+	t.Setenv("GRPC_MAX_IN_FLIGHT", "2")
+	t.Setenv("GRPC_MAX_QUEUED", "1")
+	l, err := newConcurrencyLimiter(billingpb.BillingService_UpdateBilling_FullMethodName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	info := &grpc.UnaryServerInfo{FullMethod: billingpb.BillingService_UpdateBilling_FullMethodName}
+	started := make(chan struct{}, 3)
+	release := make(chan struct{})
+	handler := func(context.Context, any) (any, error) {
+		started <- struct{}{}
+		<-release
+		return "ok", nil
+	}
+	call := func(ctx context.Context, errs chan<- error) {
+		_, err := l.UnaryInterceptor(ctx, nil, info, handler)
+		errs <- err
+	}
+
+	// Two calls fill the budget and a third waits in the queue.
+	errs := make(chan error, 3)
+	for range 2 {
+		go call(context.Background(), errs)
+		<-started
+	}
+	go call(context.Background(), errs)
+	waitFor(t, func() bool { return len(l.queue) == 1 })
+
+	// The queue is full, so the next call is turned away at once.
+	_, err = l.UnaryInterceptor(context.Background(), nil, info, handler)
+	wantCode(t, err, codes.ResourceExhausted)
+
+	// Once the running calls finish, the queued one runs too.
+	close(release)
+	for range 3 {
+		if err := <-errs; err != nil {
+			t.Errorf("admitted call failed: %v", err)
+		}
+	}
+	if len(started) != 1 {
+		t.Errorf("queued call ran %d times, want once", len(started))
+	}
+}
+
+func TestConcurrencyLimiterQueuedCallTimesOut(t *testing.T) {
+	t.Setenv("GRPC_MAX_IN_FLIGHT", "1")
+	t.Setenv("GRPC_MAX_QUEUED", "1")
+	l, err := newConcurrencyLimiter(billingpb.BillingService_GetBilling_FullMethodName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	info := &grpc.UnaryServerInfo{FullMethod: billingpb.BillingService_GetBilling_FullMethodName}
+	release := make(chan struct{})
+	defer close(release)
+	go l.UnaryInterceptor(context.Background(), nil, info, func(context.Context, any) (any, error) {
+		<-release
+		return nil, nil
+	})
+	waitFor(t, func() bool { return len(l.inFlight) == 1 })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err = l.UnaryInterceptor(ctx, nil, info, func(context.Context, any) (any, error) {
+		t.Error("handler ran without a free slot")
+		return nil, nil
+	})
+	wantCode(t, err, codes.DeadlineExceeded)
+	if len(l.queue) != 0 {
+		t.Error("timed out call still holds a queue slot")
+	}
+
+	// Other RPCs are not limited.
+	if _, err := l.UnaryInterceptor(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: billingpb.BillingService_Stats_FullMethodName},
+		func(context.Context, any) (any, error) { return nil, nil }); err != nil {
+		t.Errorf("unlimited RPC: %v", err)
+	}
+}
+
+// waitFor polls cond until it holds, failing the test after a second.
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
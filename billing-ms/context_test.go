@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+
+	"billing-ms/billingpb"
+)
+
+// TestQueriesStopWithTheCaller checks that a query outliving the caller's
+// deadline is abandoned instead of holding the RPC open. database/sql rolls
+// the transactions back itself once their context is done.
+func TestQueriesStopWithTheCaller(t *testing.T) {
+	tests := []struct {
+		name   string
+		expect func(sqlmock.Sqlmock)
+		call   func(context.Context, *server) error
+	}{
+		{
+			"GetBilling",
+			func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(literal("SELECT amount, version FROM billing")).WillDelayFor(time.Minute).
+					WillReturnRows(sqlmock.NewRows([]string{"amount", "version"}).AddRow(1.0, 1))
+			},
+			func(ctx context.Context, s *server) error {
+				_, err := s.GetBilling(ctx, &billingpb.GetBillingRequest{UserId: "u1"})
+				return err
+			},
+		},
+		{
+			"UpdateBilling",
+			func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(literal("FOR UPDATE")).WillDelayFor(time.Minute).
+					WillReturnRows(sqlmock.NewRows([]string{"amount", "version"}).AddRow(1.0, 1))
+			},
+			func(ctx context.Context, s *server) error {
+				_, err := s.UpdateBilling(ctx, &billingpb.UpdateBillingRequest{UserId: "u1", Amount: 5})
+				return err
+			},
+		},
+		{
+			"RecalculateBilling",
+			func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(literal("FOR UPDATE")).WillDelayFor(time.Minute).
+					WillReturnRows(sqlmock.NewRows([]string{"amount"}).AddRow(1.0))
+			},
+			func(ctx context.Context, s *server) error {
+				_, err := s.RecalculateBilling(ctx, &billingpb.RecalculateBillingRequest{UserId: "u1"})
+				return err
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, mock := newTestServer(t)
+			s.bounds = amountBounds{min: 0, max: 100}
+			tt.expect(mock)
+
+			ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+			defer cancel()
+			start := time.Now()
+			if err := tt.call(ctx, s); err == nil {
+				t.Fatal("call succeeded after its deadline")
+			}
+			if elapsed := time.Since(start); elapsed > time.Second {
+				t.Errorf("call returned after %s, want it to stop at the deadline", elapsed)
+			}
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"database/sql"
+	"log/slog"
+	"sync"
+)
+
+// eventCursor records the last JetStream stream sequence a consumer has
+// processed, so operators can see what was and wasn't handled and a restarted
+// consumer can resume right after it.
+type eventCursor struct {
+	db       *sql.DB
+	consumer string
+
+	mu   sync.Mutex
+	last uint64
+}
+
+// newEventCursor creates the cursor table if needed and loads the stored
+// position for consumer.
+func newEventCursor(db *sql.DB, consumer string) (*eventCursor, error) {
+	_, err := db.Exec(`CREATE TABLE IF NOT EXISTS event_cursors (consumer TEXT PRIMARY KEY, stream_seq BIGINT NOT NULL, updated_at TIMESTAMPTZ NOT NULL DEFAULT now())`)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &eventCursor{db: db, consumer: consumer}
+	err = db.QueryRow("SELECT stream_seq FROM event_cursors WHERE consumer = $1", consumer).Scan(&c.last)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Last returns the last processed stream sequence, or 0 if nothing has been
+// processed yet.
+func (c *eventCursor) Last() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.last
+}
+
+// Advance records seq as processed. Sequences at or below the current
+// position (redeliveries) are ignored, and a jump of more than one is logged
+// as a gap.
+func (c *eventCursor) Advance(seq uint64) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if seq <= c.last {
+		return nil
+	}
+	if c.last != 0 && seq > c.last+1 {
+		slog.Warn("event cursor gap detected", "consumer", c.consumer, "last_processed", c.last, "received", seq, "skipped", seq-c.last-1)
+	}
+
+	_, err := c.db.Exec(`INSERT INTO event_cursors (consumer, stream_seq, updated_at) VALUES ($1, $2, now())
+		ON CONFLICT (consumer) DO UPDATE SET stream_seq = EXCLUDED.stream_seq, updated_at = now()`, c.consumer, seq)
+	if err != nil {
+		return err
+	}
+	c.last = seq
+	return nil
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/nats-io/nats.go"
+)
+
+func TestSubscribeAfterStartsNewConsumerAfterCursor(t *testing.T) {
+	nc, js := runJetStream(t)
+	for _, uid := range []string{"u1", "u2", "u3"} {
+		if _, err := js.Publish("user.created", []byte(`{"uid":"`+uid+`"}`)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	seqs := make(chan uint64, 3)
+	subs := newSubscriptions(nc, js, "billing-ms")
+	err := subs.SubscribeAfter("user.created", func() uint64 { return 2 }, func(m *nats.Msg) {
+		meta, err := m.Metadata()
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		seqs <- meta.Sequence.Stream
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case seq := <-seqs:
+		if seq != 3 {
+			t.Fatalf("first delivery has stream sequence %d, want 3", seq)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("nothing delivered")
+	}
+	select {
+	case seq := <-seqs:
+		t.Errorf("unexpected delivery of stream sequence %d", seq)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestEnsureConsumer(t *testing.T) {
+	nc, js := runJetStream(t)
+	subs := newSubscriptions(nc, js, "billing-ms")
+	after := func() uint64 { return 7 }
+
+	entry := &subscription{after: after}
+	if err := subs.ensureConsumer("user.created", entry); err != nil {
+		t.Fatal(err)
+	}
+	info, err := js.ConsumerInfo(entry.stream, entry.durable)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Config.DeliverPolicy != nats.DeliverByStartSequencePolicy || info.Config.OptStartSeq != 8 {
+		t.Errorf("new consumer starts with %v at %d, want sequence 8", info.Config.DeliverPolicy, info.Config.OptStartSeq)
+	}
+
+	// An existing consumer is left as it is.
+	if err := js.DeleteConsumer(entry.stream, entry.durable); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := js.AddConsumer(entry.stream, &nats.ConsumerConfig{Durable: entry.durable, DeliverSubject: nats.NewInbox(), AckPolicy: nats.AckExplicitPolicy}); err != nil {
+		t.Fatal(err)
+	}
+	if err := subs.ensureConsumer("user.created", &subscription{after: after}); err != nil {
+		t.Fatal(err)
+	}
+	if info, err := js.ConsumerInfo(entry.stream, entry.durable); err != nil || info.Config.DeliverPolicy != nats.DeliverAllPolicy {
+		t.Errorf("existing consumer changed: %+v, %v", info, err)
+	}
+}
+
+func TestHandleUserCreatedSkipsProcessedSequences(t *testing.T) {
+	s, mock := newTestServer(t)
+	s.cursor = &eventCursor{db: s.db, consumer: "billing-user-created", last: 5}
+	s.createAttempts = 1
+	// jsMsg is a JetStream delivery of user.created at stream sequence seq.
+	jsMsg := func(uid, seq string) *nats.Msg {
+		return &nats.Msg{
+			Subject: "user.created",
+			Reply:   "$JS.ACK.EVENTS.billing-ms-user-created.1." + seq + ".1.0.0",
+			Data:    []byte(`{"uid":"` + uid + `"}`),
+			Sub:     &nats.Subscription{},
+		}
+	}
+
+	// Redelivered or replayed events at or below the cursor touch nothing.
+	s.handleUserCreated(jsMsg("u3", "3"))
+	s.handleUserCreated(jsMsg("u5", "5"))
+
+	mock.ExpectExec(literal("INSERT INTO billing")).WithArgs("u6", 0.0).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(literal("INSERT INTO event_cursors")).WithArgs("billing-user-created", uint64(6)).WillReturnResult(sqlmock.NewResult(0, 1))
+	s.handleUserCreated(jsMsg("u6", "6"))
+	if got := s.cursor.Last(); got != 6 {
+		t.Errorf("cursor = %d, want 6", got)
+	}
+}
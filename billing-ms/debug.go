@@ -0,0 +1,55 @@
+package main
+
+import (
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"os"
+	"strconv"
+)
+
+// defaultDebugAddr keeps the profiling endpoints off the network unless an
+// operator explicitly binds them elsewhere.
+const defaultDebugAddr = "localhost:6060"
+
+// newDebugMux returns the /debug/pprof handlers. Only one request is served
+// at a time since CPU profiles and traces are expensive; concurrent requests
+// get 429.
+func newDebugMux() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+
+	sem := make(chan struct{}, 1)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case sem <- struct{}{}:
+			defer func() { <-sem }()
+			mux.ServeHTTP(w, r)
+		default:
+			http.Error(w, "a debug request is already in progress", http.StatusTooManyRequests)
+		}
+	})
+}
+
+// startDebugServer serves pprof on a separate internal listener when
+// DEBUG_PPROF is true, bound to DEBUG_ADDR (default localhost:6060). It
+// returns the bound address, or "" when disabled.
+func startDebugServer() (string, error) {
+	if enabled, _ := strconv.ParseBool(os.Getenv("DEBUG_PPROF")); !enabled {
+		return "", nil
+	}
+	addr := os.Getenv("DEBUG_ADDR")
+	if addr == "" {
+		addr = defaultDebugAddr
+	}
+	lis, err := net.Listen("tcp", addr)
+	if err != nil {
+		return "", err
+	}
+	go http.Serve(lis, newDebugMux())
+	return lis.Addr().String(), nil
+}
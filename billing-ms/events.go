@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/nats-io/nats.go"
+)
+
+// subjectAccountCreationFailed is published when a billing account could not
+// be created for a new user, so it can be alerted on and reconciled.
+const subjectAccountCreationFailed = "billing.account_creation_failed"
+
+// createRedeliveryDelay is how long JetStream waits before redelivering a
+// user.created event whose account could not be created.
+const createRedeliveryDelay = 30 * time.Second
+
+// accountCreationFailedEvent is the payload of subjectAccountCreationFailed.
+type accountCreationFailedEvent struct {
+	EventID  string `json:"event_id"`
+	UID      string `json:"uid"`
+	Attempts int    `json:"attempts"`
+	Error    string `json:"error"`
+}
+
+// handleUserCreated creates the billing account for a newly registered user.
+func (s *server) handleUserCreated(m *nats.Msg) {
+	ctx := msgContext(m)
+	var event UserCreatedEvent
+	if err := json.Unmarshal(m.Data, &event); err != nil {
+		s.logger.ErrorContext(ctx, "failed to unmarshal user.created event", "error", err)
+		return
+	}
+	s.logger.InfoContext(ctx, "received new user", "user_id", event.UID)
+
+	// Only JetStream deliveries carry a stream sequence.
+	meta, err := m.Metadata()
+	if err == nil && meta.Sequence.Stream <= s.cursor.Last() {
+		s.logger.InfoContext(ctx, "skipping already processed user.created event", "user_id", event.UID, "stream_seq", meta.Sequence.Stream, "cursor", s.cursor.Last())
+		return
+	}
+	if err := s.createAccountWithRetry(ctx, event.UID); err != nil {
+		// A transient failure may pass later: have JetStream redeliver the
+		// event instead of acking it. Other errors will not go away.
+		if meta != nil && (isRetryable(err) || ctx.Err() != nil) {
+			if nakErr := m.NakWithDelay(createRedeliveryDelay); nakErr != nil {
+				s.logger.ErrorContext(ctx, "failed to nak user.created event", "user_id", event.UID, "error", nakErr)
+			}
+		}
+		return
+	}
+	if meta != nil {
+		if err := s.cursor.Advance(meta.Sequence.Stream); err != nil {
+			s.logger.ErrorContext(ctx, "failed to advance event cursor", "error", err)
+		}
+	}
+}
+
+// createAccountWithRetry inserts a zero-balance account for uid, retrying
+// transient database errors with exponential backoff until ctx is done. If
+// every attempt fails it logs an error and publishes
+// subjectAccountCreationFailed.
+func (s *server) createAccountWithRetry(ctx context.Context, uid string) error {
+	backoff := s.createBackoff
+	var err error
+	attempt := 1
+	for ; attempt <= s.createAttempts; attempt++ {
+		// ON CONFLICT makes a retry after a lost commit acknowledgement harmless.
+		_, err = s.db.ExecContext(ctx, "INSERT INTO billing (user_id, amount) VALUES ($1, $2) ON CONFLICT (user_id) DO NOTHING", uid, 0.0)
+		if err == nil {
+			s.reads.noteWrite(uid)
+			return nil
+		}
+		if !isRetryable(err) || attempt == s.createAttempts {
+			break
+		}
+		s.logger.WarnContext(ctx, "failed to create billing account, retrying", "user_id", uid, "attempt", attempt, "max_attempts", s.createAttempts, "backoff", backoff, "error", err)
+		select {
+		case <-ctx.Done():
+			s.logger.WarnContext(ctx, "stopped creating billing account", "user_id", uid, "attempt", attempt, "error", ctx.Err())
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, 5*time.Second)
+	}
+
+	s.logger.ErrorContext(ctx, "giving up creating billing account", "user_id", uid, "attempts", attempt, "error", err)
+	msg, _ := json.Marshal(accountCreationFailedEvent{EventID: uuid.NewString(), UID: uid, Attempts: attempt, Error: err.Error()})
+	if pubErr := s.events.Publish(ctx, subjectAccountCreationFailed, msg); pubErr != nil {
+		s.logger.ErrorContext(ctx, "failed to publish event", "subject", subjectAccountCreationFailed, "user_id", uid, "error", pubErr)
+	}
+	return err
+}
+
+// isRetryable reports whether a database error is likely transient: lost
+// connections, serialization failures and deadlocks, resource exhaustion or
+// the server shutting down.
+func isRetryable(err error) bool {
+	if errors.Is(err, driver.ErrBadConn) {
+		return true
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		code := string(pqErr.Code)
+		return strings.HasPrefix(code, "08") || // connection exception
+			strings.HasPrefix(code, "40") || // transaction rollback
+			strings.HasPrefix(code, "53") || // insufficient resources
+			strings.HasPrefix(code, "57P") // operator intervention
+	}
+	return false
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/nats-io/nats.go"
+)
+
+// transientErr is a connection failure, which is retried.
+var transientErr = &pq.Error{Code: "08006", Message: "connection failure"}
+
+const insertAccount = "INSERT INTO billing (user_id, amount)"
+
+// withEvents gives s a publisher on an embedded JetStream server and returns
+// a subscription to subjectAccountCreationFailed.
+func withEvents(t *testing.T, s *server) (*nats.Conn, nats.JetStreamContext, *nats.Subscription) {
+	t.Helper()
+	nc, js := withPublisher(t, s)
+	return nc, js, subscribeSync(t, nc, subjectAccountCreationFailed)
+}
+
+func TestCreateAccountRetriesTransientErrors(t *testing.T) {
+	s, mock := newTestServer(t)
+	s.createAttempts, s.createBackoff = 3, time.Millisecond
+	_, _, failed := withEvents(t, s)
+
+	mock.ExpectExec(literal(insertAccount)).WithArgs("u1", 0.0).WillReturnError(transientErr)
+	mock.ExpectExec(literal(insertAccount)).WithArgs("u1", 0.0).WillReturnResult(sqlmock.NewResult(0, 1))
+	if err := s.createAccountWithRetry(context.Background(), "u1"); err != nil {
+		t.Fatalf("createAccountWithRetry: %v", err)
+	}
+	if m, err := failed.NextMsg(100 * time.Millisecond); err == nil {
+		t.Errorf("failure event published after a successful retry: %s", m.Data)
+	}
+}
+
+func TestCreateAccountPublishesFailureAfterLastAttempt(t *testing.T) {
+	s, mock := newTestServer(t)
+	s.createAttempts, s.createBackoff = 3, time.Millisecond
+	_, _, failed := withEvents(t, s)
+
+	for range 3 {
+		mock.ExpectExec(literal(insertAccount)).WithArgs("u1", 0.0).WillReturnError(transientErr)
+	}
+	if err := s.createAccountWithRetry(context.Background(), "u1"); !errors.Is(err, transientErr) {
+		t.Fatalf("err = %v, want the database error", err)
+	}
+
+	m, err := failed.NextMsg(5 * time.Second)
+	if err != nil {
+		t.Fatalf("no failure event: %v", err)
+	}
+	var event accountCreationFailedEvent
+	if err := json.Unmarshal(m.Data, &event); err != nil {
+		t.Fatal(err)
+	}
+	if event.UID != "u1" || event.Attempts != 3 || event.EventID == "" {
+		t.Errorf("failure event = %+v", event)
+	}
+}
+
+func TestCreateAccountStopsWaitingWhenContextIsDone(t *testing.T) {
+	s, mock := newTestServer(t)
+	s.createAttempts, s.createBackoff = 3, time.Minute
+	_, _, failed := withEvents(t, s)
+
+	mock.ExpectExec(literal(insertAccount)).WithArgs("u1", 0.0).WillReturnError(transientErr)
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	if err := s.createAccountWithRetry(ctx, "u1"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err = %v, want context.DeadlineExceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("returned after %v, want it to stop when the context is done", elapsed)
+	}
+	if m, err := failed.NextMsg(100 * time.Millisecond); err == nil {
+		t.Errorf("failure event published for a cancelled attempt: %s", m.Data)
+	}
+}
+
+func TestHandleUserCreatedSettlesFailedEvents(t *testing.T) {
+	tests := []struct {
+		name        string
+		err         error
+		wantPending int
+	}{
+		{"transient failure is redelivered", transientErr, 1},
+		{"permanent failure is acked", &pq.Error{Code: "23502", Message: "not null violation"}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, mock := newTestServer(t)
+			s.createAttempts, s.createBackoff = 1, time.Millisecond
+			s.cursor = &eventCursor{db: s.db, consumer: "billing-user-created"}
+			nc, js, failed := withEvents(t, s)
+
+			mock.ExpectExec(literal(insertAccount)).WithArgs("u1", 0.0).WillReturnError(tt.err)
+			handled := make(chan struct{}, 1)
+			subs := newSubscriptions(nc, js, "billing-ms")
+			err := subs.Subscribe("user.created", func(m *nats.Msg) {
+				s.handleUserCreated(m)
+				handled <- struct{}{}
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := js.Publish("user.created", []byte(`{"uid":"u1"}`)); err != nil {
+				t.Fatal(err)
+			}
+
+			select {
+			case <-handled:
+			case <-time.After(5 * time.Second):
+				t.Fatal("user.created not handled")
+			}
+			if _, err := failed.NextMsg(5 * time.Second); err != nil {
+				t.Fatalf("no failure event: %v", err)
+			}
+			// The subscription acks after the handler returns; give it time to.
+			time.Sleep(200 * time.Millisecond)
+			entry := subs.subs["user.created"]
+			info, err := js.ConsumerInfo(entry.stream, entry.durable)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if info.NumAckPending != tt.wantPending {
+				t.Errorf("consumer has %d messages awaiting ack, want %d", info.NumAckPending, tt.wantPending)
+			}
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"billing-ms/billingpb"
+)
+
+// exportPageSize is how many ledger entries ExportTransactions reads and
+// sends at a time.
+const exportPageSize = 500
+
+// ExportTransactions pages through the user's ledger by id and sends each
+// page as soon as it is read, so memory use does not grow with the history.
+func (s *server) ExportTransactions(req *billingpb.ExportTransactionsRequest, stream billingpb.BillingService_ExportTransactionsServer) error {
+	if req.UserId == "" {
+		return status.Error(codes.InvalidArgument, "user_id is required")
+	}
+	ctx := stream.Context()
+	db := s.reads.dbFor(req.UserId)
+
+	var after int64
+	for {
+		rows, err := db.QueryContext(ctx, "SELECT id, delta, created_at FROM billing_ledger WHERE user_id = $1 AND id > $2 ORDER BY id LIMIT $3", req.UserId, after, exportPageSize)
+		if err != nil {
+			s.logger.ErrorContext(ctx, "failed to export transactions", "user_id", req.UserId, "error", err)
+			return status.Error(codes.Internal, "could not read transactions")
+		}
+		page := &billingpb.TransactionPage{}
+		for rows.Next() {
+			var t billingpb.Transaction
+			var createdAt time.Time
+			if err := rows.Scan(&t.Id, &t.Delta, &createdAt); err != nil {
+				rows.Close()
+				s.logger.ErrorContext(ctx, "failed to export transactions", "user_id", req.UserId, "error", err)
+				return status.Error(codes.Internal, "could not read transactions")
+			}
+			t.CreatedAt = createdAt.UTC().Format(time.RFC3339Nano)
+			page.Transactions = append(page.Transactions, &t)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			s.logger.ErrorContext(ctx, "failed to export transactions", "user_id", req.UserId, "error", err)
+			return status.Error(codes.Internal, "could not read transactions")
+		}
+
+		if len(page.Transactions) > 0 {
+			if err := stream.Send(page); err != nil {
+				return err
+			}
+			after = page.Transactions[len(page.Transactions)-1].Id
+		}
+		if len(page.Transactions) < exportPageSize {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"google.golang.org/grpc"
+
+	"billing-ms/billingpb"
+)
+
+// pageStream is an export stream that hands every page to the test, blocking
+// until the test takes it.
+type pageStream struct {
+	grpc.ServerStream
+	pages chan *billingpb.TransactionPage
+}
+
+func (f *pageStream) Context() context.Context { return context.Background() }
+
+func (f *pageStream) Send(page *billingpb.TransactionPage) error {
+	f.pages <- page
+	return nil
+}
+
+const selectLedgerPage = "SELECT id, delta, created_at FROM billing_ledger WHERE user_id = $1 AND id > $2 ORDER BY id LIMIT $3"
+
+// ledgerPage returns rows for ledger ids from..to.
+func ledgerPage(from, to int64) *sqlmock.Rows {
+	rows := sqlmock.NewRows([]string{"id", "delta", "created_at"})
+	for id := from; id <= to; id++ {
+		rows.AddRow(id, 1.0, time.Unix(id, 0))
+	}
+	return rows
+}
+
+func TestExportTransactionsStreamsPages(t *testing.T) {
+	s, mock := newTestServer(t)
+	// 1200 entries: two full pages and a partial one, each read after the
+	// page before it has been sent.
+	mock.ExpectQuery(literal(selectLedgerPage)).WithArgs("u1", 0, exportPageSize).WillReturnRows(ledgerPage(1, 500))
+	mock.ExpectQuery(literal(selectLedgerPage)).WithArgs("u1", 500, exportPageSize).WillReturnRows(ledgerPage(501, 1000))
+	mock.ExpectQuery(literal(selectLedgerPage)).WithArgs("u1", 1000, exportPageSize).WillReturnRows(ledgerPage(1001, 1200))
+
+	stream := &pageStream{pages: make(chan *billingpb.TransactionPage)}
+	done := make(chan error, 1)
+	go func() { done <- s.ExportTransactions(&billingpb.ExportTransactionsRequest{UserId: "u1"}, stream) }()
+
+	var total int
+	var next int64 = 1
+	for i, want := range []int{500, 500, 200} {
+		var page *billingpb.TransactionPage
+		select {
+		case page = <-stream.pages:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("page %d not sent", i)
+		}
+		if len(page.Transactions) != want || page.Transactions[0].Id != next {
+			t.Fatalf("page %d: %d entries from id %d, want %d from %d", i, len(page.Transactions), page.Transactions[0].Id, want, next)
+		}
+		// Later pages have not been read while this one is being sent.
+		if i < 2 && mock.ExpectationsWereMet() == nil {
+			t.Fatalf("whole ledger read before page %d was sent", i)
+		}
+		next += int64(want)
+		total += want
+	}
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if total != 1200 {
+		t.Errorf("exported %d entries, want 1200", total)
+	}
+}
+
+func TestExportTransactionsEmptyLedger(t *testing.T) {
+	s, mock := newTestServer(t)
+	mock.ExpectQuery(literal(selectLedgerPage)).WithArgs("u1", 0, exportPageSize).WillReturnRows(ledgerPage(1, 0))
+	stream := &pageStream{pages: make(chan *billingpb.TransactionPage, 1)}
+	if err := s.ExportTransactions(&billingpb.ExportTransactionsRequest{UserId: "u1"}, stream); err != nil {
+		t.Fatal(err)
+	}
+	if len(stream.pages) != 0 {
+		t.Error("page sent for an empty ledger")
+	}
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"google.golang.org/grpc/codes"
+
+	"billing-ms/billingpb"
+)
+
+func TestGetBilling(t *testing.T) {
+	s, mock := newTestServer(t)
+	mock.ExpectQuery(literal(selectBilling)).WithArgs("u1").WillReturnRows(sqlmock.NewRows([]string{"amount", "version"}).AddRow(12.5, 2))
+	res, err := s.GetBilling(context.Background(), &billingpb.GetBillingRequest{UserId: "u1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Amount != 12.5 || res.Version != 2 {
+		t.Errorf("GetBilling = %+v, want amount 12.5 at version 2", res)
+	}
+}
+
+func TestGetBillingMissingAccount(t *testing.T) {
+	s, mock := newTestServer(t)
+	// A new user whose account has not been created from user.created yet.
+	mock.ExpectQuery(literal(selectBilling)).WithArgs("u1").WillReturnRows(sqlmock.NewRows([]string{"amount", "version"}))
+	_, err := s.GetBilling(context.Background(), &billingpb.GetBillingRequest{UserId: "u1"})
+	wantCode(t, err, codes.NotFound)
+}
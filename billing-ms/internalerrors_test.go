@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"billing-ms/billingpb"
+)
+
+func TestDatabaseErrorsDoNotLeakSQL(t *testing.T) {
+	dbErr := errors.New(`pq: relation "billing_ledger" does not exist`)
+	tests := []struct {
+		name   string
+		expect func(sqlmock.Sqlmock)
+		call   func(*server) error
+	}{
+		{
+			name: "create",
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec(literal("INSERT INTO billing (user_id, amount)")).WillReturnError(dbErr)
+			},
+			call: func(s *server) error {
+				_, err := s.CreateBillingAccount(context.Background(), &billingpb.CreateBillingAccountRequest{UserId: "u1"})
+				return err
+			},
+		},
+		{
+			name: "get",
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(literal("SELECT amount, version FROM billing")).WillReturnError(dbErr)
+			},
+			call: func(s *server) error {
+				_, err := s.GetBilling(context.Background(), &billingpb.GetBillingRequest{UserId: "u1"})
+				return err
+			},
+		},
+		{
+			name: "update",
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(literal("SELECT amount, version FROM billing WHERE user_id = $1 FOR UPDATE")).
+					WillReturnRows(sqlmock.NewRows([]string{"amount", "version"}).AddRow(10.0, 1))
+				mock.ExpectExec(literal("UPDATE billing SET amount = $1")).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec(literal("INSERT INTO billing_ledger")).WillReturnError(dbErr)
+				mock.ExpectRollback()
+			},
+			call: func(s *server) error {
+				_, err := s.UpdateBilling(context.Background(), &billingpb.UpdateBillingRequest{UserId: "u1", Amount: 20})
+				return err
+			},
+		},
+		{
+			name: "recalculate",
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(literal("SELECT amount FROM billing WHERE user_id = $1 FOR UPDATE")).
+					WillReturnRows(sqlmock.NewRows([]string{"amount"}).AddRow(10.0))
+				mock.ExpectQuery(literal("SUM(delta)")).WillReturnError(dbErr)
+				mock.ExpectRollback()
+			},
+			call: func(s *server) error {
+				_, err := s.RecalculateBilling(context.Background(), &billingpb.RecalculateBillingRequest{UserId: "u1"})
+				return err
+			},
+		},
+		{
+			name: "stats",
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(literal(selectStats)).WillReturnError(dbErr)
+			},
+			call: func(s *server) error {
+				_, err := s.Stats(context.Background(), &billingpb.StatsRequest{})
+				return err
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, mock := newTestServer(t)
+			tt.expect(mock)
+			err := tt.call(s)
+			wantCode(t, err, codes.Internal)
+			if msg := status.Convert(err).Message(); strings.Contains(msg, "billing_ledger") || strings.Contains(msg, "pq:") {
+				t.Errorf("error message leaks the database error: %q", msg)
+			}
+		})
+	}
+}
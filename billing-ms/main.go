@@ -3,22 +3,47 @@ package main
 import (
 	"context"
 	"database/sql"
-	"encoding/json"
-	"fmt"
-	"log"
+	"log/slog"
+	"math"
 	"net"
+	"os"
+	"strconv"
+	"time"
 
+	"github.com/XSAM/otelsql"
 	_ "github.com/lib/pq"
 	"github.com/nats-io/nats.go"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 
 	"billing-ms/billingpb"
 )
 
 type server struct {
 	billingpb.UnimplementedBillingServiceServer
-	db *sql.DB
-	nc *nats.Conn
+	logger *slog.Logger
+	db     *sql.DB
+	events *publisher
+	// bills publishes bill.update events, coalescing them while
+	// notification-ms is down.
+	bills *billNotifier
+	// autoCreate makes UpdateBilling create a missing account instead of
+	// returning NotFound.
+	autoCreate bool
+	// bounds is the global sanity range for updated amounts.
+	bounds amountBounds
+	// subs holds the NATS subscriptions so consumption can be paused.
+	subs *subscriptions
+	// cursor tracks the last processed user.created stream sequence.
+	cursor *eventCursor
+	// createAttempts and createBackoff bound the retries when creating an
+	// account for a new user.
+	createAttempts int
+	createBackoff  time.Duration
+	// reads picks the database GetBilling reads from.
+	reads *readRouter
 }
 
 type UserCreatedEvent struct {
@@ -27,96 +52,377 @@ type UserCreatedEvent struct {
 }
 
 func (s *server) CreateBillingAccount(ctx context.Context, req *billingpb.CreateBillingAccountRequest) (*billingpb.CreateBillingAccountResponse, error) {
-	_, err := s.db.Exec("INSERT INTO billing (user_id, amount) VALUES ($1, $2)", req.UserId, 0.0)
+	_, err := s.db.ExecContext(ctx, "INSERT INTO billing (user_id, amount) VALUES ($1, $2)", req.UserId, 0.0)
 	if err != nil {
-		return nil, fmt.Errorf("could not create billing account: %v", err)
+		s.logger.ErrorContext(ctx, "failed to create billing account", "user_id", req.UserId, "error", err)
+		return nil, status.Error(codes.Internal, "internal server error")
 	}
+	s.reads.noteWrite(req.UserId)
 	return &billingpb.CreateBillingAccountResponse{Success: true}, nil
 }
 
 func (s *server) GetBilling(ctx context.Context, req *billingpb.GetBillingRequest) (*billingpb.GetBillingResponse, error) {
 	var amount float64
-	err := s.db.QueryRow("SELECT amount FROM billing WHERE user_id = $1", req.UserId).Scan(&amount)
+	var version int64
+	err := s.reads.dbFor(req.UserId).QueryRowContext(ctx, "SELECT amount, version FROM billing WHERE user_id = $1", req.UserId).Scan(&amount, &version)
+	if err == sql.ErrNoRows {
+		// The account is created asynchronously from user.created, so a
+		// brand-new user may not have one yet.
+		return nil, status.Error(codes.NotFound, "billing account not found")
+	}
 	if err != nil {
-		return nil, fmt.Errorf("could not get billing: %v", err)
+		s.logger.ErrorContext(ctx, "failed to get billing", "user_id", req.UserId, "error", err)
+		return nil, status.Error(codes.Internal, "internal server error")
 	}
-	return &billingpb.GetBillingResponse{Amount: amount}, nil
+	return &billingpb.GetBillingResponse{Amount: amount, Version: version}, nil
 }
 
 func (s *server) UpdateBilling(ctx context.Context, req *billingpb.UpdateBillingRequest) (*billingpb.UpdateBillingResponse, error) {
-	_, err := s.db.Exec("UPDATE billing SET amount = $1 WHERE user_id = $2", req.Amount, req.UserId)
+	if err := s.bounds.check(req.Amount); err != nil {
+		return nil, err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
 	if err != nil {
-		return nil, fmt.Errorf("could not update billing: %v", err)
+		s.logger.ErrorContext(ctx, "failed to update billing", "user_id", req.UserId, "error", err)
+		return nil, status.Error(codes.Internal, "internal server error")
 	}
+	defer tx.Rollback()
 
-	msg := struct {
-		Id      string
-		Message string
-	}{
-		req.UserId,
-		fmt.Sprintf("Your bill was updated to %.2f", req.Amount),
+	// Record the change as a ledger entry so the balance can be rebuilt later.
+	var previous float64
+	var version int64
+	err = tx.QueryRowContext(ctx, "SELECT amount, version FROM billing WHERE user_id = $1 FOR UPDATE", req.UserId).Scan(&previous, &version)
+	if err == sql.ErrNoRows && s.autoCreate {
+		// Create the missing account on demand; it starts at zero.
+		s.logger.InfoContext(ctx, "creating missing billing account", "user_id", req.UserId)
+		_, err = tx.ExecContext(ctx, "INSERT INTO billing (user_id, amount) VALUES ($1, $2)", req.UserId, 0.0)
+	}
+	if err == sql.ErrNoRows {
+		return nil, status.Error(codes.NotFound, "no billing account for user")
+	}
+	if err != nil {
+		s.logger.ErrorContext(ctx, "failed to update billing", "user_id", req.UserId, "error", err)
+		return nil, status.Error(codes.Internal, "internal server error")
 	}
 
-	if req.Amount > 100 {
-		msg.Message = fmt.Sprintf("Dr. Prakash Metre made you poor!! bill updated to %.2f", req.Amount)
+	// Compare-and-set: reject the write if someone else updated the account first.
+	if req.ExpectedVersion != nil && *req.ExpectedVersion != version {
+		return nil, status.Errorf(codes.Aborted, "billing account was modified concurrently: expected version %d, current version %d", *req.ExpectedVersion, version)
 	}
 
-	msgBytes, err := json.Marshal(msg)
+	res, err := tx.ExecContext(ctx, "UPDATE billing SET amount = $1, version = version + 1 WHERE user_id = $2", req.Amount, req.UserId)
 	if err != nil {
-		log.Println("marshalling error")
-		return nil, fmt.Errorf("internal server")
+		s.logger.ErrorContext(ctx, "failed to update billing", "user_id", req.UserId, "error", err)
+		return nil, status.Error(codes.Internal, "internal server error")
+	}
+	if n, err := res.RowsAffected(); err != nil || n == 0 {
+		return nil, status.Error(codes.NotFound, "no billing account for user")
+	}
+	if _, err := tx.ExecContext(ctx, "INSERT INTO billing_ledger (user_id, delta) VALUES ($1, $2)", req.UserId, req.Amount-previous); err != nil {
+		s.logger.ErrorContext(ctx, "failed to update billing", "user_id", req.UserId, "error", err)
+		return nil, status.Error(codes.Internal, "internal server error")
+	}
+	if err := tx.Commit(); err != nil {
+		s.logger.ErrorContext(ctx, "failed to update billing", "user_id", req.UserId, "error", err)
+		return nil, status.Error(codes.Internal, "internal server error")
+	}
+	s.reads.noteWrite(req.UserId)
+
+	// Send notification. The update is already committed, so a failed
+	// confirmation is reported without rolling it back.
+	if err := s.bills.Notify(ctx, req.UserId, req.Amount); err != nil {
+		s.logger.ErrorContext(ctx, "failed to publish bill.update", "user_id", req.UserId, "error", err)
+		return nil, status.Error(codes.Unavailable, "billing updated but the bill.update event was not confirmed")
 	}
-	// Send notification
-	s.nc.Publish("bill.update", msgBytes)
 
-	return &billingpb.UpdateBillingResponse{Success: true}, nil
+	return &billingpb.UpdateBillingResponse{Success: true, Version: version + 1}, nil
+}
+
+// RecalculateBilling rebuilds a user's balance from the ledger and corrects
+// the stored amount if it has drifted.
+func (s *server) RecalculateBilling(ctx context.Context, req *billingpb.RecalculateBillingRequest) (*billingpb.RecalculateBillingResponse, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "failed to recalculate billing", "user_id", req.UserId, "error", err)
+		return nil, status.Error(codes.Internal, "internal server error")
+	}
+	defer tx.Rollback()
+
+	var stored float64
+	err = tx.QueryRowContext(ctx, "SELECT amount FROM billing WHERE user_id = $1 FOR UPDATE", req.UserId).Scan(&stored)
+	if err == sql.ErrNoRows {
+		return nil, status.Error(codes.NotFound, "no billing account for user")
+	}
+	if err != nil {
+		s.logger.ErrorContext(ctx, "failed to recalculate billing", "user_id", req.UserId, "error", err)
+		return nil, status.Error(codes.Internal, "internal server error")
+	}
+
+	var computed float64
+	err = tx.QueryRowContext(ctx, "SELECT COALESCE(SUM(delta), 0) FROM billing_ledger WHERE user_id = $1", req.UserId).Scan(&computed)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "failed to recalculate billing", "user_id", req.UserId, "error", err)
+		return nil, status.Error(codes.Internal, "internal server error")
+	}
+
+	// Summing float deltas can leave sub-cent noise, so compare at cent precision.
+	corrected := math.Abs(stored-computed) >= 0.005
+	if corrected {
+		s.logger.WarnContext(ctx, "billing discrepancy", "user_id", req.UserId, "stored", stored, "ledger", computed)
+		if _, err := tx.ExecContext(ctx, "UPDATE billing SET amount = $1, version = version + 1 WHERE user_id = $2", computed, req.UserId); err != nil {
+			s.logger.ErrorContext(ctx, "failed to recalculate billing", "user_id", req.UserId, "error", err)
+			return nil, status.Error(codes.Internal, "internal server error")
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		s.logger.ErrorContext(ctx, "failed to recalculate billing", "user_id", req.UserId, "error", err)
+		return nil, status.Error(codes.Internal, "internal server error")
+	}
+	if corrected {
+		s.reads.noteWrite(req.UserId)
+	}
+
+	return &billingpb.RecalculateBillingResponse{
+		Amount:         computed,
+		PreviousAmount: stored,
+		Corrected:      corrected,
+	}, nil
+}
+
+func (s *server) Stats(ctx context.Context, req *billingpb.StatsRequest) (*billingpb.StatsResponse, error) {
+	var res billingpb.StatsResponse
+	err := s.db.QueryRowContext(ctx, "SELECT COUNT(*), COALESCE(SUM(amount), 0) FROM billing").Scan(&res.Accounts, &res.TotalAmount)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "failed to compute billing stats", "error", err)
+		return nil, status.Error(codes.Internal, "internal server error")
+	}
+	res.ConsumptionPaused, _ = s.subs.Paused()
+	res.NatsBufferedBytes = int64(s.events.Buffered())
+	return &res, nil
+}
+
+// SetConsumptionPaused pauses or resumes processing of user.created events.
+func (s *server) SetConsumptionPaused(ctx context.Context, req *billingpb.SetConsumptionPausedRequest) (*billingpb.SetConsumptionPausedResponse, error) {
+	if req.Paused {
+		s.subs.Pause()
+	} else if err := s.subs.Resume(); err != nil {
+		return nil, status.Error(codes.Unavailable, "could not resume event consumption, still paused")
+	}
+	paused, pending := s.subs.Paused()
+	return &billingpb.SetConsumptionPausedResponse{Paused: paused, PendingEvents: int64(pending)}, nil
 }
 
 func main() {
+	// The level can be changed at runtime by a SIGHUP reload.
+	logLevel := new(slog.LevelVar)
+	logger := slog.New(requestIDHandler{slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: logLevel})})
+	slog.SetDefault(logger)
+
+	// CONFIG_FILE settings override the environment; see reload.go.
+	if err := loadConfigFile(); err != nil {
+		logger.Error("failed to load config file", "error", err)
+		os.Exit(1)
+	}
+	logLevel.Set(loadLogLevel(logger))
+	go watchReload(logger, logLevel)
+
+	shutdownTracing, err := setupTracing(context.Background(), "billing-ms")
+	if err != nil {
+		logger.Error("failed to set up tracing", "error", err)
+		os.Exit(1)
+	}
+	defer shutdownTracing(context.Background())
+
 	// Database connection
 	connStr := "user=postgres password=postgres dbname=billingdb sslmode=disable host=postgres"
-	db, err := sql.Open("postgres", connStr)
+	// Queries are traced as children of the RPC that runs them.
+	db, err := otelsql.Open("postgres", connStr)
 	if err != nil {
-		log.Fatalf("failed to connect to database: %v", err)
+		logger.Error("failed to connect to database", "error", err)
+		os.Exit(1)
 	}
 	defer db.Close()
 
+	// Optional read replica for GetBilling. Users who changed their account
+	// within BILLING_PRIMARY_READ_WINDOW keep reading from the primary.
+	var replica *sql.DB
+	if replicaConnStr := os.Getenv("BILLING_REPLICA_DSN"); replicaConnStr != "" {
+		replica, err = otelsql.Open("postgres", replicaConnStr)
+		if err != nil {
+			logger.Error("failed to connect to replica database", "error", err)
+			os.Exit(1)
+		}
+		defer replica.Close()
+	}
+	primaryReadWindow := defaultPrimaryReadWindow
+	if v := os.Getenv("BILLING_PRIMARY_READ_WINDOW"); v != "" {
+		if primaryReadWindow, err = time.ParseDuration(v); err != nil {
+			logger.Error("invalid BILLING_PRIMARY_READ_WINDOW", "value", v, "error", err)
+			os.Exit(1)
+		}
+	}
+
 	// NATS connection
-	nc, err := nats.Connect("nats:4222")
+	bufOpt, err := reconnectBufferOption()
+	if err != nil {
+		logger.Error("failed to configure nats", "error", err)
+		os.Exit(1)
+	}
+	nc, err := nats.Connect("nats:4222", nats.MaxReconnects(-1), bufOpt)
 	if err != nil {
-		log.Fatalf("failed to connect to nats: %v", err)
+		logger.Error("failed to connect to nats", "error", err)
+		os.Exit(1)
 	}
 	defer nc.Close()
+	js, err := ensureEventStream(nc)
+	if err != nil {
+		logger.Error("failed to set up event stream", "error", err)
+		os.Exit(1)
+	}
 
 	// Create table if not exists
-	_, err = db.Exec(`CREATE TABLE IF NOT EXISTS billing (user_id TEXT PRIMARY KEY, amount REAL)`)
+	_, err = db.Exec(`CREATE TABLE IF NOT EXISTS billing (user_id TEXT PRIMARY KEY, amount DOUBLE PRECISION)`)
+	if err != nil {
+		logger.Error("failed to create table", "error", err)
+		os.Exit(1)
+	}
+	_, err = db.Exec(`ALTER TABLE billing ADD COLUMN IF NOT EXISTS version BIGINT NOT NULL DEFAULT 0`)
+	if err != nil {
+		logger.Error("failed to migrate table", "error", err)
+		os.Exit(1)
+	}
+	// amount was REAL, whose steps above 262,144 are wider than a cent; it
+	// must hold the same values as the ledger it is reconciled against.
+	_, err = db.Exec(`ALTER TABLE billing ALTER COLUMN amount TYPE DOUBLE PRECISION`)
+	if err != nil {
+		logger.Error("failed to migrate table", "error", err)
+		os.Exit(1)
+	}
+	_, err = db.Exec(`CREATE TABLE IF NOT EXISTS billing_ledger (id BIGSERIAL PRIMARY KEY, user_id TEXT NOT NULL, delta DOUBLE PRECISION NOT NULL, created_at TIMESTAMPTZ NOT NULL DEFAULT now())`)
 	if err != nil {
-		log.Fatalf("failed to create table: %v", err)
+		logger.Error("failed to create table", "error", err)
+		os.Exit(1)
+	}
+	// Seed opening balances for accounts that predate the ledger
+	_, err = db.Exec(`INSERT INTO billing_ledger (user_id, delta)
+		SELECT b.user_id, b.amount FROM billing b
+		WHERE b.amount <> 0 AND NOT EXISTS (SELECT 1 FROM billing_ledger l WHERE l.user_id = b.user_id)`)
+	if err != nil {
+		logger.Error("failed to seed billing ledger", "error", err)
+		os.Exit(1)
 	}
 
-	// NATS subscription
-	nc.Subscribe("user.created", func(m *nats.Msg) {
-		var event UserCreatedEvent
-		if err := json.Unmarshal(m.Data, &event); err != nil {
-			log.Printf("error unmarshalling user created event: %v", err)
-			return
-		}
-		log.Printf("Received new user: %s", event.UID)
-		_, err := db.Exec("INSERT INTO billing (user_id, amount) VALUES ($1, $2)", event.UID, 0.0)
-		if err != nil {
-			log.Printf("failed to create billing account for user %s: %v", event.UID, err)
-		}
-	})
+	// Processing cursor for user.created; only JetStream deliveries carry a stream sequence.
+	cursor, err := newEventCursor(db, "billing-user-created")
+	if err != nil {
+		logger.Error("failed to load event cursor", "error", err)
+		os.Exit(1)
+	}
+	logger.Info("loaded user.created cursor", "stream_seq", cursor.Last())
+
+	autoCreate, _ := strconv.ParseBool(os.Getenv("BILLING_AUTO_CREATE"))
+	createAttempts, err := strconv.Atoi(os.Getenv("BILLING_CREATE_ATTEMPTS"))
+	if err != nil || createAttempts < 1 {
+		createAttempts = 5
+	}
+	bounds, err := loadAmountBounds()
+	if err != nil {
+		logger.Error("failed to load amount bounds", "error", err)
+		os.Exit(1)
+	}
+	events, err := newPublisher(nc, js, subjectAccountCreationFailed)
+	if err != nil {
+		logger.Error("failed to configure event publishing", "error", err)
+		os.Exit(1)
+	}
+	bills, err := newBillNotifier(logger, events)
+	if err != nil {
+		logger.Error("failed to configure bill.update notifications", "error", err)
+		os.Exit(1)
+	}
+	srv := &server{
+		logger:         logger,
+		db:             db,
+		events:         events,
+		bills:          bills,
+		subs:           newSubscriptions(nc, js, "billing-ms"),
+		autoCreate:     autoCreate,
+		bounds:         bounds,
+		cursor:         cursor,
+		createAttempts: createAttempts,
+		createBackoff:  200 * time.Millisecond,
+		reads:          newReadRouter(db, replica, primaryReadWindow),
+	}
+
+	// Durable user.created consumer, so accounts are created for users who
+	// registered while billing-ms was down. A recreated consumer resumes
+	// after the cursor.
+	if err := srv.subs.SubscribeAfter("user.created", cursor.Last, srv.handleUserCreated); err != nil {
+		logger.Error("failed to subscribe to user.created", "error", err)
+		os.Exit(1)
+	}
 
 	// gRPC client for notification service
 	lis, err := net.Listen("tcp", ":50052")
 	if err != nil {
-		log.Fatalf("failed to listen: %v", err)
+		logger.Error("failed to listen", "error", err)
+		os.Exit(1)
 	}
-	s := grpc.NewServer()
-	billingpb.RegisterBillingServiceServer(s, &server{db: db, nc: nc})
-	log.Printf("server listening at %v", lis.Addr())
+	opts, err := tlsServerOptions()
+	if err != nil {
+		logger.Error("failed to configure TLS", "error", err)
+		os.Exit(1)
+	}
+	useTLS := len(opts) > 0
+	// Handlers log the gateway's request id with every line, and every RPC
+	// continues the caller's trace.
+	opts = append(opts,
+		grpc.ChainUnaryInterceptor(requestIDUnaryInterceptor),
+		grpc.ChainStreamInterceptor(requestIDStreamInterceptor),
+		grpc.StatsHandler(otelgrpc.NewServerHandler()),
+	)
+	// Bound concurrent work on the database-heavy RPCs.
+	limiter, err := newConcurrencyLimiter(
+		billingpb.BillingService_CreateBillingAccount_FullMethodName,
+		billingpb.BillingService_GetBilling_FullMethodName,
+		billingpb.BillingService_UpdateBilling_FullMethodName,
+		billingpb.BillingService_RecalculateBilling_FullMethodName,
+	)
+	if err != nil {
+		logger.Error("failed to configure concurrency limit", "error", err)
+		os.Exit(1)
+	}
+	if limiter != nil {
+		opts = append(opts, grpc.ChainUnaryInterceptor(limiter.UnaryInterceptor))
+	}
+	s := grpc.NewServer(opts...)
+	billingpb.RegisterBillingServiceServer(s, srv)
+	// Probes use the standard gRPC health service, which tracks Postgres and NATS.
+	healthServer, err := startHealthChecks(s, billingpb.BillingService_ServiceDesc.ServiceName, db, nc)
+	if err != nil {
+		logger.Error("failed to configure health checks", "error", err)
+		os.Exit(1)
+	}
+	srv.subs.OnPauseChange(func(paused bool) { reportConsumption(healthServer, paused) })
+	// Profiling endpoints on an internal listener, disabled unless DEBUG_PPROF is set.
+	debugAddr, err := startDebugServer()
+	if err != nil {
+		logger.Error("failed to start debug server", "error", err)
+		os.Exit(1)
+	}
+	logStartup(logger, lis.Addr().String(), connStr, nc.ConnectedUrlRedacted(), map[string]any{
+		"auto_create_accounts": autoCreate,
+		"confirmed_events":     events.Confirmed(),
+		"coalesce_bill_events": bills.Coalescing(),
+		"amount_bounds":        []float64{bounds.min, bounds.max},
+		"read_replica":         replica != nil,
+		"primary_read_window":  primaryReadWindow.String(),
+		"tls":                  useTLS,
+		"debug_addr":           debugAddr,
+	})
 	if err := s.Serve(lis); err != nil {
-		log.Fatalf("failed to serve: %v", err)
+		logger.Error("failed to serve", "error", err)
+		os.Exit(1)
 	}
 }
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	natsserver "github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go"
+)
+
+func TestConfirmedPublishWaitsForBroker(t *testing.T) {
+	t.Setenv("EVENT_CONFIRM_SUBJECTS", "audit.security")
+	t.Setenv("EVENT_CONFIRM_TIMEOUT", "200ms")
+	ns, nc, js := startJetStream(t)
+	p, err := newPublisher(nc, js)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sub, err := nc.SubscribeSync("audit.security")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The broker acknowledges the round trip, so the message has reached it
+	// by the time Publish returns.
+	if err := p.Publish(context.Background(), "audit.security", []byte("{}")); err != nil {
+		t.Fatalf("confirmed publish: %v", err)
+	}
+	if _, err := sub.NextMsg(time.Second); err != nil {
+		t.Errorf("confirmed message not delivered: %v", err)
+	}
+
+	// Without a broker the confirmed publish fails after the timeout while
+	// fire-and-forget ones are buffered for the reconnect.
+	ns.Shutdown()
+	start := time.Now()
+	err = p.Publish(context.Background(), "audit.security", []byte("{}"))
+	if err == nil || !strings.Contains(err.Error(), "not confirmed") {
+		t.Errorf("confirmed publish without a broker: %v, want not confirmed", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("confirmed publish took %s, want it bounded by EVENT_CONFIRM_TIMEOUT", elapsed)
+	}
+	if err := p.Publish(context.Background(), "audit.debug", []byte("{}")); err != nil {
+		t.Errorf("fire-and-forget publish without a broker: %v", err)
+	}
+	if err := p.Publish(context.Background(), subjectBillUpdate, []byte("{}")); err == nil {
+		t.Error("stream publish succeeded without a broker")
+	}
+}
+
+func TestPublisherConfirmSubjects(t *testing.T) {
+	nc, js := runJetStream(t)
+	for _, tt := range []struct {
+		env  *string
+		want []string
+	}{
+		{nil, []string{subjectAccountCreationFailed}},
+		{ptr("none"), nil},
+		{ptr(" a.b , c.d,"), []string{"a.b", "c.d"}},
+	} {
+		if tt.env != nil {
+			t.Setenv("EVENT_CONFIRM_SUBJECTS", *tt.env)
+		}
+		p, err := newPublisher(nc, js, subjectAccountCreationFailed)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := p.Confirmed()
+		slices.Sort(got)
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("EVENT_CONFIRM_SUBJECTS=%v: confirmed %v, want %v", tt.env, got, tt.want)
+		}
+	}
+
+	t.Setenv("EVENT_CONFIRM_TIMEOUT", "soon")
+	if _, err := newPublisher(nc, js); err == nil {
+		t.Error("invalid EVENT_CONFIRM_TIMEOUT accepted")
+	}
+}
+
+func ptr(s string) *string { return &s }
+
+// disconnectedPublisher returns a publisher whose NATS connection has lost
+// its server and is reconnecting, with a reconnect buffer of
+// NATS_RECONNECT_BUFFER bytes.
+func disconnectedPublisher(t *testing.T) *publisher {
+	t.Helper()
+	ns, err := natsserver.NewServer(&natsserver.Options{Port: -1, NoLog: true, NoSigs: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ns.Start()
+	if !ns.ReadyForConnections(5 * time.Second) {
+		t.Fatal("NATS server not ready")
+	}
+	bufOpt, err := reconnectBufferOption()
+	if err != nil {
+		t.Fatal(err)
+	}
+	reconnecting := make(chan struct{}, 1)
+	nc, err := nats.Connect(ns.ClientURL(), nats.MaxReconnects(-1), bufOpt,
+		nats.DisconnectErrHandler(func(*nats.Conn, error) { reconnecting <- struct{}{} }))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(nc.Close)
+	ns.Shutdown()
+	select {
+	case <-reconnecting:
+	case <-time.After(5 * time.Second):
+		t.Fatal("connection did not notice the server going away")
+	}
+	p, err := newPublisher(nc, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestPublishFailsFastWhenReconnectBufferFull(t *testing.T) {
+	t.Setenv("NATS_RECONNECT_BUFFER", "1024")
+	p := disconnectedPublisher(t)
+	data := []byte(strings.Repeat("x", 100))
+
+	var err error
+	published := 0
+	for ; published < 100; published++ {
+		if err = p.Publish(context.Background(), "audit.debug", data); err != nil {
+			break
+		}
+	}
+	if !errors.Is(err, nats.ErrReconnectBufExceeded) {
+		t.Fatalf("after %d publishes: %v, want the reconnect buffer exceeded", published, err)
+	}
+	// A publish is refused once the buffer has reached the limit, so the last
+	// accepted one may take it a little past.
+	if published == 0 || (published-1)*len(data) > 1024 {
+		t.Errorf("%d publishes buffered, want as many as fit in 1024 bytes", published)
+	}
+	if n := p.Buffered(); n < published*len(data) {
+		t.Errorf("Buffered() = %d, want at least the %d bytes published", n, published*len(data))
+	}
+
+	start := time.Now()
+	if err := p.Publish(context.Background(), "audit.debug", data); !errors.Is(err, nats.ErrReconnectBufExceeded) {
+		t.Errorf("publish with a full buffer: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 100*time.Millisecond {
+		t.Errorf("fail-fast publish took %s", elapsed)
+	}
+}
+
+func TestPublishBlocksWhenReconnectBufferFull(t *testing.T) {
+	t.Setenv("NATS_RECONNECT_BUFFER", "1024")
+	t.Setenv("NATS_BUFFER_FULL", "block")
+	t.Setenv("NATS_BUFFER_BLOCK_TIMEOUT", "300ms")
+	p := disconnectedPublisher(t)
+	data := []byte(strings.Repeat("x", 600))
+
+	for i := range 2 {
+		if err := p.Publish(context.Background(), "audit.debug", data); err != nil {
+			t.Fatalf("publish %d: %v", i, err)
+		}
+	}
+	start := time.Now()
+	err := p.Publish(context.Background(), "audit.debug", data)
+	if !errors.Is(err, nats.ErrReconnectBufExceeded) {
+		t.Errorf("publish with a full buffer: %v, want the reconnect buffer exceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed < 300*time.Millisecond {
+		t.Errorf("gave up after %s, before NATS_BUFFER_BLOCK_TIMEOUT", elapsed)
+	}
+}
+
+func TestPublisherBufferFullConfig(t *testing.T) {
+	t.Setenv("NATS_BUFFER_FULL", "drop")
+	if _, err := newPublisher(nil, nil); err == nil {
+		t.Error("NATS_BUFFER_FULL=drop accepted")
+	}
+	t.Setenv("NATS_BUFFER_FULL", "")
+	t.Setenv("NATS_RECONNECT_BUFFER", "lots")
+	if _, err := reconnectBufferOption(); err == nil {
+		t.Error("NATS_RECONNECT_BUFFER=lots accepted")
+	}
+}
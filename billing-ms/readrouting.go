@@ -0,0 +1,62 @@
+package main
+
+import (
+	"database/sql"
+	"sync"
+	"time"
+)
+
+// defaultPrimaryReadWindow is how long a user's reads stay on the primary
+// after they changed their account; comfortably above normal replica lag.
+const defaultPrimaryReadWindow = 5 * time.Second
+
+// readRouter sends reads to an optional replica, except for users who wrote
+// within the last window: their reads go to the primary so they always see
+// their own changes (read-after-write consistency).
+type readRouter struct {
+	primary *sql.DB
+	replica *sql.DB
+	window  time.Duration
+
+	mu         sync.Mutex
+	lastWrites map[string]time.Time
+}
+
+// newReadRouter routes reads to replica, which may be nil to read everything
+// from the primary.
+func newReadRouter(primary, replica *sql.DB, window time.Duration) *readRouter {
+	return &readRouter{primary: primary, replica: replica, window: window, lastWrites: make(map[string]time.Time)}
+}
+
+// noteWrite records that userID's account just changed on the primary.
+func (r *readRouter) noteWrite(userID string) {
+	if r.replica == nil || r.window <= 0 {
+		return
+	}
+	now := time.Now()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.lastWrites[userID] = now
+	// Forget users whose window has passed so the map stays small.
+	if len(r.lastWrites) > 1024 {
+		for id, at := range r.lastWrites {
+			if now.Sub(at) > r.window {
+				delete(r.lastWrites, id)
+			}
+		}
+	}
+}
+
+// dbFor returns the database to read userID's account from.
+func (r *readRouter) dbFor(userID string) *sql.DB {
+	if r.replica == nil {
+		return r.primary
+	}
+	r.mu.Lock()
+	at, ok := r.lastWrites[userID]
+	r.mu.Unlock()
+	if ok && time.Since(at) <= r.window {
+		return r.primary
+	}
+	return r.replica
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+
+	"billing-ms/billingpb"
+)
+
+const selectBilling = "SELECT amount, version FROM billing WHERE user_id = $1"
+
+func TestUpdateThenReadSeesFreshValue(t *testing.T) {
+	s, mock := newTestServer(t)
+	withPublisher(t, s)
+	replica, replicaMock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer replica.Close()
+	const window = 200 * time.Millisecond
+	s.reads = newReadRouter(s.db, replica, window)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(literal(selectForUpdate)).WithArgs("u1").WillReturnRows(sqlmock.NewRows([]string{"amount", "version"}).AddRow(4.0, 3))
+	mock.ExpectExec(literal("UPDATE billing SET amount")).WithArgs(10.0, "u1").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(literal("INSERT INTO billing_ledger")).WithArgs("u1", 6.0).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	if _, err := s.UpdateBilling(context.Background(), &billingpb.UpdateBillingRequest{UserId: "u1", Amount: 10}); err != nil {
+		t.Fatalf("UpdateBilling: %v", err)
+	}
+
+	// The writer reads from the primary, which has the update; the lagging
+	// replica still has the old amount.
+	mock.ExpectQuery(literal(selectBilling)).WithArgs("u1").WillReturnRows(sqlmock.NewRows([]string{"amount", "version"}).AddRow(10.0, 4))
+	res, err := s.GetBilling(context.Background(), &billingpb.GetBillingRequest{UserId: "u1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Amount != 10 || res.Version != 4 {
+		t.Errorf("read after write = %+v, want amount 10 at version 4", res)
+	}
+
+	// Other users keep reading from the replica.
+	replicaMock.ExpectQuery(literal(selectBilling)).WithArgs("u2").WillReturnRows(sqlmock.NewRows([]string{"amount", "version"}).AddRow(1.0, 1))
+	if _, err := s.GetBilling(context.Background(), &billingpb.GetBillingRequest{UserId: "u2"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Once the window has passed, the writer is back on the replica.
+	time.Sleep(window + 50*time.Millisecond)
+	replicaMock.ExpectQuery(literal(selectBilling)).WithArgs("u1").WillReturnRows(sqlmock.NewRows([]string{"amount", "version"}).AddRow(10.0, 4))
+	if _, err := s.GetBilling(context.Background(), &billingpb.GetBillingRequest{UserId: "u1"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := replicaMock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestReadsUsePrimaryWithoutReplica(t *testing.T) {
+	s, mock := newTestServer(t)
+	s.reads = newReadRouter(s.db, nil, time.Minute)
+	s.reads.noteWrite("u1")
+	if s.reads.dbFor("u1") != s.db || s.reads.dbFor("u2") != s.db {
+		t.Error("read routed away from the only database")
+	}
+	mock.ExpectQuery(literal(selectBilling)).WithArgs("u2").WillReturnRows(sqlmock.NewRows([]string{"amount", "version"}).AddRow(1.0, 1))
+	if _, err := s.GetBilling(context.Background(), &billingpb.GetBillingRequest{UserId: "u2"}); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"google.golang.org/grpc/codes"
+
+	"billing-ms/billingpb"
+)
+
+func TestRecalculateBillingCorrectsDrift(t *testing.T) {
+	s, mock := newTestServer(t)
+	// The stored balance was corrupted to 1000; the ledger sums to 42.5.
+	mock.ExpectBegin()
+	mock.ExpectQuery(literal("SELECT amount FROM billing WHERE user_id = $1 FOR UPDATE")).WithArgs("u1").
+		WillReturnRows(sqlmock.NewRows([]string{"amount"}).AddRow(1000.0))
+	mock.ExpectQuery(literal("SUM(delta)")).WithArgs("u1").
+		WillReturnRows(sqlmock.NewRows([]string{"sum"}).AddRow(42.5))
+	mock.ExpectExec(literal("UPDATE billing SET amount = $1")).WithArgs(42.5, "u1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	res, err := s.RecalculateBilling(context.Background(), &billingpb.RecalculateBillingRequest{UserId: "u1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Amount != 42.5 || res.PreviousAmount != 1000 || !res.Corrected {
+		t.Errorf("response = %+v, want amount 42.5 corrected from 1000", res)
+	}
+}
+
+func TestRecalculateBillingLeavesMatchingBalance(t *testing.T) {
+	s, mock := newTestServer(t)
+	// Sub-cent differences come from summing float deltas, not drift.
+	mock.ExpectBegin()
+	mock.ExpectQuery(literal("FOR UPDATE")).WithArgs("u1").
+		WillReturnRows(sqlmock.NewRows([]string{"amount"}).AddRow(10.001))
+	mock.ExpectQuery(literal("SUM(delta)")).WithArgs("u1").
+		WillReturnRows(sqlmock.NewRows([]string{"sum"}).AddRow(10.0))
+	mock.ExpectCommit()
+
+	res, err := s.RecalculateBilling(context.Background(), &billingpb.RecalculateBillingRequest{UserId: "u1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Corrected {
+		t.Errorf("response = %+v, want no correction", res)
+	}
+}
+
+func TestRecalculateBillingLeavesLargeBalance(t *testing.T) {
+	s, mock := newTestServer(t)
+	// Near the maximum amount a float4 step is 0.0625; the stored value must
+	// round-trip exactly so a correct balance is not reported as drift.
+	const amount = 987654.32
+	mock.ExpectBegin()
+	mock.ExpectQuery(literal("FOR UPDATE")).WithArgs("u1").
+		WillReturnRows(sqlmock.NewRows([]string{"amount"}).AddRow(amount))
+	mock.ExpectQuery(literal("SUM(delta)")).WithArgs("u1").
+		WillReturnRows(sqlmock.NewRows([]string{"sum"}).AddRow(900000.0 + 87654.32))
+	mock.ExpectCommit()
+
+	res, err := s.RecalculateBilling(context.Background(), &billingpb.RecalculateBillingRequest{UserId: "u1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Corrected || res.Amount != amount {
+		t.Errorf("response = %+v, want %v uncorrected", res, amount)
+	}
+}
+
+func TestRecalculateBillingMissingAccount(t *testing.T) {
+	s, mock := newTestServer(t)
+	mock.ExpectBegin()
+	mock.ExpectQuery(literal("FOR UPDATE")).WithArgs("missing").
+		WillReturnRows(sqlmock.NewRows([]string{"amount"}))
+	mock.ExpectRollback()
+
+	_, err := s.RecalculateBilling(context.Background(), &billingpb.RecalculateBillingRequest{UserId: "missing"})
+	wantCode(t, err, codes.NotFound)
+}
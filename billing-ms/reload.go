@@ -0,0 +1,157 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"syscall"
+)
+
+// reloadableKeys are the settings applied to the running service on SIGHUP.
+// Every other setting is read once at startup.
+var reloadableKeys = []string{"LOG_LEVEL"}
+
+// loadLogLevel reads LOG_LEVEL: "debug", "info" (default), "warn" or "error".
+func loadLogLevel(logger *slog.Logger) slog.Level {
+	v := os.Getenv("LOG_LEVEL")
+	if v == "" {
+		return slog.LevelInfo
+	}
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(v)); err != nil {
+		logger.Warn("invalid log level, using default", "key", "LOG_LEVEL", "value", v, "default", slog.LevelInfo.String())
+		return slog.LevelInfo
+	}
+	return level
+}
+
+// envValue is an environment variable's value before CONFIG_FILE overrode it.
+type envValue struct {
+	value string
+	set   bool
+}
+
+// fileOverrides holds the previous value of every variable the last loaded
+// CONFIG_FILE set, so a key removed from the file goes back to the process
+// environment, or to its default when the environment does not set it.
+var fileOverrides = map[string]envValue{}
+
+// loadConfigFile sets the environment variables listed in the file named by
+// CONFIG_FILE, if any. Each line is KEY=VALUE; blank lines and lines starting
+// with # are skipped. Values in the file override the process environment.
+// A file that cannot be parsed changes nothing.
+func loadConfigFile() error {
+	path := os.Getenv("CONFIG_FILE")
+	if path == "" {
+		return nil
+	}
+	values, err := parseConfigFile(path)
+	if err != nil {
+		return err
+	}
+
+	for key, prev := range fileOverrides {
+		if _, ok := values[key]; ok {
+			continue
+		}
+		if prev.set {
+			os.Setenv(key, prev.value)
+		} else {
+			os.Unsetenv(key)
+		}
+		delete(fileOverrides, key)
+	}
+	for key, value := range values {
+		if _, ok := fileOverrides[key]; !ok {
+			prev, set := os.LookupEnv(key)
+			fileOverrides[key] = envValue{value: prev, set: set}
+		}
+		if err := os.Setenv(key, value); err != nil {
+			return fmt.Errorf("%s: %s: %w", path, key, err)
+		}
+	}
+	return nil
+}
+
+// parseConfigFile reads the KEY=VALUE lines of the file at path.
+func parseConfigFile(path string) (map[string]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	values := make(map[string]string)
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("%s:%d: expected KEY=VALUE", path, n)
+		}
+		values[key] = strings.TrimSpace(value)
+	}
+	return values, scanner.Err()
+}
+
+// watchReload reloads the configuration every time the process receives
+// SIGHUP.
+func watchReload(logger *slog.Logger, level *slog.LevelVar) {
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, syscall.SIGHUP)
+	reloadOn(sig, logger, level)
+}
+
+// reloadOn reloads the configuration for every signal received on sig until
+// it is closed.
+func reloadOn(sig <-chan os.Signal, logger *slog.Logger, level *slog.LevelVar) {
+	for range sig {
+		reload(logger, level)
+	}
+}
+
+// reload re-reads CONFIG_FILE and applies the reloadable settings. Any other
+// setting that changed is logged and left as it was until a restart.
+func reload(logger *slog.Logger, level *slog.LevelVar) {
+	before := environ()
+	if err := loadConfigFile(); err != nil {
+		logger.Error("failed to reload configuration, keeping the current settings", "error", err)
+		return
+	}
+	after := environ()
+
+	applied := []string{}
+	if after["LOG_LEVEL"] != before["LOG_LEVEL"] {
+		level.Set(loadLogLevel(logger))
+		applied = append(applied, "LOG_LEVEL")
+	}
+	for key, value := range after {
+		if value != before[key] && !slices.Contains(reloadableKeys, key) {
+			logger.Warn("setting changed but requires a restart, ignored", "key", key)
+		}
+	}
+	for key := range before {
+		if _, ok := after[key]; !ok && !slices.Contains(reloadableKeys, key) {
+			logger.Warn("setting changed but requires a restart, ignored", "key", key)
+		}
+	}
+	logger.Info("configuration reloaded", "applied", applied, "log_level", level.Level().String())
+}
+
+// environ returns the process environment as a map.
+func environ() map[string]string {
+	env := make(map[string]string)
+	for _, kv := range os.Environ() {
+		key, value, _ := strings.Cut(kv, "=")
+		env[key] = value
+	}
+	return env
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"bytes"
+	"log/slog"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// useConfigFile points CONFIG_FILE at a file in a temporary directory and
+// returns a function that replaces its contents. keys are the variables the
+// file will set; they are restored once the test ends.
+func useConfigFile(t *testing.T, keys ...string) func(contents string) {
+	t.Helper()
+	for _, key := range keys {
+		prev, set := os.LookupEnv(key)
+		t.Cleanup(func() {
+			if set {
+				os.Setenv(key, prev)
+			} else {
+				os.Unsetenv(key)
+			}
+		})
+	}
+	t.Cleanup(func() { fileOverrides = map[string]envValue{} })
+
+	path := filepath.Join(t.TempDir(), "billing-ms.env")
+	t.Setenv("CONFIG_FILE", path)
+	return func(contents string) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(contents), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestReloadLogLevelOnSIGHUP(t *testing.T) {
+	write := useConfigFile(t, "LOG_LEVEL")
+	write("LOG_LEVEL=info\n")
+	if err := loadConfigFile(); err != nil {
+		t.Fatal(err)
+	}
+	logger := slog.New(slog.NewTextHandler(&bytes.Buffer{}, nil))
+	level := new(slog.LevelVar)
+	level.Set(loadLogLevel(logger))
+
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, syscall.SIGHUP)
+	done := make(chan struct{})
+	go func() {
+		reloadOn(sig, logger, level)
+		close(done)
+	}()
+	defer func() {
+		signal.Stop(sig)
+		close(sig)
+		<-done
+	}()
+
+	write("LOG_LEVEL=debug\n")
+	deadline := time.Now().Add(5 * time.Second)
+	for level.Level() != slog.LevelDebug {
+		if time.Now().After(deadline) {
+			t.Fatalf("log level still %v after SIGHUP", level.Level())
+		}
+		if err := syscall.Kill(os.Getpid(), syscall.SIGHUP); err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+func TestReloadIgnoresRestartOnlySettings(t *testing.T) {
+	write := useConfigFile(t, "LOG_LEVEL", "BILLING_CREATE_ATTEMPTS")
+	write("LOG_LEVEL=info\nBILLING_CREATE_ATTEMPTS=4\n")
+	if err := loadConfigFile(); err != nil {
+		t.Fatal(err)
+	}
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&logs, nil))
+	level := new(slog.LevelVar)
+
+	write("LOG_LEVEL=warn\nBILLING_CREATE_ATTEMPTS=8\n")
+	reload(logger, level)
+	if level.Level() != slog.LevelWarn {
+		t.Errorf("log level %v, want warn", level.Level())
+	}
+	if !strings.Contains(logs.String(), `requires a restart, ignored" key=BILLING_CREATE_ATTEMPTS`) {
+		t.Errorf("restart-only change not reported:\n%s", logs.String())
+	}
+
+	// A file that does not parse changes nothing.
+	write("LOG_LEVEL=debug\nnot a setting\n")
+	reload(logger, level)
+	if level.Level() != slog.LevelWarn || os.Getenv("LOG_LEVEL") != "warn" {
+		t.Errorf("invalid file applied: level %v, LOG_LEVEL %q", level.Level(), os.Getenv("LOG_LEVEL"))
+	}
+}
+
+func TestLoadConfigFileRestoresRemovedKeys(t *testing.T) {
+	write := useConfigFile(t, "LOG_LEVEL")
+	os.Unsetenv("LOG_LEVEL")
+	write("LOG_LEVEL=debug\n")
+	if err := loadConfigFile(); err != nil {
+		t.Fatal(err)
+	}
+	write("")
+	if err := loadConfigFile(); err != nil {
+		t.Fatal(err)
+	}
+	if v, set := os.LookupEnv("LOG_LEVEL"); set {
+		t.Errorf("LOG_LEVEL=%q after it was removed from the file, want unset", v)
+	}
+	if got := loadLogLevel(slog.Default()); got != slog.LevelInfo {
+		t.Errorf("log level %v, want the default", got)
+	}
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/nats-io/nats.go"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// requestIDMetadataKey is the gRPC metadata key the gateway forwards the HTTP
+// request id in, and requestIDHeader the NATS header events carry it in.
+const (
+	requestIDMetadataKey = "x-request-id"
+	requestIDHeader      = "X-Request-ID"
+)
+
+type requestIDKey struct{}
+
+// withRequestID returns a copy of ctx carrying id; an empty id leaves ctx as is.
+func withRequestID(ctx context.Context, id string) context.Context {
+	if id == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// requestIDFrom returns the request id in ctx, or "".
+func requestIDFrom(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// msgContext returns a context carrying the request id and trace context of
+// an incoming event.
+func msgContext(m *nats.Msg) context.Context {
+	ctx := otel.GetTextMapPropagator().Extract(context.Background(), propagation.HeaderCarrier(m.Header))
+	return withRequestID(ctx, m.Header.Get(requestIDHeader))
+}
+
+// incomingRequestID moves the request id from the incoming metadata into ctx.
+func incomingRequestID(ctx context.Context) context.Context {
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if v := md.Get(requestIDMetadataKey); len(v) > 0 {
+			return withRequestID(ctx, v[0])
+		}
+	}
+	return ctx
+}
+
+// requestIDUnaryInterceptor makes the caller's request id available to the
+// handler and its log lines.
+func requestIDUnaryInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	return handler(incomingRequestID(ctx), req)
+}
+
+// requestIDStreamInterceptor does the same for streaming RPCs.
+func requestIDStreamInterceptor(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	return handler(srv, &requestIDStream{ServerStream: ss, ctx: incomingRequestID(ss.Context())})
+}
+
+type requestIDStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *requestIDStream) Context() context.Context { return s.ctx }
+
+// requestIDHandler adds a request_id attribute to every record logged with a
+// context that carries one.
+type requestIDHandler struct {
+	slog.Handler
+}
+
+func (h requestIDHandler) Handle(ctx context.Context, r slog.Record) error {
+	if id := requestIDFrom(ctx); id != "" {
+		r.AddAttrs(slog.String("request_id", id))
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h requestIDHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return requestIDHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h requestIDHandler) WithGroup(name string) slog.Handler {
+	return requestIDHandler{h.Handler.WithGroup(name)}
+}
@@ -0,0 +1,18 @@
+package main
+
+import (
+	"context"
+
+	"billing-ms/billingpb"
+)
+
+// eventSchemas lists the events billing-ms publishes. Bump a version whenever
+// a payload changes in a way existing consumers cannot read.
+var eventSchemas = []*billingpb.EventSchema{
+	{Subject: "bill.update", Version: 2, Description: "A bill amount changed. Payload: event_id, Id, MessageID, Params, locale (optional)."},
+	{Subject: subjectAccountCreationFailed, Version: 1, Description: "A billing account could not be created. Payload: event_id, uid, attempts, error."},
+}
+
+func (s *server) GetEventSchemas(ctx context.Context, req *billingpb.GetEventSchemasRequest) (*billingpb.GetEventSchemasResponse, error) {
+	return &billingpb.GetEventSchemasResponse{Events: eventSchemas}, nil
+}
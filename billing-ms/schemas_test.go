@@ -0,0 +1,28 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"billing-ms/billingpb"
+)
+
+func TestGetEventSchemas(t *testing.T) {
+	s, _ := newTestServer(t)
+	res, err := s.GetEventSchemas(context.Background(), &billingpb.GetEventSchemasRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]int32{subjectBillUpdate: 2, subjectAccountCreationFailed: 1}
+	if len(res.Events) != len(want) {
+		t.Errorf("%d events listed, want %d", len(res.Events), len(want))
+	}
+	for _, e := range res.Events {
+		if v, ok := want[e.Subject]; !ok || e.Version != v {
+			t.Errorf("%s listed at version %d, want %d", e.Subject, e.Version, v)
+		}
+		if e.Description == "" {
+			t.Errorf("%s has no description", e.Subject)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"io"
+	"log/slog"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	natsserver "github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// newTestServer returns a server backed by a mock database with reads going
+// to the primary and the default amount bounds. The mock's expectations are checked when the test ends.
+func newTestServer(t *testing.T) (*server, sqlmock.Sqlmock) {
+	t.Helper()
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+		db.Close()
+	})
+	return &server{
+		logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+		db:     db,
+		reads:  newReadRouter(db, nil, 0),
+		bounds: amountBounds{min: defaultMinAmount, max: defaultMaxAmount},
+	}, mock
+}
+
+// literal matches a query containing s literally.
+func literal(s string) string { return regexp.QuoteMeta(s) }
+
+// wantCode fails the test unless err carries the gRPC code want.
+func wantCode(t *testing.T, err error, want codes.Code) {
+	t.Helper()
+	if got := status.Code(err); got != want {
+		t.Fatalf("got code %v (%v), want %v", got, err, want)
+	}
+}
+
+// runJetStream starts an in-process NATS server with JetStream, creates the
+// events stream on it and returns a connection to it. Both are shut down
+// when the test ends.
+func runJetStream(t *testing.T) (*nats.Conn, nats.JetStreamContext) {
+	t.Helper()
+	_, nc, js := startJetStream(t)
+	return nc, js
+}
+
+// startJetStream is runJetStream for tests that also need the server, e.g.
+// to shut it down early.
+func startJetStream(t *testing.T) (*natsserver.Server, *nats.Conn, nats.JetStreamContext) {
+	t.Helper()
+	ns, err := natsserver.NewServer(&natsserver.Options{Port: -1, JetStream: true, StoreDir: t.TempDir(), NoLog: true, NoSigs: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ns.Start()
+	t.Cleanup(ns.Shutdown)
+	if !ns.ReadyForConnections(5 * time.Second) {
+		t.Fatal("NATS server not ready")
+	}
+	nc, err := nats.Connect(ns.ClientURL())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(nc.Close)
+	js, err := ensureEventStream(nc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ns, nc, js
+}
+
+// withPublisher gives s an event publisher and bill notifier on an embedded
+// JetStream server and returns a connection to it.
+func withPublisher(t *testing.T, s *server) (*nats.Conn, nats.JetStreamContext) {
+	t.Helper()
+	nc, js := runJetStream(t)
+	events, err := newPublisher(nc, js)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bills, err := newBillNotifier(s.logger, events)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.events, s.bills = events, bills
+	return nc, js
+}
+
+// subscribeSync subscribes to subject, failing the test on error.
+func subscribeSync(t *testing.T, nc *nats.Conn, subject string) *nats.Subscription {
+	t.Helper()
+	sub, err := nc.SubscribeSync(subject)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sub
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"net/url"
+	"strings"
+)
+
+// version is the build version, set with -ldflags "-X main.version=...".
+var version = "dev"
+
+// dsnTarget describes a Postgres DSN, in URL or key=value form, as
+// "host/dbname" so it can be logged without credentials.
+func dsnTarget(dsn string) string {
+	if u, err := url.Parse(dsn); err == nil && u.Scheme != "" {
+		return u.Host + u.Path
+	}
+	var host, dbname string
+	for _, kv := range strings.Fields(dsn) {
+		k, v, _ := strings.Cut(kv, "=")
+		switch k {
+		case "host":
+			host = v
+		case "dbname":
+			dbname = v
+		}
+	}
+	return host + "/" + dbname
+}
+
+// logStartup emits the single "service started" entry summarising what the
+// service is wired to. Call it once every dependency has been connected.
+func logStartup(logger *slog.Logger, listenAddr, dsn, natsURL string, features map[string]any) {
+	logger.Info("service started",
+		"service", "billing-ms",
+		"version", version,
+		"listen_addr", listenAddr,
+		"log_level", enabledLevel(logger.Handler()).String(),
+		"db", dsnTarget(dsn),
+		"nats_url", natsURL,
+		"features", features,
+	)
+}
+
+// enabledLevel returns the lowest standard level h logs.
+func enabledLevel(h slog.Handler) slog.Level {
+	for _, level := range []slog.Level{slog.LevelDebug, slog.LevelInfo, slog.LevelWarn} {
+		if h.Enabled(context.Background(), level) {
+			return level
+		}
+	}
+	return slog.LevelError
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"google.golang.org/grpc/codes"
+
+	"billing-ms/billingpb"
+)
+
+const selectStats = "SELECT COUNT(*), COALESCE(SUM(amount), 0) FROM billing"
+
+func TestStatsAggregatesAccounts(t *testing.T) {
+	s, mock := newTestServer(t)
+	nc, js := withPublisher(t, s)
+	s.subs = newSubscriptions(nc, js, "billing-ms")
+	s.subs.Pause()
+
+	mock.ExpectQuery(literal(selectStats)).WillReturnRows(sqlmock.NewRows([]string{"count", "sum"}).AddRow(3, 42.5))
+	res, err := s.Stats(context.Background(), &billingpb.StatsRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Accounts != 3 || res.TotalAmount != 42.5 || !res.ConsumptionPaused {
+		t.Errorf("stats = %+v, want 3 accounts totalling 42.50 with consumption paused", res)
+	}
+}
+
+func TestStatsDatabaseError(t *testing.T) {
+	s, mock := newTestServer(t)
+	mock.ExpectQuery(literal(selectStats)).WillReturnError(errors.New("connection reset"))
+	_, err := s.Stats(context.Background(), &billingpb.StatsRequest{})
+	wantCode(t, err, codes.Internal)
+}
@@ -0,0 +1,302 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+// subscriptions keeps track of the NATS subscriptions the service depends on
+// so they can be re-established after a reconnect, and lets operators pause
+// event processing.
+//
+// Subjects in the events stream are consumed through a durable JetStream
+// consumer named after the service, so events published while the service was
+// down are delivered when it starts again. Each message is acked once its
+// handler returns, unless the handler already settled it, e.g. with a Nak to
+// have it redelivered. One that was never acked, e.g. because the service
+// crashed mid-way, is redelivered after the consumer's ack wait.
+//
+// The consumers are created here and subscriptions bind to them, so
+// unsubscribing leaves the consumer and its position in place. Pausing
+// drains every subscription: messages already received are still handled,
+// and nothing more is delivered until Resume subscribes again. A consumer
+// stays bound to its draining subscription, so Resume waits for the drain to
+// finish before binding a new one. Core NATS
+// subjects are not stored, so what is published on them while paused is
+// missed.
+type subscriptions struct {
+	nc      *nats.Conn
+	js      nats.JetStreamContext
+	service string
+
+	mu     sync.Mutex
+	subs   map[string]*subscription
+	paused bool
+	// onPause is told about every pause and resume.
+	onPause func(paused bool)
+}
+
+type subscription struct {
+	handler nats.MsgHandler
+	// after returns the last stream sequence already processed, see
+	// SubscribeAfter; nil for subscriptions without one.
+	after func() uint64
+	// stream and durable name the JetStream consumer; both are empty for
+	// core subjects.
+	stream  string
+	durable string
+	// sub is nil while consumption is paused.
+	sub *nats.Subscription
+	// draining is the subscription Pause drained, until it has finished.
+	draining *nats.Subscription
+}
+
+// drainWait bounds how long Resume waits for a paused subscription to finish
+// handling what it had already received.
+var drainWait = 10 * time.Second
+
+// newSubscriptions creates a registry for nc and installs a reconnect handler
+// that restores any subscription the reconnect left invalid. service prefixes
+// the names of the durable consumers.
+func newSubscriptions(nc *nats.Conn, js nats.JetStreamContext, service string) *subscriptions {
+	s := &subscriptions{nc: nc, js: js, service: service, subs: make(map[string]*subscription)}
+	nc.SetDisconnectErrHandler(func(_ *nats.Conn, err error) {
+		slog.Warn("disconnected from NATS", "error", err)
+	})
+	nc.SetReconnectHandler(func(nc *nats.Conn) {
+		slog.Info("reconnected to NATS", "url", nc.ConnectedUrlRedacted())
+		s.resubscribe()
+	})
+	return s
+}
+
+// Subscribe subscribes handler to subject and remembers it for resubscription.
+func (s *subscriptions) Subscribe(subject string, handler nats.MsgHandler) error {
+	return s.SubscribeAfter(subject, nil, handler)
+}
+
+// SubscribeAfter is Subscribe for a stream subject whose progress is also
+// recorded outside NATS: after returns the last stream sequence processed.
+// If the durable consumer has to be created, e.g. because it was deleted or
+// expired, it starts right after that sequence instead of replaying the
+// whole stream. An existing consumer keeps its own position.
+func (s *subscriptions) SubscribeAfter(subject string, after func() uint64, handler nats.MsgHandler) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry := &subscription{handler: handler, after: after}
+	if isStreamSubject(subject) {
+		if err := s.ensureConsumer(subject, entry); err != nil {
+			return err
+		}
+	}
+	if !s.paused {
+		sub, err := s.subscribe(subject, entry)
+		if err != nil {
+			return err
+		}
+		entry.sub = sub
+	}
+	s.subs[subject] = entry
+	return nil
+}
+
+// durableName is the name of the service's durable consumer for subject.
+func (s *subscriptions) durableName(subject string) string {
+	return s.service + "-" + strings.ReplaceAll(subject, ".", "-")
+}
+
+// ensureConsumer looks up the durable consumer for subject, creating it if it
+// does not exist yet, and records it in entry.
+func (s *subscriptions) ensureConsumer(subject string, entry *subscription) error {
+	stream, err := s.js.StreamNameBySubject(subject)
+	if err != nil {
+		return err
+	}
+	durable := s.durableName(subject)
+	entry.stream, entry.durable = stream, durable
+
+	_, err = s.js.ConsumerInfo(stream, durable)
+	if err == nil || !errors.Is(err, nats.ErrConsumerNotFound) {
+		return err
+	}
+	cfg := &nats.ConsumerConfig{
+		Durable:        durable,
+		DeliverSubject: nats.NewInbox(),
+		DeliverPolicy:  nats.DeliverAllPolicy,
+		AckPolicy:      nats.AckExplicitPolicy,
+		FilterSubject:  subject,
+	}
+	if entry.after != nil {
+		if last := entry.after(); last > 0 {
+			slog.Info("starting consumer after processed cursor", "subject", subject, "durable", durable, "start_seq", last+1)
+			cfg.DeliverPolicy = nats.DeliverByStartSequencePolicy
+			cfg.OptStartSeq = last + 1
+		}
+	}
+	if _, err := s.js.AddConsumer(stream, cfg); err != nil {
+		// Another instance may have created it in the meantime.
+		if _, infoErr := s.js.ConsumerInfo(stream, durable); infoErr != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// subscribe creates the subscription for subject: a manually acked
+// subscription bound to the durable consumer for stream subjects and a core
+// subscription otherwise.
+func (s *subscriptions) subscribe(subject string, entry *subscription) (*nats.Subscription, error) {
+	if entry.durable == "" {
+		return s.nc.Subscribe(subject, entry.handler)
+	}
+	return s.js.Subscribe(subject, func(m *nats.Msg) {
+		entry.handler(m)
+		if err := m.Ack(); err != nil && !errors.Is(err, nats.ErrMsgAlreadyAckd) {
+			slog.Error("failed to ack message", "subject", subject, "error", err)
+		}
+	}, nats.Bind(entry.stream, entry.durable), nats.ManualAck())
+}
+
+// OnPauseChange registers fn to be called whenever consumption is paused or
+// resumed.
+func (s *subscriptions) OnPauseChange(fn func(paused bool)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.onPause = fn
+}
+
+// Pause stops event processing until Resume is called.
+func (s *subscriptions) Pause() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.paused {
+		return
+	}
+	s.paused = true
+	for subject, entry := range s.subs {
+		s.drain(subject, entry)
+	}
+	slog.Info("event consumption paused")
+	if s.onPause != nil {
+		s.onPause(true)
+	}
+}
+
+// drain drains entry's subscription, if any, and keeps it as draining.
+func (s *subscriptions) drain(subject string, entry *subscription) {
+	if entry.sub == nil {
+		return
+	}
+	if err := entry.sub.Drain(); err != nil {
+		slog.Error("failed to drain subscription", "subject", subject, "error", err)
+	}
+	entry.draining, entry.sub = entry.sub, nil
+}
+
+// Resume continues event processing, starting with the messages stored
+// while paused. If a subject cannot be subscribed again, consumption stays
+// paused and the error is returned; Resume can then be retried.
+func (s *subscriptions) Resume() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.paused {
+		return nil
+	}
+	for subject, entry := range s.subs {
+		if err := s.awaitDrained(entry); err != nil {
+			return s.abortResume(subject, err)
+		}
+		sub, err := s.subscribe(subject, entry)
+		if err != nil {
+			return s.abortResume(subject, err)
+		}
+		entry.sub = sub
+	}
+	s.paused = false
+	slog.Info("event consumption resumed")
+	if s.onPause != nil {
+		s.onPause(false)
+	}
+	return nil
+}
+
+// awaitDrained waits up to drainWait for the subscription Pause drained to
+// finish, so its consumer is free to bind again.
+func (s *subscriptions) awaitDrained(entry *subscription) error {
+	if entry.draining == nil {
+		return nil
+	}
+	deadline := time.Now().Add(drainWait)
+	for entry.draining.IsValid() {
+		if time.Now().After(deadline) {
+			return errors.New("previous subscription is still draining")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	entry.draining = nil
+	return nil
+}
+
+// abortResume drains the subscriptions a failed Resume already made, so
+// consumption is paused again as a whole.
+func (s *subscriptions) abortResume(subject string, err error) error {
+	slog.Error("failed to resubscribe, staying paused", "subject", subject, "error", err)
+	for subject, entry := range s.subs {
+		s.drain(subject, entry)
+	}
+	return fmt.Errorf("resubscribe to %s: %w", subject, err)
+}
+
+// Paused reports whether consumption is paused and how many messages are
+// waiting across all subscriptions: stored and not yet acked for stream
+// subjects, received and not yet handled for core ones.
+func (s *subscriptions) Paused() (bool, int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	pending := 0
+	for _, entry := range s.subs {
+		if entry.durable != "" {
+			if info, err := s.js.ConsumerInfo(entry.stream, entry.durable); err == nil {
+				pending += int(info.NumPending) + info.NumAckPending
+			}
+			continue
+		}
+		if entry.sub != nil {
+			if n, _, err := entry.sub.Pending(); err == nil {
+				pending += n
+			}
+		}
+	}
+	return s.paused, pending
+}
+
+// resubscribe re-creates every subscription that is no longer valid. Core
+// NATS subscriptions normally survive a reconnect; this covers the ones that
+// don't. Nothing is subscribed while paused.
+func (s *subscriptions) resubscribe() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.paused {
+		return
+	}
+
+	for subject, entry := range s.subs {
+		if entry.sub != nil && entry.sub.IsValid() {
+			continue
+		}
+		sub, err := s.subscribe(subject, entry)
+		if err != nil {
+			slog.Error("failed to resubscribe", "subject", subject, "error", err)
+			continue
+		}
+		entry.sub = sub
+		slog.Info("resubscribed", "subject", subject)
+	}
+}
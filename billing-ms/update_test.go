@@ -0,0 +1,160 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+
+	"billing-ms/billingpb"
+)
+
+const selectForUpdate = "SELECT amount, version FROM billing WHERE user_id = $1 FOR UPDATE"
+
+func TestUpdateBillingMissingAccount(t *testing.T) {
+	s, mock := newTestServer(t)
+	nc, _ := withPublisher(t, s)
+	updates := subscribeSync(t, nc, subjectBillUpdate)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(literal(selectForUpdate)).WithArgs("u1").WillReturnRows(sqlmock.NewRows([]string{"amount", "version"}))
+	mock.ExpectRollback()
+
+	_, err := s.UpdateBilling(context.Background(), &billingpb.UpdateBillingRequest{UserId: "u1", Amount: 10})
+	wantCode(t, err, codes.NotFound)
+	if m, err := updates.NextMsg(100 * time.Millisecond); err == nil {
+		t.Errorf("bill.update published for a missing account: %s", m.Data)
+	}
+}
+
+func TestUpdateBillingAutoCreatesMissingAccount(t *testing.T) {
+	s, mock := newTestServer(t)
+	s.autoCreate = true
+	nc, _ := withPublisher(t, s)
+	updates := subscribeSync(t, nc, subjectBillUpdate)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(literal(selectForUpdate)).WithArgs("u1").WillReturnRows(sqlmock.NewRows([]string{"amount", "version"}))
+	mock.ExpectExec(literal("INSERT INTO billing (user_id, amount)")).WithArgs("u1", 0.0).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(literal("UPDATE billing SET amount")).WithArgs(10.0, "u1").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(literal("INSERT INTO billing_ledger")).WithArgs("u1", 10.0).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	res, err := s.UpdateBilling(context.Background(), &billingpb.UpdateBillingRequest{UserId: "u1", Amount: 10})
+	if err != nil {
+		t.Fatalf("UpdateBilling: %v", err)
+	}
+	if !res.Success || res.Version != 1 {
+		t.Errorf("response = %+v", res)
+	}
+	if _, err := updates.NextMsg(5 * time.Second); err != nil {
+		t.Errorf("no bill.update for the created account: %v", err)
+	}
+}
+
+func TestUpdateBillingPublishesUpdate(t *testing.T) {
+	s, mock := newTestServer(t)
+	nc, _ := withPublisher(t, s)
+	updates := subscribeSync(t, nc, subjectBillUpdate)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(literal(selectForUpdate)).WithArgs("u1").WillReturnRows(sqlmock.NewRows([]string{"amount", "version"}).AddRow(4.0, 3))
+	mock.ExpectExec(literal("UPDATE billing SET amount")).WithArgs(10.0, "u1").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(literal("INSERT INTO billing_ledger")).WithArgs("u1", 6.0).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	res, err := s.UpdateBilling(context.Background(), &billingpb.UpdateBillingRequest{UserId: "u1", Amount: 10})
+	if err != nil {
+		t.Fatalf("UpdateBilling: %v", err)
+	}
+	if res.Version != 4 {
+		t.Errorf("version %d, want 4", res.Version)
+	}
+	m, err := updates.NextMsg(5 * time.Second)
+	if err != nil {
+		t.Fatalf("no bill.update: %v", err)
+	}
+	var event billUpdateEvent
+	if err := json.Unmarshal(m.Data, &event); err != nil {
+		t.Fatal(err)
+	}
+	if event.Id != "u1" || event.Params["amount"] != "10.00" || event.EventID == "" {
+		t.Errorf("bill.update = %+v", event)
+	}
+}
+
+func TestUpdateBillingRejectsStaleVersion(t *testing.T) {
+	s, mock := newTestServer(t)
+	nc, _ := withPublisher(t, s)
+	updates := subscribeSync(t, nc, subjectBillUpdate)
+
+	// Two clients read the account at version 3.
+	mock.ExpectQuery(literal("SELECT amount, version FROM billing WHERE user_id = $1")).WithArgs("u1").
+		WillReturnRows(sqlmock.NewRows([]string{"amount", "version"}).AddRow(4.0, 3))
+	read, err := s.GetBilling(context.Background(), &billingpb.GetBillingRequest{UserId: "u1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if read.Version != 3 {
+		t.Fatalf("GetBilling version %d, want 3", read.Version)
+	}
+
+	// The first write at version 3 wins and moves the account to version 4.
+	mock.ExpectBegin()
+	mock.ExpectQuery(literal(selectForUpdate)).WithArgs("u1").WillReturnRows(sqlmock.NewRows([]string{"amount", "version"}).AddRow(4.0, 3))
+	mock.ExpectExec(literal("UPDATE billing SET amount = $1, version = version + 1")).WithArgs(10.0, "u1").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(literal("INSERT INTO billing_ledger")).WithArgs("u1", 6.0).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	res, err := s.UpdateBilling(context.Background(), &billingpb.UpdateBillingRequest{UserId: "u1", Amount: 10, ExpectedVersion: &read.Version})
+	if err != nil {
+		t.Fatalf("current-version update: %v", err)
+	}
+	if res.Version != 4 {
+		t.Errorf("version %d after update, want 4", res.Version)
+	}
+	if _, err := updates.NextMsg(5 * time.Second); err != nil {
+		t.Fatalf("no bill.update for the winning write: %v", err)
+	}
+
+	// The second write still expects version 3 and is rolled back untouched.
+	mock.ExpectBegin()
+	mock.ExpectQuery(literal(selectForUpdate)).WithArgs("u1").WillReturnRows(sqlmock.NewRows([]string{"amount", "version"}).AddRow(10.0, 4))
+	mock.ExpectRollback()
+	_, err = s.UpdateBilling(context.Background(), &billingpb.UpdateBillingRequest{UserId: "u1", Amount: 20, ExpectedVersion: &read.Version})
+	wantCode(t, err, codes.Aborted)
+	if m, err := updates.NextMsg(100 * time.Millisecond); err == nil {
+		t.Errorf("bill.update published for a rejected write: %s", m.Data)
+	}
+}
+
+func TestUpdateBillingTagsEventWithForwardedLocale(t *testing.T) {
+	s, mock := newTestServer(t)
+	nc, _ := withPublisher(t, s)
+	updates := subscribeSync(t, nc, subjectBillUpdate)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(literal(selectForUpdate)).WithArgs("u1").WillReturnRows(sqlmock.NewRows([]string{"amount", "version"}).AddRow(4.0, 3))
+	mock.ExpectExec(literal("UPDATE billing SET amount")).WithArgs(10.0, "u1").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(literal("INSERT INTO billing_ledger")).WithArgs("u1", 6.0).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(localeMetadataKey, "de"))
+	if _, err := s.UpdateBilling(ctx, &billingpb.UpdateBillingRequest{UserId: "u1", Amount: 10}); err != nil {
+		t.Fatalf("UpdateBilling: %v", err)
+	}
+	m, err := updates.NextMsg(5 * time.Second)
+	if err != nil {
+		t.Fatalf("no bill.update: %v", err)
+	}
+	var event billUpdateEvent
+	if err := json.Unmarshal(m.Data, &event); err != nil {
+		t.Fatal(err)
+	}
+	if event.Locale != "de" {
+		t.Errorf("bill.update locale %q, want de", event.Locale)
+	}
+}
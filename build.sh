@@ -23,7 +23,7 @@ count=1
 for i in "${arr[@]}"
 do
     echo -e "${BLUE}[$count/${#arr[@]}] Building $i$RESET"
-    docker build --file "$projectDir/$i/Dockerfile" --tag "$i-local" "$projectDir/$i/"
+    docker build --file "$projectDir/$i/Dockerfile" --build-arg VERSION="$timestamp" --tag "$i-local" "$projectDir/$i/"
     EXITCODE=$?
     if [ $EXITCODE != 0 ]; then
         echo -e "${RED}Build failed with exit code $EXITCODE$RESET"
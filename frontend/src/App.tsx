@@ -20,6 +20,7 @@ interface User {
 interface LoginResponse {
   token: string;
   user: User;
+  refresh_token: string;
 }
 
 // Notification object from WebSocket
@@ -28,6 +29,11 @@ interface Notification {
   userId: string;
   message: string;
   timestamp: string; // This will be a string from JSON, we can format it.
+  event?: string; // Set on control messages such as "notification.read"
+  // Enum names, e.g. "NOTIFICATION_TYPE_BILLING" and "SEVERITY_WARNING"
+  type?: string;
+  severity?: string;
+  source?: string;
 }
 
 // Props for sub-components
@@ -46,23 +52,31 @@ const App: React.FC = () => {
   const [email, setEmail] = useState("");
   const [password, setPassword] = useState("");
   const [loggedInUser, setLoggedInUser] = useState<User | null>(null);
+  const [token, setToken] = useState<string | null>(null);
+  const [refreshToken, setRefreshToken] = useState<string | null>(null);
   const [billingAmount, setBillingAmount] = useState<number | null>(null);
   const [notifications, setNotifications] = useState<Notification[]>([]);
   const [error, setError] = useState<string | null>(null);
 
   // Ref to hold the WebSocket instance
   const ws = useRef<WebSocket | null>(null);
+  // Identifies this tab's notification session across reconnects
+  const sessionId = useRef(crypto.randomUUID());
 
   // --- Effects ---
 
   // Effect for WebSocket connection
   useEffect(() => {
-    if (loggedInUser) {
+    if (loggedInUser && token) {
       // Fetch initial data
       fetchBillingInfo(loggedInUser.id);
 
-      // Connect to WebSocket
-      const socket = new WebSocket(`${WS_URL}?userId=${loggedInUser.id}`);
+      // Connect to WebSocket; the token goes in the subprotocol list so it
+      // stays out of the URL.
+      const socket = new WebSocket(
+        `${WS_URL}?sessionId=${sessionId.current}`,
+        ["bearer", token]
+      );
       ws.current = socket;
 
       socket.onopen = () => {
@@ -73,7 +87,17 @@ const App: React.FC = () => {
 
       socket.onmessage = (event) => {
         try {
-          const notification: Notification = JSON.parse(event.data);
+          const data = JSON.parse(event.data);
+          // The gateway sends an error frame before closing when it can't subscribe
+          if (data.error) {
+            setError(`Notifications unavailable: ${data.error}`);
+            return;
+          }
+          const notification: Notification = data;
+          // Control messages (e.g. read-sync from another device) are not shown
+          if (notification.event) {
+            return;
+          }
           // Add the received notification to the state
           setNotifications((prev) => [notification, ...prev]);
         } catch (err) {
@@ -104,14 +128,15 @@ const App: React.FC = () => {
       setBillingAmount(null);
       setNotifications([]);
     }
-  }, [loggedInUser]);
+  }, [loggedInUser, token]);
 
   // --- API Calls ---
 
   const fetchBillingInfo = async (userId: string) => {
     try {
       const response = await axios.get<{ amount: number }>(
-        `${API_URL}/user/billing/${userId}`
+        `${API_URL}/user/billing/${userId}`,
+        { headers: authHeaders() }
       );
       if (response.data && typeof response.data.amount === "number") {
         setBillingAmount(response.data.amount);
@@ -132,7 +157,11 @@ const App: React.FC = () => {
     e.preventDefault();
     setError(null);
     try {
-      await axios.post(`${API_URL}/register`, { email, password });
+      await axios.post(`${API_URL}/register`, {
+        email,
+        password,
+        locale: navigator.language,
+      });
       addLocalNotification(
         `User '${email}' registered successfully! Please log in.`
       );
@@ -154,6 +183,8 @@ const App: React.FC = () => {
         password,
       });
       if (response.data && response.data.user) {
+        setToken(response.data.token);
+        setRefreshToken(response.data.refresh_token);
         setLoggedInUser(response.data.user);
       } else {
         throw new Error("Invalid login response from server");
@@ -165,8 +196,18 @@ const App: React.FC = () => {
   };
 
   const handleLogout = () => {
+    // Revoke the token server-side; the local session ends either way.
+    axios
+      .post(
+        `${API_URL}/logout`,
+        { refresh_token: refreshToken },
+        { headers: authHeaders() }
+      )
+      .catch((err) => console.error("Failed to revoke token:", err));
     addLocalNotification(`User ${loggedInUser?.email} logged out.`);
     setLoggedInUser(null);
+    setToken(null);
+    setRefreshToken(null);
     // The useEffect will handle closing the WebSocket
   };
 
@@ -176,7 +217,7 @@ const App: React.FC = () => {
       await axios.post(`${API_URL}/user/billing/update`, {
         user_id: loggedInUser.id,
         amount: 10.5 + billingAmount!, // Increment by a fixed amount for demo
-      });
+      }, { headers: authHeaders() });
       // Re-fetch billing info to show the update
       // A notification will also arrive via WebSocket
       fetchBillingInfo(loggedInUser.id);
@@ -188,6 +229,9 @@ const App: React.FC = () => {
 
   // --- Helper Functions ---
 
+  // The billing routes require the token returned by /login
+  const authHeaders = () => (token ? { Authorization: `Bearer ${token}` } : {});
+
   // Adds a notification generated by the frontend
   const addLocalNotification = (message: string) => {
     const newNotif: Notification = {
@@ -4,5 +4,6 @@ set -e
 psql -v ON_ERROR_STOP=1 --username "$POSTGRES_USER" --dbname "$POSTGRES_DB" <<-EOSQL
     CREATE DATABASE userdb;
     CREATE DATABASE billingdb;
+    CREATE DATABASE notificationdb;
 EOSQL
 
@@ -0,0 +1,234 @@
+package main
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	"notification-ms/notifpb"
+	"notification-ms/userpb"
+)
+
+const (
+	backfillRunning   = "running"
+	backfillCompleted = "completed"
+	backfillFailed    = "failed"
+
+	// backfillPageSize is how many users are fetched from user-ms at a time.
+	// Progress is saved after every page.
+	backfillPageSize = 100
+)
+
+// backfillRunner runs backfill jobs in the background. A job interrupted by a
+// shutdown or crash keeps its "running" status and is picked up again from
+// its last saved page when the service starts.
+type backfillRunner struct {
+	users userpb.UserServiceClient
+	// interval is the pause between two notifications; zero sends as fast as
+	// the dispatcher accepts them.
+	interval time.Duration
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	mu      sync.Mutex
+	running map[string]bool
+}
+
+// newBackfillRunner sends at most rate notifications per second; rate <= 0
+// removes the limit.
+func newBackfillRunner(users userpb.UserServiceClient, rate int) *backfillRunner {
+	r := &backfillRunner{users: users, running: make(map[string]bool)}
+	if rate > 0 {
+		r.interval = time.Second / time.Duration(rate)
+	}
+	r.ctx, r.cancel = context.WithCancel(context.Background())
+	return r
+}
+
+// Stop interrupts running jobs and waits for them to save their progress.
+func (r *backfillRunner) Stop() {
+	r.cancel()
+	r.wg.Wait()
+}
+
+// StartBackfill creates a backfill job for req and starts it.
+func (s *notificationServer) StartBackfill(ctx context.Context, req *notifpb.StartBackfillRequest) (*notifpb.StartBackfillResponse, error) {
+	if req.MessageId == "" {
+		return nil, status.Error(codes.InvalidArgument, "message_id is required")
+	}
+	if req.Type == notifpb.NotificationType_NOTIFICATION_TYPE_UNSPECIFIED {
+		return nil, status.Error(codes.InvalidArgument, "type is required")
+	}
+	if _, err := s.catalog.Render("", req.MessageId, req.Params); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "cannot render message: %v", err)
+	}
+	job := &notifpb.BackfillJob{
+		Id:        uuid.NewString(),
+		MessageId: req.MessageId,
+		Params:    req.Params,
+		Type:      req.Type,
+		Severity:  req.Severity,
+		Status:    backfillRunning,
+	}
+	if job.Severity == notifpb.Severity_SEVERITY_UNSPECIFIED {
+		job.Severity = notifpb.Severity_SEVERITY_INFO
+	}
+	if err := s.store.CreateBackfill(ctx, job); err != nil {
+		logf(ctx, "failed to create backfill job: %v", err)
+		return nil, status.Error(codes.Internal, "could not create backfill job")
+	}
+	logf(ctx, "Starting backfill %s of message %s", job.Id, job.MessageId)
+	s.startBackfill(ctx, job)
+	return &notifpb.StartBackfillResponse{Job: job}, nil
+}
+
+// GetBackfill returns a job and its saved progress.
+func (s *notificationServer) GetBackfill(ctx context.Context, req *notifpb.GetBackfillRequest) (*notifpb.GetBackfillResponse, error) {
+	job, err := s.store.Backfill(ctx, req.Id)
+	if err == errBackfillNotFound {
+		return nil, status.Error(codes.NotFound, "backfill job not found")
+	}
+	if err != nil {
+		logf(ctx, "failed to load backfill job %s: %v", req.Id, err)
+		return nil, status.Error(codes.Internal, "could not load backfill job")
+	}
+	return &notifpb.GetBackfillResponse{Job: job}, nil
+}
+
+// ResumeBackfill restarts a failed job after its last saved page.
+func (s *notificationServer) ResumeBackfill(ctx context.Context, req *notifpb.ResumeBackfillRequest) (*notifpb.ResumeBackfillResponse, error) {
+	job, err := s.store.Backfill(ctx, req.Id)
+	if err == errBackfillNotFound {
+		return nil, status.Error(codes.NotFound, "backfill job not found")
+	}
+	if err != nil {
+		logf(ctx, "failed to load backfill job %s: %v", req.Id, err)
+		return nil, status.Error(codes.Internal, "could not load backfill job")
+	}
+	if job.Status != backfillFailed {
+		return nil, status.Errorf(codes.FailedPrecondition, "backfill job is %s, only failed jobs can be resumed", job.Status)
+	}
+
+	job.Status, job.Error = backfillRunning, ""
+	if err := s.store.UpdateBackfill(ctx, job); err != nil {
+		logf(ctx, "failed to update backfill job %s: %v", job.Id, err)
+		return nil, status.Error(codes.Internal, "could not resume backfill job")
+	}
+	logf(ctx, "Resuming backfill %s after user %q (%d processed)", job.Id, job.Cursor, job.Processed)
+	s.startBackfill(ctx, job)
+	return &notifpb.ResumeBackfillResponse{Job: job}, nil
+}
+
+// resumeBackfills restarts the jobs left running by a previous process.
+func (s *notificationServer) resumeBackfills(ctx context.Context) error {
+	jobs, err := s.store.BackfillsWithStatus(ctx, backfillRunning)
+	if err != nil {
+		return err
+	}
+	for _, job := range jobs {
+		log.Printf("Resuming interrupted backfill %s after user %q (%d processed)", job.Id, job.Cursor, job.Processed)
+		s.startBackfill(ctx, job)
+	}
+	return nil
+}
+
+// startBackfill runs a copy of job in the background unless it is already
+// running, so the caller can keep using job. The run logs the request id of
+// ctx, the call that started it, but is not cancelled with it.
+func (s *notificationServer) startBackfill(ctx context.Context, job *notifpb.BackfillJob) {
+	job = proto.Clone(job).(*notifpb.BackfillJob)
+	r := s.backfills
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.running[job.Id] {
+		return
+	}
+	r.running[job.Id] = true
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		s.runBackfill(withRequestID(r.ctx, contextRequestID(ctx)), job)
+		r.mu.Lock()
+		delete(r.running, job.Id)
+		r.mu.Unlock()
+	}()
+}
+
+// runBackfill pages through the users after job.Cursor and queues the job's
+// notification for each. Every notification carries the job id as its source
+// event, and users who already have one are skipped, so a page repeated
+// after a crash does not notify anyone twice.
+func (s *notificationServer) runBackfill(ctx context.Context, job *notifpb.BackfillJob) {
+	sourceEventID := "backfill:" + job.Id
+	for {
+		res, err := s.backfills.users.ListUsers(ctx, &userpb.ListUsersRequest{AfterId: job.Cursor, Limit: backfillPageSize})
+		if err != nil {
+			s.stopBackfill(ctx, job, err)
+			return
+		}
+
+		for _, user := range res.Users {
+			exists, err := s.store.HasSourceEvent(ctx, user.Id, sourceEventID)
+			if err != nil {
+				s.stopBackfill(ctx, job, err)
+				return
+			}
+			if !exists {
+				if s.backfills.interval > 0 {
+					select {
+					case <-s.clock.After(s.backfills.interval):
+					case <-ctx.Done():
+						s.stopBackfill(ctx, job, ctx.Err())
+						return
+					}
+				}
+				s.dispatcher.Enqueue(ctx, &notifpb.Notification{
+					Id:            uuid.NewString(),
+					UserId:        user.Id,
+					Message:       s.render(ctx, user.Id, user.Locale, job.MessageId, job.Params),
+					Timestamp:     s.timestamp(),
+					SourceEventId: sourceEventID,
+					Type:          job.Type,
+					Severity:      job.Severity,
+				})
+			}
+			job.Cursor = user.Id
+			job.Processed++
+		}
+
+		if len(res.Users) < backfillPageSize {
+			job.Status = backfillCompleted
+		}
+		if err := s.store.UpdateBackfill(context.WithoutCancel(ctx), job); err != nil {
+			logf(ctx, "failed to save progress of backfill %s: %v", job.Id, err)
+		}
+		if job.Status == backfillCompleted {
+			logf(ctx, "Backfill %s completed, %d users processed", job.Id, job.Processed)
+			return
+		}
+		logf(ctx, "Backfill %s: %d users processed", job.Id, job.Processed)
+	}
+}
+
+// stopBackfill saves the progress of a job that could not continue. A job
+// stopped by shutdown stays running so it resumes on the next start; any other
+// error marks it failed until an admin resumes it.
+func (s *notificationServer) stopBackfill(ctx context.Context, job *notifpb.BackfillJob, err error) {
+	if ctx.Err() != nil {
+		logf(ctx, "Backfill %s interrupted after %d users, will resume on restart", job.Id, job.Processed)
+	} else {
+		logf(ctx, "Backfill %s failed after %d users: %v", job.Id, job.Processed, err)
+		job.Status, job.Error = backfillFailed, err.Error()
+	}
+	if err := s.store.UpdateBackfill(context.WithoutCancel(ctx), job); err != nil {
+		logf(ctx, "failed to save progress of backfill %s: %v", job.Id, err)
+	}
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"google.golang.org/grpc"
+
+	"notification-ms/notifpb"
+	"notification-ms/userpb"
+)
+
+const selectSourceEvent = "SELECT EXISTS (SELECT 1 FROM notifications WHERE user_id = $1 AND source_event_id = $2)"
+
+// seededUsers is a user-ms that only answers ListUsers, paging through users
+// ordered by id.
+type seededUsers struct {
+	userpb.UserServiceClient
+	users []*userpb.User
+}
+
+func (u *seededUsers) ListUsers(ctx context.Context, in *userpb.ListUsersRequest, _ ...grpc.CallOption) (*userpb.ListUsersResponse, error) {
+	res := &userpb.ListUsersResponse{}
+	for _, user := range u.users {
+		if user.Id > in.AfterId && len(res.Users) < int(in.Limit) {
+			res.Users = append(res.Users, user)
+		}
+	}
+	return res, nil
+}
+
+// withBackfills gives s a backfill runner over users and a dispatcher that
+// records what it is handed instead of delivering it.
+func withBackfills(s *notificationServer, users ...string) (delivered func() map[string]int) {
+	seeded := &seededUsers{}
+	for _, id := range users {
+		seeded.users = append(seeded.users, &userpb.User{Id: id, Locale: "en"})
+	}
+	s.backfills = newBackfillRunner(seeded, 0)
+
+	var mu sync.Mutex
+	got := map[string]int{}
+	s.dispatcher = newDispatcher(1, 10, notifpb.Severity_SEVERITY_UNSPECIFIED,
+		func(_ context.Context, n *notifpb.Notification) {
+			mu.Lock()
+			got[n.UserId]++
+			mu.Unlock()
+		},
+		func(context.Context, *notifpb.Notification) {})
+	return func() map[string]int {
+		s.dispatcher.Close(5 * time.Second)
+		mu.Lock()
+		defer mu.Unlock()
+		return got
+	}
+}
+
+func backfillJob() *notifpb.BackfillJob {
+	return &notifpb.BackfillJob{
+		Id:        "job-1",
+		MessageId: "bill.updated",
+		Params:    map[string]string{"amount": "0.00"},
+		Type:      notifpb.NotificationType_NOTIFICATION_TYPE_BILLING,
+		Severity:  notifpb.Severity_SEVERITY_INFO,
+		Status:    backfillRunning,
+	}
+}
+
+func TestBackfillNotifiesEveryUserOnce(t *testing.T) {
+	s, mock := newTestServer(t)
+	users := []string{"u1", "u2", "u3"}
+	delivered := withBackfills(s, users...)
+
+	for _, user := range users {
+		mock.ExpectQuery(literal(selectSourceEvent)).WithArgs(user, "backfill:job-1").
+			WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+	}
+	mock.ExpectExec(literal("UPDATE backfill_jobs")).
+		WithArgs("job-1", backfillCompleted, int64(3), "u3", "", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	job := backfillJob()
+	s.runBackfill(context.Background(), job)
+
+	if job.Status != backfillCompleted || job.Processed != 3 {
+		t.Errorf("job = %+v, want completed with 3 users processed", job)
+	}
+	got := delivered()
+	for _, user := range users {
+		if got[user] != 1 {
+			t.Errorf("%s got %d notifications, want 1", user, got[user])
+		}
+	}
+}
+
+func TestBackfillRerunSkipsNotifiedUsers(t *testing.T) {
+	s, mock := newTestServer(t)
+	users := []string{"u1", "u2", "u3"}
+	delivered := withBackfills(s, users...)
+
+	// The first run notifies u1 and u2, then fails on u3.
+	for _, user := range users[:2] {
+		mock.ExpectQuery(literal(selectSourceEvent)).WithArgs(user, "backfill:job-1").
+			WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+	}
+	mock.ExpectQuery(literal(selectSourceEvent)).WithArgs("u3", "backfill:job-1").
+		WillReturnError(errors.New("connection reset"))
+	mock.ExpectExec(literal("UPDATE backfill_jobs")).
+		WithArgs("job-1", backfillFailed, int64(2), "u2", "connection reset", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	job := backfillJob()
+	s.runBackfill(context.Background(), job)
+	if job.Status != backfillFailed {
+		t.Fatalf("job = %+v, want failed", job)
+	}
+
+	// The page is repeated from the start, as if its progress had never been
+	// saved; u1 and u2 already have the notification.
+	for _, user := range users[:2] {
+		mock.ExpectQuery(literal(selectSourceEvent)).WithArgs(user, "backfill:job-1").
+			WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+	}
+	mock.ExpectQuery(literal(selectSourceEvent)).WithArgs("u3", "backfill:job-1").
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+	mock.ExpectExec(literal("UPDATE backfill_jobs")).
+		WithArgs("job-1", backfillCompleted, int64(3), "u3", "", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	rerun := backfillJob()
+	s.runBackfill(context.Background(), rerun)
+
+	got := delivered()
+	for _, user := range users {
+		if got[user] != 1 {
+			t.Errorf("%s got %d notifications, want exactly 1", user, got[user])
+		}
+	}
+}
@@ -0,0 +1,227 @@
+package main
+
+import (
+	"context"
+	"hash/fnv"
+	"log"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"notification-ms/notifpb"
+)
+
+// dispatcher fans notifications out to a fixed pool of workers. Every
+// notification for a given user goes to the same worker, so a user's
+// notifications are handled in the order they were enqueued while different
+// users are processed in parallel.
+//
+// Each worker also has an urgent queue for notifications at or above
+// prioritySeverity. A worker always drains its urgent queue before taking the
+// next normal notification, so urgent alerts overtake a backlog instead of
+// waiting behind it.
+//
+// persist stores a notification without delivering it. It is used for work
+// the dispatcher gives up on at shutdown, so the notification is redelivered
+// when its user next connects instead of being lost.
+//
+// Both are called with the context the notification was enqueued with, minus
+// its cancellation, so what they log carries the originating request id.
+type dispatcher struct {
+	queues           []chan queued
+	urgent           []chan queued
+	prioritySeverity notifpb.Severity // SEVERITY_UNSPECIFIED disables the urgent queues
+	handle           func(context.Context, *notifpb.Notification)
+	persist          func(context.Context, *notifpb.Notification)
+
+	mu        sync.RWMutex // Guards closed against concurrent Enqueue
+	closed    bool
+	closing   chan struct{} // closed when Close starts, releasing Enqueues blocked on a full queue
+	closeOnce sync.Once
+	abandoned atomic.Bool // set when Close times out; workers persist instead of handle
+	wg        sync.WaitGroup
+}
+
+// queued is a notification waiting for its worker.
+type queued struct {
+	ctx   context.Context
+	notif *notifpb.Notification
+}
+
+func newDispatcher(workers, queueSize int, prioritySeverity notifpb.Severity, handle, persist func(context.Context, *notifpb.Notification)) *dispatcher {
+	workers = max(workers, 1)
+	d := &dispatcher{
+		queues:           make([]chan queued, workers),
+		urgent:           make([]chan queued, workers),
+		prioritySeverity: prioritySeverity,
+		handle:           handle,
+		persist:          persist,
+		closing:          make(chan struct{}),
+	}
+	for i := range d.queues {
+		d.queues[i] = make(chan queued, queueSize)
+		d.urgent[i] = make(chan queued, queueSize)
+		d.wg.Add(1)
+		go d.work(d.queues[i], d.urgent[i])
+	}
+	return d
+}
+
+func (d *dispatcher) work(queue, urgent <-chan queued) {
+	defer d.wg.Done()
+	for queue != nil || urgent != nil {
+		// Anything urgent goes first.
+		select {
+		case q, ok := <-urgent:
+			if !ok {
+				urgent = nil
+				continue
+			}
+			d.process(q)
+			continue
+		default:
+		}
+
+		select {
+		case q, ok := <-urgent:
+			if !ok {
+				urgent = nil
+				continue
+			}
+			d.process(q)
+		case q, ok := <-queue:
+			if !ok {
+				queue = nil
+				continue
+			}
+			d.process(q)
+		}
+	}
+}
+
+// process handles a dequeued notification, or only persists it once Close
+// has given up waiting.
+func (d *dispatcher) process(q queued) {
+	if d.abandoned.Load() {
+		d.persist(q.ctx, q.notif)
+		return
+	}
+	d.handle(q.ctx, q.notif)
+}
+
+// Enqueue hands a notification to its user's worker. It blocks while that
+// worker's queue is full, pushing back on the event consumer rather than
+// reordering or dropping. Urgent notifications have their own queue and so
+// are not held up by a full normal one. Once Close is called the notification
+// is only persisted, including by an Enqueue already waiting for room.
+func (d *dispatcher) Enqueue(ctx context.Context, notif *notifpb.Notification) {
+	// The notification outlives the call that produced it.
+	ctx = context.WithoutCancel(ctx)
+	if !d.send(queued{ctx: ctx, notif: notif}) {
+		logf(ctx, "dispatcher closed, persisting notification %s for redelivery", notif.Id)
+		d.persist(ctx, notif)
+	}
+}
+
+// send puts q on its worker's queue, waiting while the queue is full. It
+// reports false, without queueing q, once Close has started.
+func (d *dispatcher) send(q queued) bool {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	if d.closed {
+		return false
+	}
+	w := d.worker(q.notif.UserId)
+	queue := d.queues[w]
+	if d.isUrgent(q.notif) {
+		queue = d.urgent[w]
+	}
+	select {
+	case queue <- q:
+		return true
+	case <-d.closing:
+		return false
+	}
+}
+
+// isUrgent reports whether notif should skip ahead of normal notifications.
+func (d *dispatcher) isUrgent(notif *notifpb.Notification) bool {
+	return d.prioritySeverity != notifpb.Severity_SEVERITY_UNSPECIFIED && notif.Severity >= d.prioritySeverity
+}
+
+// worker picks the queue for userID by hashing it.
+func (d *dispatcher) worker(userID string) int {
+	h := fnv.New32a()
+	h.Write([]byte(userID))
+	return int(h.Sum32() % uint32(len(d.queues)))
+}
+
+// Close stops accepting work and gives the workers up to timeout to finish
+// what is already queued. Whatever is still queued after that is persisted
+// rather than delivered. A worker busy with a slow delivery is not waited
+// for beyond the timeout, and neither is an Enqueue waiting for room: it
+// persists its notification instead.
+func (d *dispatcher) Close(timeout time.Duration) {
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+	first := false
+	d.closeOnce.Do(func() {
+		first = true
+		close(d.closing)
+	})
+	if !first {
+		return
+	}
+
+	// Blocked Enqueues have been released, so this only waits for sends
+	// already in progress.
+	d.mu.Lock()
+	d.closed = true
+	for i := range d.queues {
+		close(d.queues[i])
+		close(d.urgent[i])
+	}
+	d.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		d.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return
+	case <-deadline.C:
+	}
+
+	// Workers still running persist what they take from here on; drain the
+	// rest alongside them.
+	d.abandoned.Store(true)
+	persisted := 0
+	for i := range d.queues {
+		for _, queue := range []chan queued{d.urgent[i], d.queues[i]} {
+			for q := range queue {
+				d.persist(q.ctx, q.notif)
+				persisted++
+			}
+		}
+	}
+	log.Printf("Dispatcher did not drain within %s, persisted %d queued notifications for redelivery", timeout, persisted)
+}
+
+// loadPrioritySeverity reads NOTIF_PRIORITY_SEVERITY: "critical" (default),
+// "warning", or "off" to process every notification in arrival order.
+func loadPrioritySeverity() notifpb.Severity {
+	switch v := strings.ToLower(getEnv("NOTIF_PRIORITY_SEVERITY", "critical")); v {
+	case "critical":
+		return notifpb.Severity_SEVERITY_CRITICAL
+	case "warning":
+		return notifpb.Severity_SEVERITY_WARNING
+	case "off":
+		return notifpb.Severity_SEVERITY_UNSPECIFIED
+	default:
+		log.Printf("invalid NOTIF_PRIORITY_SEVERITY %q, using %q", v, "critical")
+		return notifpb.Severity_SEVERITY_CRITICAL
+	}
+}
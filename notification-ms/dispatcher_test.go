@@ -0,0 +1,140 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"notification-ms/notifpb"
+)
+
+func TestDispatcherKeepsPerUserOrder(t *testing.T) {
+	const perUser = 50
+	var (
+		mu       sync.Mutex
+		got      = map[string][]int{}
+		inFlight atomic.Int32
+		peak     atomic.Int32
+	)
+	d := newDispatcher(4, 10, notifpb.Severity_SEVERITY_UNSPECIFIED,
+		func(_ context.Context, n *notifpb.Notification) {
+			peak.Store(max(peak.Load(), inFlight.Add(1)))
+			// Uneven handling times would reorder an unordered pool.
+			time.Sleep(time.Duration(rand.IntN(500)) * time.Microsecond)
+			inFlight.Add(-1)
+			var seq int
+			fmt.Sscanf(n.Id, "%d", &seq)
+			mu.Lock()
+			got[n.UserId] = append(got[n.UserId], seq)
+			mu.Unlock()
+		},
+		func(context.Context, *notifpb.Notification) { t.Error("notification persisted instead of handled") })
+
+	// Pick users that land on different workers so they run in parallel.
+	var users []string
+	seen := map[int]bool{}
+	for i := 0; len(users) < 3; i++ {
+		user := fmt.Sprintf("user-%d", i)
+		if w := d.worker(user); !seen[w] {
+			seen[w] = true
+			users = append(users, user)
+		}
+	}
+	for seq := range perUser {
+		for _, user := range users {
+			d.Enqueue(context.Background(), &notifpb.Notification{Id: fmt.Sprint(seq), UserId: user})
+		}
+	}
+	d.Close(10 * time.Second)
+
+	for _, user := range users {
+		if len(got[user]) != perUser {
+			t.Fatalf("%s: handled %d notifications, want %d", user, len(got[user]), perUser)
+		}
+		for i, seq := range got[user] {
+			if seq != i {
+				t.Fatalf("%s: handled %v, want creation order", user, got[user])
+			}
+		}
+	}
+	if peak.Load() < 2 {
+		t.Error("users on different workers were never handled in parallel")
+	}
+}
+
+func TestDispatcherCriticalOvertakesBacklog(t *testing.T) {
+	const backlog = 100
+	started, release := make(chan struct{}), make(chan struct{})
+	var (
+		order     []string
+		enqueued  time.Time
+		delivered time.Time
+	)
+	d := newDispatcher(1, backlog, notifpb.Severity_SEVERITY_CRITICAL,
+		func(_ context.Context, n *notifpb.Notification) {
+			switch n.Id {
+			case "first":
+				close(started)
+				<-release
+			case "critical":
+				delivered = time.Now()
+			default:
+				// Each normal delivery takes a while, so waiting behind
+				// the backlog would cost at least backlog milliseconds.
+				time.Sleep(time.Millisecond)
+			}
+			order = append(order, n.Id)
+		},
+		func(context.Context, *notifpb.Notification) { t.Error("notification persisted instead of handled") })
+
+	// Hold the only worker so the backlog builds up behind it.
+	d.Enqueue(context.Background(), &notifpb.Notification{Id: "first", UserId: "u1"})
+	<-started
+	for i := range backlog {
+		d.Enqueue(context.Background(), &notifpb.Notification{Id: fmt.Sprint(i), UserId: "u1"})
+	}
+	enqueued = time.Now()
+	d.Enqueue(context.Background(), &notifpb.Notification{Id: "critical", UserId: "u1", Severity: notifpb.Severity_SEVERITY_CRITICAL})
+	close(release)
+	d.Close(10 * time.Second)
+
+	if len(order) != backlog+2 {
+		t.Fatalf("handled %d notifications, want %d", len(order), backlog+2)
+	}
+	if order[1] != "critical" {
+		t.Errorf("critical notification handled at position %d, want right after the one in flight", slices.Index(order, "critical"))
+	}
+	if latency := delivered.Sub(enqueued); latency > backlog*time.Millisecond/2 {
+		t.Errorf("critical notification took %v despite priority", latency)
+	}
+}
+
+func TestDispatcherPriorityOff(t *testing.T) {
+	started, release := make(chan struct{}), make(chan struct{})
+	var order []string
+	d := newDispatcher(1, 10, notifpb.Severity_SEVERITY_UNSPECIFIED,
+		func(_ context.Context, n *notifpb.Notification) {
+			if n.Id == "first" {
+				close(started)
+				<-release
+			}
+			order = append(order, n.Id)
+		},
+		func(context.Context, *notifpb.Notification) {})
+
+	d.Enqueue(context.Background(), &notifpb.Notification{Id: "first", UserId: "u1"})
+	<-started
+	d.Enqueue(context.Background(), &notifpb.Notification{Id: "normal", UserId: "u1"})
+	d.Enqueue(context.Background(), &notifpb.Notification{Id: "critical", UserId: "u1", Severity: notifpb.Severity_SEVERITY_CRITICAL})
+	close(release)
+	d.Close(5 * time.Second)
+
+	if want := []string{"first", "normal", "critical"}; !slices.Equal(order, want) {
+		t.Errorf("handled %v, want creation order with priority off", order)
+	}
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+
+	"notification-ms/notifpb"
+)
+
+func TestBillUpdateNotificationRecordsSourceEvent(t *testing.T) {
+	s, mock := newTestServer(t)
+	nc, js := runJetStream(t)
+	s.subs = newSubscriptions(nc, js, "notification-ms")
+	s.dispatcher = newDispatcher(1, 10, notifpb.Severity_SEVERITY_UNSPECIFIED, s.deliver, s.persist)
+	defer s.dispatcher.Close(time.Second)
+	sub := addSubscriber(s, "u1", "a")
+
+	mock.ExpectQuery(literal("SELECT locale FROM user_locales")).WithArgs("u1").WillReturnRows(sqlmock.NewRows([]string{"locale"}))
+	anyArg := sqlmock.AnyArg()
+	mock.ExpectExec(literal("INSERT INTO notifications")).
+		WithArgs(anyArg, "u1", "Your bill was updated to 12.50", anyArg, "evt-1", anyArg, anyArg, anyArg).WillReturnResult(sqlmock.NewResult(0, 1))
+
+	data, err := json.Marshal(billUpdate{EventID: "evt-1", Id: "u1", MessageID: msgBillUpdated, Params: map[string]string{"amount": "12.50"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The stream keeps the message until the durable consumer picks it up.
+	if _, err := js.Publish("bill.update", data); err != nil {
+		t.Fatal(err)
+	}
+	go s.subscribeToEvents()
+
+	select {
+	case batch := <-sub.ch:
+		if len(batch) != 1 || batch[0].SourceEventId != "evt-1" {
+			t.Fatalf("delivered %v, want a notification from evt-1", batch)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no notification for bill.update")
+	}
+	waitForExpectations(t, mock)
+}
+
+func TestBillUpdateRenderedInForwardedLocale(t *testing.T) {
+	s, mock := newTestServer(t)
+	nc, js := runJetStream(t)
+	s.subs = newSubscriptions(nc, js, "notification-ms")
+	s.dispatcher = newDispatcher(1, 10, notifpb.Severity_SEVERITY_UNSPECIFIED, s.deliver, s.persist)
+	defer s.dispatcher.Close(time.Second)
+	sub := addSubscriber(s, "u1", "a")
+
+	// No locale is stored for u1, so the one the gateway forwarded with the
+	// request that changed the bill is used.
+	mock.ExpectQuery(literal("SELECT locale FROM user_locales")).WithArgs("u1").WillReturnRows(sqlmock.NewRows([]string{"locale"}))
+	anyArg := sqlmock.AnyArg()
+	mock.ExpectExec(literal("INSERT INTO notifications")).
+		WithArgs(anyArg, "u1", "Ihre Rechnung wurde auf 12.50 aktualisiert", anyArg, "evt-1", anyArg, anyArg, anyArg).WillReturnResult(sqlmock.NewResult(0, 1))
+
+	data, err := json.Marshal(billUpdate{EventID: "evt-1", Id: "u1", MessageID: msgBillUpdated, Params: map[string]string{"amount": "12.50"}, Locale: "de"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := js.Publish("bill.update", data); err != nil {
+		t.Fatal(err)
+	}
+	go s.subscribeToEvents()
+
+	select {
+	case batch := <-sub.ch:
+		if len(batch) != 1 || batch[0].Message != "Ihre Rechnung wurde auf 12.50 aktualisiert" {
+			t.Fatalf("delivered %v, want the German bill message", batch)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no notification for bill.update")
+	}
+	waitForExpectations(t, mock)
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// healthCheckTimeout bounds a single Postgres ping.
+const healthCheckTimeout = 2 * time.Second
+
+// eventsHealthService is the health check name reporting event consumption:
+// NOT_SERVING while an operator has paused it. RPCs are still served then,
+// so it does not affect the overall status.
+const eventsHealthService = "events"
+
+// startHealthChecks registers the standard gRPC health service on s and keeps
+// it in step with the service's dependencies. Every HEALTH_CHECK_INTERVAL
+// (default 10s) Postgres is pinged and the NATS connection checked; while
+// either is down, both the overall status and service's status are
+// NOT_SERVING.
+func startHealthChecks(s *grpc.Server, service string, db *sql.DB, nc *nats.Conn) *health.Server {
+	interval := getEnvDuration("HEALTH_CHECK_INTERVAL", 10*time.Second)
+	if interval <= 0 {
+		log.Printf("invalid HEALTH_CHECK_INTERVAL %s, using 10s", interval)
+		interval = 10 * time.Second
+	}
+
+	hs := health.NewServer()
+	healthpb.RegisterHealthServer(s, hs)
+	reportConsumption(hs, false)
+	go func() {
+		var lastErr error
+		healthy := true
+		for {
+			err := checkDependencies(db, nc)
+			status := healthpb.HealthCheckResponse_SERVING
+			if err != nil {
+				status = healthpb.HealthCheckResponse_NOT_SERVING
+			}
+			hs.SetServingStatus("", status)
+			hs.SetServingStatus(service, status)
+
+			switch {
+			case err != nil && (healthy || err.Error() != lastErr.Error()):
+				log.Printf("dependency check failed, reporting NOT_SERVING: %v", err)
+			case err == nil && !healthy:
+				log.Println("Dependencies recovered, reporting SERVING")
+			}
+			healthy, lastErr = err == nil, err
+			time.Sleep(interval)
+		}
+	}()
+	return hs
+}
+
+// checkDependencies pings Postgres and checks that NATS is connected.
+func checkDependencies(db *sql.DB, nc *nats.Conn) error {
+	ctx, cancel := context.WithTimeout(context.Background(), healthCheckTimeout)
+	defer cancel()
+	if err := db.PingContext(ctx); err != nil {
+		return fmt.Errorf("postgres: %w", err)
+	}
+	if !nc.IsConnected() {
+		return fmt.Errorf("nats: %s", nc.Status())
+	}
+	return nil
+}
+
+// reportConsumption sets the eventsHealthService status for paused.
+func reportConsumption(hs *health.Server, paused bool) {
+	status := healthpb.HealthCheckResponse_SERVING
+	if paused {
+		status = healthpb.HealthCheckResponse_NOT_SERVING
+	}
+	hs.SetServingStatus(eventsHealthService, status)
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func TestReportConsumption(t *testing.T) {
+	hs := health.NewServer()
+	check := func() healthpb.HealthCheckResponse_ServingStatus {
+		t.Helper()
+		res, err := hs.Check(context.Background(), &healthpb.HealthCheckRequest{Service: eventsHealthService})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return res.Status
+	}
+
+	reportConsumption(hs, true)
+	if got := check(); got != healthpb.HealthCheckResponse_NOT_SERVING {
+		t.Errorf("paused: %v, want NOT_SERVING", got)
+	}
+	reportConsumption(hs, false)
+	if got := check(); got != healthpb.HealthCheckResponse_SERVING {
+		t.Errorf("resumed: %v, want SERVING", got)
+	}
+	// Pausing leaves the overall status alone.
+	if res, err := hs.Check(context.Background(), &healthpb.HealthCheckRequest{}); err != nil || res.Status != healthpb.HealthCheckResponse_SERVING {
+		t.Errorf("overall status = %v, %v", res, err)
+	}
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"context"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"notification-ms/notifpb"
+)
+
+// Page sizes for GetNotificationHistory.
+const (
+	defaultHistoryLimit = 20
+	maxHistoryLimit     = 100
+)
+
+// GetNotificationHistory returns a page of the user's stored notifications,
+// newest first, with the total so clients can paginate.
+func (s *notificationServer) GetNotificationHistory(ctx context.Context, req *notifpb.HistoryRequest) (*notifpb.HistoryResponse, error) {
+	if req.UserId == "" {
+		return nil, status.Error(codes.InvalidArgument, "user_id is required")
+	}
+	if req.Limit < 0 || req.Offset < 0 {
+		return nil, status.Error(codes.InvalidArgument, "limit and offset must not be negative")
+	}
+	limit := int(req.Limit)
+	if limit == 0 {
+		limit = defaultHistoryLimit
+	}
+	limit = min(limit, maxHistoryLimit)
+
+	notifs, total, err := s.store.History(ctx, req.UserId, limit, int(req.Offset))
+	if err != nil {
+		logf(ctx, "failed to load notification history for user %s: %v", req.UserId, err)
+		return nil, status.Error(codes.Internal, "could not load notification history")
+	}
+	return &notifpb.HistoryResponse{Notifications: notifs, Total: total}, nil
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"context"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+	"sync"
+	"text/template"
+)
+
+// Message ids emitted by the services that publish notification events.
+const (
+	msgUserWelcome     = "user.welcome"
+	msgBillUpdated     = "bill.updated"
+	msgBillUpdatedHigh = "bill.updated.high"
+)
+
+// fallbackLocale is the locale every message is guaranteed to exist in.
+const fallbackLocale = "en"
+
+//go:embed messages/*.json
+var catalogFiles embed.FS
+
+// catalog holds the notification message templates, keyed by locale and
+// message id. Each messages/<locale>.json file maps message ids to
+// text/template strings rendered with the event's params. Templates stored in
+// the database override the embedded ones for the same locale and id.
+type catalog struct {
+	defaultLocale string
+	templates     map[string]map[string]*template.Template
+
+	mu        sync.RWMutex
+	overrides map[string]map[string]*template.Template
+}
+
+// loadCatalog parses the embedded translations. defaultLocale is tried before
+// English when a user's locale has no translation for a message.
+func loadCatalog(defaultLocale string) (*catalog, error) {
+	files, err := catalogFiles.ReadDir("messages")
+	if err != nil {
+		return nil, err
+	}
+	c := &catalog{defaultLocale: normalizeLocale(defaultLocale), templates: make(map[string]map[string]*template.Template)}
+	for _, f := range files {
+		data, err := catalogFiles.ReadFile(path.Join("messages", f.Name()))
+		if err != nil {
+			return nil, err
+		}
+		var messages map[string]string
+		if err := json.Unmarshal(data, &messages); err != nil {
+			return nil, fmt.Errorf("%s: %w", f.Name(), err)
+		}
+		locale := normalizeLocale(strings.TrimSuffix(f.Name(), ".json"))
+		c.templates[locale] = make(map[string]*template.Template, len(messages))
+		for id, text := range messages {
+			tmpl, err := parseMessage(id, text)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", f.Name(), err)
+			}
+			c.templates[locale][id] = tmpl
+		}
+	}
+	if _, ok := c.templates[fallbackLocale]; !ok {
+		return nil, fmt.Errorf("catalog has no %q translations", fallbackLocale)
+	}
+	return c, nil
+}
+
+// parseMessage parses the template text of message id.
+func parseMessage(id, text string) (*template.Template, error) {
+	return template.New(id).Option("missingkey=zero").Parse(text)
+}
+
+// SetOverrides replaces the database templates used in front of the embedded
+// catalog. Templates that fail to parse are skipped.
+func (c *catalog) SetOverrides(ctx context.Context, stored []storedTemplate) {
+	overrides := make(map[string]map[string]*template.Template)
+	for _, t := range stored {
+		tmpl, err := parseMessage(t.MessageID, t.Body)
+		if err != nil {
+			logf(ctx, "skipping stored template %s/%s: %v", t.MessageID, t.Locale, err)
+			continue
+		}
+		locale := normalizeLocale(t.Locale)
+		if overrides[locale] == nil {
+			overrides[locale] = make(map[string]*template.Template)
+		}
+		overrides[locale][t.MessageID] = tmpl
+	}
+
+	c.mu.Lock()
+	c.overrides = overrides
+	c.mu.Unlock()
+}
+
+// lookup returns the template for id in exactly locale, preferring a stored
+// override to the embedded default.
+func (c *catalog) lookup(locale, id string) (*template.Template, bool) {
+	c.mu.RLock()
+	tmpl, ok := c.overrides[locale][id]
+	c.mu.RUnlock()
+	if ok {
+		return tmpl, true
+	}
+	tmpl, ok = c.templates[locale][id]
+	return tmpl, ok
+}
+
+// Render renders message id in the closest available locale: the exact tag
+// ("pt-br"), its base language ("pt"), the default locale, then English.
+func (c *catalog) Render(locale, id string, params map[string]string) (string, error) {
+	for _, l := range c.candidates(locale) {
+		tmpl, ok := c.lookup(l, id)
+		if !ok {
+			continue
+		}
+		var sb strings.Builder
+		if err := tmpl.Execute(&sb, params); err != nil {
+			return "", err
+		}
+		return sb.String(), nil
+	}
+	return "", fmt.Errorf("unknown message id %q", id)
+}
+
+func (c *catalog) candidates(locale string) []string {
+	locale = normalizeLocale(locale)
+	var out []string
+	if locale != "" {
+		out = append(out, locale)
+		if base, _, ok := strings.Cut(locale, "-"); ok {
+			out = append(out, base)
+		}
+	}
+	return append(out, c.defaultLocale, fallbackLocale)
+}
+
+// normalizeLocale lower-cases a language tag and uses "-" as the separator.
+func normalizeLocale(locale string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(locale), "_", "-"))
+}
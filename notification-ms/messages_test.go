@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestCatalogRendersPerLocale(t *testing.T) {
+	c, err := loadCatalog(fallbackLocale)
+	if err != nil {
+		t.Fatal(err)
+	}
+	params := map[string]string{"amount": "42.00"}
+	tests := []struct {
+		locale, want string
+	}{
+		{"en", "Your bill was updated to 42.00"},
+		{"de", "Ihre Rechnung wurde auf 42.00 aktualisiert"},
+		{"de_AT", "Ihre Rechnung wurde auf 42.00 aktualisiert"},
+		{"pt-BR", "Your bill was updated to 42.00"},
+		{"", "Your bill was updated to 42.00"},
+	}
+	for _, tt := range tests {
+		got, err := c.Render(tt.locale, msgBillUpdated, params)
+		if err != nil {
+			t.Fatalf("%q: %v", tt.locale, err)
+		}
+		if got != tt.want {
+			t.Errorf("%q: rendered %q, want %q", tt.locale, got, tt.want)
+		}
+	}
+
+	if _, err := c.Render("en", "no.such.message", nil); err == nil {
+		t.Error("unknown message id rendered")
+	}
+}
+
+func TestCatalogDefaultLocale(t *testing.T) {
+	c, err := loadCatalog("es")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := c.Render("ja", msgUserWelcome, map[string]string{"username": "ana"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "¡Bienvenido a la plataforma, ana!"; got != want {
+		t.Errorf("locale without translations rendered %q, want the default locale's %q", got, want)
+	}
+}
+
+func TestRenderUsesStoredLocale(t *testing.T) {
+	s, mock := newTestServer(t)
+	params := map[string]string{"username": "sam"}
+	mock.ExpectQuery(literal("SELECT locale FROM user_locales WHERE user_id = $1")).WithArgs("u-de").
+		WillReturnRows(sqlmock.NewRows([]string{"locale"}).AddRow("de"))
+	mock.ExpectQuery(literal("SELECT locale FROM user_locales WHERE user_id = $1")).WithArgs("u-fr").
+		WillReturnRows(sqlmock.NewRows([]string{"locale"}).AddRow("fr"))
+
+	de := s.render(context.Background(), "u-de", "", msgUserWelcome, params)
+	fr := s.render(context.Background(), "u-fr", "", msgUserWelcome, params)
+	if de != "Willkommen auf der Plattform, sam!" {
+		t.Errorf("de: %q", de)
+	}
+	if fr != "Bienvenue sur la plateforme, sam !" {
+		t.Errorf("fr: %q", fr)
+	}
+}
+
+func TestPreferredLocaleLooksUpOnce(t *testing.T) {
+	s, mock := newTestServer(t)
+	for _, tt := range []struct {
+		stored, hint, want string
+	}{
+		{"de", "fr", "de"},
+		{"", "fr", "fr"},
+		{"", "", fallbackLocale},
+	} {
+		rows := sqlmock.NewRows([]string{"locale"})
+		if tt.stored != "" {
+			rows.AddRow(tt.stored)
+		}
+		mock.ExpectQuery(literal("SELECT locale FROM user_locales")).WithArgs("u1").WillReturnRows(rows)
+		locale := s.preferredLocale(context.Background(), "u1", tt.hint)
+		if locale != tt.want {
+			t.Errorf("stored %q, hint %q: locale %q, want %q", tt.stored, tt.hint, locale, tt.want)
+		}
+		// Rendering with the result must not query the store again.
+		s.render(context.Background(), "u1", locale, msgBillUpdated, nil)
+	}
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"notification-ms/notifpb"
+)
+
+func TestMarkNotificationReadSyncsEveryDevice(t *testing.T) {
+	s, mock := newTestServer(t)
+	phone := addSubscriber(s, "u1", "phone")
+	laptop := addSubscriber(s, "u1", "laptop")
+	other := addSubscriber(s, "u2", "other")
+
+	mock.ExpectExec(literal("UPDATE notifications SET read = TRUE")).WithArgs("n1", "u1").WillReturnResult(sqlmock.NewResult(0, 1))
+	if _, err := s.MarkNotificationRead(context.Background(), &notifpb.MarkNotificationReadRequest{UserId: "u1", NotificationId: "n1"}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, sub := range []*subscriber{phone, laptop} {
+		batch := received(t, sub)
+		if len(batch) != 1 || batch[0].Event != eventNotificationRead || batch[0].Id != "n1" {
+			t.Errorf("%s got %v, want a %s control message for n1", sub.sessionID, batch, eventNotificationRead)
+		}
+	}
+	if len(other.ch) != 0 {
+		t.Error("another user's stream got the read event")
+	}
+}
+
+func TestMarkNotificationReadUnknownNotification(t *testing.T) {
+	s, mock := newTestServer(t)
+	sub := addSubscriber(s, "u1", "phone")
+
+	mock.ExpectExec(literal("UPDATE notifications SET read = TRUE")).WithArgs("n1", "u1").WillReturnResult(sqlmock.NewResult(0, 0))
+	_, err := s.MarkNotificationRead(context.Background(), &notifpb.MarkNotificationReadRequest{UserId: "u1", NotificationId: "n1"})
+	if status.Code(err) != codes.NotFound {
+		t.Fatalf("got %v, want NotFound", err)
+	}
+	if len(sub.ch) != 0 {
+		t.Error("read event broadcast for a notification that does not exist")
+	}
+
+	_, err = s.MarkNotificationRead(context.Background(), &notifpb.MarkNotificationReadRequest{UserId: "u1"})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("got %v, want InvalidArgument", err)
+	}
+}
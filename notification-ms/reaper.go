@@ -0,0 +1,43 @@
+package main
+
+import (
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// runIdleReaper periodically closes subscribers that have not had a
+// successful send within idle. It is a safety net for streams whose client
+// went away without the context being cancelled.
+func (s *notificationServer) runIdleReaper(idle time.Duration) {
+	interval := max(idle/2, time.Second)
+	for {
+		<-s.clock.After(interval)
+		s.reapIdle(idle)
+	}
+}
+
+// reapIdle closes every subscriber idle for longer than idle and returns how
+// many were closed.
+func (s *notificationServer) reapIdle(idle time.Duration) int {
+	cutoff := s.clock.Now().Add(-idle)
+
+	s.mu.RLock()
+	var stale []*subscriber
+	for _, subs := range s.subscribers {
+		for _, sub := range subs {
+			if sub.lastActive().Before(cutoff) {
+				stale = append(stale, sub)
+			}
+		}
+	}
+	s.mu.RUnlock()
+
+	for _, sub := range stale {
+		logf(sub.ctx, "Reaping idle subscriber for user %s (session %s, last active %s)", sub.userId, sub.sessionID, sub.lastActive().Format(time.RFC3339))
+		sub.close(status.Error(codes.Unavailable, "subscription closed after being idle"))
+	}
+	s.reaped.Add(int64(len(stale)))
+	return len(stale)
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestIdleReaperClosesStaleSubscribers(t *testing.T) {
+	const idle = time.Minute
+	s, _ := newTestServer(t)
+	clock := newFakeClock()
+	s.clock = clock
+	stale := addSubscriber(s, "u1", "stale")
+	fresh := addSubscriber(s, "u2", "fresh")
+
+	go s.runIdleReaper(idle)
+	clock.waitForWaiters(t, 1)
+	// fresh had a successful send just before the idle window ran out.
+	clock.Advance(idle / 2)
+	clock.waitForWaiters(t, 1)
+	clock.Advance(idle/2 - time.Second)
+	fresh.touch(clock.Now())
+	clock.waitForWaiters(t, 1)
+	clock.Advance(time.Second)
+	clock.waitForWaiters(t, 1)
+	clock.Advance(idle / 2)
+
+	select {
+	case <-stale.closed:
+	case <-time.After(time.Second):
+		t.Fatal("idle subscriber not reaped")
+	}
+	if status.Code(stale.closeErr) != codes.Unavailable {
+		t.Errorf("stream closed with %v, want Unavailable", stale.closeErr)
+	}
+	select {
+	case <-fresh.closed:
+		t.Error("active subscriber reaped")
+	default:
+	}
+	// The reaper re-arms once the scan, counting included, is done.
+	clock.waitForWaiters(t, 1)
+	if got := s.reaped.Load(); got != 1 {
+		t.Errorf("reaped = %d, want 1", got)
+	}
+}
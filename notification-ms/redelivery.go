@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+const (
+	// redeliveryGrace skips notifications young enough to still be on their
+	// way through the dispatcher, so they are not sent twice.
+	redeliveryGrace = 2 * time.Second
+	// redeliveryBatch caps how many stored notifications are sent to a user
+	// per pass.
+	redeliveryBatch = 50
+)
+
+// runRedelivery periodically retries stored notifications for every user with
+// an active stream and drops the ones that ran out of attempts.
+func (s *notificationServer) runRedelivery(interval time.Duration, maxAttempts int) {
+	for {
+		<-s.clock.After(interval)
+
+		s.mu.RLock()
+		var subs []*subscriber
+		for _, userSubs := range s.subscribers {
+			subs = append(subs, userSubs...)
+		}
+		s.mu.RUnlock()
+
+		for _, sub := range subs {
+			s.redeliver(sub)
+		}
+		n, err := s.store.DropExhausted(context.Background(), maxAttempts)
+		if err != nil {
+			log.Printf("failed to drop exhausted notifications: %v", err)
+		} else if n > 0 {
+			log.Printf("Dropped %d notifications after %d delivery attempts", n, maxAttempts)
+		}
+	}
+}
+
+// redeliver sends a user's stored notifications to the subscriber's stream.
+// It stops as soon as the stream goes away; anything not sent stays stored
+// and is only marked delivered by the send loop after a successful send.
+func (s *notificationServer) redeliver(sub *subscriber) {
+	notifs, err := s.store.Undelivered(sub.ctx, sub.userId, s.clock.Now().Add(-redeliveryGrace), redeliveryBatch)
+	if err != nil {
+		if sub.ctx.Err() == nil {
+			logf(sub.ctx, "failed to load undelivered notifications for user %s: %v", sub.userId, err)
+		}
+		return
+	}
+	for sent := 0; sent < len(notifs); {
+		if sub.ctx.Err() != nil {
+			logf(sub.ctx, "Stream closed for user %s, stopping redelivery with %d notifications left", sub.userId, len(notifs)-sent)
+			return
+		}
+		batch := notifs[sent:min(sent+sub.batchSize, len(notifs))]
+		logf(sub.ctx, "Redelivering %d notifications to user %s", len(batch), sub.userId)
+		select {
+		case sub.ch <- batch:
+			sent += len(batch)
+		case <-sub.ctx.Done():
+		case <-s.clock.After(1 * time.Second):
+			logf(sub.ctx, "Subscriber channel full for user %s, stopping redelivery.", sub.userId)
+			for _, notif := range batch {
+				s.recordDelivery(sub.ctx, notif, deliveryDropped, nil)
+			}
+			return
+		}
+	}
+}
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"google.golang.org/grpc"
+
+	"notification-ms/notifpb"
+)
+
+func TestStoredNotificationDeliveredAfterSubscribe(t *testing.T) {
+	s, mock := newTestServer(t)
+	clock := newFakeClock()
+	s.clock = clock
+
+	// The user is offline: the notification is stored and the missed live
+	// delivery recorded.
+	notif := &notifpb.Notification{Id: "n1", UserId: "u1", Message: "msg n1"}
+	expectInsert(mock, "n1").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(literal("INSERT INTO notification_deliveries")).
+		WithArgs("n1", channelWebSocket, deliveryNoSubscriber, "", sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(0, 1))
+	s.deliver(context.Background(), notif)
+
+	sub := addSubscriber(s, "u1", "a")
+	go s.runRedelivery(time.Minute, 5)
+	clock.waitForWaiters(t, 1)
+
+	cutoff := clock.Now().Add(time.Minute - redeliveryGrace)
+	mock.ExpectExec(literal("UPDATE notifications n SET delivery_status = $1")).
+		WithArgs(statusDelivered, "u1", statusStored, deliveryDelivered).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(literal("WITH picked AS")).WithArgs("u1", statusStored, cutoff, redeliveryBatch).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "message", "created_at", "source_event_id", "type", "severity", "source"}).
+			AddRow("n1", "u1", "msg n1", clock.Now(), "", 0, 0, 0))
+	mock.ExpectExec(literal("UPDATE notifications SET delivery_status = $1 WHERE delivery_status = $2 AND delivery_attempts >= $3")).
+		WithArgs(statusDropped, statusStored, 5).WillReturnResult(sqlmock.NewResult(0, 0))
+	clock.Advance(time.Minute)
+
+	select {
+	case batch := <-sub.ch:
+		if len(batch) != 1 || batch[0].Id != "n1" || batch[0].Message != "msg n1" {
+			t.Errorf("redelivered %v, want n1", batch)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("stored notification not redelivered after the user subscribed")
+	}
+	// The next pass is scheduled once this one, dropping included, is done.
+	clock.waitForWaiters(t, 1)
+}
+
+// disconnectingStream is a subscription stream whose client goes away right
+// after receiving its first batch. Like a real stream, sends fail once its
+// context is done.
+type disconnectingStream struct {
+	grpc.ServerStream
+	ctx    context.Context
+	cancel context.CancelFunc
+	sent   []string
+}
+
+func (f *disconnectingStream) Context() context.Context { return f.ctx }
+
+func (f *disconnectingStream) Send(batch *notifpb.NotificationBatch) error {
+	if err := f.ctx.Err(); err != nil {
+		return err
+	}
+	for _, n := range batch.Notifications {
+		f.sent = append(f.sent, n.Id)
+	}
+	f.cancel()
+	return nil
+}
+
+func TestRedeliveryStopsWhenClientDisconnects(t *testing.T) {
+	s, mock := newTestServer(t)
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	defer log.SetOutput(os.Stderr)
+
+	rows := sqlmock.NewRows([]string{"id", "user_id", "message", "created_at", "source_event_id", "type", "severity", "source"})
+	for _, id := range []string{"n1", "n2", "n3"} {
+		rows.AddRow(id, "u1", "msg "+id, time.Now(), "", 0, 0, 0)
+	}
+	mock.ExpectExec(literal("UPDATE notifications n SET delivery_status = $1")).
+		WithArgs(statusDelivered, "u1", statusStored, deliveryDelivered).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(literal("WITH picked AS")).WillReturnRows(rows)
+	// Only the batch the client received is marked delivered.
+	mock.ExpectExec(literal("INSERT INTO notification_deliveries")).
+		WithArgs("n1", channelWebSocket, deliveryDelivered, "", sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(literal("UPDATE notifications SET delivery_status = $1 WHERE id = $2")).
+		WithArgs(statusDelivered, "n1").WillReturnResult(sqlmock.NewResult(0, 1))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stream := &disconnectingStream{ctx: ctx, cancel: cancel}
+	if err := s.SubscribeToNotifications(&notifpb.SubscribeRequest{UserId: "u1"}, stream); err == nil {
+		t.Fatal("subscription ended without an error after the client went away")
+	}
+
+	if len(stream.sent) != 1 || stream.sent[0] != "n1" {
+		t.Errorf("sent %v, want only n1", stream.sent)
+	}
+	waitForExpectations(t, mock)
+	log.SetOutput(os.Stderr)
+	for _, id := range []string{"n2", "n3"} {
+		if strings.Contains(logs.String(), "mark notification "+id+" delivered") {
+			t.Errorf("%s was marked delivered after the client disconnected", id)
+		}
+	}
+}
+
+// recordingStream is a subscription stream that passes every batch it is
+// sent to the test.
+type recordingStream struct {
+	grpc.ServerStream
+	ctx     context.Context
+	batches chan []string
+}
+
+func (f *recordingStream) Context() context.Context { return f.ctx }
+
+func (f *recordingStream) Send(batch *notifpb.NotificationBatch) error {
+	var ids []string
+	for _, n := range batch.Notifications {
+		ids = append(ids, n.Id)
+	}
+	f.batches <- ids
+	return nil
+}
+
+func TestBacklogFlushedInBatches(t *testing.T) {
+	s, mock := newTestServer(t)
+	rows := sqlmock.NewRows([]string{"id", "user_id", "message", "created_at", "source_event_id", "type", "severity", "source"})
+	var ids []string
+	for i := 1; i <= 7; i++ {
+		id := fmt.Sprintf("n%d", i)
+		ids = append(ids, id)
+		rows.AddRow(id, "u1", "msg "+id, time.Now(), "", 0, 0, 0)
+	}
+	mock.ExpectExec(literal("UPDATE notifications n SET delivery_status = $1")).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(literal("WITH picked AS")).WillReturnRows(rows)
+	for _, id := range append(ids, "live") {
+		mock.ExpectExec(literal("INSERT INTO notification_deliveries")).
+			WithArgs(id, channelWebSocket, deliveryDelivered, "", sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(literal("UPDATE notifications SET delivery_status = $1 WHERE id = $2")).
+			WithArgs(statusDelivered, id).WillReturnResult(sqlmock.NewResult(0, 1))
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stream := &recordingStream{ctx: ctx, batches: make(chan []string, 10)}
+	done := make(chan error, 1)
+	go func() {
+		done <- s.SubscribeToNotifications(&notifpb.SubscribeRequest{UserId: "u1", BatchSize: 3}, stream)
+	}()
+
+	next := func() []string {
+		t.Helper()
+		select {
+		case batch := <-stream.batches:
+			return batch
+		case <-time.After(5 * time.Second):
+			t.Fatal("no batch sent")
+			return nil
+		}
+	}
+	for _, want := range [][]string{{"n1", "n2", "n3"}, {"n4", "n5", "n6"}, {"n7"}} {
+		if got := next(); !slices.Equal(got, want) {
+			t.Fatalf("backlog batch %v, want %v", got, want)
+		}
+	}
+
+	// Live notifications are still sent one at a time.
+	s.broadcast(context.Background(), "u1", &notifpb.Notification{Id: "live", UserId: "u1"})
+	if got := next(); !slices.Equal(got, []string{"live"}) {
+		t.Errorf("live batch %v, want [live]", got)
+	}
+	waitForExpectations(t, mock)
+	cancel()
+	<-done
+}
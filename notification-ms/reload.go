@@ -0,0 +1,143 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"syscall"
+)
+
+// reloadableKeys are the settings applied to the running service on SIGHUP.
+// Every other setting is read once at startup.
+var reloadableKeys = []string{"NOTIF_TEST_NOTIFICATIONS"}
+
+// envValue is an environment variable's value before CONFIG_FILE overrode it.
+type envValue struct {
+	value string
+	set   bool
+}
+
+// fileOverrides holds the previous value of every variable the last loaded
+// CONFIG_FILE set, so a key removed from the file goes back to the process
+// environment, or to its default when the environment does not set it.
+var fileOverrides = map[string]envValue{}
+
+// loadConfigFile sets the environment variables listed in the file named by
+// CONFIG_FILE, if any. Each line is KEY=VALUE; blank lines and lines starting
+// with # are skipped. Values in the file override the process environment.
+// A file that cannot be parsed changes nothing.
+func loadConfigFile() error {
+	path := os.Getenv("CONFIG_FILE")
+	if path == "" {
+		return nil
+	}
+	values, err := parseConfigFile(path)
+	if err != nil {
+		return err
+	}
+
+	for key, prev := range fileOverrides {
+		if _, ok := values[key]; ok {
+			continue
+		}
+		if prev.set {
+			os.Setenv(key, prev.value)
+		} else {
+			os.Unsetenv(key)
+		}
+		delete(fileOverrides, key)
+	}
+	for key, value := range values {
+		if _, ok := fileOverrides[key]; !ok {
+			prev, set := os.LookupEnv(key)
+			fileOverrides[key] = envValue{value: prev, set: set}
+		}
+		if err := os.Setenv(key, value); err != nil {
+			return fmt.Errorf("%s: %s: %w", path, key, err)
+		}
+	}
+	return nil
+}
+
+// parseConfigFile reads the KEY=VALUE lines of the file at path.
+func parseConfigFile(path string) (map[string]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	values := make(map[string]string)
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("%s:%d: expected KEY=VALUE", path, n)
+		}
+		values[key] = strings.TrimSpace(value)
+	}
+	return values, scanner.Err()
+}
+
+// watchReload reloads the configuration every time the process receives
+// SIGHUP.
+func (s *notificationServer) watchReload() {
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, syscall.SIGHUP)
+	s.reloadOn(sig)
+}
+
+// reloadOn reloads the configuration for every signal received on sig until
+// it is closed.
+func (s *notificationServer) reloadOn(sig <-chan os.Signal) {
+	for range sig {
+		s.reload()
+	}
+}
+
+// reload re-reads CONFIG_FILE and applies the reloadable settings. Any other
+// setting that changed is logged and left as it was until a restart.
+func (s *notificationServer) reload() {
+	before := environ()
+	if err := loadConfigFile(); err != nil {
+		log.Printf("failed to reload configuration, keeping the current settings: %v", err)
+		return
+	}
+	after := environ()
+
+	applied := []string{}
+	if after["NOTIF_TEST_NOTIFICATIONS"] != before["NOTIF_TEST_NOTIFICATIONS"] {
+		s.testNotifications.Store(getEnvBool("NOTIF_TEST_NOTIFICATIONS", false))
+		applied = append(applied, "NOTIF_TEST_NOTIFICATIONS")
+	}
+	for key, value := range after {
+		if value != before[key] && !slices.Contains(reloadableKeys, key) {
+			log.Printf("%s changed but requires a restart, ignored", key)
+		}
+	}
+	for key := range before {
+		if _, ok := after[key]; !ok && !slices.Contains(reloadableKeys, key) {
+			log.Printf("%s changed but requires a restart, ignored", key)
+		}
+	}
+	log.Printf("configuration reloaded: applied %v, test notifications %t", applied, s.testNotifications.Load())
+}
+
+// environ returns the process environment as a map.
+func environ() map[string]string {
+	env := make(map[string]string)
+	for _, kv := range os.Environ() {
+		key, value, _ := strings.Cut(kv, "=")
+		env[key] = value
+	}
+	return env
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"bytes"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// useConfigFile points CONFIG_FILE at a file in a temporary directory and
+// returns a function that replaces its contents. keys are the variables the
+// file will set; they are restored once the test ends.
+func useConfigFile(t *testing.T, keys ...string) func(contents string) {
+	t.Helper()
+	for _, key := range keys {
+		prev, set := os.LookupEnv(key)
+		t.Cleanup(func() {
+			if set {
+				os.Setenv(key, prev)
+			} else {
+				os.Unsetenv(key)
+			}
+		})
+	}
+	t.Cleanup(func() { fileOverrides = map[string]envValue{} })
+
+	path := filepath.Join(t.TempDir(), "notification-ms.env")
+	t.Setenv("CONFIG_FILE", path)
+	return func(contents string) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(contents), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestReloadTestNotificationsOnSIGHUP(t *testing.T) {
+	s, _ := newTestServer(t)
+	write := useConfigFile(t, "NOTIF_TEST_NOTIFICATIONS")
+	write("NOTIF_TEST_NOTIFICATIONS=false\n")
+	if err := loadConfigFile(); err != nil {
+		t.Fatal(err)
+	}
+
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, syscall.SIGHUP)
+	done := make(chan struct{})
+	go func() {
+		s.reloadOn(sig)
+		close(done)
+	}()
+	defer func() {
+		signal.Stop(sig)
+		close(sig)
+		<-done
+	}()
+
+	write("NOTIF_TEST_NOTIFICATIONS=true\n")
+	deadline := time.Now().Add(5 * time.Second)
+	for !s.testNotifications.Load() {
+		if time.Now().After(deadline) {
+			t.Fatal("test notifications still disabled after SIGHUP")
+		}
+		if err := syscall.Kill(os.Getpid(), syscall.SIGHUP); err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+func TestReloadIgnoresRestartOnlySettings(t *testing.T) {
+	s, _ := newTestServer(t)
+	write := useConfigFile(t, "NOTIF_TEST_NOTIFICATIONS", "NOTIF_FLUSH_BATCH_SIZE")
+	write("NOTIF_TEST_NOTIFICATIONS=false\nNOTIF_FLUSH_BATCH_SIZE=1\n")
+	if err := loadConfigFile(); err != nil {
+		t.Fatal(err)
+	}
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+
+	write("NOTIF_TEST_NOTIFICATIONS=true\nNOTIF_FLUSH_BATCH_SIZE=50\n")
+	s.reload()
+	if !s.testNotifications.Load() {
+		t.Error("test notifications not enabled by reload")
+	}
+	if !strings.Contains(logs.String(), "NOTIF_FLUSH_BATCH_SIZE changed but requires a restart, ignored") {
+		t.Errorf("restart-only change not reported:\n%s", logs.String())
+	}
+
+	// Removing the key from the file turns the setting back to its default.
+	write("NOTIF_FLUSH_BATCH_SIZE=50\n")
+	s.reload()
+	if s.testNotifications.Load() {
+		t.Error("test notifications still enabled after the key was removed")
+	}
+
+	// A file that does not parse changes nothing.
+	write("NOTIF_TEST_NOTIFICATIONS=true\nnot a setting\n")
+	s.reload()
+	if s.testNotifications.Load() {
+		t.Error("invalid file applied")
+	}
+}
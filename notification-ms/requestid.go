@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// requestIDMetadataKey is the gRPC metadata key the gateway forwards the HTTP
+// request id in, and requestIDHeader the NATS header events carry it in.
+const (
+	requestIDMetadataKey = "x-request-id"
+	requestIDHeader      = "X-Request-ID"
+)
+
+// incomingRequestID returns the request id in the incoming metadata, or "".
+func incomingRequestID(ctx context.Context) string {
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if v := md.Get(requestIDMetadataKey); len(v) > 0 {
+			return v[0]
+		}
+	}
+	return ""
+}
+
+// requestIDKey is the context key of a request id that did not arrive as
+// gRPC metadata, such as the one in a NATS event's headers.
+type requestIDKey struct{}
+
+// withRequestID returns ctx carrying id, or ctx itself when id is "".
+func withRequestID(ctx context.Context, id string) context.Context {
+	if id == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// contextRequestID returns the request id ctx carries, set by withRequestID
+// or in the incoming gRPC metadata, or "".
+func contextRequestID(ctx context.Context) string {
+	if id, ok := ctx.Value(requestIDKey{}).(string); ok {
+		return id
+	}
+	return incomingRequestID(ctx)
+}
+
+// logf logs like log.Printf, followed by the request id ctx carries, if any,
+// so every line logged for a request can be found by its id.
+func logf(ctx context.Context, format string, args ...any) {
+	if id := contextRequestID(ctx); id != "" {
+		format += " (request_id=%s)"
+		args = append(args, id)
+	}
+	log.Output(2, fmt.Sprintf(format, args...))
+}
+
+// requestIDUnaryInterceptor logs every call that carries a request id, so
+// the gateway's id can be followed into this service.
+func requestIDUnaryInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	start := time.Now()
+	res, err := handler(ctx, req)
+	if incomingRequestID(ctx) != "" {
+		logf(ctx, "%s finished in %s with %s", info.FullMethod, time.Since(start), status.Code(err))
+	}
+	return res, err
+}
+
+// requestIDStreamInterceptor does the same for streams, logging when they
+// open as well as when they end.
+func requestIDStreamInterceptor(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx := ss.Context()
+	if incomingRequestID(ctx) == "" {
+		return handler(srv, ss)
+	}
+	start := time.Now()
+	logf(ctx, "%s opened", info.FullMethod)
+	err := handler(srv, ss)
+	logf(ctx, "%s closed after %s with %s", info.FullMethod, time.Since(start), status.Code(err))
+	return err
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/nats-io/nats.go"
+	"google.golang.org/grpc/metadata"
+
+	"notification-ms/notifpb"
+)
+
+// wantRequestIDOnLinesAbout fails the test unless every line of logs that
+// mentions subject also carries id.
+func wantRequestIDOnLinesAbout(t *testing.T, logs, subject, id string) {
+	t.Helper()
+	seen := 0
+	for _, line := range strings.Split(logs, "\n") {
+		if !strings.Contains(line, subject) {
+			continue
+		}
+		seen++
+		if !strings.HasSuffix(line, "(request_id="+id+")") {
+			t.Errorf("line without request id %s: %s", id, line)
+		}
+	}
+	if seen == 0 {
+		t.Errorf("nothing logged about %s:\n%s", subject, logs)
+	}
+}
+
+func TestStreamLogsCarryRequestID(t *testing.T) {
+	s, mock := newTestServer(t)
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	defer log.SetOutput(os.Stderr)
+
+	mock.ExpectExec(literal("UPDATE notifications n SET delivery_status = $1")).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(literal("WITH picked AS")).WillReturnRows(
+		sqlmock.NewRows([]string{"id", "user_id", "message", "created_at", "source_event_id", "type", "severity", "source"}).
+			AddRow("n1", "u1", "msg n1", time.Now(), "", 0, 0, 0))
+	mock.ExpectExec(literal("INSERT INTO notification_deliveries")).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(literal("UPDATE notifications SET delivery_status = $1 WHERE id = $2")).WillReturnResult(sqlmock.NewResult(0, 1))
+
+	md := metadata.Pairs(sessionIDMetadataKey, "sess-1", requestIDMetadataKey, "req-1")
+	ctx, cancel := context.WithCancel(metadata.NewIncomingContext(context.Background(), md))
+	stream := &disconnectingStream{ctx: ctx, cancel: cancel}
+	s.SubscribeToNotifications(&notifpb.SubscribeRequest{UserId: "u1"}, stream)
+	waitForExpectations(t, mock)
+	log.SetOutput(os.Stderr)
+
+	wantRequestIDOnLinesAbout(t, logs.String(), "u1", "req-1")
+}
+
+func TestEventLogsCarryRequestID(t *testing.T) {
+	s, mock := newTestServer(t)
+	nc, js := runJetStream(t)
+	s.subs = newSubscriptions(nc, js, "notification-ms")
+	s.dispatcher = newDispatcher(1, 10, notifpb.Severity_SEVERITY_UNSPECIFIED, s.deliver, s.persist)
+	sub := addSubscriber(s, "u1", "a")
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	defer log.SetOutput(os.Stderr)
+
+	mock.ExpectQuery(literal("SELECT locale FROM user_locales")).WithArgs("u1").WillReturnRows(sqlmock.NewRows([]string{"locale"}))
+	mock.ExpectExec(literal("INSERT INTO notifications")).WillReturnResult(sqlmock.NewResult(0, 1))
+
+	data, err := json.Marshal(billUpdate{EventID: "evt-1", Id: "u1", MessageID: msgBillUpdated, Params: map[string]string{"amount": "12.50"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := nats.NewMsg("bill.update")
+	msg.Data = data
+	msg.Header.Set(requestIDHeader, "req-2")
+	if _, err := js.PublishMsg(msg); err != nil {
+		t.Fatal(err)
+	}
+	go s.subscribeToEvents()
+
+	select {
+	case <-sub.ch:
+	case <-time.After(5 * time.Second):
+		t.Fatal("no notification for bill.update")
+	}
+	// Closing waits for the worker, so its log lines are all written.
+	s.dispatcher.Close(time.Second)
+	waitForExpectations(t, mock)
+	log.SetOutput(os.Stderr)
+
+	wantRequestIDOnLinesAbout(t, logs.String(), "u1", "req-2")
+	if !strings.Contains(logs.String(), "Successfully broadcasted notification to user: u1 (session a) (request_id=req-2)") {
+		t.Errorf("broadcast not logged with the event's request id:\n%s", logs.String())
+	}
+}
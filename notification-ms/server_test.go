@@ -0,0 +1,175 @@
+package main
+
+import (
+	"context"
+	"regexp"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	natsserver "github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go"
+
+	"notification-ms/notifpb"
+)
+
+// newTestServer returns a server whose store writes through unbatched to a
+// mock database, with the embedded message catalog. The mock's expectations
+// are checked when the test ends.
+func newTestServer(t *testing.T) (*notificationServer, sqlmock.Sqlmock) {
+	t.Helper()
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+		db.Close()
+	})
+	catalog, err := loadCatalog(fallbackLocale)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &notificationServer{
+		store:             newNotificationStore(db, realClock{}, 0, 0),
+		catalog:           catalog,
+		flushBatchSize:    1,
+		clock:             realClock{},
+		subscribers:       make(map[string][]*subscriber),
+		duplicateSessions: duplicateReplace,
+	}, mock
+}
+
+// literal matches a query containing s literally.
+func literal(s string) string { return regexp.QuoteMeta(s) }
+
+// addSubscriber registers a stream for userID without a gRPC connection and
+// returns it; what is broadcast to it can be read from its channel.
+func addSubscriber(s *notificationServer, userID, sessionID string) *subscriber {
+	sub := &subscriber{
+		ch:          make(chan []*notifpb.Notification, subscriberBufferSize),
+		userId:      userID,
+		batchSize:   s.flushBatchSize,
+		ctx:         context.Background(),
+		sessionID:   sessionID,
+		connectedAt: s.clock.Now(),
+		closed:      make(chan struct{}),
+	}
+	sub.touch(s.clock.Now())
+	s.mu.Lock()
+	s.subscribers[userID] = append(s.subscribers[userID], sub)
+	s.mu.Unlock()
+	return sub
+}
+
+// received returns the next batch sent to sub, failing the test if none
+// arrives within a second.
+func received(t *testing.T, sub *subscriber) []*notifpb.Notification {
+	t.Helper()
+	select {
+	case batch := <-sub.ch:
+		return batch
+	case <-time.After(time.Second):
+		t.Fatalf("nothing sent to subscriber %s", sub.sessionID)
+		return nil
+	}
+}
+
+// runJetStream starts an in-process NATS server with JetStream, creates the
+// events stream on it and returns a connection to it. Both are shut down
+// when the test ends.
+func runJetStream(t *testing.T) (*nats.Conn, nats.JetStreamContext) {
+	t.Helper()
+	ns, err := natsserver.NewServer(&natsserver.Options{Port: -1, JetStream: true, StoreDir: t.TempDir(), NoLog: true, NoSigs: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ns.Start()
+	t.Cleanup(ns.Shutdown)
+	if !ns.ReadyForConnections(5 * time.Second) {
+		t.Fatal("NATS server not ready")
+	}
+	nc, err := nats.Connect(ns.ClientURL())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(nc.Close)
+	js, err := ensureEventStream(nc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return nc, js
+}
+
+// fakeClock is a Clock whose time only moves when Advance is called. After
+// channels fire once the clock has been advanced past their deadline.
+type fakeClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	waiters []fakeWaiter
+}
+
+type fakeWaiter struct {
+	at time.Time
+	ch chan time.Time
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ch := make(chan time.Time, 1)
+	if d <= 0 {
+		ch <- c.now
+		return ch
+	}
+	c.waiters = append(c.waiters, fakeWaiter{at: c.now.Add(d), ch: ch})
+	return ch
+}
+
+// Advance moves the clock forward by d and fires every After that is due.
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+	pending := c.waiters[:0]
+	for _, w := range c.waiters {
+		if w.at.After(c.now) {
+			pending = append(pending, w)
+			continue
+		}
+		w.ch <- c.now
+	}
+	c.waiters = pending
+}
+
+// waitForWaiters blocks until n After calls are pending, failing the test
+// if that takes more than a second.
+func (c *fakeClock) waitForWaiters(t *testing.T, n int) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for {
+		c.mu.Lock()
+		got := len(c.waiters)
+		c.mu.Unlock()
+		if got >= n {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("%d clock waiters, want %d", got, n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
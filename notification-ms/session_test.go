@@ -0,0 +1,196 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"notification-ms/notifpb"
+)
+
+func TestDeliveryLogsCarrySessionID(t *testing.T) {
+	s, mock := newTestServer(t)
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	defer log.SetOutput(os.Stderr)
+
+	mock.ExpectExec(literal("UPDATE notifications n SET delivery_status = $1")).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(literal("WITH picked AS")).WillReturnRows(
+		sqlmock.NewRows([]string{"id", "user_id", "message", "created_at", "source_event_id", "type", "severity", "source"}).
+			AddRow("n1", "u1", "msg n1", time.Now(), "", 0, 0, 0))
+	mock.ExpectExec(literal("INSERT INTO notification_deliveries")).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(literal("UPDATE notifications SET delivery_status = $1 WHERE id = $2")).WillReturnResult(sqlmock.NewResult(0, 1))
+
+	ctx, cancel := context.WithCancel(metadata.NewIncomingContext(context.Background(), metadata.Pairs(sessionIDMetadataKey, "sess-1")))
+	stream := &disconnectingStream{ctx: ctx, cancel: cancel}
+	s.SubscribeToNotifications(&notifpb.SubscribeRequest{UserId: "u1"}, stream)
+	waitForExpectations(t, mock)
+	log.SetOutput(os.Stderr)
+
+	for _, want := range []string{
+		"New subscriber for user: u1 (session sess-1)",
+		"Delivered notification n1 to user u1 (session sess-1)",
+		"Subscriber disconnected for user: u1 (session sess-1)",
+	} {
+		if !strings.Contains(logs.String(), want) {
+			t.Errorf("logs missing %q:\n%s", want, logs.String())
+		}
+	}
+}
+
+// expectRedelivery expects the stored-notification lookups of n new
+// subscriptions, none of which finds anything.
+func expectRedelivery(mock sqlmock.Sqlmock, n int) {
+	mock.MatchExpectationsInOrder(false)
+	for range n {
+		mock.ExpectExec(literal("UPDATE notifications n SET delivery_status = $1")).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(literal("WITH picked AS")).WillReturnRows(
+			sqlmock.NewRows([]string{"id", "user_id", "message", "created_at", "source_event_id", "type", "severity", "source"}))
+	}
+}
+
+// subscribeSession starts a subscription for u1 with the given session id
+// and returns its stream and the channel its result arrives on.
+func subscribeSession(s *notificationServer, sessionID string) (*recordingStream, context.CancelFunc, <-chan error) {
+	ctx, cancel := context.WithCancel(metadata.NewIncomingContext(context.Background(), metadata.Pairs(sessionIDMetadataKey, sessionID)))
+	stream := &recordingStream{ctx: ctx, batches: make(chan []string, 10)}
+	done := make(chan error, 1)
+	go func() { done <- s.SubscribeToNotifications(&notifpb.SubscribeRequest{UserId: "u1"}, stream) }()
+	return stream, cancel, done
+}
+
+// waitForStreams waits until u1 has n active streams.
+func waitForStreams(t *testing.T, s *notificationServer, n int) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for {
+		s.mu.RLock()
+		got := len(s.subscribers["u1"])
+		s.mu.RUnlock()
+		if got == n {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("u1 has %d streams, want %d", got, n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestDuplicateSessionReplacesOldStream(t *testing.T) {
+	s, mock := newTestServer(t)
+	s.duplicateSessions = duplicateReplace
+	expectRedelivery(mock, 3)
+
+	_, cancelOld, oldDone := subscribeSession(s, "sess-1")
+	defer cancelOld()
+	waitForStreams(t, s, 1)
+	newStream, cancelNew, newDone := subscribeSession(s, "sess-1")
+	defer cancelNew()
+
+	select {
+	case err := <-oldDone:
+		if status.Code(err) != codes.Aborted {
+			t.Errorf("replaced stream ended with %v, want Aborted", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("old stream not closed when the session subscribed again")
+	}
+	// The old stream ending does not remove its replacement, and another
+	// session of the same user is unaffected.
+	_, cancelOther, _ := subscribeSession(s, "sess-2")
+	defer cancelOther()
+	waitForStreams(t, s, 2)
+
+	// A control message, so no delivery is recorded.
+	s.broadcast(context.Background(), "u1", &notifpb.Notification{Id: "n1", UserId: "u1", Event: eventNotificationRead})
+	select {
+	case batch := <-newStream.batches:
+		if len(batch) != 1 || batch[0] != "n1" {
+			t.Errorf("new stream got %v", batch)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("replacement stream not receiving")
+	}
+	select {
+	case err := <-newDone:
+		t.Fatalf("replacement stream ended: %v", err)
+	default:
+	}
+	waitForExpectations(t, mock)
+}
+
+func TestDuplicateSessionRejectsNewStream(t *testing.T) {
+	s, mock := newTestServer(t)
+	s.duplicateSessions = duplicateReject
+	expectRedelivery(mock, 1)
+
+	_, cancelOld, oldDone := subscribeSession(s, "sess-1")
+	defer cancelOld()
+	waitForStreams(t, s, 1)
+
+	_, cancelNew, newDone := subscribeSession(s, "sess-1")
+	defer cancelNew()
+	select {
+	case err := <-newDone:
+		if status.Code(err) != codes.AlreadyExists {
+			t.Errorf("duplicate stream ended with %v, want AlreadyExists", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("duplicate stream not rejected")
+	}
+	waitForStreams(t, s, 1)
+	select {
+	case err := <-oldDone:
+		t.Errorf("original stream ended: %v", err)
+	default:
+	}
+	waitForExpectations(t, mock)
+}
+
+func TestBroadcastReachesEveryStreamOfUser(t *testing.T) {
+	s, mock := newTestServer(t)
+	expectRedelivery(mock, 2)
+	for range 3 {
+		mock.ExpectExec(literal("INSERT INTO notification_deliveries")).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(literal("UPDATE notifications SET delivery_status = $1 WHERE id = $2")).WillReturnResult(sqlmock.NewResult(0, 1))
+	}
+
+	tab1, cancel1, done1 := subscribeSession(s, "tab-1")
+	defer cancel1()
+	tab2, cancel2, _ := subscribeSession(s, "tab-2")
+	defer cancel2()
+	waitForStreams(t, s, 2)
+
+	receive := func(stream *recordingStream, name, want string) {
+		t.Helper()
+		select {
+		case batch := <-stream.batches:
+			if len(batch) != 1 || batch[0] != want {
+				t.Errorf("%s got %v, want %s", name, batch, want)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("%s did not receive %s", name, want)
+		}
+	}
+	s.broadcast(context.Background(), "u1", &notifpb.Notification{Id: "n1", UserId: "u1"})
+	receive(tab1, "tab 1", "n1")
+	receive(tab2, "tab 2", "n1")
+
+	// Closing one tab leaves the other subscribed.
+	cancel1()
+	<-done1
+	waitForStreams(t, s, 1)
+	s.broadcast(context.Background(), "u1", &notifpb.Notification{Id: "n2", UserId: "u1"})
+	receive(tab2, "tab 2", "n2")
+	waitForExpectations(t, mock)
+}
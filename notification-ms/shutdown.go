@@ -0,0 +1,47 @@
+package main
+
+import (
+	"log"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// closeSubscribers ends every open notification stream with Unavailable so
+// clients reconnect to another instance. It returns how many were closed.
+func (s *notificationServer) closeSubscribers() int {
+	s.mu.RLock()
+	var open []*subscriber
+	for _, subs := range s.subscribers {
+		open = append(open, subs...)
+	}
+	s.mu.RUnlock()
+
+	for _, sub := range open {
+		sub.close(status.Error(codes.Unavailable, "server shutting down"))
+	}
+	return len(open)
+}
+
+// stopServing stops the gRPC server. Notification streams never end on their
+// own, so GracefulStop would wait for them forever: they are closed once the
+// server has stopped accepting new calls. Calls still running after timeout
+// are cancelled with Stop.
+func stopServing(s *grpc.Server, server *notificationServer, timeout time.Duration) {
+	stopped := make(chan struct{})
+	go func() {
+		s.GracefulStop()
+		close(stopped)
+	}()
+	log.Printf("Closed %d notification streams", server.closeSubscribers())
+
+	select {
+	case <-stopped:
+	case <-time.After(timeout):
+		log.Printf("gRPC calls still running after %s, stopping forcefully", timeout)
+		s.Stop()
+		<-stopped
+	}
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"context"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"notification-ms/notifpb"
+)
+
+func TestStopServingClosesOpenStreams(t *testing.T) {
+	server, mock := newTestServer(t)
+	// The new stream catches up on stored notifications; there are none.
+	mock.ExpectExec(literal("UPDATE notifications n SET delivery_status")).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(literal("WITH picked AS")).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "message", "created_at", "source_event_id", "type", "severity", "source"}))
+
+	lis := bufconn.Listen(1 << 16)
+	s := grpc.NewServer()
+	notifpb.RegisterNotificationServiceServer(s, server)
+	go s.Serve(lis)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return lis.Dial() }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	stream, err := notifpb.NewNotificationServiceClient(conn).SubscribeToNotifications(context.Background(), &notifpb.SubscribeRequest{UserId: "u1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Wait for the stream to have subscribed and caught up.
+	for deadline := time.Now().Add(time.Second); mock.ExpectationsWereMet() != nil; {
+		if time.Now().After(deadline) {
+			t.Fatal("stream never subscribed")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	start := time.Now()
+	stopServing(s, server, 5*time.Second)
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("stopServing took %s with an open stream", elapsed)
+	}
+	if _, err := stream.Recv(); status.Code(err) != codes.Unavailable {
+		t.Errorf("stream ended with %v, want Unavailable", err)
+	}
+}
+
+func TestCloseSubscribers(t *testing.T) {
+	server, _ := newTestServer(t)
+	subs := []*subscriber{
+		addSubscriber(server, "u1", "s1"),
+		addSubscriber(server, "u1", "s2"),
+		addSubscriber(server, "u2", "s3"),
+	}
+	if n := server.closeSubscribers(); n != len(subs) {
+		t.Errorf("closed %d streams, want %d", n, len(subs))
+	}
+	for _, sub := range subs {
+		select {
+		case <-sub.closed:
+		default:
+			t.Fatalf("stream %s not closed", sub.sessionID)
+		}
+		if status.Code(sub.closeErr) != codes.Unavailable {
+			t.Errorf("stream %s closed with %v, want Unavailable", sub.sessionID, sub.closeErr)
+		}
+	}
+}
+
+func TestDispatcherCloseKeepsEveryNotification(t *testing.T) {
+	const total = 50
+	var handled, persisted atomic.Int64
+	d := newDispatcher(2, total, notifpb.Severity_SEVERITY_UNSPECIFIED,
+		func(context.Context, *notifpb.Notification) {
+			time.Sleep(5 * time.Millisecond)
+			handled.Add(1)
+		},
+		func(context.Context, *notifpb.Notification) { persisted.Add(1) })
+	for i := range total {
+		d.Enqueue(context.Background(), &notifpb.Notification{Id: string(rune('a' + i)), UserId: string(rune('a' + i%7))})
+	}
+
+	// Too short to handle everything: the rest must be persisted.
+	d.Close(20 * time.Millisecond)
+	// Workers finish the delivery they were in the middle of.
+	time.Sleep(50 * time.Millisecond)
+	if got := handled.Load() + persisted.Load(); got != total {
+		t.Errorf("handled %d + persisted %d = %d, want %d", handled.Load(), persisted.Load(), got, total)
+	}
+	if persisted.Load() == 0 {
+		t.Error("nothing persisted although Close timed out")
+	}
+
+	// Notifications arriving after Close are persisted too.
+	d.Enqueue(context.Background(), &notifpb.Notification{Id: "late", UserId: "u1"})
+	if got := handled.Load() + persisted.Load(); got != total+1 {
+		t.Errorf("late notification lost: %d accounted for, want %d", got, total+1)
+	}
+}
+
+func TestDispatcherCloseReleasesBlockedEnqueue(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	var persisted atomic.Int64
+	d := newDispatcher(1, 1, notifpb.Severity_SEVERITY_UNSPECIFIED,
+		func(context.Context, *notifpb.Notification) { <-release },
+		func(context.Context, *notifpb.Notification) { persisted.Add(1) })
+
+	// The worker is stuck on the first notification and the queue holds the
+	// second, so the third Enqueue waits for room.
+	d.Enqueue(context.Background(), &notifpb.Notification{Id: "busy", UserId: "u1"})
+	time.Sleep(20 * time.Millisecond)
+	d.Enqueue(context.Background(), &notifpb.Notification{Id: "queued", UserId: "u1"})
+	blocked := make(chan struct{})
+	go func() {
+		d.Enqueue(context.Background(), &notifpb.Notification{Id: "blocked", UserId: "u1"})
+		close(blocked)
+	}()
+	time.Sleep(20 * time.Millisecond)
+
+	closed := make(chan struct{})
+	go func() {
+		d.Close(50 * time.Millisecond)
+		close(closed)
+	}()
+	select {
+	case <-closed:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Close waited on an Enqueue blocked by a full queue")
+	}
+	select {
+	case <-blocked:
+	case <-time.After(time.Second):
+		t.Fatal("blocked Enqueue not released by Close")
+	}
+	// The blocked notification and the queued one are persisted.
+	if got := persisted.Load(); got != 2 {
+		t.Errorf("persisted %d notifications, want 2", got)
+	}
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"maps"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// version is the build version, set with -ldflags "-X main.version=...".
+var version = "dev"
+
+// dsnTarget describes a Postgres DSN, in URL or key=value form, as
+// "host/dbname" so it can be logged without credentials.
+func dsnTarget(dsn string) string {
+	if u, err := url.Parse(dsn); err == nil && u.Scheme != "" {
+		return u.Host + u.Path
+	}
+	var host, dbname string
+	for _, kv := range strings.Fields(dsn) {
+		k, v, _ := strings.Cut(kv, "=")
+		switch k {
+		case "host":
+			host = v
+		case "dbname":
+			dbname = v
+		}
+	}
+	return host + "/" + dbname
+}
+
+// logStartup logs the single "service started" line summarising what the
+// service is wired to. Call it once every dependency has been connected.
+func logStartup(listenAddr, dsn, natsURL string, features map[string]any) {
+	var b strings.Builder
+	for _, k := range slices.Sorted(maps.Keys(features)) {
+		fmt.Fprintf(&b, " %s=%v", k, features[k])
+	}
+	log.Printf("service started: service=notification-ms version=%s listen_addr=%s db=%s nats_url=%s features:%s",
+		version, listenAddr, dsnTarget(dsn), natsURL, b.String())
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"notification-ms/notifpb"
+)
+
+const countSince = "SELECT COUNT(*) FROM notifications WHERE created_at >= $1"
+
+func TestStatsCountsSubscribersAndToday(t *testing.T) {
+	s, mock := newTestServer(t)
+	nc, js := runJetStream(t)
+	s.subs = newSubscriptions(nc, js, "notification-ms")
+	clock := newFakeClock()
+	s.clock = clock
+	addSubscriber(s, "u1", "a")
+	addSubscriber(s, "u1", "b")
+	addSubscriber(s, "u2", "c")
+	s.reaped.Add(2)
+
+	midnight := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	mock.ExpectQuery(literal(countSince)).WithArgs(midnight).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(5))
+	res, err := s.Stats(context.Background(), &notifpb.StatsRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.ActiveSubscribers != 3 || res.NotificationsToday != 5 || res.ReapedSubscribers != 2 || res.ConsumptionPaused {
+		t.Errorf("stats = %+v, want 3 active, 5 today, 2 reaped, not paused", res)
+	}
+
+	// Today starts again at the next UTC midnight.
+	clock.Advance(12 * time.Hour)
+	mock.ExpectQuery(literal(countSince)).WithArgs(midnight.Add(24 * time.Hour)).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	if _, err := s.Stats(context.Background(), &notifpb.StatsRequest{}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestStatsDatabaseError(t *testing.T) {
+	s, mock := newTestServer(t)
+	mock.ExpectQuery(literal(countSince)).WillReturnError(errors.New("connection reset"))
+	_, err := s.Stats(context.Background(), &notifpb.StatsRequest{})
+	if status.Code(err) != codes.Internal {
+		t.Fatalf("err = %v, want Internal", err)
+	}
+}
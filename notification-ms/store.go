@@ -0,0 +1,680 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"notification-ms/notifpb"
+)
+
+// pendingNotification is a notification waiting in the batch buffer together
+// with the time it was accepted, so created_at is not skewed by the flush delay,
+// and the id of the request that produced it, for logging a failed flush.
+type pendingNotification struct {
+	notif     *notifpb.Notification
+	createdAt time.Time
+	requestID string
+}
+
+// notificationStore persists notifications to Postgres. When batchSize is
+// greater than one, inserts are buffered and written as multi-row INSERTs
+// whenever the buffer fills up or the flush interval elapses.
+type notificationStore struct {
+	db        *sql.DB
+	clock     Clock
+	batchSize int
+	interval  time.Duration
+
+	mu      sync.Mutex
+	pending []pendingNotification
+	closed  bool
+
+	flushCh chan struct{}
+	done    chan struct{}
+	wg      sync.WaitGroup
+}
+
+func newNotificationStore(db *sql.DB, clock Clock, batchSize int, interval time.Duration) *notificationStore {
+	st := &notificationStore{
+		db:        db,
+		clock:     clock,
+		batchSize: batchSize,
+		interval:  interval,
+		flushCh:   make(chan struct{}, 1),
+		done:      make(chan struct{}),
+	}
+	if st.batching() {
+		st.wg.Add(1)
+		go st.run()
+	}
+	return st
+}
+
+// migrate creates the notifications table if it does not exist.
+func (st *notificationStore) migrate() error {
+	_, err := st.db.Exec(`CREATE TABLE IF NOT EXISTS notifications (
+		id TEXT PRIMARY KEY,
+		user_id TEXT NOT NULL,
+		message TEXT NOT NULL,
+		created_at TIMESTAMPTZ NOT NULL,
+		read BOOLEAN NOT NULL DEFAULT FALSE
+	)`)
+	if err != nil {
+		return err
+	}
+	_, err = st.db.Exec(`CREATE INDEX IF NOT EXISTS notifications_user_created_idx ON notifications (user_id, created_at)`)
+	if err != nil {
+		return err
+	}
+	_, err = st.db.Exec(`ALTER TABLE notifications ADD COLUMN IF NOT EXISTS resend_count INT NOT NULL DEFAULT 0`)
+	if err != nil {
+		return err
+	}
+	_, err = st.db.Exec(`CREATE TABLE IF NOT EXISTS notification_deliveries (
+		id BIGSERIAL PRIMARY KEY,
+		notification_id TEXT NOT NULL,
+		channel TEXT NOT NULL,
+		result TEXT NOT NULL,
+		error TEXT NOT NULL DEFAULT '',
+		attempted_at TIMESTAMPTZ NOT NULL
+	)`)
+	if err != nil {
+		return err
+	}
+	_, err = st.db.Exec(`CREATE INDEX IF NOT EXISTS notification_deliveries_notification_idx ON notification_deliveries (notification_id)`)
+	if err != nil {
+		return err
+	}
+	// Rows that predate delivery tracking are treated as delivered; new rows
+	// are inserted as stored until a live send succeeds.
+	_, err = st.db.Exec(`ALTER TABLE notifications
+		ADD COLUMN IF NOT EXISTS delivery_status TEXT NOT NULL DEFAULT 'delivered',
+		ADD COLUMN IF NOT EXISTS delivery_attempts INT NOT NULL DEFAULT 0`)
+	if err != nil {
+		return err
+	}
+	_, err = st.db.Exec(`CREATE INDEX IF NOT EXISTS notifications_stored_idx ON notifications (user_id, created_at) WHERE delivery_status = 'stored'`)
+	if err != nil {
+		return err
+	}
+	_, err = st.db.Exec(`ALTER TABLE notifications ADD COLUMN IF NOT EXISTS source_event_id TEXT NOT NULL DEFAULT ''`)
+	if err != nil {
+		return err
+	}
+	_, err = st.db.Exec(`ALTER TABLE notifications
+		ADD COLUMN IF NOT EXISTS type INT NOT NULL DEFAULT 0,
+		ADD COLUMN IF NOT EXISTS severity INT NOT NULL DEFAULT 0,
+		ADD COLUMN IF NOT EXISTS source INT NOT NULL DEFAULT 0`)
+	if err != nil {
+		return err
+	}
+	_, err = st.db.Exec(`CREATE TABLE IF NOT EXISTS user_locales (user_id TEXT PRIMARY KEY, locale TEXT NOT NULL)`)
+	if err != nil {
+		return err
+	}
+	_, err = st.db.Exec(`CREATE TABLE IF NOT EXISTS notification_templates (
+		message_id TEXT NOT NULL,
+		locale TEXT NOT NULL,
+		body TEXT NOT NULL,
+		updated_at TIMESTAMPTZ NOT NULL,
+		PRIMARY KEY (message_id, locale)
+	)`)
+	if err != nil {
+		return err
+	}
+	_, err = st.db.Exec(`CREATE INDEX IF NOT EXISTS notifications_source_event_idx ON notifications (user_id, source_event_id) WHERE source_event_id <> ''`)
+	if err != nil {
+		return err
+	}
+	_, err = st.db.Exec(`CREATE TABLE IF NOT EXISTS backfill_jobs (
+		id TEXT PRIMARY KEY,
+		message_id TEXT NOT NULL,
+		params JSONB NOT NULL DEFAULT '{}',
+		type INT NOT NULL,
+		severity INT NOT NULL,
+		status TEXT NOT NULL,
+		processed BIGINT NOT NULL DEFAULT 0,
+		cursor TEXT NOT NULL DEFAULT '',
+		error TEXT NOT NULL DEFAULT '',
+		created_at TIMESTAMPTZ NOT NULL,
+		updated_at TIMESTAMPTZ NOT NULL
+	)`)
+	if err != nil {
+		return err
+	}
+	// One row per user who has been sent a welcome, seeded from the welcomes
+	// stored before the table existed.
+	_, err = st.db.Exec(`CREATE TABLE IF NOT EXISTS welcome_notifications (
+		user_id TEXT PRIMARY KEY,
+		notification_id TEXT NOT NULL
+	)`)
+	if err != nil {
+		return err
+	}
+	_, err = st.db.Exec(`INSERT INTO welcome_notifications (user_id, notification_id)
+		SELECT DISTINCT ON (user_id) user_id, id FROM notifications WHERE type = $1
+		ORDER BY user_id, created_at
+		ON CONFLICT (user_id) DO NOTHING`, notifpb.NotificationType_NOTIFICATION_TYPE_WELCOME)
+	return err
+}
+
+func (st *notificationStore) batching() bool {
+	return st.batchSize > 1
+}
+
+// Save persists a notification. In batching mode it only buffers the
+// notification; it is written by the next flush.
+func (st *notificationStore) Save(ctx context.Context, notif *notifpb.Notification) error {
+	p := pendingNotification{notif: notif, createdAt: st.clock.Now(), requestID: contextRequestID(ctx)}
+
+	st.mu.Lock()
+	if !st.batching() || st.closed {
+		st.mu.Unlock()
+		return st.insert(ctx, []pendingNotification{p})
+	}
+	st.pending = append(st.pending, p)
+	full := len(st.pending) >= st.batchSize
+	st.mu.Unlock()
+
+	if full {
+		select {
+		case st.flushCh <- struct{}{}:
+		default:
+		}
+	}
+	return nil
+}
+
+// run flushes the buffer every interval or when Save reports a full buffer.
+func (st *notificationStore) run() {
+	defer st.wg.Done()
+	tick := st.clock.After(st.interval)
+
+	for {
+		select {
+		case <-tick:
+			st.flush()
+			tick = st.clock.After(st.interval)
+		case <-st.flushCh:
+			st.flush()
+		case <-st.done:
+			st.flush()
+			return
+		}
+	}
+}
+
+// flush writes everything currently buffered. On failure the batch is put
+// back at the front of the buffer so it is retried on the next flush.
+func (st *notificationStore) flush() {
+	st.mu.Lock()
+	batch := st.pending
+	st.pending = nil
+	st.mu.Unlock()
+
+	for len(batch) > 0 {
+		n := min(len(batch), st.batchSize)
+		if err := st.insert(context.Background(), batch[:n]); err != nil {
+			log.Printf("failed to flush %d notifications: %v%s", len(batch), err, requestIDsOf(batch))
+			st.mu.Lock()
+			st.pending = append(batch, st.pending...)
+			st.mu.Unlock()
+			return
+		}
+		batch = batch[n:]
+	}
+}
+
+// requestIDsOf formats the distinct request ids of batch for a log line, or
+// returns "" when none has one.
+func requestIDsOf(batch []pendingNotification) string {
+	var ids []string
+	for _, p := range batch {
+		if p.requestID != "" && !slices.Contains(ids, p.requestID) {
+			ids = append(ids, p.requestID)
+		}
+	}
+	if len(ids) == 0 {
+		return ""
+	}
+	return " (request_ids=" + strings.Join(ids, ",") + ")"
+}
+
+// insert writes the given notifications with a single multi-row INSERT.
+func (st *notificationStore) insert(ctx context.Context, batch []pendingNotification) error {
+	query, args := insertStatement(batch)
+	_, err := st.db.ExecContext(ctx, query, args...)
+	return err
+}
+
+// insertStatement builds the multi-row INSERT for batch.
+func insertStatement(batch []pendingNotification) (string, []any) {
+	var sb strings.Builder
+	sb.WriteString("INSERT INTO notifications (id, user_id, message, created_at, source_event_id, type, severity, source, delivery_status) VALUES ")
+	const cols = 8
+	args := make([]any, 0, len(batch)*cols)
+	for i, p := range batch {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		sb.WriteString("(")
+		for c := 1; c <= cols; c++ {
+			fmt.Fprintf(&sb, "$%d, ", i*cols+c)
+		}
+		fmt.Fprintf(&sb, "'%s')", statusStored)
+		n := p.notif
+		args = append(args, n.Id, n.UserId, n.Message, p.createdAt, n.SourceEventId, n.Type, n.Severity, n.Source)
+	}
+	sb.WriteString(" ON CONFLICT (id) DO NOTHING")
+	return sb.String(), args
+}
+
+// Close stops the background flusher and writes out anything still buffered.
+// Saves after Close fall back to direct inserts so nothing is lost.
+func (st *notificationStore) Close() {
+	st.mu.Lock()
+	if st.closed {
+		st.mu.Unlock()
+		return
+	}
+	st.closed = true
+	st.mu.Unlock()
+
+	if st.batching() {
+		close(st.done)
+		st.wg.Wait()
+		st.mu.Lock()
+		if len(st.pending) > 0 {
+			log.Printf("%d notifications could not be flushed on shutdown%s", len(st.pending), requestIDsOf(st.pending))
+		}
+		st.mu.Unlock()
+	}
+}
+
+// MarkRead flags a user's notification as read. It reports false when no
+// such notification exists for the user.
+func (st *notificationStore) MarkRead(ctx context.Context, userID, notificationID string) (bool, error) {
+	res, err := st.db.ExecContext(ctx, "UPDATE notifications SET read = TRUE WHERE id = $1 AND user_id = $2", notificationID, userID)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// CountSince returns how many notifications were created at or after t.
+func (st *notificationStore) CountSince(ctx context.Context, t time.Time) (int64, error) {
+	var n int64
+	err := st.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM notifications WHERE created_at >= $1", t).Scan(&n)
+	return n, err
+}
+
+// Delivery channels and attempt results recorded in notification_deliveries.
+const (
+	channelWebSocket = "websocket"
+
+	deliveryDelivered    = "delivered"
+	deliveryFailed       = "failed"
+	deliveryDropped      = "dropped"
+	deliveryNoSubscriber = "no_subscriber"
+)
+
+// Delivery outcomes of a notification, kept in notifications.delivery_status.
+// A stored notification is retried by the redelivery loop until it is
+// delivered or runs out of attempts and is dropped.
+const (
+	statusDelivered = "delivered"
+	statusStored    = "stored"
+	statusDropped   = "dropped"
+)
+
+// SetDeliveryStatus updates the delivery outcome of a notification.
+func (st *notificationStore) SetDeliveryStatus(ctx context.Context, notificationID, status string) error {
+	_, err := st.db.ExecContext(ctx, "UPDATE notifications SET delivery_status = $1 WHERE id = $2", status, notificationID)
+	return err
+}
+
+// Undelivered returns up to limit of a user's stored notifications created
+// before cutoff, oldest first, and counts a delivery attempt for each.
+func (st *notificationStore) Undelivered(ctx context.Context, userID string, cutoff time.Time, limit int) ([]*notifpb.Notification, error) {
+	// A live send can succeed before a batched insert lands, leaving the row
+	// stored; the recorded attempt is authoritative.
+	_, err := st.db.ExecContext(ctx, `UPDATE notifications n SET delivery_status = $1
+		WHERE n.user_id = $2 AND n.delivery_status = $3
+		AND EXISTS (SELECT 1 FROM notification_deliveries d WHERE d.notification_id = n.id AND d.result = $4)`,
+		statusDelivered, userID, statusStored, deliveryDelivered)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := st.db.QueryContext(ctx, `WITH picked AS (
+			UPDATE notifications SET delivery_attempts = delivery_attempts + 1
+			WHERE id IN (
+				SELECT id FROM notifications
+				WHERE user_id = $1 AND delivery_status = $2 AND created_at < $3
+				ORDER BY created_at LIMIT $4
+			)
+			RETURNING id, user_id, message, created_at, source_event_id, type, severity, source
+		)
+		SELECT id, user_id, message, created_at, source_event_id, type, severity, source FROM picked ORDER BY created_at`, userID, statusStored, cutoff, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var notifs []*notifpb.Notification
+	for rows.Next() {
+		var n notifpb.Notification
+		var createdAt time.Time
+		if err := rows.Scan(&n.Id, &n.UserId, &n.Message, &createdAt, &n.SourceEventId, &n.Type, &n.Severity, &n.Source); err != nil {
+			return nil, err
+		}
+		n.Timestamp = timestamppb.New(createdAt).AsTime().String()
+		notifs = append(notifs, &n)
+	}
+	return notifs, rows.Err()
+}
+
+// DropExhausted marks stored notifications that have used up maxAttempts
+// redelivery attempts as dropped and returns how many there were.
+func (st *notificationStore) DropExhausted(ctx context.Context, maxAttempts int) (int64, error) {
+	res, err := st.db.ExecContext(ctx, "UPDATE notifications SET delivery_status = $1 WHERE delivery_status = $2 AND delivery_attempts >= $3",
+		statusDropped, statusStored, maxAttempts)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// RecordDelivery stores one delivery attempt for a notification.
+func (st *notificationStore) RecordDelivery(ctx context.Context, notificationID, channel, result string, deliveryErr error) error {
+	var errMsg string
+	if deliveryErr != nil {
+		errMsg = deliveryErr.Error()
+	}
+	_, err := st.db.ExecContext(ctx, `INSERT INTO notification_deliveries (notification_id, channel, result, error, attempted_at)
+		VALUES ($1, $2, $3, $4, $5)`, notificationID, channel, result, errMsg, st.clock.Now())
+	return err
+}
+
+// Deliveries returns the recorded delivery attempts for a notification, oldest first.
+func (st *notificationStore) Deliveries(ctx context.Context, notificationID string) ([]*notifpb.DeliveryAttempt, error) {
+	rows, err := st.db.QueryContext(ctx, `SELECT channel, result, error, attempted_at FROM notification_deliveries
+		WHERE notification_id = $1 ORDER BY attempted_at, id`, notificationID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var attempts []*notifpb.DeliveryAttempt
+	for rows.Next() {
+		var a notifpb.DeliveryAttempt
+		var at time.Time
+		if err := rows.Scan(&a.Channel, &a.Result, &a.Error, &at); err != nil {
+			return nil, err
+		}
+		a.Timestamp = at.UTC().Format(time.RFC3339Nano)
+		attempts = append(attempts, &a)
+	}
+	return attempts, rows.Err()
+}
+
+// errNotificationNotFound is returned when a looked-up notification does not exist.
+var errNotificationNotFound = errors.New("notification not found")
+
+// Get returns a user's notification by id, or the user's most recent one when
+// notificationID is empty.
+func (st *notificationStore) Get(ctx context.Context, userID, notificationID string) (*notifpb.Notification, error) {
+	query := "SELECT id, user_id, message, created_at, source_event_id, type, severity, source FROM notifications WHERE user_id = $1 AND id = $2"
+	args := []any{userID, notificationID}
+	if notificationID == "" {
+		query = "SELECT id, user_id, message, created_at, source_event_id, type, severity, source FROM notifications WHERE user_id = $1 ORDER BY created_at DESC LIMIT 1"
+		args = args[:1]
+	}
+
+	var n notifpb.Notification
+	var createdAt time.Time
+	err := st.db.QueryRowContext(ctx, query, args...).Scan(&n.Id, &n.UserId, &n.Message, &createdAt, &n.SourceEventId, &n.Type, &n.Severity, &n.Source)
+	if err == sql.ErrNoRows {
+		return nil, errNotificationNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	n.Timestamp = timestamppb.New(createdAt).AsTime().String()
+	return &n, nil
+}
+
+// History returns one page of a user's notifications, newest first, and the
+// total number stored for the user.
+func (st *notificationStore) History(ctx context.Context, userID string, limit, offset int) ([]*notifpb.Notification, int64, error) {
+	var total int64
+	if err := st.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM notifications WHERE user_id = $1", userID).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := st.db.QueryContext(ctx, `SELECT id, user_id, message, created_at, source_event_id, type, severity, source, read
+		FROM notifications WHERE user_id = $1 ORDER BY created_at DESC, id LIMIT $2 OFFSET $3`, userID, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	notifs := []*notifpb.Notification{}
+	for rows.Next() {
+		var n notifpb.Notification
+		var createdAt time.Time
+		if err := rows.Scan(&n.Id, &n.UserId, &n.Message, &createdAt, &n.SourceEventId, &n.Type, &n.Severity, &n.Source, &n.Read); err != nil {
+			return nil, 0, err
+		}
+		n.Timestamp = timestamppb.New(createdAt).AsTime().String()
+		notifs = append(notifs, &n)
+	}
+	return notifs, total, rows.Err()
+}
+
+// SourceEventID returns the id of the event a notification was created from,
+// or "" when it has none.
+func (st *notificationStore) SourceEventID(ctx context.Context, notificationID string) (string, error) {
+	var id string
+	err := st.db.QueryRowContext(ctx, "SELECT source_event_id FROM notifications WHERE id = $1", notificationID).Scan(&id)
+	if err == sql.ErrNoRows {
+		return "", errNotificationNotFound
+	}
+	return id, err
+}
+
+// MarkResent counts a resend of an existing notification.
+func (st *notificationStore) MarkResent(ctx context.Context, notificationID string) error {
+	_, err := st.db.ExecContext(ctx, "UPDATE notifications SET resend_count = resend_count + 1 WHERE id = $1", notificationID)
+	return err
+}
+
+// SetLocale records the locale a user's notifications are rendered in.
+func (st *notificationStore) SetLocale(ctx context.Context, userID, locale string) error {
+	_, err := st.db.ExecContext(ctx, `INSERT INTO user_locales (user_id, locale) VALUES ($1, $2)
+		ON CONFLICT (user_id) DO UPDATE SET locale = EXCLUDED.locale`, userID, locale)
+	return err
+}
+
+// Locale returns a user's locale, or "" when none is known.
+func (st *notificationStore) Locale(ctx context.Context, userID string) (string, error) {
+	var locale string
+	err := st.db.QueryRowContext(ctx, "SELECT locale FROM user_locales WHERE user_id = $1", userID).Scan(&locale)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return locale, err
+}
+
+// storedTemplate is an admin-edited message template.
+type storedTemplate struct {
+	MessageID string
+	Locale    string
+	Body      string
+	UpdatedAt time.Time
+}
+
+// errTemplateNotFound is returned when deleting a template that is not stored.
+var errTemplateNotFound = errors.New("template not found")
+
+// Templates returns every stored template ordered by message id and locale.
+func (st *notificationStore) Templates(ctx context.Context) ([]storedTemplate, error) {
+	rows, err := st.db.QueryContext(ctx, `SELECT message_id, locale, body, updated_at FROM notification_templates ORDER BY message_id, locale`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var templates []storedTemplate
+	for rows.Next() {
+		var t storedTemplate
+		if err := rows.Scan(&t.MessageID, &t.Locale, &t.Body, &t.UpdatedAt); err != nil {
+			return nil, err
+		}
+		templates = append(templates, t)
+	}
+	return templates, rows.Err()
+}
+
+// PutTemplate creates or replaces the template for t's message id and locale
+// and returns it with its new updated_at.
+func (st *notificationStore) PutTemplate(ctx context.Context, t storedTemplate) (storedTemplate, error) {
+	t.UpdatedAt = st.clock.Now()
+	_, err := st.db.ExecContext(ctx, `INSERT INTO notification_templates (message_id, locale, body, updated_at) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (message_id, locale) DO UPDATE SET body = EXCLUDED.body, updated_at = EXCLUDED.updated_at`,
+		t.MessageID, t.Locale, t.Body, t.UpdatedAt)
+	return t, err
+}
+
+// DeleteTemplate removes the template for messageID and locale.
+func (st *notificationStore) DeleteTemplate(ctx context.Context, messageID, locale string) error {
+	res, err := st.db.ExecContext(ctx, `DELETE FROM notification_templates WHERE message_id = $1 AND locale = $2`, messageID, locale)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return errTemplateNotFound
+	}
+	return nil
+}
+
+// HasSourceEvent reports whether a notification created from sourceEventID is
+// already stored for userID.
+func (st *notificationStore) HasSourceEvent(ctx context.Context, userID, sourceEventID string) (bool, error) {
+	var exists bool
+	err := st.db.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM notifications WHERE user_id = $1 AND source_event_id = $2)", userID, sourceEventID).Scan(&exists)
+	return exists, err
+}
+
+// SaveWelcome stores notif as userID's welcome notification, bypassing the
+// batch buffer. The claim and the notification are written in one
+// transaction, so a claim never exists without its notification. It returns
+// false, storing nothing, if the user already has one, e.g. because
+// user.created was redelivered, in which case no new welcome should be sent.
+func (st *notificationStore) SaveWelcome(ctx context.Context, notif *notifpb.Notification) (bool, error) {
+	tx, err := st.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, "INSERT INTO welcome_notifications (user_id, notification_id) VALUES ($1, $2) ON CONFLICT (user_id) DO NOTHING", notif.UserId, notif.Id)
+	if err != nil {
+		return false, err
+	}
+	if n, err := res.RowsAffected(); err != nil || n == 0 {
+		return false, err
+	}
+	query, args := insertStatement([]pendingNotification{{notif: notif, createdAt: st.clock.Now()}})
+	if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
+}
+
+// errBackfillNotFound is returned for an unknown backfill job id.
+var errBackfillNotFound = errors.New("backfill job not found")
+
+const backfillColumns = "id, message_id, params, type, severity, status, processed, cursor, error, created_at, updated_at"
+
+// CreateBackfill stores a new backfill job.
+func (st *notificationStore) CreateBackfill(ctx context.Context, job *notifpb.BackfillJob) error {
+	params, err := json.Marshal(job.Params)
+	if err != nil {
+		return err
+	}
+	now := st.clock.Now()
+	_, err = st.db.ExecContext(ctx, `INSERT INTO backfill_jobs (id, message_id, params, type, severity, status, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $7)`, job.Id, job.MessageId, params, job.Type, job.Severity, job.Status, now)
+	if err != nil {
+		return err
+	}
+	job.CreatedAt = now.UTC().Format(time.RFC3339)
+	job.UpdatedAt = job.CreatedAt
+	return nil
+}
+
+// UpdateBackfill saves a job's status and progress.
+func (st *notificationStore) UpdateBackfill(ctx context.Context, job *notifpb.BackfillJob) error {
+	now := st.clock.Now()
+	_, err := st.db.ExecContext(ctx, "UPDATE backfill_jobs SET status = $2, processed = $3, cursor = $4, error = $5, updated_at = $6 WHERE id = $1",
+		job.Id, job.Status, job.Processed, job.Cursor, job.Error, now)
+	if err != nil {
+		return err
+	}
+	job.UpdatedAt = now.UTC().Format(time.RFC3339)
+	return nil
+}
+
+// Backfill returns the job with id, or errBackfillNotFound.
+func (st *notificationStore) Backfill(ctx context.Context, id string) (*notifpb.BackfillJob, error) {
+	jobs, err := st.backfills(ctx, "SELECT "+backfillColumns+" FROM backfill_jobs WHERE id = $1", id)
+	if err != nil {
+		return nil, err
+	}
+	if len(jobs) == 0 {
+		return nil, errBackfillNotFound
+	}
+	return jobs[0], nil
+}
+
+// BackfillsWithStatus returns the jobs with the given status, oldest first.
+func (st *notificationStore) BackfillsWithStatus(ctx context.Context, status string) ([]*notifpb.BackfillJob, error) {
+	return st.backfills(ctx, "SELECT "+backfillColumns+" FROM backfill_jobs WHERE status = $1 ORDER BY created_at", status)
+}
+
+func (st *notificationStore) backfills(ctx context.Context, query string, args ...any) ([]*notifpb.BackfillJob, error) {
+	rows, err := st.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var jobs []*notifpb.BackfillJob
+	for rows.Next() {
+		var job notifpb.BackfillJob
+		var params []byte
+		var createdAt, updatedAt time.Time
+		if err := rows.Scan(&job.Id, &job.MessageId, &params, &job.Type, &job.Severity, &job.Status, &job.Processed, &job.Cursor, &job.Error, &createdAt, &updatedAt); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(params, &job.Params); err != nil {
+			return nil, fmt.Errorf("backfill %s params: %w", job.Id, err)
+		}
+		job.CreatedAt = createdAt.UTC().Format(time.RFC3339)
+		job.UpdatedAt = updatedAt.UTC().Format(time.RFC3339)
+		jobs = append(jobs, &job)
+	}
+	return jobs, rows.Err()
+}
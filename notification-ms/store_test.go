@@ -0,0 +1,199 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+
+	"notification-ms/notifpb"
+)
+
+const batchInterval = 100 * time.Millisecond
+
+// newBatchingStore returns a store that buffers up to batchSize
+// notifications, flushed when the fake clock passes batchInterval. It is
+// closed when the test ends.
+func newBatchingStore(t *testing.T, batchSize int) (*notificationStore, sqlmock.Sqlmock, *fakeClock) {
+	t.Helper()
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	clock := newFakeClock()
+	st := newNotificationStore(db, clock, batchSize, batchInterval)
+	t.Cleanup(func() {
+		st.Close()
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+		db.Close()
+	})
+	// The flusher is waiting for its first interval.
+	clock.waitForWaiters(t, 1)
+	return st, mock, clock
+}
+
+// expectInsert expects one multi-row INSERT of the notifications with the
+// given ids, in order.
+func expectInsert(mock sqlmock.Sqlmock, ids ...string) *sqlmock.ExpectedExec {
+	var args []driver.Value
+	for _, id := range ids {
+		args = append(args, id, "u1", "msg "+id)
+		for range 5 {
+			args = append(args, sqlmock.AnyArg())
+		}
+	}
+	return mock.ExpectExec(literal("INSERT INTO notifications")).WithArgs(args...)
+}
+
+func saveAll(t *testing.T, st *notificationStore, ids ...string) {
+	t.Helper()
+	for _, id := range ids {
+		if err := st.Save(context.Background(), &notifpb.Notification{Id: id, UserId: "u1", Message: "msg " + id}); err != nil {
+			t.Fatalf("Save(%s): %v", id, err)
+		}
+	}
+}
+
+// waitForExpectations waits up to a second for the background flusher to
+// meet mock's expectations.
+func waitForExpectations(t *testing.T, mock sqlmock.Sqlmock) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for {
+		err := mock.ExpectationsWereMet()
+		if err == nil {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatal(err)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func pendingCount(st *notificationStore) int {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	return len(st.pending)
+}
+
+func TestStoreFlushesFullBatch(t *testing.T) {
+	st, mock, _ := newBatchingStore(t, 3)
+
+	saveAll(t, st, "n1", "n2")
+	time.Sleep(20 * time.Millisecond)
+	if n := pendingCount(st); n != 2 {
+		t.Fatalf("%d notifications buffered before the batch is full, want 2", n)
+	}
+
+	expectInsert(mock, "n1", "n2", "n3").WillReturnResult(sqlmock.NewResult(0, 3))
+	saveAll(t, st, "n3")
+	waitForExpectations(t, mock)
+}
+
+func TestStoreFlushesOnInterval(t *testing.T) {
+	st, mock, clock := newBatchingStore(t, 10)
+
+	saveAll(t, st, "n1", "n2")
+	clock.Advance(batchInterval / 2)
+	time.Sleep(20 * time.Millisecond)
+	if n := pendingCount(st); n != 2 {
+		t.Fatalf("%d notifications buffered before the interval, want 2", n)
+	}
+
+	expectInsert(mock, "n1", "n2").WillReturnResult(sqlmock.NewResult(0, 2))
+	clock.Advance(batchInterval / 2)
+	waitForExpectations(t, mock)
+}
+
+func TestStoreRequeuesFailedBatch(t *testing.T) {
+	st, mock, clock := newBatchingStore(t, 10)
+
+	saveAll(t, st, "n1", "n2")
+	expectInsert(mock, "n1", "n2").WillReturnError(errors.New("connection reset"))
+	clock.Advance(batchInterval)
+	waitForExpectations(t, mock)
+
+	// The failed batch goes back in front of what was saved since.
+	clock.waitForWaiters(t, 1)
+	saveAll(t, st, "n3")
+	expectInsert(mock, "n1", "n2", "n3").WillReturnResult(sqlmock.NewResult(0, 3))
+	clock.Advance(batchInterval)
+	waitForExpectations(t, mock)
+	if n := pendingCount(st); n != 0 {
+		t.Errorf("%d notifications still buffered after a successful flush", n)
+	}
+}
+
+func TestStoreCloseFlushesBuffer(t *testing.T) {
+	st, mock, _ := newBatchingStore(t, 10)
+
+	saveAll(t, st, "n1", "n2")
+	expectInsert(mock, "n1", "n2").WillReturnResult(sqlmock.NewResult(0, 2))
+	st.Close()
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("buffer not flushed by Close: %v", err)
+	}
+
+	// Saves after Close are written straight away.
+	expectInsert(mock, "n3").WillReturnResult(sqlmock.NewResult(0, 1))
+	saveAll(t, st, "n3")
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("save after Close not written: %v", err)
+	}
+}
+
+// BenchmarkSave compares writing every notification with its own INSERT to
+// batching them, against a database that takes 200µs per statement.
+func BenchmarkSave(b *testing.B) {
+	for _, batchSize := range []int{0, 50} {
+		b.Run(fmt.Sprintf("batch=%d", batchSize), func(b *testing.B) {
+			var statements atomic.Int64
+			db := sql.OpenDB(slowConnector{statements: &statements, latency: 200 * time.Microsecond})
+			defer db.Close()
+			st := newNotificationStore(db, realClock{}, batchSize, 10*time.Millisecond)
+
+			b.ResetTimer()
+			for i := range b.N {
+				notif := &notifpb.Notification{Id: fmt.Sprint(i), UserId: "u1", Message: "hello"}
+				if err := st.Save(context.Background(), notif); err != nil {
+					b.Fatal(err)
+				}
+			}
+			st.Close()
+			b.ReportMetric(float64(statements.Load())/float64(b.N), "stmts/op")
+		})
+	}
+}
+
+// slowConnector opens connections whose statements each take latency, like
+// a round trip to a remote database, and counts them.
+type slowConnector struct {
+	statements *atomic.Int64
+	latency    time.Duration
+}
+
+func (c slowConnector) Connect(context.Context) (driver.Conn, error) { return slowConn(c), nil }
+func (c slowConnector) Driver() driver.Driver                        { return nil }
+
+type slowConn slowConnector
+
+func (c slowConn) ExecContext(context.Context, string, []driver.NamedValue) (driver.Result, error) {
+	c.statements.Add(1)
+	time.Sleep(c.latency)
+	return driver.RowsAffected(1), nil
+}
+
+func (c slowConn) Prepare(string) (driver.Stmt, error) {
+	return nil, errors.New("not supported")
+}
+func (c slowConn) Close() error              { return nil }
+func (c slowConn) Begin() (driver.Tx, error) { return nil, errors.New("not supported") }
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+// eventStreamSubjects are persisted in a JetStream stream, so events published
+// while a consumer is down are replayed to it once it is back.
+var eventStreamSubjects = []string{"user.created", "bill.update"}
+
+// defaultEventStreamMaxAge is how long events are kept when
+// NATS_STREAM_MAX_AGE is not set.
+const defaultEventStreamMaxAge = 72 * time.Hour
+
+// isStreamSubject reports whether subject is stored in the events stream.
+func isStreamSubject(subject string) bool {
+	return slices.Contains(eventStreamSubjects, subject)
+}
+
+// ensureEventStream returns a JetStream context for nc, first creating the
+// events stream (NATS_STREAM, default EVENTS) if it does not exist yet. Every
+// service calls it on startup, so whichever starts first creates the stream.
+// NATS_STREAM_MAX_AGE bounds how long events are retained.
+func ensureEventStream(nc *nats.Conn) (nats.JetStreamContext, error) {
+	js, err := nc.JetStream()
+	if err != nil {
+		return nil, fmt.Errorf("jetstream: %w", err)
+	}
+	name := os.Getenv("NATS_STREAM")
+	if name == "" {
+		name = "EVENTS"
+	}
+	maxAge := defaultEventStreamMaxAge
+	if v := os.Getenv("NATS_STREAM_MAX_AGE"); v != "" {
+		if maxAge, err = time.ParseDuration(v); err != nil {
+			return nil, fmt.Errorf("invalid NATS_STREAM_MAX_AGE %q: %w", v, err)
+		}
+	}
+
+	info, err := js.StreamInfo(name)
+	if err == nil {
+		log.Printf("using JetStream stream %s (%d messages)", name, info.State.Msgs)
+		return js, nil
+	}
+	if !errors.Is(err, nats.ErrStreamNotFound) {
+		return nil, fmt.Errorf("look up stream %s: %w", name, err)
+	}
+	_, err = js.AddStream(&nats.StreamConfig{
+		Name:     name,
+		Subjects: eventStreamSubjects,
+		Storage:  nats.FileStorage,
+		MaxAge:   maxAge,
+	})
+	// Another service may have created it in the meantime.
+	if err != nil && !errors.Is(err, nats.ErrStreamNameAlreadyInUse) {
+		return nil, fmt.Errorf("create stream %s: %w", name, err)
+	}
+	log.Printf("created JetStream stream %s for %v", name, eventStreamSubjects)
+	return js, nil
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"notification-ms/notifpb"
+)
+
+const (
+	defaultListStreamsLimit = 50
+	maxListStreamsLimit     = 500
+)
+
+// ListStreams returns a page of the streams open on this instance, ordered by
+// user and session so pages stay stable while streams come and go.
+func (s *notificationServer) ListStreams(ctx context.Context, req *notifpb.ListStreamsRequest) (*notifpb.ListStreamsResponse, error) {
+	if req.Limit < 0 || req.Offset < 0 {
+		return nil, status.Error(codes.InvalidArgument, "limit and offset must not be negative")
+	}
+	limit := int(req.Limit)
+	if limit == 0 {
+		limit = defaultListStreamsLimit
+	}
+	limit = min(limit, maxListStreamsLimit)
+
+	s.mu.RLock()
+	var subs []*subscriber
+	for _, userSubs := range s.subscribers {
+		subs = append(subs, userSubs...)
+	}
+	s.mu.RUnlock()
+	sort.Slice(subs, func(i, j int) bool {
+		if subs[i].userId != subs[j].userId {
+			return subs[i].userId < subs[j].userId
+		}
+		return subs[i].sessionID < subs[j].sessionID
+	})
+
+	res := &notifpb.ListStreamsResponse{Total: int64(len(subs))}
+	start := min(int(req.Offset), len(subs))
+	for _, sub := range subs[start:min(start+limit, len(subs))] {
+		res.Streams = append(res.Streams, &notifpb.StreamInfo{
+			UserId:       sub.userId,
+			SessionId:    sub.sessionID,
+			ConnectedAt:  sub.connectedAt.UTC().Format(time.RFC3339),
+			LastActiveAt: sub.lastActive().UTC().Format(time.RFC3339),
+			Buffered:     int32(len(sub.ch)),
+			BufferSize:   int32(cap(sub.ch)),
+			Dropped:      sub.dropped.Load(),
+		})
+	}
+	return res, nil
+}
+
+// TerminateStream closes every stream of a session, optionally only those of
+// one user. The streams are removed by their send loops as they end.
+func (s *notificationServer) TerminateStream(ctx context.Context, req *notifpb.TerminateStreamRequest) (*notifpb.TerminateStreamResponse, error) {
+	if req.SessionId == "" || req.SessionId == "-" {
+		return nil, status.Error(codes.InvalidArgument, "session_id is required")
+	}
+
+	s.mu.RLock()
+	var matched []*subscriber
+	for userID, subs := range s.subscribers {
+		if req.UserId != "" && userID != req.UserId {
+			continue
+		}
+		for _, sub := range subs {
+			if sub.sessionID == req.SessionId {
+				matched = append(matched, sub)
+			}
+		}
+	}
+	s.mu.RUnlock()
+	if len(matched) == 0 {
+		return nil, status.Error(codes.NotFound, "no open stream for session")
+	}
+
+	for _, sub := range matched {
+		logf(ctx, "Terminating stream for user %s (session %s) on admin request", sub.userId, sub.sessionID)
+		sub.close(status.Error(codes.Aborted, "stream terminated by an administrator"))
+	}
+	return &notifpb.TerminateStreamResponse{Terminated: int32(len(matched))}, nil
+}
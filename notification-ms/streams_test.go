@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"notification-ms/notifpb"
+)
+
+func TestListStreamsReflectsOpenStreams(t *testing.T) {
+	s, mock := newTestServer(t)
+	expectRedelivery(mock, 2)
+
+	_, cancel1, _ := subscribeSession(s, "tab-1")
+	defer cancel1()
+	_, cancel2, _ := subscribeSession(s, "tab-2")
+	defer cancel2()
+	waitForStreams(t, s, 2)
+	busy := addSubscriber(s, "u0", "tab-9")
+	busy.ch <- []*notifpb.Notification{{Id: "n1"}}
+	busy.dropped.Add(2)
+
+	res, err := s.ListStreams(context.Background(), &notifpb.ListStreamsRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Total != 3 || len(res.Streams) != 3 {
+		t.Fatalf("listed %d of %d streams, want 3", len(res.Streams), res.Total)
+	}
+	var got []string
+	for _, st := range res.Streams {
+		got = append(got, st.UserId+"/"+st.SessionId)
+		if _, err := time.Parse(time.RFC3339, st.ConnectedAt); err != nil {
+			t.Errorf("%s/%s connected_at %q: %v", st.UserId, st.SessionId, st.ConnectedAt, err)
+		}
+	}
+	if want := "[u0/tab-9 u1/tab-1 u1/tab-2]"; fmt.Sprint(got) != want {
+		t.Errorf("streams %v, want %s ordered by user and session", got, want)
+	}
+	if first := res.Streams[0]; first.Buffered != 1 || first.BufferSize != subscriberBufferSize || first.Dropped != 2 {
+		t.Errorf("buffer stats = %+v", first)
+	}
+
+	page, err := s.ListStreams(context.Background(), &notifpb.ListStreamsRequest{Limit: 2, Offset: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if page.Total != 3 || len(page.Streams) != 1 || page.Streams[0].SessionId != "tab-2" {
+		t.Errorf("second page = %v", page)
+	}
+	_, err = s.ListStreams(context.Background(), &notifpb.ListStreamsRequest{Offset: -1})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("negative offset: %v, want InvalidArgument", err)
+	}
+	waitForExpectations(t, mock)
+}
+
+func TestTerminateStreamClosesOneSession(t *testing.T) {
+	s, mock := newTestServer(t)
+	expectRedelivery(mock, 2)
+
+	_, cancel1, done1 := subscribeSession(s, "tab-1")
+	defer cancel1()
+	_, cancel2, done2 := subscribeSession(s, "tab-2")
+	defer cancel2()
+	waitForStreams(t, s, 2)
+
+	res, err := s.TerminateStream(context.Background(), &notifpb.TerminateStreamRequest{SessionId: "tab-1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Terminated != 1 {
+		t.Errorf("terminated %d streams, want 1", res.Terminated)
+	}
+	select {
+	case err := <-done1:
+		if status.Code(err) != codes.Aborted {
+			t.Errorf("terminated stream ended with %v, want Aborted", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("terminated stream still open")
+	}
+	waitForStreams(t, s, 1)
+	select {
+	case err := <-done2:
+		t.Fatalf("other session's stream ended: %v", err)
+	default:
+	}
+	if list, _ := s.ListStreams(context.Background(), &notifpb.ListStreamsRequest{}); list.Total != 1 || list.Streams[0].SessionId != "tab-2" {
+		t.Errorf("after terminate: %v", list)
+	}
+	waitForExpectations(t, mock)
+}
+
+func TestTerminateStreamErrors(t *testing.T) {
+	s, _ := newTestServer(t)
+	addSubscriber(s, "u1", "tab-1")
+
+	for name, tc := range map[string]struct {
+		req  *notifpb.TerminateStreamRequest
+		want codes.Code
+	}{
+		"no session":     {&notifpb.TerminateStreamRequest{}, codes.InvalidArgument},
+		"unknown":        {&notifpb.TerminateStreamRequest{SessionId: "tab-2"}, codes.NotFound},
+		"other user":     {&notifpb.TerminateStreamRequest{SessionId: "tab-1", UserId: "u2"}, codes.NotFound},
+		"sessionless id": {&notifpb.TerminateStreamRequest{SessionId: "-"}, codes.InvalidArgument},
+	} {
+		if _, err := s.TerminateStream(context.Background(), tc.req); status.Code(err) != tc.want {
+			t.Errorf("%s: %v, want %v", name, err, tc.want)
+		}
+	}
+}
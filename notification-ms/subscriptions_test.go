@@ -0,0 +1,216 @@
+package main
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+func TestPauseStopsDeliveryUntilResume(t *testing.T) {
+	nc, js := runJetStream(t)
+	subs := newSubscriptions(nc, js, "notification-ms")
+	var changes []bool
+	subs.OnPauseChange(func(paused bool) { changes = append(changes, paused) })
+	handled := make(chan string, 10)
+	if err := subs.Subscribe("user.created", func(m *nats.Msg) { handled <- string(m.Data) }); err != nil {
+		t.Fatal(err)
+	}
+	publish := func(data string) {
+		t.Helper()
+		if _, err := js.Publish("user.created", []byte(data)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	next := func() string {
+		t.Helper()
+		select {
+		case data := <-handled:
+			return data
+		case <-time.After(5 * time.Second):
+			t.Fatal("nothing handled")
+			return ""
+		}
+	}
+
+	publish("1")
+	if got := next(); got != "1" {
+		t.Fatalf("handled %q, want 1", got)
+	}
+
+	subs.Pause()
+	publish("2")
+	publish("3")
+	select {
+	case data := <-handled:
+		t.Fatalf("handled %q while paused", data)
+	case <-time.After(200 * time.Millisecond):
+	}
+	if paused, pending := subs.Paused(); !paused || pending != 2 {
+		t.Errorf("Paused() = %v, %d, want true, 2", paused, pending)
+	}
+
+	if err := subs.Resume(); err != nil {
+		t.Fatal(err)
+	}
+	if got := next() + next(); got != "23" {
+		t.Errorf("handled %q after resume, want 2 then 3", got)
+	}
+	// Everything handled is acked, so nothing is left waiting.
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		paused, pending := subs.Paused()
+		if !paused && pending == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Paused() = %v, %d after resume, want false, 0", paused, pending)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if len(changes) != 2 || !changes[0] || changes[1] {
+		t.Errorf("pause changes = %v, want [true false]", changes)
+	}
+}
+
+// pauseMidMessage subscribes a handler to user.created that blocks on its
+// first message, and pauses while that message is being handled, so the
+// drained subscription is still bound to the consumer.
+func pauseMidMessage(t *testing.T) (subs *subscriptions, release func(), handled <-chan string, publish func(string)) {
+	t.Helper()
+	nc, js := runJetStream(t)
+	subs = newSubscriptions(nc, js, "notification-ms")
+	got := make(chan string, 10)
+	started := make(chan struct{})
+	unblock := make(chan struct{})
+	var once sync.Once
+	if err := subs.Subscribe("user.created", func(m *nats.Msg) {
+		once.Do(func() {
+			close(started)
+			<-unblock
+		})
+		got <- string(m.Data)
+	}); err != nil {
+		t.Fatal(err)
+	}
+	publish = func(data string) {
+		t.Helper()
+		if _, err := js.Publish("user.created", []byte(data)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	publish("1")
+	select {
+	case <-started:
+	case <-time.After(5 * time.Second):
+		t.Fatal("first message not delivered")
+	}
+	subs.Pause()
+	var released sync.Once
+	release = func() { released.Do(func() { close(unblock) }) }
+	t.Cleanup(release)
+	return subs, release, got, publish
+}
+
+func TestResumeRightAfterPause(t *testing.T) {
+	subs, release, handled, publish := pauseMidMessage(t)
+	publish("2")
+
+	// The drained subscription finishes while Resume is waiting for it.
+	time.AfterFunc(100*time.Millisecond, release)
+	if err := subs.Resume(); err != nil {
+		t.Fatalf("Resume() = %v", err)
+	}
+	if paused, _ := subs.Paused(); paused {
+		t.Fatal("still paused after Resume")
+	}
+	for _, want := range []string{"1", "2"} {
+		select {
+		case got := <-handled:
+			if got != want {
+				t.Fatalf("handled %q, want %q", got, want)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("%s not handled after resume", want)
+		}
+	}
+}
+
+func TestResumeStaysPausedWhileDraining(t *testing.T) {
+	prev := drainWait
+	drainWait = 50 * time.Millisecond
+	t.Cleanup(func() { drainWait = prev })
+	subs, release, handled, _ := pauseMidMessage(t)
+
+	if err := subs.Resume(); err == nil {
+		t.Fatal("Resume() succeeded while the consumer was still bound")
+	}
+	if paused, _ := subs.Paused(); !paused {
+		t.Fatal("Paused() = false after a failed Resume")
+	}
+
+	// Once the drain finishes, Resume can be retried.
+	release()
+	<-handled
+	drainWait = prev
+	if err := subs.Resume(); err != nil {
+		t.Fatalf("retried Resume() = %v", err)
+	}
+	if paused, _ := subs.Paused(); paused {
+		t.Fatal("still paused after retried Resume")
+	}
+}
+
+func TestReconnectRestoresSubscriptions(t *testing.T) {
+	nc, js := runJetStream(t)
+	subs := newSubscriptions(nc, js, "notification-ms")
+	handled := make(chan string, 10)
+	for _, subject := range []string{"user.created", "audit.test"} {
+		if err := subs.Subscribe(subject, func(m *nats.Msg) { handled <- m.Subject }); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Simulate subscriptions the reconnect left invalid.
+	subs.mu.Lock()
+	for _, entry := range subs.subs {
+		if err := entry.sub.Unsubscribe(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	subs.mu.Unlock()
+	if err := nc.ForceReconnect(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Publish until both arrive: whatever is sent before the reconnect
+	// completes may not reach a subscriber.
+	seen := map[string]bool{}
+	deadline := time.After(5 * time.Second)
+	tick := time.NewTicker(20 * time.Millisecond)
+	defer tick.Stop()
+	for !seen["user.created"] || !seen["audit.test"] {
+		select {
+		case subject := <-handled:
+			seen[subject] = true
+		case <-tick.C:
+			for _, subject := range []string{"user.created", "audit.test"} {
+				if err := nc.Publish(subject, []byte("{}")); err != nil {
+					t.Fatal(err)
+				}
+			}
+		case <-deadline:
+			t.Fatalf("handled %v after reconnect, want both subjects", seen)
+		}
+	}
+
+	subs.mu.Lock()
+	defer subs.mu.Unlock()
+	for subject, entry := range subs.subs {
+		if entry.sub == nil || !entry.sub.IsValid() {
+			t.Errorf("%s not subscribed after reconnect", subject)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"notification-ms/notifpb"
+)
+
+// reloadTemplates loads the stored templates into the catalog. It runs at
+// startup and after every template change so rendering picks up new wording
+// immediately.
+func (s *notificationServer) reloadTemplates(ctx context.Context) error {
+	templates, err := s.store.Templates(ctx)
+	if err != nil {
+		return err
+	}
+	s.catalog.SetOverrides(ctx, templates)
+	return nil
+}
+
+// ListTemplates returns the stored templates.
+func (s *notificationServer) ListTemplates(ctx context.Context, req *notifpb.ListTemplatesRequest) (*notifpb.ListTemplatesResponse, error) {
+	templates, err := s.store.Templates(ctx)
+	if err != nil {
+		logf(ctx, "failed to list templates: %v", err)
+		return nil, status.Error(codes.Internal, "could not load templates")
+	}
+	res := &notifpb.ListTemplatesResponse{Templates: make([]*notifpb.Template, 0, len(templates))}
+	for _, t := range templates {
+		res.Templates = append(res.Templates, templateToProto(t))
+	}
+	return res, nil
+}
+
+// PutTemplate stores a template after checking that it parses, then refreshes
+// the catalog.
+func (s *notificationServer) PutTemplate(ctx context.Context, req *notifpb.PutTemplateRequest) (*notifpb.PutTemplateResponse, error) {
+	locale := normalizeLocale(req.Locale)
+	if req.MessageId == "" || locale == "" {
+		return nil, status.Error(codes.InvalidArgument, "message_id and locale are required")
+	}
+	if _, err := parseMessage(req.MessageId, req.Body); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid template: %v", err)
+	}
+
+	t, err := s.store.PutTemplate(ctx, storedTemplate{MessageID: req.MessageId, Locale: locale, Body: req.Body})
+	if err != nil {
+		logf(ctx, "failed to store template %s/%s: %v", req.MessageId, locale, err)
+		return nil, status.Error(codes.Internal, "could not store template")
+	}
+	if err := s.reloadTemplates(ctx); err != nil {
+		logf(ctx, "failed to reload templates: %v", err)
+		return nil, status.Error(codes.Internal, "template stored but could not be reloaded")
+	}
+	logf(ctx, "Updated template %s/%s", t.MessageID, t.Locale)
+	return &notifpb.PutTemplateResponse{Template: templateToProto(t)}, nil
+}
+
+// DeleteTemplate removes a stored template so the embedded default applies
+// again.
+func (s *notificationServer) DeleteTemplate(ctx context.Context, req *notifpb.DeleteTemplateRequest) (*notifpb.DeleteTemplateResponse, error) {
+	locale := normalizeLocale(req.Locale)
+	err := s.store.DeleteTemplate(ctx, req.MessageId, locale)
+	if err == errTemplateNotFound {
+		return nil, status.Error(codes.NotFound, "template not found")
+	}
+	if err != nil {
+		logf(ctx, "failed to delete template %s/%s: %v", req.MessageId, locale, err)
+		return nil, status.Error(codes.Internal, "could not delete template")
+	}
+	if err := s.reloadTemplates(ctx); err != nil {
+		logf(ctx, "failed to reload templates: %v", err)
+		return nil, status.Error(codes.Internal, "template deleted but could not be reloaded")
+	}
+	logf(ctx, "Deleted template %s/%s", req.MessageId, locale)
+	return &notifpb.DeleteTemplateResponse{}, nil
+}
+
+func templateToProto(t storedTemplate) *notifpb.Template {
+	return &notifpb.Template{
+		MessageId: t.MessageID,
+		Locale:    t.Locale,
+		Body:      t.Body,
+		UpdatedAt: t.UpdatedAt.UTC().Format(time.RFC3339),
+	}
+}
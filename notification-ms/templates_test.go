@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"notification-ms/notifpb"
+)
+
+var templateColumns = []string{"message_id", "locale", "body", "updated_at"}
+
+func TestPutTemplateOverridesCatalog(t *testing.T) {
+	s, mock := newTestServer(t)
+	body := "Neue Rechnung: {{.amount}}"
+	mock.ExpectExec(literal("INSERT INTO notification_templates")).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(literal("FROM notification_templates")).
+		WillReturnRows(sqlmock.NewRows(templateColumns).AddRow(msgBillUpdated, "de", body, time.Now()))
+
+	res, err := s.PutTemplate(context.Background(), &notifpb.PutTemplateRequest{MessageId: msgBillUpdated, Locale: "DE", Body: body})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Template.Locale != "de" {
+		t.Errorf("stored locale %q, want it normalized to de", res.Template.Locale)
+	}
+	if got, err := s.catalog.Render("de", msgBillUpdated, map[string]string{"amount": "7.00"}); err != nil || got != "Neue Rechnung: 7.00" {
+		t.Errorf("rendered %q, %v; want the new template", got, err)
+	}
+}
+
+func TestPutTemplateRejectsInvalidTemplates(t *testing.T) {
+	s, _ := newTestServer(t)
+	for _, req := range []*notifpb.PutTemplateRequest{
+		{MessageId: msgBillUpdated, Locale: "de", Body: "{{.amount"},
+		{MessageId: "", Locale: "de", Body: "x"},
+		{MessageId: msgBillUpdated, Locale: "", Body: "x"},
+	} {
+		if _, err := s.PutTemplate(context.Background(), req); status.Code(err) != codes.InvalidArgument {
+			t.Errorf("PutTemplate(%+v) = %v, want InvalidArgument", req, err)
+		}
+	}
+}
+
+func TestDeleteTemplate(t *testing.T) {
+	s, mock := newTestServer(t)
+	mock.ExpectExec(literal("DELETE FROM notification_templates")).WithArgs(msgBillUpdated, "de").WillReturnResult(sqlmock.NewResult(0, 0))
+	if _, err := s.DeleteTemplate(context.Background(), &notifpb.DeleteTemplateRequest{MessageId: msgBillUpdated, Locale: "de"}); status.Code(err) != codes.NotFound {
+		t.Errorf("deleting a missing template: %v, want NotFound", err)
+	}
+
+	mock.ExpectExec(literal("DELETE FROM notification_templates")).WithArgs(msgBillUpdated, "de").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(literal("FROM notification_templates")).WillReturnRows(sqlmock.NewRows(templateColumns))
+	if _, err := s.DeleteTemplate(context.Background(), &notifpb.DeleteTemplateRequest{MessageId: msgBillUpdated, Locale: "de"}); err != nil {
+		t.Fatal(err)
+	}
+}
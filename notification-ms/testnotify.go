@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"notification-ms/notifpb"
+)
+
+// testNotifications is one representative notification per type and
+// severity, rendered from the same catalog messages as the real events.
+var testNotifications = []struct {
+	typ       notifpb.NotificationType
+	severity  notifpb.Severity
+	source    notifpb.Source
+	messageID string
+	params    map[string]string
+}{
+	{notifpb.NotificationType_NOTIFICATION_TYPE_WELCOME, notifpb.Severity_SEVERITY_INFO, notifpb.Source_SOURCE_USER, msgUserWelcome, map[string]string{"username": "test@example.com"}},
+	{notifpb.NotificationType_NOTIFICATION_TYPE_WELCOME, notifpb.Severity_SEVERITY_WARNING, notifpb.Source_SOURCE_USER, msgUserWelcome, map[string]string{"username": "test@example.com"}},
+	{notifpb.NotificationType_NOTIFICATION_TYPE_WELCOME, notifpb.Severity_SEVERITY_CRITICAL, notifpb.Source_SOURCE_USER, msgUserWelcome, map[string]string{"username": "test@example.com"}},
+	{notifpb.NotificationType_NOTIFICATION_TYPE_BILLING, notifpb.Severity_SEVERITY_INFO, notifpb.Source_SOURCE_BILLING, msgBillUpdated, map[string]string{"amount": "42.00"}},
+	{notifpb.NotificationType_NOTIFICATION_TYPE_BILLING, notifpb.Severity_SEVERITY_WARNING, notifpb.Source_SOURCE_BILLING, msgBillUpdatedHigh, map[string]string{"amount": "420.00"}},
+	{notifpb.NotificationType_NOTIFICATION_TYPE_BILLING, notifpb.Severity_SEVERITY_CRITICAL, notifpb.Source_SOURCE_BILLING, msgBillUpdatedHigh, map[string]string{"amount": "4200.00"}},
+}
+
+// SendTestNotifications queues one of each test notification for the user.
+// They are persisted and delivered like real ones.
+func (s *notificationServer) SendTestNotifications(ctx context.Context, req *notifpb.SendTestNotificationsRequest) (*notifpb.SendTestNotificationsResponse, error) {
+	if !s.testNotifications.Load() {
+		return nil, status.Error(codes.PermissionDenied, "test notifications are disabled")
+	}
+	if req.UserId == "" {
+		return nil, status.Error(codes.InvalidArgument, "user_id is required")
+	}
+
+	res := &notifpb.SendTestNotificationsResponse{}
+	locale := s.preferredLocale(ctx, req.UserId, "")
+	for _, t := range testNotifications {
+		notif := &notifpb.Notification{
+			Id:        uuid.New().String(),
+			UserId:    req.UserId,
+			Message:   s.render(ctx, req.UserId, locale, t.messageID, t.params),
+			Timestamp: s.timestamp(),
+			Type:      t.typ,
+			Severity:  t.severity,
+			Source:    t.source,
+		}
+		s.dispatcher.Enqueue(ctx, notif)
+		res.Notifications = append(res.Notifications, notif)
+	}
+	logf(ctx, "Queued %d test notifications for user %s", len(res.Notifications), req.UserId)
+	return res, nil
+}
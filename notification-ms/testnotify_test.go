@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"notification-ms/notifpb"
+)
+
+func TestSendTestNotificationsOnePerTypeAndSeverity(t *testing.T) {
+	s, mock := newTestServer(t)
+	s.testNotifications.Store(true)
+	var (
+		mu      sync.Mutex
+		handled []*notifpb.Notification
+	)
+	s.dispatcher = newDispatcher(1, 10, notifpb.Severity_SEVERITY_UNSPECIFIED,
+		func(_ context.Context, n *notifpb.Notification) {
+			mu.Lock()
+			handled = append(handled, n)
+			mu.Unlock()
+		},
+		func(context.Context, *notifpb.Notification) {
+			t.Error("test notification persisted instead of handled")
+		})
+	mock.ExpectQuery(literal("SELECT locale FROM user_locales")).WithArgs("u1").WillReturnRows(sqlmock.NewRows([]string{"locale"}))
+
+	res, err := s.SendTestNotifications(context.Background(), &notifpb.SendTestNotificationsRequest{UserId: "u1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.dispatcher.Close(time.Second)
+
+	type kind struct {
+		typ      notifpb.NotificationType
+		severity notifpb.Severity
+	}
+	seen := map[kind]bool{}
+	for _, n := range res.Notifications {
+		k := kind{n.Type, n.Severity}
+		if seen[k] {
+			t.Errorf("%v sent twice", k)
+		}
+		seen[k] = true
+		if n.UserId != "u1" || n.Message == "" {
+			t.Errorf("notification %+v", n)
+		}
+	}
+	for typ := range notifpb.NotificationType_name {
+		for severity := range notifpb.Severity_name {
+			k := kind{notifpb.NotificationType(typ), notifpb.Severity(severity)}
+			if k.typ != notifpb.NotificationType_NOTIFICATION_TYPE_UNSPECIFIED && k.severity != notifpb.Severity_SEVERITY_UNSPECIFIED && !seen[k] {
+				t.Errorf("no test notification for %v", k)
+			}
+		}
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(handled) != len(res.Notifications) {
+		t.Errorf("dispatched %d notifications, want %d", len(handled), len(res.Notifications))
+	}
+}
+
+func TestSendTestNotificationsDisabled(t *testing.T) {
+	s, _ := newTestServer(t)
+	_, err := s.SendTestNotifications(context.Background(), &notifpb.SendTestNotificationsRequest{UserId: "u1"})
+	if status.Code(err) != codes.PermissionDenied {
+		t.Errorf("disabled: %v, want PermissionDenied", err)
+	}
+}
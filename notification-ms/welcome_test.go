@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+
+	"notification-ms/notifpb"
+)
+
+func TestSaveWelcome(t *testing.T) {
+	welcome := &notifpb.Notification{Id: "n1", UserId: "u1", Message: "Welcome", Type: notifpb.NotificationType_NOTIFICATION_TYPE_WELCOME}
+
+	t.Run("first delivery stores claim and notification together", func(t *testing.T) {
+		s, mock := newTestServer(t)
+		mock.ExpectBegin()
+		mock.ExpectExec(literal("INSERT INTO welcome_notifications")).WithArgs("u1", "n1").WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(literal("INSERT INTO notifications")).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		claimed, err := s.store.SaveWelcome(context.Background(), welcome)
+		if err != nil || !claimed {
+			t.Fatalf("SaveWelcome = %v, %v, want claimed", claimed, err)
+		}
+	})
+
+	t.Run("redelivery stores nothing", func(t *testing.T) {
+		s, mock := newTestServer(t)
+		mock.ExpectBegin()
+		mock.ExpectExec(literal("INSERT INTO welcome_notifications")).WithArgs("u1", "n1").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectRollback()
+
+		claimed, err := s.store.SaveWelcome(context.Background(), welcome)
+		if err != nil || claimed {
+			t.Fatalf("SaveWelcome = %v, %v, want not claimed", claimed, err)
+		}
+	})
+
+	t.Run("failed insert releases the claim", func(t *testing.T) {
+		s, mock := newTestServer(t)
+		mock.ExpectBegin()
+		mock.ExpectExec(literal("INSERT INTO welcome_notifications")).WithArgs("u1", "n1").WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(literal("INSERT INTO notifications")).WillReturnError(errors.New("connection reset"))
+		mock.ExpectRollback()
+
+		if claimed, err := s.store.SaveWelcome(context.Background(), welcome); err == nil || claimed {
+			t.Fatalf("SaveWelcome = %v, %v, want an error", claimed, err)
+		}
+	})
+
+	t.Run("batching store writes through", func(t *testing.T) {
+		s, mock := newTestServer(t)
+		s.store = newNotificationStore(s.store.db, realClock{}, 100, time.Hour)
+		defer s.store.Close()
+		mock.ExpectBegin()
+		mock.ExpectExec(literal("INSERT INTO welcome_notifications")).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(literal("INSERT INTO notifications")).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		if claimed, err := s.store.SaveWelcome(context.Background(), welcome); err != nil || !claimed {
+			t.Fatalf("SaveWelcome = %v, %v, want claimed", claimed, err)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Fatalf("welcome was buffered instead of stored: %v", err)
+		}
+	})
+}
+
+func TestWelcomeStoredUnreadWithoutLiveConnection(t *testing.T) {
+	s, mock := newTestServer(t)
+	nc, js := runJetStream(t)
+	s.subs = newSubscriptions(nc, js, "notification-ms")
+	s.dispatcher = newDispatcher(1, 10, notifpb.Severity_SEVERITY_UNSPECIFIED, s.deliver, s.persist)
+	defer s.dispatcher.Close(time.Second)
+
+	// u1 has just registered and has no stream open. The welcome is stored
+	// with delivery_status 'stored' and without touching read, which
+	// defaults to false: it stays unread until the user marks it.
+	const welcome = "Welcome to the platform, alice!"
+	anyArg := sqlmock.AnyArg()
+	mock.ExpectExec(literal("INSERT INTO user_locales")).WithArgs("u1", "en").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectBegin()
+	mock.ExpectExec(literal("INSERT INTO welcome_notifications")).WithArgs("u1", anyArg).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(literal("INSERT INTO notifications (id, user_id, message, created_at, source_event_id, type, severity, source, delivery_status) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, 'stored')")).
+		WithArgs(anyArg, "u1", welcome, anyArg, "evt-1", notifpb.NotificationType_NOTIFICATION_TYPE_WELCOME, anyArg, anyArg).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	// Delivery persists it again, which the stored row absorbs.
+	mock.ExpectExec(literal("INSERT INTO notifications")).WithArgs(anyArg, "u1", welcome, anyArg, "evt-1", anyArg, anyArg, anyArg).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	data, err := json.Marshal(UserCreatedEvent{EventID: "evt-1", UID: "u1", Username: "alice", Locale: "en"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := js.Publish("user.created", data); err != nil {
+		t.Fatal(err)
+	}
+	go s.subscribeToEvents()
+	waitForExpectations(t, mock)
+
+}
@@ -208,6 +208,7 @@ func (x *GetBillingRequest) GetUserId() string {
 type GetBillingResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Amount        float64                `protobuf:"fixed64,1,opt,name=amount,proto3" json:"amount,omitempty"`
+	Version       int64                  `protobuf:"varint,2,opt,name=version,proto3" json:"version,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -249,12 +250,22 @@ func (x *GetBillingResponse) GetAmount() float64 {
 	return 0
 }
 
+func (x *GetBillingResponse) GetVersion() int64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
 type UpdateBillingRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
-	Amount        float64                `protobuf:"fixed64,2,opt,name=amount,proto3" json:"amount,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	UserId string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	Amount float64                `protobuf:"fixed64,2,opt,name=amount,proto3" json:"amount,omitempty"`
+	// When set, the update only applies if the account is still at this
+	// version; otherwise it fails with ABORTED.
+	ExpectedVersion *int64 `protobuf:"varint,3,opt,name=expected_version,json=expectedVersion,proto3,oneof" json:"expected_version,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
 }
 
 func (x *UpdateBillingRequest) Reset() {
@@ -301,9 +312,17 @@ func (x *UpdateBillingRequest) GetAmount() float64 {
 	return 0
 }
 
+func (x *UpdateBillingRequest) GetExpectedVersion() int64 {
+	if x != nil && x.ExpectedVersion != nil {
+		return *x.ExpectedVersion
+	}
+	return 0
+}
+
 type UpdateBillingResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
+	Version       int64                  `protobuf:"varint,2,opt,name=version,proto3" json:"version,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -345,6 +364,610 @@ func (x *UpdateBillingResponse) GetSuccess() bool {
 	return false
 }
 
+func (x *UpdateBillingResponse) GetVersion() int64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+type RecalculateBillingRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RecalculateBillingRequest) Reset() {
+	*x = RecalculateBillingRequest{}
+	mi := &file_billingpb_billingpb_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RecalculateBillingRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RecalculateBillingRequest) ProtoMessage() {}
+
+func (x *RecalculateBillingRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_billingpb_billingpb_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RecalculateBillingRequest.ProtoReflect.Descriptor instead.
+func (*RecalculateBillingRequest) Descriptor() ([]byte, []int) {
+	return file_billingpb_billingpb_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *RecalculateBillingRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+type RecalculateBillingResponse struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Amount         float64                `protobuf:"fixed64,1,opt,name=amount,proto3" json:"amount,omitempty"`
+	PreviousAmount float64                `protobuf:"fixed64,2,opt,name=previous_amount,json=previousAmount,proto3" json:"previous_amount,omitempty"`
+	Corrected      bool                   `protobuf:"varint,3,opt,name=corrected,proto3" json:"corrected,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *RecalculateBillingResponse) Reset() {
+	*x = RecalculateBillingResponse{}
+	mi := &file_billingpb_billingpb_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RecalculateBillingResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RecalculateBillingResponse) ProtoMessage() {}
+
+func (x *RecalculateBillingResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_billingpb_billingpb_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RecalculateBillingResponse.ProtoReflect.Descriptor instead.
+func (*RecalculateBillingResponse) Descriptor() ([]byte, []int) {
+	return file_billingpb_billingpb_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *RecalculateBillingResponse) GetAmount() float64 {
+	if x != nil {
+		return x.Amount
+	}
+	return 0
+}
+
+func (x *RecalculateBillingResponse) GetPreviousAmount() float64 {
+	if x != nil {
+		return x.PreviousAmount
+	}
+	return 0
+}
+
+func (x *RecalculateBillingResponse) GetCorrected() bool {
+	if x != nil {
+		return x.Corrected
+	}
+	return false
+}
+
+type EventSchema struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// NATS subject the event is published on.
+	Subject string `protobuf:"bytes,1,opt,name=subject,proto3" json:"subject,omitempty"`
+	// Schema version, bumped on every incompatible payload change.
+	Version       int32  `protobuf:"varint,2,opt,name=version,proto3" json:"version,omitempty"`
+	Description   string `protobuf:"bytes,3,opt,name=description,proto3" json:"description,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *EventSchema) Reset() {
+	*x = EventSchema{}
+	mi := &file_billingpb_billingpb_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EventSchema) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EventSchema) ProtoMessage() {}
+
+func (x *EventSchema) ProtoReflect() protoreflect.Message {
+	mi := &file_billingpb_billingpb_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EventSchema.ProtoReflect.Descriptor instead.
+func (*EventSchema) Descriptor() ([]byte, []int) {
+	return file_billingpb_billingpb_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *EventSchema) GetSubject() string {
+	if x != nil {
+		return x.Subject
+	}
+	return ""
+}
+
+func (x *EventSchema) GetVersion() int32 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *EventSchema) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+type GetEventSchemasRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetEventSchemasRequest) Reset() {
+	*x = GetEventSchemasRequest{}
+	mi := &file_billingpb_billingpb_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetEventSchemasRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetEventSchemasRequest) ProtoMessage() {}
+
+func (x *GetEventSchemasRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_billingpb_billingpb_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetEventSchemasRequest.ProtoReflect.Descriptor instead.
+func (*GetEventSchemasRequest) Descriptor() ([]byte, []int) {
+	return file_billingpb_billingpb_proto_rawDescGZIP(), []int{10}
+}
+
+type GetEventSchemasResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Events        []*EventSchema         `protobuf:"bytes,1,rep,name=events,proto3" json:"events,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetEventSchemasResponse) Reset() {
+	*x = GetEventSchemasResponse{}
+	mi := &file_billingpb_billingpb_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetEventSchemasResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetEventSchemasResponse) ProtoMessage() {}
+
+func (x *GetEventSchemasResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_billingpb_billingpb_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetEventSchemasResponse.ProtoReflect.Descriptor instead.
+func (*GetEventSchemasResponse) Descriptor() ([]byte, []int) {
+	return file_billingpb_billingpb_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *GetEventSchemasResponse) GetEvents() []*EventSchema {
+	if x != nil {
+		return x.Events
+	}
+	return nil
+}
+
+type SetConsumptionPausedRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Paused        bool                   `protobuf:"varint,1,opt,name=paused,proto3" json:"paused,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SetConsumptionPausedRequest) Reset() {
+	*x = SetConsumptionPausedRequest{}
+	mi := &file_billingpb_billingpb_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetConsumptionPausedRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetConsumptionPausedRequest) ProtoMessage() {}
+
+func (x *SetConsumptionPausedRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_billingpb_billingpb_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetConsumptionPausedRequest.ProtoReflect.Descriptor instead.
+func (*SetConsumptionPausedRequest) Descriptor() ([]byte, []int) {
+	return file_billingpb_billingpb_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *SetConsumptionPausedRequest) GetPaused() bool {
+	if x != nil {
+		return x.Paused
+	}
+	return false
+}
+
+type SetConsumptionPausedResponse struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Paused bool                   `protobuf:"varint,1,opt,name=paused,proto3" json:"paused,omitempty"`
+	// Events received but not yet processed.
+	PendingEvents int64 `protobuf:"varint,2,opt,name=pending_events,json=pendingEvents,proto3" json:"pending_events,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SetConsumptionPausedResponse) Reset() {
+	*x = SetConsumptionPausedResponse{}
+	mi := &file_billingpb_billingpb_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetConsumptionPausedResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetConsumptionPausedResponse) ProtoMessage() {}
+
+func (x *SetConsumptionPausedResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_billingpb_billingpb_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetConsumptionPausedResponse.ProtoReflect.Descriptor instead.
+func (*SetConsumptionPausedResponse) Descriptor() ([]byte, []int) {
+	return file_billingpb_billingpb_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *SetConsumptionPausedResponse) GetPaused() bool {
+	if x != nil {
+		return x.Paused
+	}
+	return false
+}
+
+func (x *SetConsumptionPausedResponse) GetPendingEvents() int64 {
+	if x != nil {
+		return x.PendingEvents
+	}
+	return 0
+}
+
+type StatsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StatsRequest) Reset() {
+	*x = StatsRequest{}
+	mi := &file_billingpb_billingpb_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StatsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StatsRequest) ProtoMessage() {}
+
+func (x *StatsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_billingpb_billingpb_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StatsRequest.ProtoReflect.Descriptor instead.
+func (*StatsRequest) Descriptor() ([]byte, []int) {
+	return file_billingpb_billingpb_proto_rawDescGZIP(), []int{14}
+}
+
+type StatsResponse struct {
+	state             protoimpl.MessageState `protogen:"open.v1"`
+	Accounts          int64                  `protobuf:"varint,1,opt,name=accounts,proto3" json:"accounts,omitempty"`
+	TotalAmount       float64                `protobuf:"fixed64,2,opt,name=total_amount,json=totalAmount,proto3" json:"total_amount,omitempty"`
+	ConsumptionPaused bool                   `protobuf:"varint,3,opt,name=consumption_paused,json=consumptionPaused,proto3" json:"consumption_paused,omitempty"`
+	// Bytes of published events buffered while NATS is unreachable.
+	NatsBufferedBytes int64 `protobuf:"varint,4,opt,name=nats_buffered_bytes,json=natsBufferedBytes,proto3" json:"nats_buffered_bytes,omitempty"`
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
+}
+
+func (x *StatsResponse) Reset() {
+	*x = StatsResponse{}
+	mi := &file_billingpb_billingpb_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StatsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StatsResponse) ProtoMessage() {}
+
+func (x *StatsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_billingpb_billingpb_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StatsResponse.ProtoReflect.Descriptor instead.
+func (*StatsResponse) Descriptor() ([]byte, []int) {
+	return file_billingpb_billingpb_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *StatsResponse) GetAccounts() int64 {
+	if x != nil {
+		return x.Accounts
+	}
+	return 0
+}
+
+func (x *StatsResponse) GetTotalAmount() float64 {
+	if x != nil {
+		return x.TotalAmount
+	}
+	return 0
+}
+
+func (x *StatsResponse) GetConsumptionPaused() bool {
+	if x != nil {
+		return x.ConsumptionPaused
+	}
+	return false
+}
+
+func (x *StatsResponse) GetNatsBufferedBytes() int64 {
+	if x != nil {
+		return x.NatsBufferedBytes
+	}
+	return 0
+}
+
+type ExportTransactionsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ExportTransactionsRequest) Reset() {
+	*x = ExportTransactionsRequest{}
+	mi := &file_billingpb_billingpb_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ExportTransactionsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExportTransactionsRequest) ProtoMessage() {}
+
+func (x *ExportTransactionsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_billingpb_billingpb_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExportTransactionsRequest.ProtoReflect.Descriptor instead.
+func (*ExportTransactionsRequest) Descriptor() ([]byte, []int) {
+	return file_billingpb_billingpb_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *ExportTransactionsRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+// A ledger entry: one change to a user's balance.
+type Transaction struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Delta         float64                `protobuf:"fixed64,2,opt,name=delta,proto3" json:"delta,omitempty"`
+	CreatedAt     string                 `protobuf:"bytes,3,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"` // RFC 3339 timestamp
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Transaction) Reset() {
+	*x = Transaction{}
+	mi := &file_billingpb_billingpb_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Transaction) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Transaction) ProtoMessage() {}
+
+func (x *Transaction) ProtoReflect() protoreflect.Message {
+	mi := &file_billingpb_billingpb_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Transaction.ProtoReflect.Descriptor instead.
+func (*Transaction) Descriptor() ([]byte, []int) {
+	return file_billingpb_billingpb_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *Transaction) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *Transaction) GetDelta() float64 {
+	if x != nil {
+		return x.Delta
+	}
+	return 0
+}
+
+func (x *Transaction) GetCreatedAt() string {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return ""
+}
+
+type TransactionPage struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Transactions  []*Transaction         `protobuf:"bytes,1,rep,name=transactions,proto3" json:"transactions,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TransactionPage) Reset() {
+	*x = TransactionPage{}
+	mi := &file_billingpb_billingpb_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TransactionPage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TransactionPage) ProtoMessage() {}
+
+func (x *TransactionPage) ProtoReflect() protoreflect.Message {
+	mi := &file_billingpb_billingpb_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TransactionPage.ProtoReflect.Descriptor instead.
+func (*TransactionPage) Descriptor() ([]byte, []int) {
+	return file_billingpb_billingpb_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *TransactionPage) GetTransactions() []*Transaction {
+	if x != nil {
+		return x.Transactions
+	}
+	return nil
+}
+
 var File_billingpb_billingpb_proto protoreflect.FileDescriptor
 
 const file_billingpb_billingpb_proto_rawDesc = "" +
@@ -358,19 +981,61 @@ const file_billingpb_billingpb_proto_rawDesc = "" +
 	"\x1cCreateBillingAccountResponse\x12\x18\n" +
 	"\asuccess\x18\x01 \x01(\bR\asuccess\",\n" +
 	"\x11GetBillingRequest\x12\x17\n" +
-	"\auser_id\x18\x01 \x01(\tR\x06userId\",\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\"F\n" +
 	"\x12GetBillingResponse\x12\x16\n" +
-	"\x06amount\x18\x01 \x01(\x01R\x06amount\"G\n" +
+	"\x06amount\x18\x01 \x01(\x01R\x06amount\x12\x18\n" +
+	"\aversion\x18\x02 \x01(\x03R\aversion\"\x8c\x01\n" +
 	"\x14UpdateBillingRequest\x12\x17\n" +
 	"\auser_id\x18\x01 \x01(\tR\x06userId\x12\x16\n" +
-	"\x06amount\x18\x02 \x01(\x01R\x06amount\"1\n" +
+	"\x06amount\x18\x02 \x01(\x01R\x06amount\x12.\n" +
+	"\x10expected_version\x18\x03 \x01(\x03H\x00R\x0fexpectedVersion\x88\x01\x01B\x13\n" +
+	"\x11_expected_version\"K\n" +
 	"\x15UpdateBillingResponse\x12\x18\n" +
-	"\asuccess\x18\x01 \x01(\bR\asuccess2\x98\x02\n" +
+	"\asuccess\x18\x01 \x01(\bR\asuccess\x12\x18\n" +
+	"\aversion\x18\x02 \x01(\x03R\aversion\"4\n" +
+	"\x19RecalculateBillingRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\"{\n" +
+	"\x1aRecalculateBillingResponse\x12\x16\n" +
+	"\x06amount\x18\x01 \x01(\x01R\x06amount\x12'\n" +
+	"\x0fprevious_amount\x18\x02 \x01(\x01R\x0epreviousAmount\x12\x1c\n" +
+	"\tcorrected\x18\x03 \x01(\bR\tcorrected\"c\n" +
+	"\vEventSchema\x12\x18\n" +
+	"\asubject\x18\x01 \x01(\tR\asubject\x12\x18\n" +
+	"\aversion\x18\x02 \x01(\x05R\aversion\x12 \n" +
+	"\vdescription\x18\x03 \x01(\tR\vdescription\"\x18\n" +
+	"\x16GetEventSchemasRequest\"I\n" +
+	"\x17GetEventSchemasResponse\x12.\n" +
+	"\x06events\x18\x01 \x03(\v2\x16.billingpb.EventSchemaR\x06events\"5\n" +
+	"\x1bSetConsumptionPausedRequest\x12\x16\n" +
+	"\x06paused\x18\x01 \x01(\bR\x06paused\"]\n" +
+	"\x1cSetConsumptionPausedResponse\x12\x16\n" +
+	"\x06paused\x18\x01 \x01(\bR\x06paused\x12%\n" +
+	"\x0epending_events\x18\x02 \x01(\x03R\rpendingEvents\"\x0e\n" +
+	"\fStatsRequest\"\xad\x01\n" +
+	"\rStatsResponse\x12\x1a\n" +
+	"\baccounts\x18\x01 \x01(\x03R\baccounts\x12!\n" +
+	"\ftotal_amount\x18\x02 \x01(\x01R\vtotalAmount\x12-\n" +
+	"\x12consumption_paused\x18\x03 \x01(\bR\x11consumptionPaused\x12.\n" +
+	"\x13nats_buffered_bytes\x18\x04 \x01(\x03R\x11natsBufferedBytes\"4\n" +
+	"\x19ExportTransactionsRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\"R\n" +
+	"\vTransaction\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x14\n" +
+	"\x05delta\x18\x02 \x01(\x01R\x05delta\x12\x1d\n" +
+	"\n" +
+	"created_at\x18\x03 \x01(\tR\tcreatedAt\"M\n" +
+	"\x0fTransactionPage\x12:\n" +
+	"\ftransactions\x18\x01 \x03(\v2\x16.billingpb.TransactionR\ftransactions2\xd4\x05\n" +
 	"\x0eBillingService\x12g\n" +
 	"\x14CreateBillingAccount\x12&.billingpb.CreateBillingAccountRequest\x1a'.billingpb.CreateBillingAccountResponse\x12I\n" +
 	"\n" +
 	"GetBilling\x12\x1c.billingpb.GetBillingRequest\x1a\x1d.billingpb.GetBillingResponse\x12R\n" +
-	"\rUpdateBilling\x12\x1f.billingpb.UpdateBillingRequest\x1a .billingpb.UpdateBillingResponseB\rZ\v./billingpbb\x06proto3"
+	"\rUpdateBilling\x12\x1f.billingpb.UpdateBillingRequest\x1a .billingpb.UpdateBillingResponse\x12a\n" +
+	"\x12RecalculateBilling\x12$.billingpb.RecalculateBillingRequest\x1a%.billingpb.RecalculateBillingResponse\x12:\n" +
+	"\x05Stats\x12\x17.billingpb.StatsRequest\x1a\x18.billingpb.StatsResponse\x12g\n" +
+	"\x14SetConsumptionPaused\x12&.billingpb.SetConsumptionPausedRequest\x1a'.billingpb.SetConsumptionPausedResponse\x12X\n" +
+	"\x0fGetEventSchemas\x12!.billingpb.GetEventSchemasRequest\x1a\".billingpb.GetEventSchemasResponse\x12X\n" +
+	"\x12ExportTransactions\x12$.billingpb.ExportTransactionsRequest\x1a\x1a.billingpb.TransactionPage0\x01B\rZ\v./billingpbb\x06proto3"
 
 var (
 	file_billingpb_billingpb_proto_rawDescOnce sync.Once
@@ -384,7 +1049,7 @@ func file_billingpb_billingpb_proto_rawDescGZIP() []byte {
 	return file_billingpb_billingpb_proto_rawDescData
 }
 
-var file_billingpb_billingpb_proto_msgTypes = make([]protoimpl.MessageInfo, 7)
+var file_billingpb_billingpb_proto_msgTypes = make([]protoimpl.MessageInfo, 19)
 var file_billingpb_billingpb_proto_goTypes = []any{
 	(*BillingAccount)(nil),               // 0: billingpb.BillingAccount
 	(*CreateBillingAccountRequest)(nil),  // 1: billingpb.CreateBillingAccountRequest
@@ -393,19 +1058,43 @@ var file_billingpb_billingpb_proto_goTypes = []any{
 	(*GetBillingResponse)(nil),           // 4: billingpb.GetBillingResponse
 	(*UpdateBillingRequest)(nil),         // 5: billingpb.UpdateBillingRequest
 	(*UpdateBillingResponse)(nil),        // 6: billingpb.UpdateBillingResponse
+	(*RecalculateBillingRequest)(nil),    // 7: billingpb.RecalculateBillingRequest
+	(*RecalculateBillingResponse)(nil),   // 8: billingpb.RecalculateBillingResponse
+	(*EventSchema)(nil),                  // 9: billingpb.EventSchema
+	(*GetEventSchemasRequest)(nil),       // 10: billingpb.GetEventSchemasRequest
+	(*GetEventSchemasResponse)(nil),      // 11: billingpb.GetEventSchemasResponse
+	(*SetConsumptionPausedRequest)(nil),  // 12: billingpb.SetConsumptionPausedRequest
+	(*SetConsumptionPausedResponse)(nil), // 13: billingpb.SetConsumptionPausedResponse
+	(*StatsRequest)(nil),                 // 14: billingpb.StatsRequest
+	(*StatsResponse)(nil),                // 15: billingpb.StatsResponse
+	(*ExportTransactionsRequest)(nil),    // 16: billingpb.ExportTransactionsRequest
+	(*Transaction)(nil),                  // 17: billingpb.Transaction
+	(*TransactionPage)(nil),              // 18: billingpb.TransactionPage
 }
 var file_billingpb_billingpb_proto_depIdxs = []int32{
-	1, // 0: billingpb.BillingService.CreateBillingAccount:input_type -> billingpb.CreateBillingAccountRequest
-	3, // 1: billingpb.BillingService.GetBilling:input_type -> billingpb.GetBillingRequest
-	5, // 2: billingpb.BillingService.UpdateBilling:input_type -> billingpb.UpdateBillingRequest
-	2, // 3: billingpb.BillingService.CreateBillingAccount:output_type -> billingpb.CreateBillingAccountResponse
-	4, // 4: billingpb.BillingService.GetBilling:output_type -> billingpb.GetBillingResponse
-	6, // 5: billingpb.BillingService.UpdateBilling:output_type -> billingpb.UpdateBillingResponse
-	3, // [3:6] is the sub-list for method output_type
-	0, // [0:3] is the sub-list for method input_type
-	0, // [0:0] is the sub-list for extension type_name
-	0, // [0:0] is the sub-list for extension extendee
-	0, // [0:0] is the sub-list for field type_name
+	9,  // 0: billingpb.GetEventSchemasResponse.events:type_name -> billingpb.EventSchema
+	17, // 1: billingpb.TransactionPage.transactions:type_name -> billingpb.Transaction
+	1,  // 2: billingpb.BillingService.CreateBillingAccount:input_type -> billingpb.CreateBillingAccountRequest
+	3,  // 3: billingpb.BillingService.GetBilling:input_type -> billingpb.GetBillingRequest
+	5,  // 4: billingpb.BillingService.UpdateBilling:input_type -> billingpb.UpdateBillingRequest
+	7,  // 5: billingpb.BillingService.RecalculateBilling:input_type -> billingpb.RecalculateBillingRequest
+	14, // 6: billingpb.BillingService.Stats:input_type -> billingpb.StatsRequest
+	12, // 7: billingpb.BillingService.SetConsumptionPaused:input_type -> billingpb.SetConsumptionPausedRequest
+	10, // 8: billingpb.BillingService.GetEventSchemas:input_type -> billingpb.GetEventSchemasRequest
+	16, // 9: billingpb.BillingService.ExportTransactions:input_type -> billingpb.ExportTransactionsRequest
+	2,  // 10: billingpb.BillingService.CreateBillingAccount:output_type -> billingpb.CreateBillingAccountResponse
+	4,  // 11: billingpb.BillingService.GetBilling:output_type -> billingpb.GetBillingResponse
+	6,  // 12: billingpb.BillingService.UpdateBilling:output_type -> billingpb.UpdateBillingResponse
+	8,  // 13: billingpb.BillingService.RecalculateBilling:output_type -> billingpb.RecalculateBillingResponse
+	15, // 14: billingpb.BillingService.Stats:output_type -> billingpb.StatsResponse
+	13, // 15: billingpb.BillingService.SetConsumptionPaused:output_type -> billingpb.SetConsumptionPausedResponse
+	11, // 16: billingpb.BillingService.GetEventSchemas:output_type -> billingpb.GetEventSchemasResponse
+	18, // 17: billingpb.BillingService.ExportTransactions:output_type -> billingpb.TransactionPage
+	10, // [10:18] is the sub-list for method output_type
+	2,  // [2:10] is the sub-list for method input_type
+	2,  // [2:2] is the sub-list for extension type_name
+	2,  // [2:2] is the sub-list for extension extendee
+	0,  // [0:2] is the sub-list for field type_name
 }
 
 func init() { file_billingpb_billingpb_proto_init() }
@@ -413,13 +1102,14 @@ func file_billingpb_billingpb_proto_init() {
 	if File_billingpb_billingpb_proto != nil {
 		return
 	}
+	file_billingpb_billingpb_proto_msgTypes[5].OneofWrappers = []any{}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_billingpb_billingpb_proto_rawDesc), len(file_billingpb_billingpb_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   7,
+			NumMessages:   19,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
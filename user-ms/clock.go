@@ -0,0 +1,16 @@
+package main
+
+import "time"
+
+// Clock is the source of time for time-dependent behaviour, so it can be
+// replaced with a controllable fake when exercising expiry and timeouts.
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+// realClock is the Clock backed by the time package.
+type realClock struct{}
+
+func (realClock) Now() time.Time                         { return time.Now() }
+func (realClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	defaultMaxInFlight = 16
+	defaultMaxQueued   = 32
+)
+
+// concurrencyLimiter bounds how many calls to a set of RPCs run at once.
+// Calls beyond maxInFlight wait in a queue of up to maxQueued; calls arriving
+// when the queue is full fail fast with ResourceExhausted instead of piling
+// more work onto the database.
+type concurrencyLimiter struct {
+	methods  map[string]bool
+	inFlight chan struct{}
+	queue    chan struct{}
+}
+
+// newConcurrencyLimiter limits the given full method names using
+// GRPC_MAX_IN_FLIGHT and GRPC_MAX_QUEUED. It returns nil, meaning no limit,
+// when GRPC_MAX_IN_FLIGHT is 0.
+func newConcurrencyLimiter(methods ...string) (*concurrencyLimiter, error) {
+	maxInFlight, err := envInt("GRPC_MAX_IN_FLIGHT", defaultMaxInFlight)
+	if err != nil {
+		return nil, err
+	}
+	maxQueued, err := envInt("GRPC_MAX_QUEUED", defaultMaxQueued)
+	if err != nil {
+		return nil, err
+	}
+	if maxInFlight <= 0 {
+		return nil, nil
+	}
+	l := &concurrencyLimiter{
+		methods:  make(map[string]bool, len(methods)),
+		inFlight: make(chan struct{}, maxInFlight),
+		queue:    make(chan struct{}, max(maxQueued, 0)),
+	}
+	for _, m := range methods {
+		l.methods[m] = true
+	}
+	return l, nil
+}
+
+// envInt returns key parsed as an int, or def when it is unset.
+func envInt(key string, def int) (int, error) {
+	v := os.Getenv(key)
+	if v == "" {
+		return def, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s %q: %w", key, v, err)
+	}
+	return n, nil
+}
+
+// UnaryInterceptor applies the limit to the configured methods and passes
+// every other call straight through.
+func (l *concurrencyLimiter) UnaryInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	if !l.methods[info.FullMethod] {
+		return handler(ctx, req)
+	}
+	if err := l.acquire(ctx); err != nil {
+		return nil, err
+	}
+	defer func() { <-l.inFlight }()
+	return handler(ctx, req)
+}
+
+// acquire takes an in-flight slot, waiting in the queue while none is free.
+func (l *concurrencyLimiter) acquire(ctx context.Context) error {
+	select {
+	case l.inFlight <- struct{}{}:
+		return nil
+	default:
+	}
+
+	select {
+	case l.queue <- struct{}{}:
+	default:
+		return status.Error(codes.ResourceExhausted, "server busy, try again later")
+	}
+	defer func() { <-l.queue }()
+
+	select {
+	case l.inFlight <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return status.FromContextError(ctx.Err()).Err()
+	}
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+
+	"user-ms/userpb"
+)
+
+func TestConcurrencyLimiterRejectsOverflow(t *testing.T) {
+	t.Setenv("GRPC_MAX_IN_FLIGHT", "1")
+	t.Setenv("GRPC_MAX_QUEUED", "1")
+	l, err := newConcurrencyLimiter(userpb.UserService_Login_FullMethodName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	login := &grpc.UnaryServerInfo{FullMethod: userpb.UserService_Login_FullMethodName}
+	release := make(chan struct{})
+	var ran []string
+	handler := func(_ context.Context, req any) (any, error) {
+		<-release
+		ran = append(ran, req.(string))
+		return nil, nil
+	}
+
+	errs := make(chan error, 2)
+	go func() { _, err := l.UnaryInterceptor(context.Background(), "first", login, handler); errs <- err }()
+	for len(l.inFlight) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	go func() { _, err := l.UnaryInterceptor(context.Background(), "queued", login, handler); errs <- err }()
+	for len(l.queue) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	_, err = l.UnaryInterceptor(context.Background(), "overflow", login, handler)
+	wantCode(t, err, codes.ResourceExhausted)
+
+	// Unlimited RPCs are not held up by the full budget.
+	if _, err := l.UnaryInterceptor(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: userpb.UserService_ValidateToken_FullMethodName},
+		func(context.Context, any) (any, error) { return nil, nil }); err != nil {
+		t.Errorf("ValidateToken: %v", err)
+	}
+
+	close(release)
+	for range 2 {
+		if err := <-errs; err != nil {
+			t.Errorf("admitted Login failed: %v", err)
+		}
+	}
+	// Slots are taken in turn, so the calls ran one after the other.
+	if len(ran) != 2 || ran[0] != "first" || ran[1] != "queued" {
+		t.Errorf("ran %v, want first then queued", ran)
+	}
+}
+
+func TestConcurrencyLimiterConfig(t *testing.T) {
+	t.Setenv("GRPC_MAX_IN_FLIGHT", "0")
+	if l, err := newConcurrencyLimiter(userpb.UserService_Login_FullMethodName); err != nil || l != nil {
+		t.Errorf("GRPC_MAX_IN_FLIGHT=0: limiter %v, err %v; want no limit", l, err)
+	}
+	t.Setenv("GRPC_MAX_IN_FLIGHT", "many")
+	if _, err := newConcurrencyLimiter(); err == nil {
+		t.Error("GRPC_MAX_IN_FLIGHT=many accepted")
+	}
+	t.Setenv("GRPC_MAX_IN_FLIGHT", "")
+	t.Setenv("GRPC_MAX_QUEUED", "-")
+	if _, err := newConcurrencyLimiter(); err == nil {
+		t.Error("GRPC_MAX_QUEUED=- accepted")
+	}
+}
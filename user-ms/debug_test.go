@@ -0,0 +1,31 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestPprofOnlyOnDebugListener(t *testing.T) {
+	t.Setenv("DEBUG_PPROF", "true")
+	t.Setenv("DEBUG_ADDR", "127.0.0.1:0")
+	addr, err := startDebugServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := http.Get("http://" + addr + "/debug/pprof/cmdline")
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		t.Errorf("debug listener: status %d, want 200", res.StatusCode)
+	}
+
+}
+
+func TestPprofDisabledByDefault(t *testing.T) {
+	t.Setenv("DEBUG_PPROF", "")
+	if addr, err := startDebugServer(); err != nil || addr != "" {
+		t.Errorf("startDebugServer() = %q, %v; want it disabled", addr, err)
+	}
+}
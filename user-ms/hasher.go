@@ -0,0 +1,184 @@
+package main
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// errPasswordMismatch is returned by Verify when the password is wrong.
+var errPasswordMismatch = errors.New("password does not match")
+
+// PasswordHasher produces self-describing password hashes: the encoded string
+// identifies its algorithm and parameters, so hashes from different hashers
+// can coexist in the users table during a migration.
+type PasswordHasher interface {
+	// Name is the algorithm name used in PASSWORD_HASHER.
+	Name() string
+	// Hash returns the encoded hash of password.
+	Hash(password string) (string, error)
+	// Verify checks password against an encoded hash produced by this hasher.
+	Verify(hash, password string) error
+	// Owns reports whether hash was produced by this hasher's algorithm.
+	Owns(hash string) bool
+	// Params returns the cost parameters encoded in a hash this hasher owns,
+	// or the hasher's own parameters when hash is empty.
+	Params(hash string) (string, error)
+}
+
+// passwordHashers lists every supported algorithm, used to verify stored
+// hashes regardless of which one is currently selected.
+var passwordHashers = []PasswordHasher{
+	bcryptHasher{cost: bcrypt.DefaultCost},
+	argon2idHasher{time: 1, memory: 64 * 1024, threads: 4, keyLen: 32},
+}
+
+// newPasswordHasher returns the hasher selected by name ("bcrypt" or "argon2id").
+func newPasswordHasher(name string) (PasswordHasher, error) {
+	for _, h := range passwordHashers {
+		if h.Name() == name {
+			return h, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown password hasher %q", name)
+}
+
+// verifyPassword checks password against hash using whichever algorithm
+// produced the hash.
+func verifyPassword(hash, password string) error {
+	for _, h := range passwordHashers {
+		if h.Owns(hash) {
+			return h.Verify(hash, password)
+		}
+	}
+	return fmt.Errorf("unrecognized password hash format")
+}
+
+// describeHash returns the algorithm and cost parameters of hash, or "unknown"
+// when no supported hasher produced it.
+func describeHash(hash string) (algorithm, params string) {
+	for _, h := range passwordHashers {
+		if h.Owns(hash) {
+			params, err := h.Params(hash)
+			if err != nil {
+				params = "malformed"
+			}
+			return h.Name(), params
+		}
+	}
+	return "unknown", ""
+}
+
+// --- bcrypt ---
+
+type bcryptHasher struct {
+	cost int
+}
+
+func (h bcryptHasher) Name() string { return "bcrypt" }
+
+func (h bcryptHasher) Hash(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), h.cost)
+	return string(hash), err
+}
+
+func (h bcryptHasher) Verify(hash, password string) error {
+	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+	if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+		return errPasswordMismatch
+	}
+	return err
+}
+
+func (h bcryptHasher) Owns(hash string) bool {
+	return strings.HasPrefix(hash, "$2a$") || strings.HasPrefix(hash, "$2b$") || strings.HasPrefix(hash, "$2y$")
+}
+
+func (h bcryptHasher) Params(hash string) (string, error) {
+	if hash == "" {
+		return fmt.Sprintf("cost=%d", h.cost), nil
+	}
+	cost, err := bcrypt.Cost([]byte(hash))
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("cost=%d", cost), nil
+}
+
+// --- argon2id ---
+
+// argon2idHasher encodes hashes in the PHC string format:
+// $argon2id$v=19$m=<memory>,t=<time>,p=<threads>$<salt>$<key>
+type argon2idHasher struct {
+	time    uint32
+	memory  uint32
+	threads uint8
+	keyLen  uint32
+}
+
+const argon2idPrefix = "$argon2id$"
+
+func (h argon2idHasher) Name() string { return "argon2id" }
+
+func (h argon2idHasher) Hash(password string) (string, error) {
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	key := argon2.IDKey([]byte(password), salt, h.time, h.memory, h.threads, h.keyLen)
+	return fmt.Sprintf("%sv=%d$m=%d,t=%d,p=%d$%s$%s", argon2idPrefix, argon2.Version, h.memory, h.time, h.threads,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+// Verify uses the parameters stored in the hash, not the hasher's own, so
+// hashes made with older settings still verify.
+func (h argon2idHasher) Verify(hash, password string) error {
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 {
+		return fmt.Errorf("malformed argon2id hash")
+	}
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return fmt.Errorf("unsupported argon2id version")
+	}
+	var memory, time uint32
+	var threads uint8
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &memory, &time, &threads); err != nil {
+		return fmt.Errorf("malformed argon2id parameters: %w", err)
+	}
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return fmt.Errorf("malformed argon2id salt: %w", err)
+	}
+	want, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil {
+		return fmt.Errorf("malformed argon2id key: %w", err)
+	}
+
+	got := argon2.IDKey([]byte(password), salt, time, memory, threads, uint32(len(want)))
+	if subtle.ConstantTimeCompare(got, want) != 1 {
+		return errPasswordMismatch
+	}
+	return nil
+}
+
+func (h argon2idHasher) Owns(hash string) bool {
+	return strings.HasPrefix(hash, argon2idPrefix)
+}
+
+func (h argon2idHasher) Params(hash string) (string, error) {
+	if hash == "" {
+		return fmt.Sprintf("m=%d,t=%d,p=%d", h.memory, h.time, h.threads), nil
+	}
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 {
+		return "", fmt.Errorf("malformed argon2id hash")
+	}
+	return parts[3], nil
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"golang.org/x/crypto/bcrypt"
+	"google.golang.org/grpc/codes"
+
+	"user-ms/userpb"
+)
+
+// fastHashers are the supported algorithms with cheap parameters.
+var fastHashers = []PasswordHasher{
+	testHasher,
+	argon2idHasher{time: 1, memory: 1024, threads: 1, keyLen: 32},
+}
+
+func TestPasswordHashers(t *testing.T) {
+	for _, h := range fastHashers {
+		t.Run(h.Name(), func(t *testing.T) {
+			hash, err := h.Hash("secret123")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !h.Owns(hash) {
+				t.Errorf("hasher does not own its own hash %q", hash)
+			}
+			if err := h.Verify(hash, "secret123"); err != nil {
+				t.Errorf("correct password rejected: %v", err)
+			}
+			if err := h.Verify(hash, "secret124"); !errors.Is(err, errPasswordMismatch) {
+				t.Errorf("wrong password: err = %v, want errPasswordMismatch", err)
+			}
+			if again, _ := h.Hash("secret123"); again == hash {
+				t.Error("two hashes of the same password are equal; the salt is not random")
+			}
+		})
+	}
+}
+
+func TestVerifyPasswordAcrossAlgorithms(t *testing.T) {
+	var hashes []string
+	for _, h := range fastHashers {
+		hash, err := h.Hash("secret123")
+		if err != nil {
+			t.Fatal(err)
+		}
+		hashes = append(hashes, hash)
+	}
+
+	// Whichever hasher is selected, stored hashes of every algorithm verify.
+	for _, hash := range hashes {
+		owners := 0
+		for _, h := range passwordHashers {
+			if h.Owns(hash) {
+				owners++
+			}
+		}
+		if owners != 1 {
+			t.Errorf("%d hashers own %q, want exactly one", owners, hash)
+		}
+		if err := verifyPassword(hash, "secret123"); err != nil {
+			t.Errorf("verifyPassword(%q): %v", hash, err)
+		}
+		if err := verifyPassword(hash, "wrong1"); !errors.Is(err, errPasswordMismatch) {
+			t.Errorf("verifyPassword(%q) with a wrong password: %v", hash, err)
+		}
+	}
+	if err := verifyPassword("plaintext", "plaintext"); err == nil {
+		t.Error("unrecognized hash format verified")
+	}
+}
+
+func TestArgon2idVerifiesOlderParameters(t *testing.T) {
+	old := argon2idHasher{time: 1, memory: 1024, threads: 1, keyLen: 16}
+	hash, err := old.Hash("secret123")
+	if err != nil {
+		t.Fatal(err)
+	}
+	current := argon2idHasher{time: 2, memory: 2048, threads: 2, keyLen: 32}
+	if err := current.Verify(hash, "secret123"); err != nil {
+		t.Errorf("hash made with older parameters rejected: %v", err)
+	}
+	if params, err := current.Params(hash); err != nil || params != "m=1024,t=1,p=1" {
+		t.Errorf("Params = %q, %v", params, err)
+	}
+	if err := current.Verify(strings.Replace(hash, "v=19", "v=16", 1), "secret123"); err == nil {
+		t.Error("unsupported argon2 version accepted")
+	}
+}
+
+func TestNewPasswordHasher(t *testing.T) {
+	for _, name := range []string{"bcrypt", "argon2id"} {
+		h, err := newPasswordHasher(name)
+		if err != nil || h.Name() != name {
+			t.Errorf("newPasswordHasher(%q) = %v, %v", name, h, err)
+		}
+	}
+	if _, err := newPasswordHasher("md5"); err == nil {
+		t.Error("unknown hasher accepted")
+	}
+}
+
+func TestLoginWithHashFromOtherAlgorithm(t *testing.T) {
+	argon := fastHashers[1]
+	hash, err := argon.Hash("secret123")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The service hashes new passwords with bcrypt, but this user was
+	// registered while argon2id was selected.
+	s, mock := newTestServer(t)
+	if s.hasher.Name() != "bcrypt" {
+		t.Fatalf("test server hashes with %s", s.hasher.Name())
+	}
+	row := func() *sqlmock.Rows {
+		return sqlmock.NewRows([]string{"id", "email", "username", "password", "locale"}).AddRow("u1", "u1@example.com", "", hash, "en")
+	}
+
+	mock.ExpectQuery(literal("FROM users WHERE email = $1")).WithArgs("u1@example.com").WillReturnRows(row())
+	mock.ExpectExec(literal("INSERT INTO refresh_tokens")).WillReturnResult(sqlmock.NewResult(0, 1))
+	res, err := s.Login(context.Background(), &userpb.LoginRequest{Identifier: "u1@example.com", Password: "secret123"})
+	if err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+	if res.Token == "" {
+		t.Error("no token issued")
+	}
+
+	mock.ExpectQuery(literal("FROM users WHERE email = $1")).WithArgs("u1@example.com").WillReturnRows(row())
+	_, err = s.Login(context.Background(), &userpb.LoginRequest{Identifier: "u1@example.com", Password: "secret124"})
+	wantCode(t, err, codes.Unauthenticated)
+}
+
+func TestBcryptParams(t *testing.T) {
+	hash, err := testHasher.Hash("secret123")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if params, err := (bcryptHasher{cost: bcrypt.DefaultCost}).Params(hash); err != nil || params != "cost=4" {
+		t.Errorf("Params = %q, %v, want the cost of the hash", params, err)
+	}
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"runtime"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// hashLimiter bounds how many password hashes are computed or verified at
+// once. bcrypt and argon2id are deliberately CPU-heavy, so a burst of
+// registrations or logins would otherwise starve every other RPC. Calls
+// beyond the limit wait for a slot for up to maxWait and then fail with
+// ResourceExhausted. A nil *hashLimiter does not limit anything.
+type hashLimiter struct {
+	slots   chan struct{}
+	maxWait time.Duration
+}
+
+// newHashLimiter reads PASSWORD_HASH_CONCURRENCY (default: the number of
+// CPUs; 0 disables the limit) and PASSWORD_HASH_MAX_WAIT (default 5s).
+func newHashLimiter() (*hashLimiter, error) {
+	limit, err := envInt("PASSWORD_HASH_CONCURRENCY", runtime.NumCPU())
+	if err != nil {
+		return nil, err
+	}
+	if limit <= 0 {
+		return nil, nil
+	}
+	maxWait := 5 * time.Second
+	if v := os.Getenv("PASSWORD_HASH_MAX_WAIT"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d < 0 {
+			return nil, fmt.Errorf("invalid PASSWORD_HASH_MAX_WAIT %q", v)
+		}
+		maxWait = d
+	}
+	return &hashLimiter{slots: make(chan struct{}, limit), maxWait: maxWait}, nil
+}
+
+// Limit returns the number of concurrent hash operations allowed, or 0 when
+// unlimited.
+func (l *hashLimiter) Limit() int {
+	if l == nil {
+		return 0
+	}
+	return cap(l.slots)
+}
+
+// acquire takes a slot, waiting up to maxWait. Every successful acquire must
+// be followed by release.
+func (l *hashLimiter) acquire(ctx context.Context) error {
+	if l == nil {
+		return nil
+	}
+	select {
+	case l.slots <- struct{}{}:
+		return nil
+	default:
+	}
+
+	timer := time.NewTimer(l.maxWait)
+	defer timer.Stop()
+	select {
+	case l.slots <- struct{}{}:
+		return nil
+	case <-timer.C:
+		return status.Error(codes.ResourceExhausted, "too many password operations in progress, try again later")
+	case <-ctx.Done():
+		return status.FromContextError(ctx.Err()).Err()
+	}
+}
+
+func (l *hashLimiter) release() {
+	if l != nil {
+		<-l.slots
+	}
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"google.golang.org/grpc/codes"
+
+	"user-ms/userpb"
+)
+
+// peakHasher is testHasher that records the most hashes it ever ran at once.
+type peakHasher struct {
+	PasswordHasher
+	inFlight, peak atomic.Int32
+}
+
+func (h *peakHasher) Hash(password string) (string, error) {
+	n := h.inFlight.Add(1)
+	defer h.inFlight.Add(-1)
+	for {
+		p := h.peak.Load()
+		if n <= p || h.peak.CompareAndSwap(p, n) {
+			break
+		}
+	}
+	// Long enough for hashes that are allowed to overlap to do so.
+	time.Sleep(5 * time.Millisecond)
+	return h.PasswordHasher.Hash(password)
+}
+
+func TestRegistrationFloodRespectsHashLimit(t *testing.T) {
+	const registrations, limit = 12, 2
+	s, mock := newTestServer(t)
+	withPublisher(t, s)
+	hasher := &peakHasher{PasswordHasher: testHasher}
+	s.hasher = hasher
+	s.hashSlots = &hashLimiter{slots: make(chan struct{}, limit), maxWait: 10 * time.Second}
+
+	mock.MatchExpectationsInOrder(false)
+	for range registrations {
+		mock.ExpectExec(literal("INSERT INTO users")).WillReturnResult(sqlmock.NewResult(0, 1))
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, registrations)
+	for i := range registrations {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := s.Register(context.Background(), &userpb.RegisterRequest{Email: fmt.Sprintf("user%d@example.com", i), Password: "secret123"})
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Errorf("Register: %v", err)
+		}
+	}
+	if peak := hasher.peak.Load(); peak > limit {
+		t.Errorf("%d hashes ran at once, limit %d", peak, limit)
+	} else if peak < limit {
+		t.Errorf("at most %d hash ran at once; the limit of %d was never used", peak, limit)
+	}
+}
+
+func TestHashLimiterRejectsAfterMaxWait(t *testing.T) {
+	l := &hashLimiter{slots: make(chan struct{}, 1), maxWait: 20 * time.Millisecond}
+	if err := l.acquire(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	wantCode(t, l.acquire(context.Background()), codes.ResourceExhausted)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	wantCode(t, l.acquire(ctx), codes.Canceled)
+
+	l.release()
+	if err := l.acquire(context.Background()); err != nil {
+		t.Errorf("after release: %v", err)
+	}
+}
+
+func TestHashLimiterConfig(t *testing.T) {
+	t.Setenv("PASSWORD_HASH_CONCURRENCY", "3")
+	t.Setenv("PASSWORD_HASH_MAX_WAIT", "250ms")
+	l, err := newHashLimiter()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if l.Limit() != 3 || l.maxWait != 250*time.Millisecond {
+		t.Errorf("limit %d, max wait %s", l.Limit(), l.maxWait)
+	}
+
+	t.Setenv("PASSWORD_HASH_CONCURRENCY", "0")
+	if l, err := newHashLimiter(); err != nil || l.Limit() != 0 {
+		t.Errorf("PASSWORD_HASH_CONCURRENCY=0: limit %d, err %v; want unlimited", l.Limit(), err)
+	}
+	t.Setenv("PASSWORD_HASH_CONCURRENCY", "")
+	t.Setenv("PASSWORD_HASH_MAX_WAIT", "-1s")
+	if _, err := newHashLimiter(); err == nil {
+		t.Error("negative PASSWORD_HASH_MAX_WAIT accepted")
+	}
+}
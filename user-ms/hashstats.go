@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"sort"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"user-ms/userpb"
+)
+
+// GetPasswordHashStats reports how many users' password hashes use each
+// algorithm and parameter set, and how many differ from the configured hasher
+// and would be upgraded on rehash. Only the counts leave this method.
+func (s *server) GetPasswordHashStats(ctx context.Context, req *userpb.GetPasswordHashStatsRequest) (*userpb.GetPasswordHashStatsResponse, error) {
+	currentParams, err := s.hasher.Params("")
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "could not describe configured hasher: %v", err)
+	}
+
+	rows, err := s.db.QueryContext(ctx, "SELECT password FROM users")
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "could not read password hashes: %v", err)
+	}
+	defer rows.Close()
+
+	type key struct{ algorithm, params string }
+	counts := make(map[key]int64)
+	for rows.Next() {
+		var hash string
+		if err := rows.Scan(&hash); err != nil {
+			return nil, status.Errorf(codes.Internal, "could not read password hashes: %v", err)
+		}
+		algorithm, params := describeHash(hash)
+		counts[key{algorithm, params}]++
+	}
+	if err := rows.Err(); err != nil {
+		return nil, status.Errorf(codes.Internal, "could not read password hashes: %v", err)
+	}
+
+	res := &userpb.GetPasswordHashStatsResponse{
+		CurrentAlgorithm: s.hasher.Name(),
+		CurrentParams:    currentParams,
+	}
+	for k, n := range counts {
+		current := k.algorithm == s.hasher.Name() && k.params == currentParams
+		if !current {
+			res.PendingUpgrade += n
+		}
+		res.Hashes = append(res.Hashes, &userpb.PasswordHashStats{
+			Algorithm: k.algorithm,
+			Params:    k.params,
+			Users:     n,
+			Current:   current,
+		})
+	}
+	sort.Slice(res.Hashes, func(i, j int) bool {
+		if res.Hashes[i].Algorithm != res.Hashes[j].Algorithm {
+			return res.Hashes[i].Algorithm < res.Hashes[j].Algorithm
+		}
+		return res.Hashes[i].Params < res.Hashes[j].Params
+	})
+	return res, nil
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"golang.org/x/crypto/bcrypt"
+
+	"user-ms/userpb"
+)
+
+func TestGetPasswordHashStats(t *testing.T) {
+	s, mock := newTestServer(t)
+	older, err := bcrypt.GenerateFromPassword([]byte("s3cretpass"), bcrypt.MinCost+1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mock.ExpectQuery(literal("SELECT password FROM users")).WillReturnRows(sqlmock.NewRows([]string{"password"}).
+		AddRow(hashOf(t, "s3cretpass")).
+		AddRow(hashOf(t, "0thersecret")).
+		AddRow(string(older)).
+		AddRow("not-a-hash"))
+
+	res, err := s.GetPasswordHashStats(context.Background(), &userpb.GetPasswordHashStatsRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.CurrentAlgorithm != "bcrypt" || res.CurrentParams != "cost=4" {
+		t.Errorf("current = %s %s, want bcrypt cost=4", res.CurrentAlgorithm, res.CurrentParams)
+	}
+	if res.PendingUpgrade != 2 {
+		t.Errorf("pending upgrade = %d, want 2", res.PendingUpgrade)
+	}
+	got := make(map[string]*userpb.PasswordHashStats)
+	for _, h := range res.Hashes {
+		got[h.Algorithm+" "+h.Params] = h
+	}
+	for key, want := range map[string]struct {
+		users   int64
+		current bool
+	}{
+		"bcrypt cost=4": {2, true},
+		"bcrypt cost=5": {1, false},
+		"unknown ":      {1, false},
+	} {
+		h := got[key]
+		if h == nil || h.Users != want.users || h.Current != want.current {
+			t.Errorf("%s: %+v, want %d users, current %v", key, h, want.users, want.current)
+		}
+	}
+	// Only algorithm names and parameters are reported, never hash material.
+	for _, h := range res.Hashes {
+		if len(h.Params) > 20 {
+			t.Errorf("params %q look like hash material", h.Params)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// healthCheckTimeout bounds a single Postgres ping.
+const healthCheckTimeout = 2 * time.Second
+
+// startHealthChecks registers the standard gRPC health service on s and keeps
+// it in step with the service's dependencies. Every HEALTH_CHECK_INTERVAL
+// (default 10s) Postgres is pinged and the NATS connection checked; while
+// either is down, both the overall status and service's status are
+// NOT_SERVING.
+func startHealthChecks(s *grpc.Server, service string, db *sql.DB, nc *nats.Conn) (*health.Server, error) {
+	interval := 10 * time.Second
+	if v := os.Getenv("HEALTH_CHECK_INTERVAL"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("invalid HEALTH_CHECK_INTERVAL %q", v)
+		}
+		interval = d
+	}
+
+	hs := health.NewServer()
+	healthpb.RegisterHealthServer(s, hs)
+	go func() {
+		var lastErr error
+		healthy := true
+		for {
+			err := checkDependencies(db, nc)
+			status := healthpb.HealthCheckResponse_SERVING
+			if err != nil {
+				status = healthpb.HealthCheckResponse_NOT_SERVING
+			}
+			hs.SetServingStatus("", status)
+			hs.SetServingStatus(service, status)
+
+			switch {
+			case err != nil && (healthy || err.Error() != lastErr.Error()):
+				slog.Warn("dependency check failed, reporting NOT_SERVING", "error", err)
+			case err == nil && !healthy:
+				slog.Info("dependencies recovered, reporting SERVING")
+			}
+			healthy, lastErr = err == nil, err
+			time.Sleep(interval)
+		}
+	}()
+	return hs, nil
+}
+
+// checkDependencies pings Postgres and checks that NATS is connected.
+func checkDependencies(db *sql.DB, nc *nats.Conn) error {
+	ctx, cancel := context.WithTimeout(context.Background(), healthCheckTimeout)
+	defer cancel()
+	if err := db.PingContext(ctx); err != nil {
+		return fmt.Errorf("postgres: %w", err)
+	}
+	if !nc.IsConnected() {
+		return fmt.Errorf("nats: %s", nc.Status())
+	}
+	return nil
+}
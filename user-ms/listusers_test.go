@@ -0,0 +1,48 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+
+	"user-ms/userpb"
+)
+
+const selectUserPage = "SELECT id, email, COALESCE(username, ''), locale FROM users WHERE id > $1 ORDER BY id LIMIT $2"
+
+func TestListUsersPagesByID(t *testing.T) {
+	s, mock := newTestServer(t)
+	columns := []string{"id", "email", "username", "locale"}
+
+	mock.ExpectQuery(literal(selectUserPage)).WithArgs("", 2).
+		WillReturnRows(sqlmock.NewRows(columns).AddRow("u1", "a@example.com", "alice", "en").AddRow("u2", "b@example.com", "", "de"))
+	res, err := s.ListUsers(context.Background(), &userpb.ListUsersRequest{Limit: 2})
+	if err != nil {
+		t.Fatalf("first page: %v", err)
+	}
+	if len(res.Users) != 2 || res.Users[1].Id != "u2" || res.Users[1].Locale != "de" {
+		t.Fatalf("first page = %v", res.Users)
+	}
+
+	mock.ExpectQuery(literal(selectUserPage)).WithArgs("u2", 2).
+		WillReturnRows(sqlmock.NewRows(columns).AddRow("u3", "c@example.com", "carol", "en"))
+	res, err = s.ListUsers(context.Background(), &userpb.ListUsersRequest{AfterId: "u2", Limit: 2})
+	if err != nil {
+		t.Fatalf("second page: %v", err)
+	}
+	if len(res.Users) != 1 || res.Users[0].Id != "u3" {
+		t.Errorf("second page = %v, want the last user only", res.Users)
+	}
+}
+
+func TestListUsersLimits(t *testing.T) {
+	s, mock := newTestServer(t)
+	for limit, want := range map[int32]int{0: defaultListUsersLimit, 5000: maxListUsersLimit} {
+		mock.ExpectQuery(literal(selectUserPage)).WithArgs("", want).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "email", "username", "locale"}))
+		if _, err := s.ListUsers(context.Background(), &userpb.ListUsersRequest{Limit: limit}); err != nil {
+			t.Errorf("limit %d: %v", limit, err)
+		}
+	}
+}
@@ -0,0 +1,21 @@
+package main
+
+import (
+	"context"
+
+	"google.golang.org/grpc/metadata"
+)
+
+// localeMetadataKey is the gRPC metadata key the gateway forwards the
+// caller's locale in, resolved from Accept-Language.
+const localeMetadataKey = "x-locale"
+
+// incomingLocale returns the caller's locale from the incoming metadata, or "".
+func incomingLocale(ctx context.Context) string {
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if v := md.Get(localeMetadataKey); len(v) > 0 {
+			return v[0]
+		}
+	}
+	return ""
+}
@@ -4,135 +4,450 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
-	"fmt"
-	"log"
+	"errors"
+	"log/slog"
 	"net"
+	"os"
 
+	"github.com/XSAM/otelsql"
 	"github.com/google/uuid"
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 	"github.com/nats-io/nats.go"
-	"golang.org/x/crypto/bcrypt"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 
 	"user-ms/userpb"
 )
 
 type server struct {
 	userpb.UnimplementedUserServiceServer
-	db *sql.DB
-	nc *nats.Conn
+	logger *slog.Logger
+	db     *sql.DB
+	events *publisher
+	hasher PasswordHasher
+	// hashSlots bounds concurrent password hashing and verification.
+	hashSlots *hashLimiter
 }
 
 type UserCreatedEvent struct {
+	EventID  string `json:"event_id"`
 	UID      string `json:"uid"`
 	Username string `json:"username"`
+	Locale   string `json:"locale"`
 }
 
+// defaultLocale is stored for users who register without a locale and
+// without an Accept-Language the gateway could resolve.
+const defaultLocale = "en"
+
 func (s *server) Register(ctx context.Context, req *userpb.RegisterRequest) (*userpb.RegisterResponse, error) {
-	if req.Email == "" || req.Password == "" {
-		log.Println("email or password not present")
-		return nil, fmt.Errorf("bad input")
+	if err := validateRegistration(req); err != nil {
+		s.logger.WarnContext(ctx, "register rejected", "reason", status.Convert(err).Message())
+		return nil, err
+	}
+
+	if req.Idempotent {
+		if res, err := s.existingRegistration(ctx, req); res != nil || err != nil {
+			return res, err
+		}
 	}
 
 	// Hash the password
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	if err := s.hashSlots.acquire(ctx); err != nil {
+		return nil, err
+	}
+	hashedPassword, err := s.hasher.Hash(req.Password)
+	s.hashSlots.release()
 	if err != nil {
-		log.Printf("failed to hash password: %v", err)
-		return nil, fmt.Errorf("internal server error")
+		s.logger.ErrorContext(ctx, "failed to hash password", "error", err)
+		return nil, status.Error(codes.Internal, "internal server error")
 	}
 
 	userID := uuid.New().String()
+	// An explicit locale wins over the one the gateway resolved from the
+	// client's Accept-Language.
+	locale := req.Locale
+	if locale == "" {
+		locale = incomingLocale(ctx)
+	}
+	if locale == "" {
+		locale = defaultLocale
+	}
 
 	// Store the hashed password (as a string) in the database
-	_, err = s.db.Exec("INSERT INTO users (id, email, password) VALUES ($1, $2, $3)", userID, req.Email, string(hashedPassword))
+	_, err = s.db.ExecContext(ctx, "INSERT INTO users (id, email, password, locale, username) VALUES ($1, $2, $3, $4, NULLIF($5, ''))", userID, req.Email, hashedPassword, locale, req.Username)
+	if isUsernameConflict(err) {
+		return nil, errUsernameTaken
+	}
+	if isEmailConflict(err) {
+		return nil, errEmailTaken
+	}
 	if err != nil {
-		return nil, fmt.Errorf("could not register user: %v", err)
+		s.logger.ErrorContext(ctx, "failed to register user", "error", err)
+		return nil, status.Error(codes.Internal, "internal server error")
 	}
 
 	eventMsg := &UserCreatedEvent{
+		EventID:  uuid.NewString(),
 		UID:      userID,
 		Username: req.Email,
+		Locale:   locale,
 	}
 
 	bytes, err := json.Marshal(eventMsg)
 	if err != nil {
-		log.Println("marshalling error")
-		return nil, fmt.Errorf("internal server")
+		s.logger.ErrorContext(ctx, "failed to marshal user.created event", "user_id", userID, "error", err)
+		return nil, status.Error(codes.Internal, "internal server error")
 	}
 
-	// Publish message to NATS
-	s.nc.Publish("user.created", bytes)
+	// Publish message to NATS; billing accounts depend on it, so it is confirmed by default
+	if err := s.events.Publish(ctx, "user.created", bytes); err != nil {
+		s.logger.ErrorContext(ctx, "failed to publish user.created", "user_id", userID, "error", err)
+		return nil, status.Error(codes.Unavailable, "user registered but the user.created event was not confirmed")
+	}
 
 	return &userpb.RegisterResponse{UserId: userID}, nil
 }
 
-func (s *server) Login(ctx context.Context, req *userpb.LoginRequest) (*userpb.LoginResponse, error) {
+var errEmailTaken = status.Error(codes.AlreadyExists, "email already registered")
+
+// isEmailConflict reports whether err is a violation of the unique email
+// constraint.
+func isEmailConflict(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505" && pqErr.Constraint == "users_email_key"
+}
+
+// existingRegistration handles an idempotent retry of Register. It returns
+// the existing user when the email is registered with the same password,
+// AlreadyExists when the password differs, and nil, nil when the email is
+// not registered yet.
+func (s *server) existingRegistration(ctx context.Context, req *userpb.RegisterRequest) (*userpb.RegisterResponse, error) {
 	var uid, hashedPassword string
+	err := s.db.QueryRowContext(ctx, "SELECT id, password FROM users WHERE email = $1", req.Email).Scan(&uid, &hashedPassword)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		s.logger.ErrorContext(ctx, "failed to query user", "error", err)
+		return nil, status.Error(codes.Internal, "internal server error")
+	}
+
+	if err := s.hashSlots.acquire(ctx); err != nil {
+		return nil, err
+	}
+	err = verifyPassword(hashedPassword, req.Password)
+	s.hashSlots.release()
+	if err != nil {
+		if err != errPasswordMismatch {
+			s.logger.ErrorContext(ctx, "failed to verify password", "user_id", uid, "error", err)
+		}
+		return nil, errEmailTaken
+	}
+	return &userpb.RegisterResponse{UserId: uid, AlreadyExisted: true}, nil
+}
+
+func (s *server) Login(ctx context.Context, req *userpb.LoginRequest) (*userpb.LoginResponse, error) {
+	var uid, email, username, hashedPassword, locale string
+
+	// Older clients only send email. Usernames cannot contain "@", so the
+	// identifier's shape decides which column to match.
+	identifier := req.Identifier
+	if identifier == "" {
+		identifier = req.Email
+	}
+	query := "SELECT id, email, COALESCE(username, ''), password, locale FROM users WHERE email = $1"
+	if !isEmailIdentifier(identifier) {
+		query = "SELECT id, email, COALESCE(username, ''), password, locale FROM users WHERE lower(username) = lower($1)"
+	}
 
 	// Retrieve user from the database
-	err := s.db.QueryRow("SELECT id, password FROM users WHERE email = $1", req.Email).Scan(&uid, &hashedPassword)
+	err := s.db.QueryRowContext(ctx, query, identifier).Scan(&uid, &email, &username, &hashedPassword, &locale)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("invalid credentials")
+			return nil, status.Error(codes.Unauthenticated, "invalid credentials")
 		}
-		log.Printf("failed to query user: %v", err)
-		return nil, fmt.Errorf("internal server error")
+		s.logger.ErrorContext(ctx, "failed to query user", "error", err)
+		return nil, status.Error(codes.Internal, "internal server error")
 	}
 
-	// Compare the password with the hash
-	err = bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(req.Password))
+	// Compare the password with the hash, using the algorithm the hash was made with
+	if err := s.hashSlots.acquire(ctx); err != nil {
+		return nil, err
+	}
+	err = verifyPassword(hashedPassword, req.Password)
+	s.hashSlots.release()
 	if err != nil {
-		// Passwords don't match
-		return nil, fmt.Errorf("invalid credentials")
+		if err != errPasswordMismatch {
+			s.logger.ErrorContext(ctx, "failed to verify password", "user_id", uid, "error", err)
+		}
+		return nil, status.Error(codes.Unauthenticated, "invalid credentials")
 	}
 
 	// --- Login successful, create response ---
-	// (In a real app, you would generate a JWT token here)
-	token := "sample-jwt-token-for-" + uid
+	token, err := generateToken(uid, email)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "failed to sign token", "user_id", uid, "error", err)
+		return nil, status.Error(codes.Internal, "internal server error")
+	}
+	refreshToken, err := issueRefreshToken(ctx, s.db, uid)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "failed to store refresh token", "user_id", uid, "error", err)
+		return nil, status.Error(codes.Internal, "internal server error")
+	}
 
 	user := &userpb.User{
-		Id:    uid,
-		Email: req.Email,
+		Id:       uid,
+		Email:    email,
+		Username: username,
+		Locale:   locale,
 	}
 
-	log.Println("user built")
+	s.logger.InfoContext(ctx, "user logged in", "user_id", uid)
 
 	return &userpb.LoginResponse{
-		Token: token,
-		User:  user,
+		Token:        token,
+		User:         user,
+		RefreshToken: refreshToken,
 	}, nil
 }
 
+// GetUser returns the profile of req.UserId.
+func (s *server) GetUser(ctx context.Context, req *userpb.GetUserRequest) (*userpb.GetUserResponse, error) {
+	user := &userpb.User{Id: req.UserId}
+	err := s.db.QueryRowContext(ctx, "SELECT email, COALESCE(username, ''), locale FROM users WHERE id = $1", req.UserId).Scan(&user.Email, &user.Username, &user.Locale)
+	if err == sql.ErrNoRows {
+		return nil, status.Error(codes.NotFound, "user not found")
+	}
+	if err != nil {
+		s.logger.ErrorContext(ctx, "failed to query user", "user_id", req.UserId, "error", err)
+		return nil, status.Error(codes.Internal, "internal server error")
+	}
+	return &userpb.GetUserResponse{User: user}, nil
+}
+
+const (
+	defaultListUsersLimit = 100
+	maxListUsersLimit     = 1000
+)
+
+// ListUsers returns the page of users after req.AfterId, ordered by id.
+func (s *server) ListUsers(ctx context.Context, req *userpb.ListUsersRequest) (*userpb.ListUsersResponse, error) {
+	limit := int(req.Limit)
+	if limit <= 0 {
+		limit = defaultListUsersLimit
+	}
+	limit = min(limit, maxListUsersLimit)
+
+	rows, err := s.db.QueryContext(ctx, "SELECT id, email, COALESCE(username, ''), locale FROM users WHERE id > $1 ORDER BY id LIMIT $2", req.AfterId, limit)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "failed to list users", "after_id", req.AfterId, "error", err)
+		return nil, status.Error(codes.Internal, "internal server error")
+	}
+	defer rows.Close()
+
+	res := &userpb.ListUsersResponse{}
+	for rows.Next() {
+		user := &userpb.User{}
+		if err := rows.Scan(&user.Id, &user.Email, &user.Username, &user.Locale); err != nil {
+			s.logger.ErrorContext(ctx, "failed to list users", "after_id", req.AfterId, "error", err)
+			return nil, status.Error(codes.Internal, "internal server error")
+		}
+		res.Users = append(res.Users, user)
+	}
+	if err := rows.Err(); err != nil {
+		s.logger.ErrorContext(ctx, "failed to list users", "after_id", req.AfterId, "error", err)
+		return nil, status.Error(codes.Internal, "internal server error")
+	}
+	return res, nil
+}
+
+func (s *server) Stats(ctx context.Context, req *userpb.StatsRequest) (*userpb.StatsResponse, error) {
+	var total int64
+	if err := s.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM users").Scan(&total); err != nil {
+		s.logger.ErrorContext(ctx, "failed to count users", "error", err)
+		return nil, status.Error(codes.Internal, "internal server error")
+	}
+	return &userpb.StatsResponse{TotalUsers: total, NatsBufferedBytes: int64(s.events.Buffered())}, nil
+}
+
 func main() {
+	// The level can be changed at runtime by a SIGHUP reload.
+	logLevel := new(slog.LevelVar)
+	logger := slog.New(requestIDHandler{slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: logLevel})})
+	slog.SetDefault(logger)
+
+	// CONFIG_FILE settings override the environment; see reload.go.
+	if err := loadConfigFile(); err != nil {
+		logger.Error("failed to load config file", "error", err)
+		os.Exit(1)
+	}
+	logLevel.Set(loadLogLevel(logger))
+	go watchReload(logger, logLevel)
+
+	shutdownTracing, err := setupTracing(context.Background(), "user-ms")
+	if err != nil {
+		logger.Error("failed to set up tracing", "error", err)
+		os.Exit(1)
+	}
+	defer shutdownTracing(context.Background())
+
 	// Database connection
 	connStr := "user=postgres password=postgres dbname=userdb sslmode=disable host=postgres"
-	db, err := sql.Open("postgres", connStr)
+	// Queries are traced as children of the RPC that runs them.
+	db, err := otelsql.Open("postgres", connStr)
 	if err != nil {
-		log.Fatalf("failed to connect to database: %v", err)
+		logger.Error("failed to connect to database", "error", err)
+		os.Exit(1)
 	}
 	defer db.Close()
 
 	// NATS connection
-	nc, err := nats.Connect("nats:4222")
+	bufOpt, err := reconnectBufferOption()
 	if err != nil {
-		log.Fatalf("failed to connect to nats: %v", err)
+		logger.Error("failed to configure nats", "error", err)
+		os.Exit(1)
+	}
+	nc, err := nats.Connect("nats:4222", bufOpt)
+	if err != nil {
+		logger.Error("failed to connect to nats", "error", err)
+		os.Exit(1)
 	}
 	defer nc.Close()
+	js, err := ensureEventStream(nc)
+	if err != nil {
+		logger.Error("failed to set up event stream", "error", err)
+		os.Exit(1)
+	}
 
 	// Create table if not exists
-	_, err = db.Exec(`CREATE TABLE IF NOT EXISTS users (id TEXT PRIMARY KEY, email TEXT, password TEXT)`)
+	_, err = db.Exec(`CREATE TABLE IF NOT EXISTS users (id TEXT PRIMARY KEY, email TEXT UNIQUE, password TEXT)`)
+	if err != nil {
+		logger.Error("failed to create table", "error", err)
+		os.Exit(1)
+	}
+	// Tables created before email was unique get the same index the UNIQUE
+	// constraint creates; this fails if duplicate emails are already stored.
+	_, err = db.Exec(`CREATE UNIQUE INDEX IF NOT EXISTS users_email_key ON users (email)`)
+	if err != nil {
+		logger.Error("failed to migrate table", "error", err)
+		os.Exit(1)
+	}
+	_, err = db.Exec(`ALTER TABLE users ADD COLUMN IF NOT EXISTS locale TEXT NOT NULL DEFAULT 'en'`)
+	if err != nil {
+		logger.Error("failed to migrate table", "error", err)
+		os.Exit(1)
+	}
+	_, err = db.Exec(`ALTER TABLE users ADD COLUMN IF NOT EXISTS username TEXT`)
+	if err != nil {
+		logger.Error("failed to migrate table", "error", err)
+		os.Exit(1)
+	}
+	_, err = db.Exec(`CREATE UNIQUE INDEX IF NOT EXISTS users_username_idx ON users (lower(username))`)
 	if err != nil {
-		log.Fatalf("failed to create table: %v", err)
+		logger.Error("failed to migrate table", "error", err)
+		os.Exit(1)
+	}
+	// Logged-out tokens, kept until they would have expired.
+	_, err = db.Exec(`CREATE TABLE IF NOT EXISTS revoked_tokens (jti TEXT PRIMARY KEY, expires_at TIMESTAMPTZ NOT NULL)`)
+	if err != nil {
+		logger.Error("failed to create table", "error", err)
+		os.Exit(1)
+	}
+	// Unused refresh tokens, stored as SHA-256 hashes.
+	_, err = db.Exec(`CREATE TABLE IF NOT EXISTS refresh_tokens (token_hash TEXT PRIMARY KEY, user_id TEXT NOT NULL, expires_at TIMESTAMPTZ NOT NULL)`)
+	if err != nil {
+		logger.Error("failed to create table", "error", err)
+		os.Exit(1)
+	}
+
+	if err := loadTokenConfig(); err != nil {
+		logger.Error("failed to configure tokens", "error", err)
+		os.Exit(1)
+	}
+	if err := loadPasswordPolicy(); err != nil {
+		logger.Error("failed to configure password policy", "error", err)
+		os.Exit(1)
+	}
+
+	// Password hashing algorithm for new hashes; existing hashes verify with their own.
+	hasherName := os.Getenv("PASSWORD_HASHER")
+	if hasherName == "" {
+		hasherName = "bcrypt"
+	}
+	hasher, err := newPasswordHasher(hasherName)
+	if err != nil {
+		logger.Error("failed to configure password hasher", "error", err)
+		os.Exit(1)
 	}
 
 	lis, err := net.Listen("tcp", ":50051")
 	if err != nil {
-		log.Fatalf("failed to listen: %v", err)
+		logger.Error("failed to listen", "error", err)
+		os.Exit(1)
+	}
+	opts, err := tlsServerOptions()
+	if err != nil {
+		logger.Error("failed to configure TLS", "error", err)
+		os.Exit(1)
+	}
+	useTLS := len(opts) > 0
+	// Handlers log the gateway's request id with every line, and every RPC
+	// continues the caller's trace.
+	opts = append(opts,
+		grpc.ChainUnaryInterceptor(requestIDUnaryInterceptor),
+		grpc.StatsHandler(otelgrpc.NewServerHandler()),
+	)
+	// Bound concurrent work on the database-heavy RPCs.
+	limiter, err := newConcurrencyLimiter(
+		userpb.UserService_Register_FullMethodName,
+		userpb.UserService_Login_FullMethodName,
+		userpb.UserService_SetUsername_FullMethodName,
+		userpb.UserService_GetPasswordHashStats_FullMethodName,
+		userpb.UserService_ListUsers_FullMethodName,
+	)
+	if err != nil {
+		logger.Error("failed to configure concurrency limit", "error", err)
+		os.Exit(1)
+	}
+	if limiter != nil {
+		opts = append(opts, grpc.ChainUnaryInterceptor(limiter.UnaryInterceptor))
+	}
+	s := grpc.NewServer(opts...)
+	events, err := newPublisher(nc, js, "user.created")
+	if err != nil {
+		logger.Error("failed to configure event publishing", "error", err)
+		os.Exit(1)
+	}
+	hashSlots, err := newHashLimiter()
+	if err != nil {
+		logger.Error("failed to configure password hashing limit", "error", err)
+		os.Exit(1)
+	}
+	userpb.RegisterUserServiceServer(s, &server{logger: logger, db: db, events: events, hasher: hasher, hashSlots: hashSlots})
+	// Probes use the standard gRPC health service, which tracks Postgres and NATS.
+	if _, err := startHealthChecks(s, userpb.UserService_ServiceDesc.ServiceName, db, nc); err != nil {
+		logger.Error("failed to configure health checks", "error", err)
+		os.Exit(1)
+	}
+	// Profiling endpoints on an internal listener, disabled unless DEBUG_PPROF is set.
+	debugAddr, err := startDebugServer()
+	if err != nil {
+		logger.Error("failed to start debug server", "error", err)
+		os.Exit(1)
 	}
-	s := grpc.NewServer()
-	userpb.RegisterUserServiceServer(s, &server{db: db, nc: nc})
-	log.Printf("server listening at %v", lis.Addr())
+	logStartup(logger, lis.Addr().String(), connStr, nc.ConnectedUrlRedacted(), map[string]any{
+		"password_hasher":  hasher.Name(),
+		"hash_concurrency": hashSlots.Limit(),
+		"confirmed_events": events.Confirmed(),
+		"token_ttl":        tokenTTL.String(),
+		"tls":              useTLS,
+		"debug_addr":       debugAddr,
+	})
 	if err := s.Serve(lis); err != nil {
-		log.Fatalf("failed to serve: %v", err)
+		logger.Error("failed to serve", "error", err)
+		os.Exit(1)
 	}
 }
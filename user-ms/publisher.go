@@ -0,0 +1,164 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+// defaultConfirmTimeout bounds how long a confirmed publish waits for the
+// broker when EVENT_CONFIRM_TIMEOUT is not set.
+const defaultConfirmTimeout = 2 * time.Second
+
+// publisher publishes events either fire-and-forget or confirmed. A confirmed
+// publish flushes the connection and waits for the server's round trip, so a
+// nil error means the broker has accepted the message. Subjects in the events
+// stream always go through JetStream and wait for the stream to store them.
+//
+// While NATS is reconnecting, publishes are buffered in memory up to the
+// connection's reconnect buffer. Once it is full a publish fails immediately,
+// or with blockOnFull waits up to blockTimeout for room.
+type publisher struct {
+	nc      *nats.Conn
+	js      nats.JetStreamContext
+	confirm map[string]bool
+	timeout time.Duration
+
+	blockOnFull  bool
+	blockTimeout time.Duration
+}
+
+// defaultReconnectBuffer matches the nats.go default of 8MB.
+const defaultReconnectBuffer = 8 * 1024 * 1024
+
+// reconnectBufferOption sizes the buffer that holds publishes while NATS is
+// reconnecting from NATS_RECONNECT_BUFFER (bytes).
+func reconnectBufferOption() (nats.Option, error) {
+	size := defaultReconnectBuffer
+	if v := os.Getenv("NATS_RECONNECT_BUFFER"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid NATS_RECONNECT_BUFFER %q: %w", v, err)
+		}
+		size = n
+	}
+	return nats.ReconnectBufSize(size), nil
+}
+
+// newPublisher confirms the subjects listed in EVENT_CONFIRM_SUBJECTS
+// (comma-separated), or defaultConfirmed when it is unset; "none" confirms
+// nothing. EVENT_CONFIRM_TIMEOUT sets how long to wait for the broker.
+func newPublisher(nc *nats.Conn, js nats.JetStreamContext, defaultConfirmed ...string) (*publisher, error) {
+	p := &publisher{nc: nc, js: js, confirm: make(map[string]bool), timeout: defaultConfirmTimeout}
+
+	subjects := defaultConfirmed
+	if v, ok := os.LookupEnv("EVENT_CONFIRM_SUBJECTS"); ok {
+		subjects = strings.Split(v, ",")
+	}
+	for _, subject := range subjects {
+		if subject = strings.TrimSpace(subject); subject != "" && subject != "none" {
+			p.confirm[subject] = true
+		}
+	}
+
+	if v := os.Getenv("EVENT_CONFIRM_TIMEOUT"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid EVENT_CONFIRM_TIMEOUT %q: %w", v, err)
+		}
+		p.timeout = d
+	}
+
+	// NATS_BUFFER_FULL is "fail" (default) or "block"; blocking waits up to
+	// NATS_BUFFER_BLOCK_TIMEOUT for the reconnect buffer to drain.
+	switch mode := os.Getenv("NATS_BUFFER_FULL"); mode {
+	case "", "fail":
+	case "block":
+		p.blockOnFull = true
+	default:
+		return nil, fmt.Errorf("invalid NATS_BUFFER_FULL %q, expected fail or block", mode)
+	}
+	p.blockTimeout = defaultConfirmTimeout
+	if v := os.Getenv("NATS_BUFFER_BLOCK_TIMEOUT"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid NATS_BUFFER_BLOCK_TIMEOUT %q: %w", v, err)
+		}
+		p.blockTimeout = d
+	}
+	return p, nil
+}
+
+// Publish sends data on subject, waiting for the broker when the subject is
+// configured for confirmation. The request id in ctx, if any, is sent in the
+// X-Request-ID header so consumers can log it, along with the trace context.
+func (p *publisher) Publish(ctx context.Context, subject string, data []byte) error {
+	msg := nats.NewMsg(subject)
+	msg.Data = data
+	if id := requestIDFrom(ctx); id != "" {
+		msg.Header.Set(requestIDHeader, id)
+	}
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(msg.Header))
+
+	if isStreamSubject(subject) {
+		// The stream's ack is the confirmation; there is no reconnect buffer
+		// to fall back on, so this fails while NATS is unreachable.
+		if _, err := p.js.PublishMsg(msg, nats.AckWait(p.timeout)); err != nil {
+			return fmt.Errorf("publish to %s not stored: %w", subject, err)
+		}
+		return nil
+	}
+	if err := p.publish(msg); err != nil {
+		return err
+	}
+	if !p.confirm[subject] {
+		return nil
+	}
+	if err := p.nc.FlushTimeout(p.timeout); err != nil {
+		return fmt.Errorf("publish to %s not confirmed: %w", subject, err)
+	}
+	return nil
+}
+
+// publish hands msg to the connection, applying the buffer-full policy.
+func (p *publisher) publish(msg *nats.Msg) error {
+	err := p.nc.PublishMsg(msg)
+	if !errors.Is(err, nats.ErrReconnectBufExceeded) || !p.blockOnFull {
+		return err
+	}
+	deadline := time.Now().Add(p.blockTimeout)
+	for time.Now().Before(deadline) {
+		time.Sleep(50 * time.Millisecond)
+		if err = p.nc.PublishMsg(msg); !errors.Is(err, nats.ErrReconnectBufExceeded) {
+			return err
+		}
+	}
+	return fmt.Errorf("publish to %s: %w after waiting %s", msg.Subject, err, p.blockTimeout)
+}
+
+// Buffered returns how many bytes of published data are waiting to be sent,
+// e.g. while NATS is reconnecting.
+func (p *publisher) Buffered() int {
+	n, err := p.nc.Buffered()
+	if err != nil {
+		return 0
+	}
+	return n
+}
+
+// Confirmed returns the subjects published with confirmation.
+func (p *publisher) Confirmed() []string {
+	subjects := make([]string, 0, len(p.confirm))
+	for subject := range p.confirm {
+		subjects = append(subjects, subject)
+	}
+	return subjects
+}
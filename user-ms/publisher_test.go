@@ -0,0 +1,34 @@
+package main
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+func TestPublisherConfirmSettings(t *testing.T) {
+	nc := &nats.Conn{}
+	p, err := newPublisher(nc, nil, "user.created")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := p.Confirmed(); !slices.Equal(got, []string{"user.created"}) || p.timeout != defaultConfirmTimeout {
+		t.Errorf("defaults: confirmed %v within %s", got, p.timeout)
+	}
+
+	t.Setenv("EVENT_CONFIRM_SUBJECTS", "none")
+	t.Setenv("EVENT_CONFIRM_TIMEOUT", "500ms")
+	if p, err = newPublisher(nc, nil, "user.created"); err != nil {
+		t.Fatal(err)
+	}
+	if got := p.Confirmed(); len(got) != 0 || p.timeout != 500*time.Millisecond {
+		t.Errorf("none: confirmed %v within %s", got, p.timeout)
+	}
+
+	t.Setenv("EVENT_CONFIRM_TIMEOUT", "soon")
+	if _, err := newPublisher(nc, nil); err == nil {
+		t.Error("invalid EVENT_CONFIRM_TIMEOUT accepted")
+	}
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"user-ms/userpb"
+)
+
+// execer is satisfied by both *sql.DB and *sql.Tx.
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+// hashRefreshToken returns the form a refresh token is stored in, so a leaked
+// table does not hand out working tokens.
+func hashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// issueRefreshToken creates and stores a new refresh token for the user,
+// valid for refreshTokenTTL.
+func issueRefreshToken(ctx context.Context, db execer, userID string) (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	token := base64.RawURLEncoding.EncodeToString(b)
+	_, err := db.ExecContext(ctx, "INSERT INTO refresh_tokens (token_hash, user_id, expires_at) VALUES ($1, $2, $3)",
+		hashRefreshToken(token), userID, tokenClock.Now().Add(refreshTokenTTL))
+	if err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// Refresh rotates a refresh token: the presented token is deleted and the
+// caller gets a new access token and refresh token. Deleting it in the same
+// transaction that stores its replacement means each token is used at most
+// once, even by concurrent calls.
+func (s *server) Refresh(ctx context.Context, req *userpb.RefreshRequest) (*userpb.LoginResponse, error) {
+	if req.RefreshToken == "" {
+		return nil, status.Error(codes.Unauthenticated, "invalid refresh token")
+	}
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "failed to begin transaction", "error", err)
+		return nil, status.Error(codes.Internal, "internal server error")
+	}
+	defer tx.Rollback()
+
+	var userID string
+	var expiresAt time.Time
+	err = tx.QueryRowContext(ctx, "DELETE FROM refresh_tokens WHERE token_hash = $1 RETURNING user_id, expires_at", hashRefreshToken(req.RefreshToken)).Scan(&userID, &expiresAt)
+	if err == sql.ErrNoRows {
+		return nil, status.Error(codes.Unauthenticated, "invalid refresh token")
+	}
+	if err != nil {
+		s.logger.ErrorContext(ctx, "failed to look up refresh token", "error", err)
+		return nil, status.Error(codes.Internal, "internal server error")
+	}
+	if !tokenClock.Now().Before(expiresAt) {
+		// Keep the deletion: the token is of no further use.
+		if err := tx.Commit(); err != nil {
+			s.logger.WarnContext(ctx, "failed to delete expired refresh token", "user_id", userID, "error", err)
+		}
+		return nil, status.Error(codes.Unauthenticated, "invalid refresh token: expired")
+	}
+
+	user := &userpb.User{Id: userID}
+	err = tx.QueryRowContext(ctx, "SELECT email, COALESCE(username, ''), locale FROM users WHERE id = $1", userID).Scan(&user.Email, &user.Username, &user.Locale)
+	if err == sql.ErrNoRows {
+		return nil, status.Error(codes.Unauthenticated, "invalid refresh token: user no longer exists")
+	}
+	if err != nil {
+		s.logger.ErrorContext(ctx, "failed to query user", "user_id", userID, "error", err)
+		return nil, status.Error(codes.Internal, "internal server error")
+	}
+
+	refreshToken, err := issueRefreshToken(ctx, tx, userID)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "failed to store refresh token", "user_id", userID, "error", err)
+		return nil, status.Error(codes.Internal, "internal server error")
+	}
+	token, err := generateToken(userID, user.Email)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "failed to sign token", "user_id", userID, "error", err)
+		return nil, status.Error(codes.Internal, "internal server error")
+	}
+	if err := tx.Commit(); err != nil {
+		s.logger.ErrorContext(ctx, "failed to rotate refresh token", "user_id", userID, "error", err)
+		return nil, status.Error(codes.Internal, "internal server error")
+	}
+
+	s.logger.InfoContext(ctx, "access token refreshed", "user_id", userID)
+	return &userpb.LoginResponse{Token: token, User: user, RefreshToken: refreshToken}, nil
+}
+
+// revokeRefreshToken deletes a refresh token belonging to userID. It reports
+// whether there was one to delete.
+func (s *server) revokeRefreshToken(ctx context.Context, userID, token string) (bool, error) {
+	res, err := s.db.ExecContext(ctx, "DELETE FROM refresh_tokens WHERE token_hash = $1 AND user_id = $2", hashRefreshToken(token), userID)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"user-ms/userpb"
+)
+
+const selectByEmail = "SELECT id, password FROM users WHERE email = $1"
+
+func TestIdempotentRegisterReturnsExistingUser(t *testing.T) {
+	s, mock := newTestServer(t)
+	mock.ExpectQuery(literal(selectByEmail)).WithArgs("alice@example.com").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "password"}).AddRow("u1", hashOf(t, "secret123")))
+
+	// No insert is expected: the existing account is returned as is.
+	res, err := s.Register(context.Background(), &userpb.RegisterRequest{Email: "alice@example.com", Password: "secret123", Idempotent: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.UserId != "u1" || !res.AlreadyExisted {
+		t.Errorf("Register = %+v, want existing user u1", res)
+	}
+}
+
+func TestIdempotentRegisterRejectsOtherPassword(t *testing.T) {
+	s, mock := newTestServer(t)
+	mock.ExpectQuery(literal(selectByEmail)).WithArgs("alice@example.com").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "password"}).AddRow("u1", hashOf(t, "secret123")))
+
+	_, err := s.Register(context.Background(), &userpb.RegisterRequest{Email: "alice@example.com", Password: "other1234", Idempotent: true})
+	wantCode(t, err, codes.AlreadyExists)
+}
+
+func TestRegisterDuplicateEmail(t *testing.T) {
+	s, mock := newTestServer(t)
+	mock.ExpectExec(literal("INSERT INTO users")).
+		WithArgs(sqlmock.AnyArg(), "alice@example.com", sqlmock.AnyArg(), sqlmock.AnyArg(), "").
+		WillReturnError(&pq.Error{Code: "23505", Constraint: "users_email_key", Message: `duplicate key value violates unique constraint "users_email_key"`})
+
+	_, err := s.Register(context.Background(), &userpb.RegisterRequest{Email: "alice@example.com", Password: "secret123"})
+	wantCode(t, err, codes.AlreadyExists)
+	if msg := status.Convert(err).Message(); msg != "email already registered" {
+		t.Errorf("message %q leaks the database error", msg)
+	}
+}
+
+func TestRegisterInsertErrorDoesNotLeakSQL(t *testing.T) {
+	s, mock := newTestServer(t)
+	mock.ExpectExec(literal("INSERT INTO users")).
+		WillReturnError(errors.New(`pq: column "username" of relation "users" does not exist`))
+
+	_, err := s.Register(context.Background(), &userpb.RegisterRequest{Email: "alice@example.com", Password: "secret123"})
+	wantCode(t, err, codes.Internal)
+	if msg := status.Convert(err).Message(); strings.Contains(msg, "pq:") || strings.Contains(msg, "username") {
+		t.Errorf("error message leaks the database error: %q", msg)
+	}
+}
+
+func TestRegisterStoresForwardedLocale(t *testing.T) {
+	s, mock := newTestServer(t)
+	withPublisher(t, s)
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(localeMetadataKey, "fr"))
+
+	// The locale forwarded by the gateway is stored when the request has none.
+	mock.ExpectExec(literal("INSERT INTO users")).
+		WithArgs(sqlmock.AnyArg(), "alice@example.com", sqlmock.AnyArg(), "fr", "").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	if _, err := s.Register(ctx, &userpb.RegisterRequest{Email: "alice@example.com", Password: "secret123"}); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+
+	// An explicit locale wins over it.
+	mock.ExpectExec(literal("INSERT INTO users")).
+		WithArgs(sqlmock.AnyArg(), "bob@example.com", sqlmock.AnyArg(), "de", "").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	if _, err := s.Register(ctx, &userpb.RegisterRequest{Email: "bob@example.com", Password: "secret123", Locale: "de"}); err != nil {
+		t.Fatalf("Register with locale: %v", err)
+	}
+
+	// Without either, the default is stored.
+	mock.ExpectExec(literal("INSERT INTO users")).
+		WithArgs(sqlmock.AnyArg(), "carol@example.com", sqlmock.AnyArg(), defaultLocale, "").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	if _, err := s.Register(context.Background(), &userpb.RegisterRequest{Email: "carol@example.com", Password: "secret123"}); err != nil {
+		t.Fatalf("Register without locale: %v", err)
+	}
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// requestIDMetadataKey is the gRPC metadata key the gateway forwards the HTTP
+// request id in, and requestIDHeader the NATS header events carry it in.
+const (
+	requestIDMetadataKey = "x-request-id"
+	requestIDHeader      = "X-Request-ID"
+)
+
+type requestIDKey struct{}
+
+// withRequestID returns a copy of ctx carrying id; an empty id leaves ctx as is.
+func withRequestID(ctx context.Context, id string) context.Context {
+	if id == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// requestIDFrom returns the request id in ctx, or "".
+func requestIDFrom(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// incomingRequestID moves the request id from the incoming metadata into ctx.
+func incomingRequestID(ctx context.Context) context.Context {
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if v := md.Get(requestIDMetadataKey); len(v) > 0 {
+			return withRequestID(ctx, v[0])
+		}
+	}
+	return ctx
+}
+
+// requestIDUnaryInterceptor makes the caller's request id available to the
+// handler and its log lines.
+func requestIDUnaryInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	return handler(incomingRequestID(ctx), req)
+}
+
+// requestIDHandler adds a request_id attribute to every record logged with a
+// context that carries one.
+type requestIDHandler struct {
+	slog.Handler
+}
+
+func (h requestIDHandler) Handle(ctx context.Context, r slog.Record) error {
+	if id := requestIDFrom(ctx); id != "" {
+		r.AddAttrs(slog.String("request_id", id))
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h requestIDHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return requestIDHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h requestIDHandler) WithGroup(name string) slog.Handler {
+	return requestIDHandler{h.Handler.WithGroup(name)}
+}
@@ -0,0 +1,17 @@
+package main
+
+import (
+	"context"
+
+	"user-ms/userpb"
+)
+
+// eventSchemas lists the events user-ms publishes. Bump a version whenever a
+// payload changes in a way existing consumers cannot read.
+var eventSchemas = []*userpb.EventSchema{
+	{Subject: "user.created", Version: 2, Description: "A user registered. Payload: event_id, uid, username, locale."},
+}
+
+func (s *server) GetEventSchemas(ctx context.Context, req *userpb.GetEventSchemasRequest) (*userpb.GetEventSchemasResponse, error) {
+	return &userpb.GetEventSchemasResponse{Events: eventSchemas}, nil
+}
@@ -0,0 +1,19 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"user-ms/userpb"
+)
+
+func TestGetEventSchemas(t *testing.T) {
+	s, _ := newTestServer(t)
+	res, err := s.GetEventSchemas(context.Background(), &userpb.GetEventSchemasRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Events) != 1 || res.Events[0].Subject != "user.created" || res.Events[0].Version != 2 || res.Events[0].Description == "" {
+		t.Errorf("events = %v, want user.created at version 2", res.Events)
+	}
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"io"
+	"log/slog"
+	"regexp"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	natsserver "github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go"
+	"golang.org/x/crypto/bcrypt"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// testHasher is bcrypt at its minimum cost, so tests do not spend seconds
+// hashing.
+var testHasher = bcryptHasher{cost: bcrypt.MinCost}
+
+// newTestServer returns a server backed by a mock database. Tokens are signed
+// with a fixed test key. The mock's expectations are checked when the test
+// ends.
+func newTestServer(t *testing.T) (*server, sqlmock.Sqlmock) {
+	t.Helper()
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+		db.Close()
+	})
+	jwtSecret = []byte("test-secret")
+	return &server{
+		logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+		db:     db,
+		hasher: testHasher,
+	}, mock
+}
+
+// literal matches a query containing s literally.
+func literal(s string) string { return regexp.QuoteMeta(s) }
+
+// hashOf hashes password with testHasher.
+func hashOf(t *testing.T, password string) string {
+	t.Helper()
+	hash, err := testHasher.Hash(password)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return hash
+}
+
+// wantCode fails the test unless err carries the gRPC code want.
+func wantCode(t *testing.T, err error, want codes.Code) {
+	t.Helper()
+	if got := status.Code(err); got != want {
+		t.Fatalf("got code %v (%v), want %v", got, err, want)
+	}
+}
+
+// fakeClock is a Clock whose time only moves when Advance is called. After
+// channels fire once the clock has been advanced past their deadline.
+type fakeClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	waiters []fakeWaiter
+}
+
+type fakeWaiter struct {
+	at time.Time
+	ch chan time.Time
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ch := make(chan time.Time, 1)
+	if d <= 0 {
+		ch <- c.now
+		return ch
+	}
+	c.waiters = append(c.waiters, fakeWaiter{at: c.now.Add(d), ch: ch})
+	return ch
+}
+
+// Advance moves the clock forward by d and fires every After that is due.
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+	pending := c.waiters[:0]
+	for _, w := range c.waiters {
+		if w.at.After(c.now) {
+			pending = append(pending, w)
+			continue
+		}
+		w.ch <- c.now
+	}
+	c.waiters = pending
+}
+
+// useFakeClock makes tokens use a fake clock until the test ends.
+func useFakeClock(t *testing.T) *fakeClock {
+	t.Helper()
+	clock := newFakeClock()
+	prev := tokenClock
+	tokenClock = clock
+	t.Cleanup(func() { tokenClock = prev })
+	return clock
+}
+
+// withPublisher connects s to a throwaway JetStream server with the events
+// stream in place.
+func withPublisher(t *testing.T, s *server) {
+	t.Helper()
+	ns, err := natsserver.NewServer(&natsserver.Options{Port: -1, JetStream: true, StoreDir: t.TempDir(), NoLog: true, NoSigs: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ns.Start()
+	t.Cleanup(ns.Shutdown)
+	if !ns.ReadyForConnections(5 * time.Second) {
+		t.Fatal("NATS server not ready")
+	}
+	nc, err := nats.Connect(ns.ClientURL())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(nc.Close)
+	js, err := ensureEventStream(nc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.events, err = newPublisher(nc, js); err != nil {
+		t.Fatal(err)
+	}
+}
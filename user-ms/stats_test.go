@@ -0,0 +1,37 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/nats-io/nats.go"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"user-ms/userpb"
+)
+
+func TestStatsCountsUsers(t *testing.T) {
+	s, mock := newTestServer(t)
+	// A connection that never connected has nothing buffered.
+	s.events = &publisher{nc: &nats.Conn{}}
+
+	mock.ExpectQuery(literal("SELECT COUNT(*) FROM users")).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(7))
+	res, err := s.Stats(context.Background(), &userpb.StatsRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.TotalUsers != 7 || res.NatsBufferedBytes != 0 {
+		t.Errorf("stats = %+v, want 7 users and nothing buffered", res)
+	}
+
+	mock.ExpectQuery(literal("SELECT COUNT(*) FROM users")).WillReturnError(errors.New(`pq: relation "users" does not exist`))
+	_, err = s.Stats(context.Background(), &userpb.StatsRequest{})
+	wantCode(t, err, codes.Internal)
+	if msg := status.Convert(err).Message(); strings.Contains(msg, "pq:") {
+		t.Errorf("error message leaks the database error: %q", msg)
+	}
+}
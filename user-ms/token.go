@@ -0,0 +1,165 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"user-ms/userpb"
+)
+
+// tokenIssuer is the iss claim of every token user-ms signs.
+const tokenIssuer = "user-ms"
+
+// Signing settings, loaded once at startup by loadTokenConfig.
+var (
+	jwtSecret       []byte
+	tokenTTL        = time.Hour
+	refreshTokenTTL = 30 * 24 * time.Hour
+)
+
+// tokenClock is the time tokens are issued, refreshed and checked against.
+var tokenClock Clock = realClock{}
+
+// tokenClaims are the claims of an access token; the subject is the user id
+// and the jti identifies the token for revocation.
+type tokenClaims struct {
+	Email string `json:"email"`
+	jwt.RegisteredClaims
+}
+
+// loadTokenConfig reads the HS256 signing key from JWT_SECRET, the access
+// token lifetime from JWT_TTL and the refresh token lifetime from
+// REFRESH_TOKEN_TTL.
+func loadTokenConfig() error {
+	jwtSecret = []byte(os.Getenv("JWT_SECRET"))
+	if len(jwtSecret) == 0 {
+		return errors.New("JWT_SECRET is not set")
+	}
+	if v := os.Getenv("JWT_TTL"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			return fmt.Errorf("invalid JWT_TTL %q", v)
+		}
+		tokenTTL = d
+	}
+	if v := os.Getenv("REFRESH_TOKEN_TTL"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			return fmt.Errorf("invalid REFRESH_TOKEN_TTL %q", v)
+		}
+		refreshTokenTTL = d
+	}
+	return nil
+}
+
+// generateToken signs an access token for the user.
+func generateToken(uid, email string) (string, error) {
+	now := tokenClock.Now()
+	claims := tokenClaims{
+		Email: email,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.NewString(),
+			Issuer:    tokenIssuer,
+			Subject:   uid,
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(tokenTTL)),
+		},
+	}
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(jwtSecret)
+}
+
+// parseToken verifies the signature, issuer and expiry of a token.
+func parseToken(token string) (*tokenClaims, error) {
+	var claims tokenClaims
+	_, err := jwt.ParseWithClaims(token, &claims, func(*jwt.Token) (any, error) {
+		return jwtSecret, nil
+	},
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
+		jwt.WithIssuer(tokenIssuer),
+		jwt.WithExpirationRequired(),
+		jwt.WithTimeFunc(tokenClock.Now),
+	)
+	if err != nil {
+		return nil, err
+	}
+	if claims.Subject == "" {
+		return nil, errors.New("token has no subject")
+	}
+	return &claims, nil
+}
+
+// revoked reports whether the token with the given jti has been logged out.
+// Tokens signed before tokens carried a jti cannot be revoked.
+func (s *server) revoked(ctx context.Context, jti string) (bool, error) {
+	if jti == "" {
+		return false, nil
+	}
+	var exists bool
+	err := s.db.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM revoked_tokens WHERE jti = $1)", jti).Scan(&exists)
+	return exists, err
+}
+
+func (s *server) ValidateToken(ctx context.Context, req *userpb.ValidateTokenRequest) (*userpb.ValidateTokenResponse, error) {
+	claims, err := parseToken(req.Token)
+	if err != nil {
+		return nil, status.Errorf(codes.Unauthenticated, "invalid token: %v", err)
+	}
+	revoked, err := s.revoked(ctx, claims.ID)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "failed to check token revocation", "error", err)
+		return nil, status.Error(codes.Internal, "internal server error")
+	}
+	if revoked {
+		return nil, status.Error(codes.Unauthenticated, "invalid token: token has been revoked")
+	}
+	res := &userpb.ValidateTokenResponse{
+		UserId:    claims.Subject,
+		Email:     claims.Email,
+		ExpiresAt: claims.ExpiresAt.Unix(),
+	}
+	if claims.IssuedAt != nil {
+		res.IssuedAt = claims.IssuedAt.Unix()
+	}
+	return res, nil
+}
+
+// Logout revokes a token by recording its jti until the token expires; past
+// that, parseToken rejects it anyway, so expired entries are pruned here.
+// Logging out an already revoked token succeeds. A refresh token passed along
+// is revoked too, so the session cannot be renewed.
+func (s *server) Logout(ctx context.Context, req *userpb.LogoutRequest) (*userpb.LogoutResponse, error) {
+	claims, err := parseToken(req.Token)
+	if err != nil {
+		return nil, status.Errorf(codes.Unauthenticated, "invalid token: %v", err)
+	}
+	if claims.ID == "" {
+		return nil, status.Error(codes.FailedPrecondition, "token predates revocation support and cannot be revoked; it expires on its own")
+	}
+	_, err = s.db.ExecContext(ctx, "INSERT INTO revoked_tokens (jti, expires_at) VALUES ($1, $2) ON CONFLICT (jti) DO NOTHING", claims.ID, claims.ExpiresAt.Time)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "failed to revoke token", "user_id", claims.Subject, "error", err)
+		return nil, status.Error(codes.Internal, "internal server error")
+	}
+	if req.RefreshToken != "" {
+		if _, err := s.revokeRefreshToken(ctx, claims.Subject, req.RefreshToken); err != nil {
+			s.logger.ErrorContext(ctx, "failed to revoke refresh token", "user_id", claims.Subject, "error", err)
+			return nil, status.Error(codes.Internal, "internal server error")
+		}
+	}
+	if _, err := s.db.ExecContext(ctx, "DELETE FROM revoked_tokens WHERE expires_at < now()"); err != nil {
+		s.logger.WarnContext(ctx, "failed to prune expired revoked tokens", "error", err)
+	}
+	if _, err := s.db.ExecContext(ctx, "DELETE FROM refresh_tokens WHERE expires_at < now()"); err != nil {
+		s.logger.WarnContext(ctx, "failed to prune expired refresh tokens", "error", err)
+	}
+	s.logger.InfoContext(ctx, "token revoked", "user_id", claims.Subject)
+	return &userpb.LogoutResponse{}, nil
+}
@@ -0,0 +1,230 @@
+package main
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"user-ms/userpb"
+)
+
+const (
+	insertRevoked = "INSERT INTO revoked_tokens"
+	selectRevoked = "SELECT EXISTS (SELECT 1 FROM revoked_tokens WHERE jti = $1)"
+)
+
+func TestLoggedOutTokenIsRejected(t *testing.T) {
+	s, mock := newTestServer(t)
+	token, err := generateToken("u1", "u1@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims, err := parseToken(token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	mock.ExpectQuery(literal(selectRevoked)).WithArgs(claims.ID).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+	if res, err := s.ValidateToken(ctx, &userpb.ValidateTokenRequest{Token: token}); err != nil || res.UserId != "u1" {
+		t.Fatalf("ValidateToken before logout = %v, %v", res, err)
+	}
+
+	mock.ExpectExec(literal(insertRevoked)).WithArgs(claims.ID, sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(literal("DELETE FROM revoked_tokens")).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(literal("DELETE FROM refresh_tokens")).WillReturnResult(sqlmock.NewResult(0, 0))
+	if _, err := s.Logout(ctx, &userpb.LogoutRequest{Token: token}); err != nil {
+		t.Fatalf("Logout: %v", err)
+	}
+
+	mock.ExpectQuery(literal(selectRevoked)).WithArgs(claims.ID).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+	_, err = s.ValidateToken(ctx, &userpb.ValidateTokenRequest{Token: token})
+	wantCode(t, err, codes.Unauthenticated)
+}
+
+func TestTokenErrorsDoNotLeakSQL(t *testing.T) {
+	dbErr := errors.New(`pq: relation "revoked_tokens" does not exist`)
+	token, err := generateToken("u1", "u1@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name   string
+		expect func(sqlmock.Sqlmock)
+		call   func(*server) error
+	}{
+		{
+			name: "logout",
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec(literal(insertRevoked)).WillReturnError(dbErr)
+			},
+			call: func(s *server) error {
+				_, err := s.Logout(context.Background(), &userpb.LogoutRequest{Token: token})
+				return err
+			},
+		},
+		{
+			name: "validate",
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(literal(selectRevoked)).WillReturnError(dbErr)
+			},
+			call: func(s *server) error {
+				_, err := s.ValidateToken(context.Background(), &userpb.ValidateTokenRequest{Token: token})
+				return err
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, mock := newTestServer(t)
+			tt.expect(mock)
+			err := tt.call(s)
+			wantCode(t, err, codes.Internal)
+			if msg := status.Convert(err).Message(); strings.Contains(msg, "revoked_tokens") || strings.Contains(msg, "pq:") {
+				t.Errorf("error message leaks the database error: %q", msg)
+			}
+		})
+	}
+}
+
+func TestTokenExpiresAfterTTL(t *testing.T) {
+	clock := useFakeClock(t)
+	s, mock := newTestServer(t)
+	token, err := generateToken("u1", "u1@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims, err := parseToken(token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !claims.ExpiresAt.Time.Equal(clock.Now().Add(tokenTTL)) {
+		t.Errorf("expires at %v, want %v", claims.ExpiresAt.Time, clock.Now().Add(tokenTTL))
+	}
+
+	clock.Advance(tokenTTL - time.Second)
+	mock.ExpectQuery(literal(selectRevoked)).WithArgs(claims.ID).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+	if _, err := s.ValidateToken(context.Background(), &userpb.ValidateTokenRequest{Token: token}); err != nil {
+		t.Fatalf("token rejected a second before it expires: %v", err)
+	}
+
+	clock.Advance(time.Second)
+	_, err = s.ValidateToken(context.Background(), &userpb.ValidateTokenRequest{Token: token})
+	wantCode(t, err, codes.Unauthenticated)
+	if msg := status.Convert(err).Message(); !strings.Contains(msg, "expired") {
+		t.Errorf("message %q does not say the token expired", msg)
+	}
+}
+
+func TestRefreshTokenExpires(t *testing.T) {
+	clock := useFakeClock(t)
+	s, mock := newTestServer(t)
+
+	mock.ExpectExec(literal("INSERT INTO refresh_tokens")).WithArgs(sqlmock.AnyArg(), "u1", clock.Now().Add(refreshTokenTTL)).WillReturnResult(sqlmock.NewResult(0, 1))
+	refreshToken, err := issueRefreshToken(context.Background(), s.db, "u1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	clock.Advance(refreshTokenTTL)
+	mock.ExpectBegin()
+	mock.ExpectQuery(literal("DELETE FROM refresh_tokens WHERE token_hash = $1")).WithArgs(hashRefreshToken(refreshToken)).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id", "expires_at"}).AddRow("u1", newFakeClock().Now().Add(refreshTokenTTL)))
+	mock.ExpectCommit()
+	_, err = s.Refresh(context.Background(), &userpb.RefreshRequest{RefreshToken: refreshToken})
+	wantCode(t, err, codes.Unauthenticated)
+}
+
+// storedRefreshToken matches the hash of a refresh token being inserted and
+// records which token it was for.
+type storedRefreshToken struct{ hash *string }
+
+func (s storedRefreshToken) Match(v driver.Value) bool {
+	hash, ok := v.(string)
+	*s.hash = hash
+	return ok && hash != ""
+}
+
+// expectRefresh expects a Refresh of token that finds it valid for userID and
+// stores a replacement, whose hash is written to stored.
+func expectRefresh(mock sqlmock.Sqlmock, token, userID string, stored *string) {
+	mock.ExpectBegin()
+	mock.ExpectQuery(literal("DELETE FROM refresh_tokens WHERE token_hash = $1")).WithArgs(hashRefreshToken(token)).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id", "expires_at"}).AddRow(userID, tokenClock.Now().Add(refreshTokenTTL)))
+	mock.ExpectQuery(literal("SELECT email")).WithArgs(userID).
+		WillReturnRows(sqlmock.NewRows([]string{"email", "username", "locale"}).AddRow(userID+"@example.com", "", "en"))
+	mock.ExpectExec(literal("INSERT INTO refresh_tokens")).WithArgs(storedRefreshToken{stored}, userID, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+}
+
+// expectUsedRefreshToken expects a Refresh of a token that is no longer
+// stored.
+func expectUsedRefreshToken(mock sqlmock.Sqlmock, token string) {
+	mock.ExpectBegin()
+	mock.ExpectQuery(literal("DELETE FROM refresh_tokens WHERE token_hash = $1")).WithArgs(hashRefreshToken(token)).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id", "expires_at"}))
+	mock.ExpectRollback()
+}
+
+func TestRefreshRotatesToken(t *testing.T) {
+	useFakeClock(t)
+	s, mock := newTestServer(t)
+	ctx := context.Background()
+
+	var stored string
+	expectRefresh(mock, "old-refresh", "u1", &stored)
+	res, err := s.Refresh(ctx, &userpb.RefreshRequest{RefreshToken: "old-refresh"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.RefreshToken == "" || res.RefreshToken == "old-refresh" {
+		t.Errorf("refresh token %q, want a new one", res.RefreshToken)
+	}
+	if stored != hashRefreshToken(res.RefreshToken) {
+		t.Error("the stored refresh token is not the one returned")
+	}
+	claims, err := parseToken(res.Token)
+	if err != nil || claims.Subject != "u1" {
+		t.Errorf("access token claims %+v, %v; want one for u1", claims, err)
+	}
+
+	expectUsedRefreshToken(mock, "old-refresh")
+	_, err = s.Refresh(ctx, &userpb.RefreshRequest{RefreshToken: "old-refresh"})
+	wantCode(t, err, codes.Unauthenticated)
+
+	// The replacement works, once.
+	expectRefresh(mock, res.RefreshToken, "u1", &stored)
+	if _, err := s.Refresh(ctx, &userpb.RefreshRequest{RefreshToken: res.RefreshToken}); err != nil {
+		t.Errorf("refreshing with the new token: %v", err)
+	}
+}
+
+func TestLogoutRevokesRefreshToken(t *testing.T) {
+	s, mock := newTestServer(t)
+	ctx := context.Background()
+	token, err := generateToken("u1", "u1@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mock.ExpectExec(literal(insertRevoked)).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(literal("DELETE FROM refresh_tokens WHERE token_hash = $1 AND user_id = $2")).
+		WithArgs(hashRefreshToken("refresh-1"), "u1").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(literal("DELETE FROM revoked_tokens")).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(literal("DELETE FROM refresh_tokens WHERE expires_at")).WillReturnResult(sqlmock.NewResult(0, 0))
+	if _, err := s.Logout(ctx, &userpb.LogoutRequest{Token: token, RefreshToken: "refresh-1"}); err != nil {
+		t.Fatalf("Logout: %v", err)
+	}
+
+	expectUsedRefreshToken(mock, "refresh-1")
+	_, err = s.Refresh(ctx, &userpb.RefreshRequest{RefreshToken: "refresh-1"})
+	wantCode(t, err, codes.Unauthenticated)
+}
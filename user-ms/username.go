@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+
+	"github.com/lib/pq"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"user-ms/userpb"
+)
+
+// usernamePattern excludes "@", so an identifier containing one is always an
+// email address and anything else is always a username.
+var usernamePattern = regexp.MustCompile(`^[A-Za-z0-9_.-]{3,32}$`)
+
+var errUsernameTaken = status.Error(codes.AlreadyExists, "username is already taken")
+
+// isEmailIdentifier reports whether a login identifier refers to an email
+// address rather than a username.
+func isEmailIdentifier(identifier string) bool {
+	return strings.Contains(identifier, "@")
+}
+
+// isUsernameConflict reports whether err is a violation of the unique
+// username index.
+func isUsernameConflict(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505" && pqErr.Constraint == "users_username_idx"
+}
+
+// SetUsername sets or changes the username a user can log in with.
+func (s *server) SetUsername(ctx context.Context, req *userpb.SetUsernameRequest) (*userpb.SetUsernameResponse, error) {
+	if !usernamePattern.MatchString(req.Username) {
+		return nil, status.Error(codes.InvalidArgument, "username must be 3-32 letters, digits, '.', '_' or '-'")
+	}
+	res, err := s.db.ExecContext(ctx, "UPDATE users SET username = $1 WHERE id = $2", req.Username, req.UserId)
+	if isUsernameConflict(err) {
+		return nil, errUsernameTaken
+	}
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "could not set username: %v", err)
+	}
+	if n, err := res.RowsAffected(); err != nil || n == 0 {
+		return nil, status.Error(codes.NotFound, "user not found")
+	}
+	return &userpb.SetUsernameResponse{}, nil
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"google.golang.org/grpc/codes"
+
+	"user-ms/userpb"
+)
+
+func TestIsEmailIdentifier(t *testing.T) {
+	for identifier, want := range map[string]bool{
+		"alice@example.com": true,
+		"alice":             false,
+		"alice.smith":       false,
+		"@alice":            true,
+	} {
+		if got := isEmailIdentifier(identifier); got != want {
+			t.Errorf("isEmailIdentifier(%q) = %v, want %v", identifier, got, want)
+		}
+	}
+}
+
+func TestLoginByEmailOrUsername(t *testing.T) {
+	tests := []struct {
+		name       string
+		req        *userpb.LoginRequest
+		query      string
+		identifier string
+	}{
+		{"email", &userpb.LoginRequest{Identifier: "alice@example.com", Password: "s3cretpass"}, "WHERE email = $1", "alice@example.com"},
+		{"username", &userpb.LoginRequest{Identifier: "Alice", Password: "s3cretpass"}, "WHERE lower(username) = lower($1)", "Alice"},
+		{"legacy email field", &userpb.LoginRequest{Email: "alice@example.com", Password: "s3cretpass"}, "WHERE email = $1", "alice@example.com"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, mock := newTestServer(t)
+			mock.ExpectQuery(literal(tt.query)).WithArgs(tt.identifier).WillReturnRows(
+				sqlmock.NewRows([]string{"id", "email", "username", "password", "locale"}).
+					AddRow("u1", "alice@example.com", "alice", hashOf(t, "s3cretpass"), "de"))
+			mock.ExpectExec(literal("INSERT INTO refresh_tokens")).WillReturnResult(sqlmock.NewResult(0, 1))
+
+			res, err := s.Login(context.Background(), tt.req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if res.User.Id != "u1" || res.User.Username != "alice" || res.User.Locale != "de" {
+				t.Errorf("user = %+v", res.User)
+			}
+			claims, err := parseToken(res.Token)
+			if err != nil || claims.Subject != "u1" {
+				t.Errorf("token claims = %+v, %v", claims, err)
+			}
+		})
+	}
+}
+
+func TestLoginRejectsUnknownUserAndWrongPassword(t *testing.T) {
+	s, mock := newTestServer(t)
+	mock.ExpectQuery(literal("WHERE lower(username) = lower($1)")).WithArgs("nobody").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "email", "username", "password", "locale"}))
+	_, err := s.Login(context.Background(), &userpb.LoginRequest{Identifier: "nobody", Password: "x"})
+	wantCode(t, err, codes.Unauthenticated)
+
+	mock.ExpectQuery(literal("WHERE lower(username) = lower($1)")).WithArgs("alice").WillReturnRows(
+		sqlmock.NewRows([]string{"id", "email", "username", "password", "locale"}).
+			AddRow("u1", "alice@example.com", "alice", hashOf(t, "s3cretpass"), "en"))
+	_, err = s.Login(context.Background(), &userpb.LoginRequest{Identifier: "alice", Password: "wrong"})
+	wantCode(t, err, codes.Unauthenticated)
+}
+
+func TestSetUsername(t *testing.T) {
+	s, mock := newTestServer(t)
+
+	_, err := s.SetUsername(context.Background(), &userpb.SetUsernameRequest{UserId: "u1", Username: "a@b"})
+	wantCode(t, err, codes.InvalidArgument)
+
+	mock.ExpectExec(literal("UPDATE users SET username")).WithArgs("alice", "u1").WillReturnResult(sqlmock.NewResult(0, 1))
+	if _, err := s.SetUsername(context.Background(), &userpb.SetUsernameRequest{UserId: "u1", Username: "alice"}); err != nil {
+		t.Fatal(err)
+	}
+
+	mock.ExpectExec(literal("UPDATE users SET username")).WithArgs("alice", "u2").
+		WillReturnError(&pq.Error{Code: "23505", Constraint: "users_username_idx"})
+	_, err = s.SetUsername(context.Background(), &userpb.SetUsernameRequest{UserId: "u2", Username: "alice"})
+	wantCode(t, err, codes.AlreadyExists)
+
+	mock.ExpectExec(literal("UPDATE users SET username")).WithArgs("bob", "missing").WillReturnResult(sqlmock.NewResult(0, 0))
+	_, err = s.SetUsername(context.Background(), &userpb.SetUsernameRequest{UserId: "missing", Username: "bob"})
+	wantCode(t, err, codes.NotFound)
+}
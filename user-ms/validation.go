@@ -0,0 +1,70 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"user-ms/userpb"
+)
+
+// emailPattern accepts a local part, "@" and a domain with at least one dot,
+// without whitespace. It is deliberately looser than RFC 5322.
+var emailPattern = regexp.MustCompile(`^[A-Za-z0-9.!#$%&'*+/=?^_{|}~-]+@[A-Za-z0-9](?:[A-Za-z0-9-]*[A-Za-z0-9])?(?:\.[A-Za-z0-9](?:[A-Za-z0-9-]*[A-Za-z0-9])?)+$`)
+
+// minPasswordLength is the shortest password Register accepts, loaded once at
+// startup by loadPasswordPolicy.
+var minPasswordLength = 8
+
+// loadPasswordPolicy reads the minimum password length from
+// PASSWORD_MIN_LENGTH.
+func loadPasswordPolicy() error {
+	n, err := envInt("PASSWORD_MIN_LENGTH", minPasswordLength)
+	if err != nil {
+		return err
+	}
+	if n < 1 {
+		return fmt.Errorf("invalid PASSWORD_MIN_LENGTH %d", n)
+	}
+	minPasswordLength = n
+	return nil
+}
+
+// validatePassword checks the password policy: at least minPasswordLength
+// characters, including a letter and a digit.
+func validatePassword(password string) error {
+	if len([]rune(password)) < minPasswordLength {
+		return fmt.Errorf("password must be at least %d characters", minPasswordLength)
+	}
+	if !strings.ContainsFunc(password, unicode.IsLetter) {
+		return errors.New("password must contain a letter")
+	}
+	if !strings.ContainsFunc(password, unicode.IsDigit) {
+		return errors.New("password must contain a digit")
+	}
+	return nil
+}
+
+// validateRegistration checks a Register request before any password is
+// hashed, failing with INVALID_ARGUMENT naming the first rule broken.
+func validateRegistration(req *userpb.RegisterRequest) error {
+	switch {
+	case req.Email == "":
+		return status.Error(codes.InvalidArgument, "email is required")
+	case !emailPattern.MatchString(req.Email):
+		return status.Error(codes.InvalidArgument, "email is not a valid email address")
+	case req.Password == "":
+		return status.Error(codes.InvalidArgument, "password is required")
+	case req.Username != "" && !usernamePattern.MatchString(req.Username):
+		return status.Error(codes.InvalidArgument, "username must be 3-32 letters, digits, '.', '_' or '-'")
+	}
+	if err := validatePassword(req.Password); err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	return nil
+}
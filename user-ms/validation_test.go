@@ -0,0 +1,81 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"user-ms/userpb"
+)
+
+func TestValidateRegistration(t *testing.T) {
+	tests := []struct {
+		name    string
+		req     *userpb.RegisterRequest
+		wantErr string
+	}{
+		{"valid", &userpb.RegisterRequest{Email: "alice@example.com", Password: "secret123"}, ""},
+		{"valid with username", &userpb.RegisterRequest{Email: "alice@example.com", Password: "secret123", Username: "alice_1"}, ""},
+		{"missing email", &userpb.RegisterRequest{Password: "secret123"}, "email is required"},
+		{"email without domain dot", &userpb.RegisterRequest{Email: "alice@example", Password: "secret123"}, "not a valid email"},
+		{"email with space", &userpb.RegisterRequest{Email: "al ice@example.com", Password: "secret123"}, "not a valid email"},
+		{"missing password", &userpb.RegisterRequest{Email: "alice@example.com"}, "password is required"},
+		{"short password", &userpb.RegisterRequest{Email: "alice@example.com", Password: "abc123"}, "at least 8 characters"},
+		{"password without letter", &userpb.RegisterRequest{Email: "alice@example.com", Password: "12345678"}, "must contain a letter"},
+		{"password without digit", &userpb.RegisterRequest{Email: "alice@example.com", Password: "abcdefgh"}, "must contain a digit"},
+		{"multibyte password counts characters", &userpb.RegisterRequest{Email: "alice@example.com", Password: "ééééééé1"}, ""},
+		{"username too short", &userpb.RegisterRequest{Email: "alice@example.com", Password: "secret123", Username: "al"}, "username must be"},
+		{"username with at sign", &userpb.RegisterRequest{Email: "alice@example.com", Password: "secret123", Username: "al@ce"}, "username must be"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateRegistration(tt.req)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			wantCode(t, err, codes.InvalidArgument)
+			if msg := status.Convert(err).Message(); !strings.Contains(msg, tt.wantErr) {
+				t.Errorf("message %q, want it to contain %q", msg, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoadPasswordPolicy(t *testing.T) {
+	defer func(n int) { minPasswordLength = n }(minPasswordLength)
+
+	tests := []struct {
+		env     string
+		want    int
+		wantErr bool
+	}{
+		{"12", 12, false},
+		{"0", 12, true},
+		{"-3", 12, true},
+		{"abc", 12, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.env, func(t *testing.T) {
+			t.Setenv("PASSWORD_MIN_LENGTH", tt.env)
+			err := loadPasswordPolicy()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if minPasswordLength != tt.want {
+				t.Errorf("minPasswordLength = %d, want %d", minPasswordLength, tt.want)
+			}
+		})
+	}
+
+	if err := validatePassword("abcdefg1"); err == nil {
+		t.Error("8-character password accepted with a 12-character minimum")
+	}
+	if err := validatePassword("abcdefghijk1"); err != nil {
+		t.Errorf("12-character password rejected: %v", err)
+	}
+}